@@ -33,9 +33,13 @@ import (
 	"github.com/vulcanize/gap-filler/pkg/mux"
 
 	"github.com/vulcanize/ipld-eth-server/pkg/eth"
+	"github.com/vulcanize/ipld-eth-server/pkg/gapfill"
 	"github.com/vulcanize/ipld-eth-server/pkg/graphql"
 	srpc "github.com/vulcanize/ipld-eth-server/pkg/rpc"
 	s "github.com/vulcanize/ipld-eth-server/pkg/serve"
+	"github.com/vulcanize/ipld-eth-server/pkg/sink"
+	"github.com/vulcanize/ipld-eth-server/pkg/upstream"
+	"github.com/vulcanize/ipld-eth-server/pkg/vdb"
 	v "github.com/vulcanize/ipld-eth-server/version"
 )
 
@@ -78,6 +82,9 @@ func serve() {
 	if err := startServers(server, serverConfig); err != nil {
 		logWithCommand.Fatal(err)
 	}
+	if err := startSinks(wg, server); err != nil {
+		logWithCommand.Fatal(err)
+	}
 	graphQL, err := startEthGraphQL(server, serverConfig)
 	if err != nil {
 		logWithCommand.Fatal(err)
@@ -110,10 +117,28 @@ func serve() {
 	wg.Wait()
 }
 
+// startSinks starts the sinks declared in the eth.server.sinks tables of the config
+func startSinks(wg *sync.WaitGroup, server s.Server) error {
+	configs, err := sink.NewConfigs()
+	if err != nil {
+		return err
+	}
+	for _, conf := range configs {
+		logWithCommand.Infof("starting up %s sink %s", conf.Type, conf.Name)
+		snk, err := sink.New(conf)
+		if err != nil {
+			return err
+		}
+		sink.Start(wg, server, snk, conf.BufferSize)
+	}
+	return nil
+}
+
 func startServers(server s.Server, settings *s.Config) error {
 	if settings.IPCEnabled {
 		logWithCommand.Info("starting up IPC server")
-		_, _, err := srpc.StartIPCEndpoint(settings.IPCEndpoint, server.APIs())
+		// the IPC socket is only accessible to the server's user, so it also serves the admin APIs
+		_, _, err := srpc.StartIPCEndpoint(settings.IPCEndpoint, append(server.APIs(), server.AdminAPIs()...))
 		if err != nil {
 			return err
 		}
@@ -141,6 +166,34 @@ func startServers(server s.Server, settings *s.Config) error {
 		logWithCommand.Info("HTTP server is disabled")
 	}
 
+	if settings.SSEEnabled {
+		logWithCommand.Info("starting up SSE server")
+		if _, err := s.StartSSEEndpoint(settings.SSEEndpoint, server, settings.SSECorsOrigins); err != nil {
+			return err
+		}
+	} else {
+		logWithCommand.Info("SSE server is disabled")
+	}
+
+	if settings.GRPCEnabled {
+		logWithCommand.Info("starting up gRPC server")
+		if _, _, err := vdb.StartGRPCEndpoint(settings.GRPCEndpoint, vdb.NewServer(server, server.Backend())); err != nil {
+			return err
+		}
+	} else {
+		logWithCommand.Info("gRPC server is disabled")
+	}
+
+	if settings.AdminEnabled {
+		logWithCommand.Info("starting up admin HTTP server")
+		_, err := srpc.StartAdminHTTPEndpoint(settings.AdminEndpoint, server.AdminAPIs(), settings.AdminToken)
+		if err != nil {
+			return err
+		}
+	} else {
+		logWithCommand.Info("admin HTTP server is disabled")
+	}
+
 	return nil
 }
 
@@ -149,7 +202,7 @@ func startEthGraphQL(server s.Server, settings *s.Config) (graphQLServer *graphq
 		logWithCommand.Info("starting up ETH GraphQL server")
 		endPoint := settings.EthGraphqlEndpoint
 		if endPoint != "" {
-			graphQLServer, err = graphql.New(server.Backend(), endPoint, nil, []string{"*"}, rpc.HTTPTimeouts{})
+			graphQLServer, err = graphql.New(server.Backend(), server.Client(), server.GapFiller(), endPoint, nil, []string{"*"}, rpc.HTTPTimeouts{}, settings.EthGraphqlLimits)
 			if err != nil {
 				return
 			}
@@ -321,12 +374,29 @@ func init() {
 	// eth graphql and json-rpc parameters
 	serveCmd.PersistentFlags().Bool("eth-server-graphql", false, "turn on the eth graphql server")
 	serveCmd.PersistentFlags().String("eth-server-graphql-path", "", "endpoint url for eth graphql server (host:port)")
+	serveCmd.PersistentFlags().Int("eth-server-graphql-max-depth", 0, "maximum nesting of the fields of eth graphql queries, 0 for the default and negative for no limit")
+	serveCmd.PersistentFlags().Int("eth-server-graphql-max-cost", 0, "maximum static cost of eth graphql queries, 0 for the default and negative for no limit")
+	serveCmd.PersistentFlags().Int("eth-server-graphql-max-block-range", 0, "maximum number of blocks of the block ranges of eth graphql blocks and logs queries, 0 for the default and negative for no limit")
+	serveCmd.PersistentFlags().Int("eth-server-graphql-max-selections", 0, "maximum number of selections of eth graphql queries with their fragments expanded, 0 for the default and negative for no limit")
+	serveCmd.PersistentFlags().Int("eth-server-graphql-list-size", 0, "length assumed for unbounded lists when computing the cost of eth graphql queries, 0 for the default")
+	serveCmd.PersistentFlags().StringSlice("eth-server-graphql-field-weights", nil, "cost weights of eth graphql fields, as Type.field=weight entries such as Account.storage=10")
 	serveCmd.PersistentFlags().Bool("eth-server-http", true, "turn on the eth http json-rpc server")
 	serveCmd.PersistentFlags().String("eth-server-http-path", "", "endpoint url for eth http json-rpc server (host:port)")
 	serveCmd.PersistentFlags().Bool("eth-server-ws", false, "turn on the eth websocket json-rpc server")
 	serveCmd.PersistentFlags().String("eth-server-ws-path", "", "endpoint url for eth websocket json-rpc server (host:port)")
 	serveCmd.PersistentFlags().Bool("eth-server-ipc", false, "turn on the eth ipc json-rpc server")
 	serveCmd.PersistentFlags().String("eth-server-ipc-path", "", "path for eth ipc json-rpc server")
+	serveCmd.PersistentFlags().Bool("eth-server-sse", false, "turn on the vdb stream server-sent events server")
+	serveCmd.PersistentFlags().String("eth-server-sse-path", "", "endpoint url for the server-sent events server (host:port)")
+	serveCmd.PersistentFlags().StringSlice("eth-server-sse-cors", []string{}, "origins allowed to read the server-sent events stream")
+	serveCmd.PersistentFlags().Bool("eth-server-grpc", false, "turn on the vdb gRPC server")
+	serveCmd.PersistentFlags().String("eth-server-grpc-path", "", "endpoint url for the gRPC server (host:port)")
+	serveCmd.PersistentFlags().Bool("eth-server-admin", false, "turn on the token authenticated admin http json-rpc server")
+	serveCmd.PersistentFlags().String("eth-server-admin-path", "", "endpoint url for admin http json-rpc server (host:port)")
+	serveCmd.PersistentFlags().String("eth-server-admin-token", "", "bearer token required by the admin http json-rpc server")
+	serveCmd.PersistentFlags().Int("eth-server-max-subscriptions", 0, "maximum number of concurrent subscriptions, 0 for no limit")
+	serveCmd.PersistentFlags().Int("eth-server-max-backfills", 0, "maximum number of concurrent subscription backfills, 0 for no limit")
+	serveCmd.PersistentFlags().String("eth-server-watched-addresses-path", "", "file to persist the addresses watched by the statediffing proxy node to")
 
 	// ipld and tracing graphql parameters
 	serveCmd.PersistentFlags().Bool("ipld-server-graphql", false, "turn on the ipld graphql server")
@@ -347,6 +417,22 @@ func init() {
 	serveCmd.PersistentFlags().Bool("eth-supports-state-diff", false, "whether the proxy ethereum client supports statediffing endpoints")
 	serveCmd.PersistentFlags().Bool("eth-forward-eth-calls", false, "whether to immediately forward eth_calls to proxy client")
 	serveCmd.PersistentFlags().Bool("eth-proxy-on-error", true, "whether to forward all failed calls to proxy client")
+	serveCmd.PersistentFlags().Duration("eth-upstream-health-interval", upstream.DefaultHealthCheckInterval, "time between health checks of the upstream nodes")
+	serveCmd.PersistentFlags().Duration("eth-upstream-health-timeout", upstream.DefaultHealthCheckTimeout, "timeout of each upstream health check")
+	serveCmd.PersistentFlags().Int("eth-upstream-max-failures", upstream.DefaultMaxFailures, "consecutive failures after which an upstream node is considered down")
+	serveCmd.PersistentFlags().Uint64("eth-upstream-max-lag", 0, "blocks an upstream node can fall behind the rest of its pool before it is considered unhealthy, 0 to disable")
+	serveCmd.PersistentFlags().Duration("eth-upstream-request-timeout", 0, "timeout of each proxied request before failing over to the next upstream node, 0 to disable")
+	serveCmd.PersistentFlags().Int("eth-upstream-breaker-threshold", upstream.DefaultBreakerThreshold, "consecutive requests failed by every node of a pool after which its circuit breaker opens")
+	serveCmd.PersistentFlags().Duration("eth-upstream-breaker-cooldown", upstream.DefaultBreakerCooldown, "time an open circuit breaker waits before letting a probe request through")
+	serveCmd.PersistentFlags().Int("eth-statediff-write-workers", eth.DefaultStateDiffWriteWorkers, "number of concurrent statediff writes, shared by proxied cache misses, gap filling and watched address backfills")
+	serveCmd.PersistentFlags().Int("eth-statediff-write-queue-size", eth.DefaultStateDiffWriteQueueSize, "maximum number of queued statediff writes, further writes are dropped")
+	serveCmd.PersistentFlags().Int64("eth-statediff-write-max-range", eth.DefaultStateDiffWriteMaxRange, "maximum number of blocks written for a single eth_getLogs cache miss")
+	serveCmd.PersistentFlags().Duration("eth-statediff-write-timeout", eth.DefaultStateDiffWriteTimeout, "timeout of each statediff write")
+	serveCmd.PersistentFlags().Float64("eth-shadow-sample-rate", 0, "fraction of the locally answered requests compared with the proxy node, 0 to disable")
+	serveCmd.PersistentFlags().StringSlice("eth-shadow-methods", nil, "methods compared with the proxy node, all supported methods if empty")
+	serveCmd.PersistentFlags().Duration("eth-shadow-timeout", eth.DefaultShadowTimeout, "timeout of each shadow call to the proxy node")
+	serveCmd.PersistentFlags().Int("eth-shadow-max-concurrent", eth.DefaultShadowMaxConcurrent, "maximum number of concurrent shadow calls, further sampled requests are skipped")
+	serveCmd.PersistentFlags().StringSlice("eth-hedge-methods", nil, "methods raced against the proxy node, as method=delay entries such as eth_getBalance=50ms")
 
 	// groupcache flags
 	serveCmd.PersistentFlags().Bool("gcache-pool-enabled", false, "turn on the groupcache pool")
@@ -356,6 +442,16 @@ func init() {
 	serveCmd.PersistentFlags().Int("gcache-statedb-cache-expiry", 60, "state DB cache expiry time in mins")
 	serveCmd.PersistentFlags().Int("gcache-statedb-log-stats-interval", 60, "state DB cache stats log interval in secs")
 
+	// gap filler flags
+	serveCmd.PersistentFlags().Bool("gapfill-enabled", false, "turn on the gap filler, which fills gaps in the index from the statediffing proxy node")
+	serveCmd.PersistentFlags().Uint64("gapfill-start", 0, "first block checked for gaps")
+	serveCmd.PersistentFlags().Duration("gapfill-interval", gapfill.DefaultInterval, "time between gap scans")
+	serveCmd.PersistentFlags().Int("gapfill-workers", gapfill.DefaultWorkers, "number of concurrent statediff_writeStateDiffAt calls")
+	serveCmd.PersistentFlags().Int("gapfill-queue-size", gapfill.DefaultQueueSize, "maximum number of blocks queued to be filled")
+	serveCmd.PersistentFlags().Float64("gapfill-rate-limit", 0, "maximum number of statediff_writeStateDiffAt calls per second, 0 for no limit")
+	serveCmd.PersistentFlags().Int("gapfill-max-retries", gapfill.DefaultMaxRetries, "number of times a failed write is retried")
+	serveCmd.PersistentFlags().Duration("gapfill-retry-interval", gapfill.DefaultRetryInterval, "delay before the first retry of a failed write, doubled for every following retry")
+
 	// state validator flags
 	serveCmd.PersistentFlags().Bool("validator-enabled", false, "turn on the state validator")
 	serveCmd.PersistentFlags().Uint("validator-every-nth-block", 1500, "only validate every Nth block")
@@ -364,6 +460,12 @@ func init() {
 	// eth graphql server
 	viper.BindPFlag("eth.server.graphql", serveCmd.PersistentFlags().Lookup("eth-server-graphql"))
 	viper.BindPFlag("eth.server.graphqlPath", serveCmd.PersistentFlags().Lookup("eth-server-graphql-path"))
+	viper.BindPFlag("eth.server.graphqlMaxDepth", serveCmd.PersistentFlags().Lookup("eth-server-graphql-max-depth"))
+	viper.BindPFlag("eth.server.graphqlMaxCost", serveCmd.PersistentFlags().Lookup("eth-server-graphql-max-cost"))
+	viper.BindPFlag("eth.server.graphqlMaxBlockRange", serveCmd.PersistentFlags().Lookup("eth-server-graphql-max-block-range"))
+	viper.BindPFlag("eth.server.graphqlMaxSelections", serveCmd.PersistentFlags().Lookup("eth-server-graphql-max-selections"))
+	viper.BindPFlag("eth.server.graphqlListSize", serveCmd.PersistentFlags().Lookup("eth-server-graphql-list-size"))
+	viper.BindPFlag("eth.server.graphqlFieldWeights", serveCmd.PersistentFlags().Lookup("eth-server-graphql-field-weights"))
 
 	// eth http json-rpc server
 	viper.BindPFlag("eth.server.http", serveCmd.PersistentFlags().Lookup("eth-server-http"))
@@ -377,6 +479,23 @@ func init() {
 	viper.BindPFlag("eth.server.ipc", serveCmd.PersistentFlags().Lookup("eth-server-ipc"))
 	viper.BindPFlag("eth.server.ipcPath", serveCmd.PersistentFlags().Lookup("eth-server-ipc-path"))
 
+	// server-sent events server
+	viper.BindPFlag("eth.server.sse", serveCmd.PersistentFlags().Lookup("eth-server-sse"))
+	viper.BindPFlag("eth.server.ssePath", serveCmd.PersistentFlags().Lookup("eth-server-sse-path"))
+	viper.BindPFlag("eth.server.sseCors", serveCmd.PersistentFlags().Lookup("eth-server-sse-cors"))
+
+	// gRPC server
+	viper.BindPFlag("eth.server.grpc", serveCmd.PersistentFlags().Lookup("eth-server-grpc"))
+	viper.BindPFlag("eth.server.grpcPath", serveCmd.PersistentFlags().Lookup("eth-server-grpc-path"))
+
+	// admin json-rpc server and subscription limits
+	viper.BindPFlag("eth.server.admin", serveCmd.PersistentFlags().Lookup("eth-server-admin"))
+	viper.BindPFlag("eth.server.adminPath", serveCmd.PersistentFlags().Lookup("eth-server-admin-path"))
+	viper.BindPFlag("eth.server.adminToken", serveCmd.PersistentFlags().Lookup("eth-server-admin-token"))
+	viper.BindPFlag("eth.server.maxSubscriptions", serveCmd.PersistentFlags().Lookup("eth-server-max-subscriptions"))
+	viper.BindPFlag("eth.server.maxBackfills", serveCmd.PersistentFlags().Lookup("eth-server-max-backfills"))
+	viper.BindPFlag("eth.server.watchedAddressesPath", serveCmd.PersistentFlags().Lookup("eth-server-watched-addresses-path"))
+
 	// ipld and tracing graphql parameters
 	viper.BindPFlag("ipld.server.graphql", serveCmd.PersistentFlags().Lookup("ipld-server-graphql"))
 	viper.BindPFlag("ipld.server.graphqlPath", serveCmd.PersistentFlags().Lookup("ipld-server-graphql-path"))
@@ -396,6 +515,22 @@ func init() {
 	viper.BindPFlag("ethereum.supportsStateDiff", serveCmd.PersistentFlags().Lookup("eth-supports-state-diff"))
 	viper.BindPFlag("ethereum.forwardEthCalls", serveCmd.PersistentFlags().Lookup("eth-forward-eth-calls"))
 	viper.BindPFlag("ethereum.proxyOnError", serveCmd.PersistentFlags().Lookup("eth-proxy-on-error"))
+	viper.BindPFlag("ethereum.upstreamHealth.interval", serveCmd.PersistentFlags().Lookup("eth-upstream-health-interval"))
+	viper.BindPFlag("ethereum.upstreamHealth.timeout", serveCmd.PersistentFlags().Lookup("eth-upstream-health-timeout"))
+	viper.BindPFlag("ethereum.upstreamHealth.maxFailures", serveCmd.PersistentFlags().Lookup("eth-upstream-max-failures"))
+	viper.BindPFlag("ethereum.upstreamHealth.maxLag", serveCmd.PersistentFlags().Lookup("eth-upstream-max-lag"))
+	viper.BindPFlag("ethereum.upstreamHealth.requestTimeout", serveCmd.PersistentFlags().Lookup("eth-upstream-request-timeout"))
+	viper.BindPFlag("ethereum.upstreamHealth.breakerThreshold", serveCmd.PersistentFlags().Lookup("eth-upstream-breaker-threshold"))
+	viper.BindPFlag("ethereum.upstreamHealth.breakerCooldown", serveCmd.PersistentFlags().Lookup("eth-upstream-breaker-cooldown"))
+	viper.BindPFlag("ethereum.stateDiffWrites.workers", serveCmd.PersistentFlags().Lookup("eth-statediff-write-workers"))
+	viper.BindPFlag("ethereum.stateDiffWrites.queueSize", serveCmd.PersistentFlags().Lookup("eth-statediff-write-queue-size"))
+	viper.BindPFlag("ethereum.stateDiffWrites.maxRange", serveCmd.PersistentFlags().Lookup("eth-statediff-write-max-range"))
+	viper.BindPFlag("ethereum.stateDiffWrites.timeout", serveCmd.PersistentFlags().Lookup("eth-statediff-write-timeout"))
+	viper.BindPFlag("ethereum.shadow.sampleRate", serveCmd.PersistentFlags().Lookup("eth-shadow-sample-rate"))
+	viper.BindPFlag("ethereum.shadow.methods", serveCmd.PersistentFlags().Lookup("eth-shadow-methods"))
+	viper.BindPFlag("ethereum.shadow.timeout", serveCmd.PersistentFlags().Lookup("eth-shadow-timeout"))
+	viper.BindPFlag("ethereum.shadow.maxConcurrent", serveCmd.PersistentFlags().Lookup("eth-shadow-max-concurrent"))
+	viper.BindPFlag("ethereum.hedge.methods", serveCmd.PersistentFlags().Lookup("eth-hedge-methods"))
 
 	// groupcache flags
 	viper.BindPFlag("groupcache.pool.enabled", serveCmd.PersistentFlags().Lookup("gcache-pool-enabled"))
@@ -405,6 +540,16 @@ func init() {
 	viper.BindPFlag("groupcache.statedb.cacheExpiryInMins", serveCmd.PersistentFlags().Lookup("gcache-statedb-cache-expiry"))
 	viper.BindPFlag("groupcache.statedb.logStatsIntervalInSecs", serveCmd.PersistentFlags().Lookup("gcache-statedb-log-stats-interval"))
 
+	// gap filler flags
+	viper.BindPFlag("gapfill.enabled", serveCmd.PersistentFlags().Lookup("gapfill-enabled"))
+	viper.BindPFlag("gapfill.start", serveCmd.PersistentFlags().Lookup("gapfill-start"))
+	viper.BindPFlag("gapfill.interval", serveCmd.PersistentFlags().Lookup("gapfill-interval"))
+	viper.BindPFlag("gapfill.workers", serveCmd.PersistentFlags().Lookup("gapfill-workers"))
+	viper.BindPFlag("gapfill.queueSize", serveCmd.PersistentFlags().Lookup("gapfill-queue-size"))
+	viper.BindPFlag("gapfill.rateLimit", serveCmd.PersistentFlags().Lookup("gapfill-rate-limit"))
+	viper.BindPFlag("gapfill.maxRetries", serveCmd.PersistentFlags().Lookup("gapfill-max-retries"))
+	viper.BindPFlag("gapfill.retryInterval", serveCmd.PersistentFlags().Lookup("gapfill-retry-interval"))
+
 	// state validator flags
 	viper.BindPFlag("validator.enabled", serveCmd.PersistentFlags().Lookup("validator-enabled"))
 	viper.BindPFlag("validator.everyNthBlock", serveCmd.PersistentFlags().Lookup("validator-every-nth-block"))
@@ -17,6 +17,7 @@ package cmd
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -80,6 +81,19 @@ func subscribe() {
 				logWithCommand.Error(payload.Err)
 				continue
 			}
+			if payload.ReorgNotice() {
+				fmt.Printf("Reorg at height %d, dropped blocks %v, new chain %v\n", payload.Height, payload.Reorg.Dropped, payload.Reorg.NewChain)
+				continue
+			}
+			if payload.Decoded != nil {
+				decodedJSON, err := json.MarshalIndent(payload.Decoded, "", "  ")
+				if err != nil {
+					logWithCommand.Error(err)
+					continue
+				}
+				fmt.Printf("Decoded payload at height %d\n%s\n", payload.Height, decodedJSON)
+				continue
+			}
 			var ethData eth.IPLDs
 			if err := rlp.DecodeBytes(payload.Data, &ethData); err != nil {
 				logWithCommand.Error(err)
@@ -19,7 +19,6 @@ package eth
 import (
 	"context"
 	"database/sql"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -34,14 +33,16 @@ import (
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/eth/filters"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/rpc"
-	"github.com/ethereum/go-ethereum/statediff"
 	"github.com/sirupsen/logrus"
 
+	"github.com/vulcanize/ipld-eth-server/pkg/prom"
 	"github.com/vulcanize/ipld-eth-server/pkg/shared"
 )
 
@@ -62,14 +63,27 @@ type PublicEthAPI struct {
 	ethClient         *ethclient.Client
 	forwardEthCalls   bool // if true, forward eth_call calls directly to the configured proxy node
 	proxyOnError      bool // turn on regular proxy fall-through on errors; needed to test difference between direct and indirect fall-through
+	writer            *StateDiffWriter // shared queue for the statediff writes requested on proxied cache misses
+	shadow            *Shadow          // compares a sample of the local answers with the proxy node's
+	hedger            *Hedger          // races the local lookups of the hedged methods against the proxy node
+}
+
+// APIOptions holds the optional behaviour of the PublicEthAPI
+type APIOptions struct {
+	SupportsStateDiff bool             // whether the proxy node supports the statediff_writeStateDiffAt endpoint
+	ForwardEthCalls   bool             // forward eth_call calls directly to the proxy node
+	ProxyOnError      bool             // fall through to the proxy node on errors
+	Writer            *StateDiffWriter // fills in the index on proxied cache misses, may be nil
+	Shadow            *Shadow          // compares a sample of the local answers with the proxy node's, may be nil
+	Hedger            *Hedger          // races the local lookups of the hedged methods against the proxy node, may be nil
 }
 
 // NewPublicEthAPI creates a new PublicEthAPI with the provided underlying Backend
-func NewPublicEthAPI(b *Backend, client *rpc.Client, supportsStateDiff, forwardEthCalls, proxyOnError bool) (*PublicEthAPI, error) {
-	if forwardEthCalls && client == nil {
+func NewPublicEthAPI(b *Backend, client *rpc.Client, opts APIOptions) (*PublicEthAPI, error) {
+	if opts.ForwardEthCalls && client == nil {
 		return nil, errors.New("ipld-eth-server is configured to forward eth_calls to proxy node but no proxy node is configured")
 	}
-	if proxyOnError && client == nil {
+	if opts.ProxyOnError && client == nil {
 		return nil, errors.New("ipld-eth-server is configured to forward all calls to proxy node on errors but no proxy node is configured")
 	}
 	var ethClient *ethclient.Client
@@ -78,11 +92,14 @@ func NewPublicEthAPI(b *Backend, client *rpc.Client, supportsStateDiff, forwardE
 	}
 	return &PublicEthAPI{
 		B:                 b,
-		supportsStateDiff: supportsStateDiff,
+		supportsStateDiff: opts.SupportsStateDiff,
 		rpc:               client,
 		ethClient:         ethClient,
-		forwardEthCalls:   forwardEthCalls,
-		proxyOnError:      proxyOnError,
+		forwardEthCalls:   opts.ForwardEthCalls,
+		proxyOnError:      opts.ProxyOnError,
+		writer:            opts.Writer,
+		shadow:            opts.Shadow,
+		hedger:            opts.Hedger,
 	}, nil
 }
 
@@ -98,11 +115,15 @@ Headers and blocks
 func (pea *PublicEthAPI) GetHeaderByNumber(ctx context.Context, number rpc.BlockNumber) (map[string]interface{}, error) {
 	header, err := pea.B.HeaderByNumber(ctx, number)
 	if header != nil && err == nil {
-		return pea.rpcMarshalHeader(header)
+		res, err := pea.rpcMarshalHeader(header)
+		if err == nil {
+			pea.shadow.Compare("eth_getHeaderByNumber", res, number)
+		}
+		return res, err
 	}
 	if pea.proxyOnError {
 		if header, err := pea.ethClient.HeaderByNumber(ctx, big.NewInt(number.Int64())); header != nil && err == nil {
-			go pea.writeStateDiffAt(number.Int64())
+			pea.writeStateDiffAt(number.Int64())
 			return pea.rpcMarshalHeader(header)
 		}
 	}
@@ -121,7 +142,7 @@ func (pea *PublicEthAPI) GetHeaderByHash(ctx context.Context, hash common.Hash)
 
 	if pea.proxyOnError {
 		if header, err := pea.ethClient.HeaderByHash(ctx, hash); header != nil && err == nil {
-			go pea.writeStateDiffFor(hash)
+			pea.writeStateDiffFor(hash)
 			if res, err := pea.rpcMarshalHeader(header); err != nil {
 				return res
 			}
@@ -156,14 +177,33 @@ func (pea *PublicEthAPI) BlockNumber() hexutil.Uint64 {
 // * When fullTx is true all transactions in the block are returned, otherwise
 //   only the transaction hash is returned.
 func (pea *PublicEthAPI) GetBlockByNumber(ctx context.Context, number rpc.BlockNumber, fullTx bool) (map[string]interface{}, error) {
+	if pea.hedger.Hedges("eth_getBlockByNumber") {
+		var res map[string]interface{}
+		winner, err := pea.hedger.Do(ctx, "eth_getBlockByNumber", func(ctx context.Context) (interface{}, error) {
+			block, err := pea.B.BlockByNumber(ctx, number)
+			if block == nil || err != nil {
+				return nil, err
+			}
+			return pea.rpcMarshalBlock(block, true, fullTx)
+		}, &res, number, fullTx)
+		if winner == prom.HedgeFallback {
+			pea.writeStateDiffAt(number.Int64())
+		}
+		return res, err
+	}
+
 	block, err := pea.B.BlockByNumber(ctx, number)
 	if block != nil && err == nil {
-		return pea.rpcMarshalBlock(block, true, fullTx)
+		res, err := pea.rpcMarshalBlock(block, true, fullTx)
+		if err == nil {
+			pea.shadow.Compare("eth_getBlockByNumber", res, number, fullTx)
+		}
+		return res, err
 	}
 
 	if pea.proxyOnError {
 		if block, err := pea.ethClient.BlockByNumber(ctx, big.NewInt(number.Int64())); block != nil && err == nil {
-			go pea.writeStateDiffAt(number.Int64())
+			pea.writeStateDiffAt(number.Int64())
 			return pea.rpcMarshalBlock(block, true, fullTx)
 		}
 	}
@@ -174,14 +214,33 @@ func (pea *PublicEthAPI) GetBlockByNumber(ctx context.Context, number rpc.BlockN
 // GetBlockByHash returns the requested block. When fullTx is true all transactions in the block are returned in full
 // detail, otherwise only the transaction hash is returned.
 func (pea *PublicEthAPI) GetBlockByHash(ctx context.Context, hash common.Hash, fullTx bool) (map[string]interface{}, error) {
+	if pea.hedger.Hedges("eth_getBlockByHash") {
+		var res map[string]interface{}
+		winner, err := pea.hedger.Do(ctx, "eth_getBlockByHash", func(ctx context.Context) (interface{}, error) {
+			block, err := pea.B.BlockByHash(ctx, hash)
+			if block == nil || err != nil {
+				return nil, err
+			}
+			return pea.rpcMarshalBlock(block, true, fullTx)
+		}, &res, hash, fullTx)
+		if winner == prom.HedgeFallback {
+			pea.writeStateDiffFor(hash)
+		}
+		return res, err
+	}
+
 	block, err := pea.B.BlockByHash(ctx, hash)
 	if block != nil && err == nil {
-		return pea.rpcMarshalBlock(block, true, fullTx)
+		res, err := pea.rpcMarshalBlock(block, true, fullTx)
+		if err == nil {
+			pea.shadow.Compare("eth_getBlockByHash", res, hash, fullTx)
+		}
+		return res, err
 	}
 
 	if pea.proxyOnError {
 		if block, err := pea.ethClient.BlockByHash(ctx, hash); block != nil && err == nil {
-			go pea.writeStateDiffFor(hash)
+			pea.writeStateDiffFor(hash)
 			return pea.rpcMarshalBlock(block, true, fullTx)
 		}
 	}
@@ -229,7 +288,7 @@ func (pea *PublicEthAPI) GetUncleByBlockNumberAndIndex(ctx context.Context, bloc
 
 	if pea.proxyOnError {
 		if uncle, uncleHashes, err := getBlockAndUncleHashes(pea.rpc, ctx, "eth_getUncleByBlockNumberAndIndex", blockNr, index); uncle != nil && err == nil {
-			go pea.writeStateDiffAt(blockNr.Int64())
+			pea.writeStateDiffAt(blockNr.Int64())
 			return pea.rpcMarshalBlockWithUncleHashes(uncle, uncleHashes, false, false)
 		}
 	}
@@ -253,7 +312,7 @@ func (pea *PublicEthAPI) GetUncleByBlockHashAndIndex(ctx context.Context, blockH
 
 	if pea.proxyOnError {
 		if uncle, uncleHashes, err := getBlockAndUncleHashes(pea.rpc, ctx, "eth_getUncleByBlockHashAndIndex", blockHash, index); uncle != nil && err == nil {
-			go pea.writeStateDiffFor(blockHash)
+			pea.writeStateDiffFor(blockHash)
 			return pea.rpcMarshalBlockWithUncleHashes(uncle, uncleHashes, false, false)
 		}
 	}
@@ -271,7 +330,7 @@ func (pea *PublicEthAPI) GetUncleCountByBlockNumber(ctx context.Context, blockNr
 	if pea.proxyOnError {
 		var num *hexutil.Uint
 		if err := pea.rpc.CallContext(ctx, &num, "eth_getUncleCountByBlockNumber", blockNr); num != nil && err == nil {
-			go pea.writeStateDiffAt(blockNr.Int64())
+			pea.writeStateDiffAt(blockNr.Int64())
 			return num
 		}
 	}
@@ -289,7 +348,7 @@ func (pea *PublicEthAPI) GetUncleCountByBlockHash(ctx context.Context, blockHash
 	if pea.proxyOnError {
 		var num *hexutil.Uint
 		if err := pea.rpc.CallContext(ctx, &num, "eth_getUncleCountByBlockHash", blockHash); num != nil && err == nil {
-			go pea.writeStateDiffFor(blockHash)
+			pea.writeStateDiffFor(blockHash)
 			return num
 		}
 	}
@@ -305,15 +364,27 @@ Transactions
 
 // GetTransactionCount returns the number of transactions the given address has sent for the given block number
 func (pea *PublicEthAPI) GetTransactionCount(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (*hexutil.Uint64, error) {
+	if pea.hedger.Hedges("eth_getTransactionCount") {
+		var count *hexutil.Uint64
+		winner, err := pea.hedger.Do(ctx, "eth_getTransactionCount", func(ctx context.Context) (interface{}, error) {
+			return pea.localGetTransactionCount(ctx, address, blockNrOrHash)
+		}, &count, address, blockNrOrHash)
+		if winner == prom.HedgeFallback {
+			pea.writeStateDiffAtOrFor(blockNrOrHash)
+		}
+		return count, err
+	}
+
 	count, err := pea.localGetTransactionCount(ctx, address, blockNrOrHash)
 	if count != nil && err == nil {
+		pea.shadow.Compare("eth_getTransactionCount", count, address, blockNrOrHash)
 		return count, nil
 	}
 
 	if pea.proxyOnError {
 		var num *hexutil.Uint64
 		if err := pea.rpc.CallContext(ctx, &num, "eth_getTransactionCount", address, blockNrOrHash); num != nil && err == nil {
-			go pea.writeStateDiffAtOrFor(blockNrOrHash)
+			pea.writeStateDiffAtOrFor(blockNrOrHash)
 			return num, nil
 		}
 	}
@@ -341,7 +412,7 @@ func (pea *PublicEthAPI) GetBlockTransactionCountByNumber(ctx context.Context, b
 	if pea.proxyOnError {
 		var num *hexutil.Uint
 		if err := pea.rpc.CallContext(ctx, &num, "eth_getBlockTransactionCountByNumber", blockNr); num != nil && err == nil {
-			go pea.writeStateDiffAt(blockNr.Int64())
+			pea.writeStateDiffAt(blockNr.Int64())
 			return num
 		}
 	}
@@ -359,7 +430,7 @@ func (pea *PublicEthAPI) GetBlockTransactionCountByHash(ctx context.Context, blo
 	if pea.proxyOnError {
 		var num *hexutil.Uint
 		if err := pea.rpc.CallContext(ctx, &num, "eth_getBlockTransactionCountByHash", blockHash); num != nil && err == nil {
-			go pea.writeStateDiffFor(blockHash)
+			pea.writeStateDiffFor(blockHash)
 			return num
 		}
 	}
@@ -376,7 +447,7 @@ func (pea *PublicEthAPI) GetTransactionByBlockNumberAndIndex(ctx context.Context
 	if pea.proxyOnError {
 		var tx *RPCTransaction
 		if err := pea.rpc.CallContext(ctx, &tx, "eth_getTransactionByBlockNumberAndIndex", blockNr, index); tx != nil && err == nil {
-			go pea.writeStateDiffAt(blockNr.Int64())
+			pea.writeStateDiffAt(blockNr.Int64())
 			return tx
 		}
 	}
@@ -393,7 +464,7 @@ func (pea *PublicEthAPI) GetTransactionByBlockHashAndIndex(ctx context.Context,
 	if pea.proxyOnError {
 		var tx *RPCTransaction
 		if err := pea.rpc.CallContext(ctx, &tx, "eth_getTransactionByBlockHashAndIndex", blockHash, index); tx != nil && err == nil {
-			go pea.writeStateDiffFor(blockHash)
+			pea.writeStateDiffFor(blockHash)
 			return tx
 		}
 	}
@@ -409,7 +480,7 @@ func (pea *PublicEthAPI) GetRawTransactionByBlockNumberAndIndex(ctx context.Cont
 	if pea.proxyOnError {
 		var tx hexutil.Bytes
 		if err := pea.rpc.CallContext(ctx, &tx, "eth_getRawTransactionByBlockNumberAndIndex", blockNr, index); tx != nil && err == nil {
-			go pea.writeStateDiffAt(blockNr.Int64())
+			pea.writeStateDiffAt(blockNr.Int64())
 			return tx
 		}
 	}
@@ -424,7 +495,7 @@ func (pea *PublicEthAPI) GetRawTransactionByBlockHashAndIndex(ctx context.Contex
 	if pea.proxyOnError {
 		var tx hexutil.Bytes
 		if err := pea.rpc.CallContext(ctx, &tx, "eth_getRawTransactionByBlockHashAndIndex", blockHash, index); tx != nil && err == nil {
-			go pea.writeStateDiffFor(blockHash)
+			pea.writeStateDiffFor(blockHash)
 			return tx
 		}
 	}
@@ -434,36 +505,60 @@ func (pea *PublicEthAPI) GetRawTransactionByBlockHashAndIndex(ctx context.Contex
 // GetTransactionByHash returns the transaction for the given hash
 // eth ipld-eth-server cannot currently handle pending/tx_pool txs
 func (pea *PublicEthAPI) GetTransactionByHash(ctx context.Context, hash common.Hash) (*RPCTransaction, error) {
-	tx, blockHash, blockNumber, index, err := pea.B.GetTransaction(ctx, hash)
-	if tx != nil && err == nil {
-		header, err := pea.B.HeaderByHash(ctx, blockHash)
-		if err != nil {
-			return nil, err
+	if pea.hedger.Hedges("eth_getTransactionByHash") {
+		var res *RPCTransaction
+		winner, err := pea.hedger.Do(ctx, "eth_getTransactionByHash", func(ctx context.Context) (interface{}, error) {
+			return pea.localGetTransactionByHash(ctx, hash)
+		}, &res, hash)
+		if winner == prom.HedgeFallback {
+			pea.writeStateDiffFor(hash)
 		}
+		return res, err
+	}
 
-		return NewRPCTransaction(tx, blockHash, blockNumber, index, header.BaseFee), nil
+	res, err := pea.localGetTransactionByHash(ctx, hash)
+	if res != nil && err == nil {
+		pea.shadow.Compare("eth_getTransactionByHash", res, hash)
+		return res, nil
 	}
 	if pea.proxyOnError {
 		var tx *RPCTransaction
 		if err := pea.rpc.CallContext(ctx, &tx, "eth_getTransactionByHash", hash); tx != nil && err == nil {
-			go pea.writeStateDiffFor(hash)
+			pea.writeStateDiffFor(hash)
 			return tx, nil
 		}
 	}
 	return nil, err
 }
 
+func (pea *PublicEthAPI) localGetTransactionByHash(ctx context.Context, hash common.Hash) (*RPCTransaction, error) {
+	tx, blockHash, blockNumber, index, err := pea.B.GetTransaction(ctx, hash)
+	if tx == nil || err != nil {
+		return nil, err
+	}
+	header, err := pea.B.HeaderByHash(ctx, blockHash)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewRPCTransaction(tx, blockHash, blockNumber, index, header.BaseFee), nil
+}
+
 // GetRawTransactionByHash returns the bytes of the transaction for the given hash.
 func (pea *PublicEthAPI) GetRawTransactionByHash(ctx context.Context, hash common.Hash) (hexutil.Bytes, error) {
 	// Retrieve a finalized transaction, or a pooled otherwise
 	tx, _, _, _, err := pea.B.GetTransaction(ctx, hash)
 	if tx != nil && err == nil {
-		return rlp.EncodeToBytes(tx)
+		res, err := rlp.EncodeToBytes(tx)
+		if err == nil {
+			pea.shadow.Compare("eth_getRawTransactionByHash", hexutil.Bytes(res), hash)
+		}
+		return res, err
 	}
 	if pea.proxyOnError {
 		var tx hexutil.Bytes
 		if err := pea.rpc.CallContext(ctx, &tx, "eth_getRawTransactionByHash", hash); tx != nil && err == nil {
-			go pea.writeStateDiffFor(hash)
+			pea.writeStateDiffFor(hash)
 			return tx, nil
 		}
 	}
@@ -478,13 +573,25 @@ Receipts and Logs
 
 // GetTransactionReceipt returns the transaction receipt for the given transaction hash.
 func (pea *PublicEthAPI) GetTransactionReceipt(ctx context.Context, hash common.Hash) (map[string]interface{}, error) {
+	if pea.hedger.Hedges("eth_getTransactionReceipt") {
+		var receipt map[string]interface{}
+		winner, err := pea.hedger.Do(ctx, "eth_getTransactionReceipt", func(ctx context.Context) (interface{}, error) {
+			return pea.localGetTransactionReceipt(ctx, hash)
+		}, &receipt, hash)
+		if winner == prom.HedgeFallback {
+			pea.writeStateDiffFor(hash)
+		}
+		return receipt, err
+	}
+
 	receipt, err := pea.localGetTransactionReceipt(ctx, hash)
 	if receipt != nil && err == nil {
+		pea.shadow.Compare("eth_getTransactionReceipt", receipt, hash)
 		return receipt, nil
 	}
 	if pea.proxyOnError {
 		if receipt := pea.remoteGetTransactionReceipt(ctx, hash); receipt != nil {
-			go pea.writeStateDiffFor(hash)
+			pea.writeStateDiffFor(hash)
 			return receipt, nil
 		}
 	}
@@ -579,11 +686,25 @@ func (pea *PublicEthAPI) remoteGetTransactionReceipt(ctx context.Context, hash c
 //
 // https://github.com/ethereum/wiki/wiki/JSON-RPC#eth_getlogs
 func (pea *PublicEthAPI) GetLogs(ctx context.Context, crit filters.FilterCriteria) ([]*types.Log, error) {
+	if pea.hedger.Hedges("eth_getLogs") {
+		var logs []*types.Log
+		winner, err := pea.hedger.Do(ctx, "eth_getLogs", func(ctx context.Context) (interface{}, error) {
+			return pea.localGetLogs(crit)
+		}, &logs, filterArg(crit))
+		if winner == prom.HedgeFallback {
+			pea.writeStateDiffWithCriteria(crit)
+		}
+		return logs, err
+	}
+
 	logs, err := pea.localGetLogs(crit)
+	if err == nil {
+		pea.shadow.Compare("eth_getLogs", logs, crit)
+	}
 	if err != nil && pea.proxyOnError {
 		var res []*types.Log
 		if err := pea.rpc.CallContext(ctx, &res, "eth_getLogs", crit); err == nil {
-			go pea.writeStateDiffWithCriteria(crit)
+			pea.writeStateDiffWithCriteria(crit)
 			return res, nil
 		}
 	}
@@ -690,14 +811,29 @@ State and Storage
 // given block number. The rpc.LatestBlockNumber and rpc.PendingBlockNumber meta
 // block numbers are also allowed.
 func (pea *PublicEthAPI) GetBalance(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (*hexutil.Big, error) {
+	if pea.hedger.Hedges("eth_getBalance") {
+		var bal *hexutil.Big
+		winner, err := pea.hedger.Do(ctx, "eth_getBalance", func(ctx context.Context) (interface{}, error) {
+			return pea.localGetBalance(ctx, address, blockNrOrHash)
+		}, &bal, address, blockNrOrHash)
+		if winner == prom.HedgeFallback {
+			pea.writeStateDiffAtOrFor(blockNrOrHash)
+		}
+		if err == sql.ErrNoRows {
+			return (*hexutil.Big)(big.NewInt(0)), nil
+		}
+		return bal, err
+	}
+
 	bal, err := pea.localGetBalance(ctx, address, blockNrOrHash)
 	if bal != nil && err == nil {
+		pea.shadow.Compare("eth_getBalance", bal, address, blockNrOrHash)
 		return bal, nil
 	}
 	if pea.proxyOnError {
 		var res *hexutil.Big
 		if err := pea.rpc.CallContext(ctx, &res, "eth_getBalance", address, blockNrOrHash); res != nil && err == nil {
-			go pea.writeStateDiffAtOrFor(blockNrOrHash)
+			pea.writeStateDiffAtOrFor(blockNrOrHash)
 			return res, nil
 		}
 	}
@@ -720,24 +856,32 @@ func (pea *PublicEthAPI) localGetBalance(ctx context.Context, address common.Add
 // block number. The rpc.LatestBlockNumber and rpc.PendingBlockNumber meta block
 // numbers are also allowed.
 func (pea *PublicEthAPI) GetStorageAt(ctx context.Context, address common.Address, key string, blockNrOrHash rpc.BlockNumberOrHash) (hexutil.Bytes, error) {
-	storageVal, err := pea.B.GetStorageByNumberOrHash(ctx, address, common.HexToHash(key), blockNrOrHash)
-	if storageVal != nil && err == nil {
-		var value common.Hash
-		_, content, _, err := rlp.Split(storageVal)
-		if err == io.ErrUnexpectedEOF {
-			return hexutil.Bytes{}, nil
+	if pea.hedger.Hedges("eth_getStorageAt") {
+		var value hexutil.Bytes
+		winner, err := pea.hedger.Do(ctx, "eth_getStorageAt", func(ctx context.Context) (interface{}, error) {
+			return pea.localGetStorageAt(ctx, address, key, blockNrOrHash)
+		}, &value, address, key, blockNrOrHash)
+		if winner == prom.HedgeFallback {
+			pea.writeStateDiffAtOrFor(blockNrOrHash)
 		}
-		if err != nil {
-			return nil, err
+		if err == sql.ErrNoRows {
+			return make([]byte, 32), nil
 		}
-		value.SetBytes(content)
+		return value, err
+	}
 
-		return value[:], nil
+	storageVal, err := pea.B.GetStorageByNumberOrHash(ctx, address, common.HexToHash(key), blockNrOrHash)
+	if storageVal != nil && err == nil {
+		value, err := decodeStorageValue(storageVal)
+		if err == nil {
+			pea.shadow.Compare("eth_getStorageAt", value, address, key, blockNrOrHash)
+		}
+		return value, err
 	}
 	if pea.proxyOnError {
 		var res hexutil.Bytes
 		if err := pea.rpc.CallContext(ctx, &res, "eth_getStorageAt", address, key, blockNrOrHash); res != nil && err == nil {
-			go pea.writeStateDiffAtOrFor(blockNrOrHash)
+			pea.writeStateDiffAtOrFor(blockNrOrHash)
 			return res, nil
 		}
 	}
@@ -747,16 +891,55 @@ func (pea *PublicEthAPI) GetStorageAt(ctx context.Context, address common.Addres
 	return nil, err
 }
 
+func (pea *PublicEthAPI) localGetStorageAt(ctx context.Context, address common.Address, key string, blockNrOrHash rpc.BlockNumberOrHash) (hexutil.Bytes, error) {
+	storageVal, err := pea.B.GetStorageByNumberOrHash(ctx, address, common.HexToHash(key), blockNrOrHash)
+	if storageVal == nil || err != nil {
+		return nil, err
+	}
+	return decodeStorageValue(storageVal)
+}
+
+// decodeStorageValue unwraps the RLP encoded value of a storage leaf
+func decodeStorageValue(storageVal []byte) (hexutil.Bytes, error) {
+	var value common.Hash
+	_, content, _, err := rlp.Split(storageVal)
+	if err == io.ErrUnexpectedEOF {
+		return hexutil.Bytes{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	value.SetBytes(content)
+
+	return value[:], nil
+}
+
 // GetCode returns the code stored at the given address in the state for the given block number.
 func (pea *PublicEthAPI) GetCode(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (hexutil.Bytes, error) {
+	if pea.hedger.Hedges("eth_getCode") {
+		var code hexutil.Bytes
+		winner, err := pea.hedger.Do(ctx, "eth_getCode", func(ctx context.Context) (interface{}, error) {
+			code, err := pea.B.GetCodeByNumberOrHash(ctx, address, blockNrOrHash)
+			return hexutil.Bytes(code), err
+		}, &code, address, blockNrOrHash)
+		if winner == prom.HedgeFallback {
+			pea.writeStateDiffAtOrFor(blockNrOrHash)
+		}
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return code, err
+	}
+
 	code, err := pea.B.GetCodeByNumberOrHash(ctx, address, blockNrOrHash)
 	if code != nil && err == nil {
+		pea.shadow.Compare("eth_getCode", hexutil.Bytes(code), address, blockNrOrHash)
 		return code, nil
 	}
 	if pea.proxyOnError {
 		var res hexutil.Bytes
 		if err := pea.rpc.CallContext(ctx, &res, "eth_getCode", address, blockNrOrHash); res != nil && err == nil {
-			go pea.writeStateDiffAtOrFor(blockNrOrHash)
+			pea.writeStateDiffAtOrFor(blockNrOrHash)
 			return res, nil
 		}
 	}
@@ -776,7 +959,7 @@ func (pea *PublicEthAPI) GetProof(ctx context.Context, address common.Address, s
 	if pea.proxyOnError {
 		var res *AccountResult
 		if err := pea.rpc.CallContext(ctx, &res, "eth_getProof", address, storageKeys, blockNrOrHash); res != nil && err == nil {
-			go pea.writeStateDiffAtOrFor(blockNrOrHash)
+			pea.writeStateDiffAtOrFor(blockNrOrHash)
 			return res, nil
 		}
 	}
@@ -927,6 +1110,27 @@ func (pea *PublicEthAPI) Call(ctx context.Context, args CallArgs, blockNrOrHash
 		return hex, err
 	}
 
+	if pea.hedger.Hedges("eth_call") {
+		var hex hexutil.Bytes
+		winner, err := pea.hedger.Do(ctx, "eth_call", func(ctx context.Context) (interface{}, error) {
+			result, err := DoCall(ctx, pea.B, args, blockNrOrHash, overrides, 5*time.Second, pea.B.Config.RPCGasCap.Uint64())
+			if err != nil {
+				return nil, err
+			}
+			if len(result.Revert()) > 0 {
+				return nil, newRevertError(result)
+			}
+			if result.Err != nil {
+				return nil, result.Err
+			}
+			return hexutil.Bytes(result.Return()), nil
+		}, &hex, args, blockNrOrHash, overrides)
+		if winner == prom.HedgeFallback {
+			pea.writeStateDiffAtOrFor(blockNrOrHash)
+		}
+		return hex, err
+	}
+
 	result, err := DoCall(ctx, pea.B, args, blockNrOrHash, overrides, 5*time.Second, pea.B.Config.RPCGasCap.Uint64())
 
 	// If the result contains a revert reason, try to unpack and return it.
@@ -938,10 +1142,14 @@ func (pea *PublicEthAPI) Call(ctx context.Context, args CallArgs, blockNrOrHash
 		}
 	}
 
+	if err == nil {
+		pea.shadow.Compare("eth_call", hexutil.Bytes(result.Return()), args, blockNrOrHash, overrides)
+	}
+
 	if err != nil && pea.proxyOnError {
 		var hex hexutil.Bytes
 		if err := pea.rpc.CallContext(ctx, &hex, "eth_call", args, blockNrOrHash, overrides); hex != nil && err == nil {
-			go pea.writeStateDiffAtOrFor(blockNrOrHash)
+			pea.writeStateDiffAtOrFor(blockNrOrHash)
 			return hex, nil
 		}
 	}
@@ -1009,10 +1217,122 @@ func DoCall(ctx context.Context, b *Backend, args CallArgs, blockNrOrHash rpc.Bl
 	return result, nil
 }
 
-// writeStateDiffAtOrFor calls out to the proxy statediffing geth client to fill in a gap in the index
+// DoEstimateGas binary searches the lowest gas limit with which the call succeeds on the state for the given block
+// number or hash, with the overrides applied, between the intrinsic gas and the block's gas limit, capped by the
+// sender's balance and the gas cap.
+func DoEstimateGas(ctx context.Context, b *Backend, args CallArgs, blockNrOrHash rpc.BlockNumberOrHash, overrides *StateOverride, gasCap uint64) (hexutil.Uint64, error) {
+	var (
+		lo  = params.TxGas - 1
+		hi  uint64
+		cap uint64
+	)
+	// Use zero address if sender unspecified.
+	if args.From == nil {
+		args.From = new(common.Address)
+	}
+	// Determine the highest gas limit can be used during the estimation.
+	if args.Gas != nil && uint64(*args.Gas) >= params.TxGas {
+		hi = uint64(*args.Gas)
+	} else {
+		header, err := b.HeaderByNumberOrHash(ctx, blockNrOrHash)
+		if err != nil {
+			return 0, err
+		}
+		hi = header.GasLimit
+	}
+	// Normalize the max fee per gas the call is willing to spend.
+	var feeCap *big.Int
+	if args.GasPrice != nil && (args.MaxFeePerGas != nil || args.MaxPriorityFeePerGas != nil) {
+		return 0, errors.New("both gasPrice and (maxFeePerGas or maxPriorityFeePerGas) specified")
+	} else if args.GasPrice != nil {
+		feeCap = args.GasPrice.ToInt()
+	} else if args.MaxFeePerGas != nil {
+		feeCap = args.MaxFeePerGas.ToInt()
+	} else {
+		feeCap = common.Big0
+	}
+	// Recap the highest gas limit with account's available balance.
+	if feeCap.BitLen() != 0 {
+		state, _, err := b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+		if state == nil || err != nil {
+			return 0, err
+		}
+		if err := overrides.Apply(state); err != nil {
+			return 0, err
+		}
+		available := new(big.Int).Set(state.GetBalance(*args.From))
+		if args.Value != nil {
+			if args.Value.ToInt().Cmp(available) >= 0 {
+				return 0, errors.New("insufficient funds for transfer")
+			}
+			available.Sub(available, args.Value.ToInt())
+		}
+		allowance := new(big.Int).Div(available, feeCap)
+
+		// If the allowance is larger than maximum uint64, skip checking
+		if allowance.IsUint64() && hi > allowance.Uint64() {
+			logrus.Debugf("Gas estimation capped by limited funds: original %d, balance %s, fee cap %s, fund allowance %s",
+				hi, available, feeCap, allowance)
+			hi = allowance.Uint64()
+		}
+	}
+	// Recap the highest gas allowance with specified gascap.
+	if gasCap != 0 && hi > gasCap {
+		logrus.Debugf("Caller gas above allowance, capping: requested %d, cap %d", hi, gasCap)
+		hi = gasCap
+	}
+	cap = hi
+
+	// Create a helper to check if a gas allowance results in an executable transaction
+	executable := func(gas uint64) (bool, *core.ExecutionResult, error) {
+		args.Gas = (*hexutil.Uint64)(&gas)
+
+		result, err := DoCall(ctx, b, args, blockNrOrHash, overrides, 0, gasCap)
+		if err != nil {
+			if errors.Is(err, core.ErrIntrinsicGas) {
+				return true, nil, nil // Special case, raise gas limit
+			}
+			return true, nil, err // Bail out
+		}
+		return result.Failed(), result, nil
+	}
+	// Execute the binary search and hone in on an executable gas limit
+	for lo+1 < hi {
+		mid := (hi + lo) / 2
+		failed, _, err := executable(mid)
+		if err != nil {
+			return 0, err
+		}
+		if failed {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	// Reject the transaction as invalid if it still fails at the highest allowance
+	if hi == cap {
+		failed, result, err := executable(hi)
+		if err != nil {
+			return 0, err
+		}
+		if failed {
+			if result != nil && result.Err != vm.ErrOutOfGas {
+				if len(result.Revert()) > 0 {
+					return 0, newRevertError(result)
+				}
+				return 0, result.Err
+			}
+			// Otherwise, the specified gas cap is too low
+			return 0, fmt.Errorf("gas required exceeds allowance (%d)", cap)
+		}
+	}
+	return hexutil.Uint64(hi), nil
+}
+
+// writeStateDiffAtOrFor queues a call out to the proxy statediffing geth client to fill in a gap in the index
 func (pea *PublicEthAPI) writeStateDiffAtOrFor(blockNrOrHash rpc.BlockNumberOrHash) {
 	// short circuit right away if the proxy doesn't support diffing
-	if !pea.supportsStateDiff {
+	if !pea.supportsStateDiff || pea.writer == nil {
 		return
 	}
 	if blockNr, ok := blockNrOrHash.Number(); ok {
@@ -1024,10 +1344,11 @@ func (pea *PublicEthAPI) writeStateDiffAtOrFor(blockNrOrHash rpc.BlockNumberOrHa
 	}
 }
 
-// writeStateDiffWithCriteria calls out to the proxy statediffing geth client to fill in a gap in the index
+// writeStateDiffWithCriteria queues calls out to the proxy statediffing geth client to fill in a gap in the index
+// the range of heights written is capped by the writer
 func (pea *PublicEthAPI) writeStateDiffWithCriteria(crit filters.FilterCriteria) {
 	// short circuit right away if the proxy doesn't support diffing
-	if !pea.supportsStateDiff {
+	if !pea.supportsStateDiff || pea.writer == nil {
 		return
 	}
 	if crit.BlockHash != nil {
@@ -1043,53 +1364,23 @@ func (pea *PublicEthAPI) writeStateDiffWithCriteria(crit filters.FilterCriteria)
 	} else {
 		end = start
 	}
-	for i := start; i <= end; i++ {
-		pea.writeStateDiffAt(i)
-	}
+	pea.writer.WriteRange(start, end)
 }
 
-// writeStateDiffAt calls out to the proxy statediffing geth client to fill in a gap in the index
+// writeStateDiffAt queues a call out to the proxy statediffing geth client to fill in a gap in the index
 func (pea *PublicEthAPI) writeStateDiffAt(height int64) {
-	if !pea.supportsStateDiff {
+	if !pea.supportsStateDiff || pea.writer == nil {
 		return
 	}
-	// we use a separate context than the one provided by the client
-	ctx, cancel := context.WithTimeout(context.Background(), 240*time.Second)
-	defer cancel()
-	var data json.RawMessage
-	params := statediff.Params{
-		IntermediateStateNodes:   true,
-		IntermediateStorageNodes: true,
-		IncludeBlock:             true,
-		IncludeReceipts:          true,
-		IncludeTD:                true,
-		IncludeCode:              true,
-	}
-	if err := pea.rpc.CallContext(ctx, &data, "statediff_writeStateDiffAt", uint64(height), params); err != nil {
-		logrus.Errorf("writeStateDiffAt %d faild with err %s", height, err.Error())
-	}
+	pea.writer.WriteAt(height)
 }
 
-// writeStateDiffFor calls out to the proxy statediffing geth client to fill in a gap in the index
+// writeStateDiffFor queues a call out to the proxy statediffing geth client to fill in a gap in the index
 func (pea *PublicEthAPI) writeStateDiffFor(blockHash common.Hash) {
-	if !pea.supportsStateDiff {
+	if !pea.supportsStateDiff || pea.writer == nil {
 		return
 	}
-	// we use a separate context than the one provided by the client
-	ctx, cancel := context.WithTimeout(context.Background(), 240*time.Second)
-	defer cancel()
-	var data json.RawMessage
-	params := statediff.Params{
-		IntermediateStateNodes:   true,
-		IntermediateStorageNodes: true,
-		IncludeBlock:             true,
-		IncludeReceipts:          true,
-		IncludeTD:                true,
-		IncludeCode:              true,
-	}
-	if err := pea.rpc.CallContext(ctx, &data, "statediff_writeStateDiffFor", blockHash, params); err != nil {
-		logrus.Errorf("writeStateDiffFor %s faild with err %s", blockHash.Hex(), err.Error())
-	}
+	pea.writer.WriteFor(blockHash)
 }
 
 // rpcMarshalBlock uses the generalized output filler, then adds the total difficulty field
@@ -21,6 +21,7 @@ import (
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/statediff/indexer/models"
 	"github.com/ethereum/go-ethereum/statediff/indexer/postgres"
@@ -110,6 +111,15 @@ func (ecr *CIDRetriever) Retrieve(filter SubscriptionSettings, blockNumber int64
 				cw.Uncles = uncleCIDs
 			}
 		}
+		// Retrieve the ids of the trxs which satisfy the filter expression, if there is one
+		var exprTxIDs map[int64]bool
+		if filter.Expression != "" && (!filter.TxFilter.Off || !filter.ReceiptFilter.Off) {
+			exprTxIDs, err = ecr.RetrieveExpressionTxIDs(tx, filter.Expression, header.ID)
+			if err != nil {
+				log.Error("filter expression evaluation error")
+				return nil, true, err
+			}
+		}
 		// Retrieve cached trx CIDs
 		if !filter.TxFilter.Off {
 			cw.Transactions, err = ecr.RetrieveTxCIDs(tx, filter.TxFilter, header.ID)
@@ -117,6 +127,15 @@ func (ecr *CIDRetriever) Retrieve(filter SubscriptionSettings, blockNumber int64
 				log.Error("transaction cid retrieval error")
 				return nil, true, err
 			}
+			if exprTxIDs != nil {
+				matched := make([]models.TxModel, 0, len(cw.Transactions))
+				for _, txCID := range cw.Transactions {
+					if exprTxIDs[txCID.ID] {
+						matched = append(matched, txCID)
+					}
+				}
+				cw.Transactions = matched
+			}
 			if len(cw.Transactions) > 0 {
 				empty = false
 			}
@@ -132,6 +151,15 @@ func (ecr *CIDRetriever) Retrieve(filter SubscriptionSettings, blockNumber int64
 				log.Error("receipt cid retrieval error")
 				return nil, true, err
 			}
+			if exprTxIDs != nil {
+				matched := make([]models.ReceiptModel, 0, len(cw.Receipts))
+				for _, rctCID := range cw.Receipts {
+					if exprTxIDs[rctCID.TxID] {
+						matched = append(matched, rctCID)
+					}
+				}
+				cw.Receipts = matched
+			}
 			if len(cw.Receipts) > 0 {
 				empty = false
 			}
@@ -186,14 +214,19 @@ func (ecr *CIDRetriever) RetrieveUncleCIDsByHeaderID(tx *sqlx.Tx, headerID int64
 // also returns the ids for the returned transaction cids
 func (ecr *CIDRetriever) RetrieveTxCIDs(tx *sqlx.Tx, txFilter TxFilter, headerID int64) ([]models.TxModel, error) {
 	log.Debug("retrieving transaction cids for header id ", headerID)
-	args := make([]interface{}, 0, 3)
+	args := make([]interface{}, 0, 6)
 	results := make([]models.TxModel, 0)
 	id := 1
-	pgStr := fmt.Sprintf(`SELECT transaction_cids.id, transaction_cids.header_id,
+	pgStr := `SELECT transaction_cids.id, transaction_cids.header_id,
  			transaction_cids.tx_hash, transaction_cids.cid, transaction_cids.mh_key,
  			transaction_cids.dst, transaction_cids.src, transaction_cids.index, transaction_cids.tx_data
- 			FROM eth.transaction_cids INNER JOIN eth.header_cids ON (transaction_cids.header_id = header_cids.id)
-			WHERE header_cids.id = $%d`, id)
+ 			FROM eth.transaction_cids INNER JOIN eth.header_cids ON (transaction_cids.header_id = header_cids.id)`
+	if txFilter.FailedOnly {
+		// pre-Byzantium receipts carry a post-state root instead of a status and are never considered failed
+		pgStr += ` INNER JOIN eth.receipt_cids ON (receipt_cids.tx_id = transaction_cids.id
+			AND receipt_cids.post_status = 0 AND COALESCE(receipt_cids.post_state, '') = '')`
+	}
+	pgStr += fmt.Sprintf(` WHERE header_cids.id = $%d`, id)
 	args = append(args, headerID)
 	id++
 	if len(txFilter.Dst) > 0 {
@@ -204,9 +237,147 @@ func (ecr *CIDRetriever) RetrieveTxCIDs(tx *sqlx.Tx, txFilter TxFilter, headerID
 	if len(txFilter.Src) > 0 {
 		pgStr += fmt.Sprintf(` AND transaction_cids.src = ANY($%d::VARCHAR(66)[])`, id)
 		args = append(args, pq.Array(txFilter.Src))
+		id++
+	}
+	if len(txFilter.MethodSelectors) > 0 {
+		selectors := make([][]byte, len(txFilter.MethodSelectors))
+		for i, selector := range txFilter.MethodSelectors {
+			selectors[i] = common.FromHex(selector)
+		}
+		pgStr += fmt.Sprintf(` AND substring(transaction_cids.tx_data from 1 for 4) = ANY($%d::BYTEA[])`, id)
+		args = append(args, pq.ByteaArray(selectors))
+		id++
+	}
+	if txFilter.ContractCreation {
+		pgStr += ` AND transaction_cids.dst = ''`
+	}
+	if len(txFilter.Types) > 0 {
+		txTypes := make([]int64, len(txFilter.Types))
+		for i, ty := range txFilter.Types {
+			txTypes[i] = int64(ty)
+		}
+		pgStr += fmt.Sprintf(` AND COALESCE(transaction_cids.tx_type, 0) = ANY($%d::INTEGER[])`, id)
+		args = append(args, pq.Array(txTypes))
 	}
 	pgStr += ` ORDER BY transaction_cids.index`
-	return results, tx.Select(&results, pgStr, args...)
+	if err := tx.Select(&results, pgStr, args...); err != nil {
+		return nil, err
+	}
+	if txFilter.MinValue == nil && txFilter.MaxValue == nil {
+		return results, nil
+	}
+	return filterTxCIDsByValue(tx, txFilter, results)
+}
+
+// filterTxCIDsByValue filters the tx cids down to those whose value falls within the filter's value range
+// values are not indexed so they are decoded from the tx IPLDs
+func filterTxCIDsByValue(tx *sqlx.Tx, txFilter TxFilter, txCIDs []models.TxModel) ([]models.TxModel, error) {
+	filtered := make([]models.TxModel, 0, len(txCIDs))
+	for _, txCID := range txCIDs {
+		txBytes, err := shared.FetchIPLDByMhKey(tx, txCID.MhKey)
+		if err != nil {
+			return nil, err
+		}
+		var trx types.Transaction
+		if err := trx.UnmarshalBinary(txBytes); err != nil {
+			return nil, err
+		}
+		if txFilter.MinValue != nil && trx.Value().Cmp(txFilter.MinValue) < 0 {
+			continue
+		}
+		if txFilter.MaxValue != nil && trx.Value().Cmp(txFilter.MaxValue) > 0 {
+			continue
+		}
+		filtered = append(filtered, txCID)
+	}
+	return filtered, nil
+}
+
+// exprTxRow holds the indexed fields of a trx and its receipt needed to evaluate a filter expression
+type exprTxRow struct {
+	ID          int64  `db:"id"`
+	Index       int64  `db:"index"`
+	Src         string `db:"src"`
+	MhKey       string `db:"mh_key"`
+	BlockNumber int64  `db:"block_number"`
+	BlockHash   string `db:"block_hash"`
+	RctMhKey    string `db:"leaf_mh_key"`
+	Contract    string `db:"contract"`
+}
+
+// RetrieveExpressionTxIDs retrieves the ids of the trxs at the provided header ID which satisfy the filter expression
+// the expression is pushed down to SQL where possible, otherwise it is evaluated against the decoded trx and receipt IPLDs
+func (ecr *CIDRetriever) RetrieveExpressionTxIDs(tx *sqlx.Tx, expression string, headerID int64) (map[int64]bool, error) {
+	log.Debug("retrieving filter expression matches for header id ", headerID)
+	expr, err := compileFilterExpression(expression)
+	if err != nil {
+		return nil, err
+	}
+	args := []interface{}{headerID}
+	id := 2
+	pgStr := `SELECT transaction_cids.id, transaction_cids.index, transaction_cids.src, transaction_cids.mh_key,
+			header_cids.block_number, header_cids.block_hash, receipt_cids.leaf_mh_key, receipt_cids.contract
+			FROM eth.transaction_cids
+			INNER JOIN eth.header_cids ON (transaction_cids.header_id = header_cids.id)
+			INNER JOIN eth.receipt_cids ON (receipt_cids.tx_id = transaction_cids.id)
+			WHERE header_cids.id = $1`
+	cond, args, pushed := expr.sqlCondition(&id, args)
+	if cond != "" {
+		pgStr += " AND " + cond
+	}
+	rows := make([]exprTxRow, 0)
+	if err := tx.Select(&rows, pgStr, args...); err != nil {
+		return nil, err
+	}
+	txIDs := make(map[int64]bool, len(rows))
+	for _, row := range rows {
+		if !pushed {
+			view, err := expressionView(tx, row)
+			if err != nil {
+				return nil, err
+			}
+			if !expr.match(view) {
+				continue
+			}
+		}
+		txIDs[row.ID] = true
+	}
+	return txIDs, nil
+}
+
+// expressionView decodes the trx and receipt IPLDs for the row into the view filter expressions are evaluated against
+func expressionView(tx *sqlx.Tx, row exprTxRow) (*exprTx, error) {
+	txBytes, err := shared.FetchIPLDByMhKey(tx, row.MhKey)
+	if err != nil {
+		return nil, err
+	}
+	trx := new(types.Transaction)
+	if err := trx.UnmarshalBinary(txBytes); err != nil {
+		return nil, err
+	}
+	rctLeaf, err := shared.FetchIPLDByMhKey(tx, row.RctMhKey)
+	if err != nil {
+		return nil, err
+	}
+	rctBytes, err := DecodeLeafNode(rctLeaf)
+	if err != nil {
+		return nil, err
+	}
+	rct := new(types.Receipt)
+	if err := rct.UnmarshalBinary(rctBytes); err != nil {
+		return nil, err
+	}
+	if row.Contract != "" {
+		rct.ContractAddress = common.HexToAddress(row.Contract)
+	}
+	return &exprTx{
+		blockNumber: big.NewInt(row.BlockNumber),
+		blockHash:   common.HexToHash(row.BlockHash),
+		index:       row.Index,
+		from:        row.Src,
+		tx:          trx,
+		rct:         rct,
+	}, nil
 }
 
 func topicFilterCondition(id *int, topics [][]string, args []interface{}, pgStr string, first bool) (string, []interface{}) {
@@ -604,3 +775,62 @@ func (ecr *CIDRetriever) RetrieveReceiptCIDsByTxIDs(tx *sqlx.Tx, txIDs []int64)
 	var rctCIDs []models.ReceiptModel
 	return rctCIDs, tx.Select(&rctCIDs, pgStr, pq.Array(txIDs))
 }
+
+// RetrieveCanonicalHeaderPage retrieves the canonical headers between the provided block numbers, inclusive, in ascending order
+// At most limit headers are returned, the next page starts after the number of the last one
+func (ecr *CIDRetriever) RetrieveCanonicalHeaderPage(from, to int64, limit int) ([]HeaderPageResult, error) {
+	log.Debugf("retrieving a page of %d canonical headers from block %d to %d", limit, from, to)
+	pgStr := `SELECT block_number, block_hash FROM eth.header_cids
+			WHERE block_number BETWEEN $1 AND $2
+			AND id = (SELECT canonical_header_id(block_number))
+			ORDER BY block_number
+			LIMIT $3`
+	headers := make([]HeaderPageResult, 0, limit)
+	return headers, ecr.db.Select(&headers, pgStr, from, to, limit)
+}
+
+// RetrieveCanonicalTxPage retrieves the transactions of the canonical blocks between the provided block numbers, inclusive,
+// ordered by block number and index, which follow the provided key
+// At most limit transactions are returned
+func (ecr *CIDRetriever) RetrieveCanonicalTxPage(from, to int64, after TxPageKey, limit int) ([]TxPageResult, error) {
+	log.Debugf("retrieving a page of %d canonical transactions from block %d to %d", limit, from, to)
+	pgStr := `SELECT header_cids.block_number, header_cids.block_hash, transaction_cids.index, transaction_cids.tx_hash
+			FROM eth.transaction_cids INNER JOIN eth.header_cids ON (transaction_cids.header_id = header_cids.id)
+			WHERE header_cids.block_number BETWEEN $1 AND $2
+			AND header_cids.id = (SELECT canonical_header_id(header_cids.block_number))
+			AND (header_cids.block_number, transaction_cids.index) > ($3, $4)
+			ORDER BY header_cids.block_number, transaction_cids.index
+			LIMIT $5`
+	txs := make([]TxPageResult, 0, limit)
+	return txs, ecr.db.Select(&txs, pgStr, from, to, after.BlockNumber, after.Index, limit)
+}
+
+// RetrieveCanonicalLogPage retrieves the logs of the canonical blocks between the provided block numbers, inclusive,
+// that conform to the provided filter parameters, ordered by block number, transaction index and log index,
+// which follow the provided key
+// At most limit logs are returned
+func (ecr *CIDRetriever) RetrieveCanonicalLogPage(rctFilter ReceiptFilter, from, to int64, after LogPageKey, limit int) ([]LogResult, error) {
+	log.Debugf("retrieving a page of %d canonical logs from block %d to %d", limit, from, to)
+	args := []interface{}{from, to, after.BlockNumber, after.TxIndex, after.Index}
+	pgStr := `SELECT eth.log_cids.leaf_cid, eth.log_cids.index, eth.log_cids.receipt_id,
+       			eth.log_cids.address, eth.log_cids.topic0, eth.log_cids.topic1, eth.log_cids.topic2, eth.log_cids.topic3,
+       			eth.log_cids.log_data, eth.transaction_cids.tx_hash, eth.transaction_cids.index as txn_index,
+       			header_cids.block_hash, header_cids.block_number, data,
+       			eth.receipt_cids.leaf_cid as cid, eth.receipt_cids.post_status
+				FROM eth.log_cids, eth.receipt_cids, eth.transaction_cids, eth.header_cids, public.blocks
+				WHERE eth.log_cids.receipt_id = receipt_cids.id
+				AND receipt_cids.tx_id = transaction_cids.id
+				AND transaction_cids.header_id = header_cids.id
+				AND log_cids.leaf_mh_key = blocks.key
+				AND header_cids.block_number BETWEEN $1 AND $2
+				AND header_cids.id = (SELECT canonical_header_id(header_cids.block_number))
+				AND (header_cids.block_number, transaction_cids.index, log_cids.index) > ($3, $4, $5)`
+	id := len(args) + 1
+
+	pgStr, args = logFilterCondition(&id, pgStr, args, rctFilter)
+	pgStr += fmt.Sprintf(` ORDER BY header_cids.block_number, transaction_cids.index, log_cids.index LIMIT $%d`, id)
+	args = append(args, limit)
+
+	logCIDs := make([]LogResult, 0, limit)
+	return logCIDs, ecr.db.Select(&logCIDs, pgStr, args...)
+}
@@ -17,6 +17,7 @@
 package eth_test
 
 import (
+	"fmt"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/params"
@@ -433,6 +434,79 @@ var _ = Describe("Retriever", func() {
 			Expect(err).ToNot(HaveOccurred())
 			Expect(empty).To(BeTrue())
 		})
+
+		It("Applies the selector, value, creation, type and status transaction filters", func() {
+			txOnly := func(txFilter eth.TxFilter) eth.SubscriptionSettings {
+				return eth.SubscriptionSettings{
+					Start:         big.NewInt(0),
+					End:           big.NewInt(1),
+					HeaderFilter:  eth.HeaderFilter{Off: true},
+					TxFilter:      txFilter,
+					ReceiptFilter: eth.ReceiptFilter{Off: true},
+					StateFilter:   eth.StateFilter{Off: true},
+					StorageFilter: eth.StorageFilter{Off: true},
+				}
+			}
+
+			cids1, _, err := retriever.Retrieve(txOnly(eth.TxFilter{MethodSelectors: []string{"0x00010203"}}), 1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(cids1[0].Transactions)).To(Equal(1))
+			Expect(eth.TxModelsContainsCID(cids1[0].Transactions, test_helpers.Trx3CID.String())).To(BeTrue())
+
+			cids2, _, err := retriever.Retrieve(txOnly(eth.TxFilter{MinValue: big.NewInt(1500), MaxValue: big.NewInt(1999)}), 1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(cids2[0].Transactions)).To(Equal(1))
+			Expect(eth.TxModelsContainsCID(cids2[0].Transactions, test_helpers.Trx3CID.String())).To(BeTrue())
+
+			cids3, _, err := retriever.Retrieve(txOnly(eth.TxFilter{ContractCreation: true}), 1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(cids3[0].Transactions)).To(Equal(1))
+			Expect(eth.TxModelsContainsCID(cids3[0].Transactions, test_helpers.Trx3CID.String())).To(BeTrue())
+
+			cids4, _, err := retriever.Retrieve(txOnly(eth.TxFilter{Types: []uint64{types.DynamicFeeTxType}}), 1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(cids4[0].Transactions)).To(Equal(0))
+
+			cids5, _, err := retriever.Retrieve(txOnly(eth.TxFilter{FailedOnly: true}), 1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(cids5[0].Transactions)).To(Equal(1))
+			Expect(eth.TxModelsContainsCID(cids5[0].Transactions, test_helpers.Trx4CID.String())).To(BeTrue())
+		})
+
+		It("Applies filter expressions, whether or not they can be pushed down", func() {
+			withExpression := func(expression string) eth.SubscriptionSettings {
+				return eth.SubscriptionSettings{
+					Start:         big.NewInt(0),
+					End:           big.NewInt(1),
+					HeaderFilter:  eth.HeaderFilter{Off: true},
+					StateFilter:   eth.StateFilter{Off: true},
+					StorageFilter: eth.StorageFilter{Off: true},
+					Expression:    expression,
+				}
+			}
+
+			expression := fmt.Sprintf(`log.address == "%s" && log.topic1 == "%s"`,
+				test_helpers.AnotherAddress1.Hex(), test_helpers.MockLog4.Topics[1].Hex())
+			cids1, empty, err := retriever.Retrieve(withExpression(expression), 1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(empty).ToNot(BeTrue())
+			Expect(len(cids1[0].Transactions)).To(Equal(1))
+			Expect(eth.TxModelsContainsCID(cids1[0].Transactions, test_helpers.Trx3CID.String())).To(BeTrue())
+			Expect(len(cids1[0].Receipts)).To(Equal(1))
+			Expect(cids1[0].Receipts[0].LeafCID).To(Equal(test_helpers.Rct3CID.String()))
+
+			cids2, _, err := retriever.Retrieve(withExpression(`receipt.status == 0`), 1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(cids2[0].Transactions)).To(Equal(1))
+			Expect(eth.TxModelsContainsCID(cids2[0].Transactions, test_helpers.Trx4CID.String())).To(BeTrue())
+
+			cids3, _, err := retriever.Retrieve(withExpression(`tx.value >= 2000 && tx.to != ""`), 1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(cids3[0].Transactions)).To(Equal(2))
+			Expect(eth.TxModelsContainsCID(cids3[0].Transactions, test_helpers.Trx2CID.String())).To(BeTrue())
+			Expect(eth.TxModelsContainsCID(cids3[0].Transactions, test_helpers.Trx4CID.String())).To(BeTrue())
+			Expect(len(cids3[0].Receipts)).To(Equal(2))
+		})
 	})
 
 	Describe("RetrieveFirstBlockNumber", func() {
@@ -0,0 +1,686 @@
+// VulcanizeDB
+// Copyright © 2022 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/lib/pq"
+)
+
+// Filter expression limits
+const (
+	// MaxFilterExpressionLength is the length in bytes of the longest accepted expression
+	MaxFilterExpressionLength = 4096
+	// maxExpressionDepth caps the nesting of parentheses and negations
+	maxExpressionDepth = 32
+	// compiledExpressionsSize is the number of parsed expressions kept in the cache
+	compiledExpressionsSize = 256
+)
+
+// FilterExpression is a parsed subscription filter expression
+//
+// Expressions are written in a small boolean language of their own over a typed view of a transaction, its receipt,
+// its logs and the block they belong to, e.g.
+//
+//	log.address == "0x..." && log.topic1 == "0x..." && tx.from in ["0x...", "0x..."]
+//
+// The grammar is
+//
+//	expression = and { "||" and } .
+//	and        = unary { "&&" unary } .
+//	unary      = "!" unary | "(" expression ")" | comparison .
+//	comparison = field ( "==" | "!=" | "<" | "<=" | ">" | ">=" ) literal | field "in" "[" literal { "," literal } "]" .
+//	literal    = number | string .
+//	number     = decimal or 0x prefixed hexadecimal digits .
+//	string     = characters between double or single quotes, without escapes .
+//
+// where field is one of the exprFields; expressions are at most MaxFilterExpressionLength bytes long and nest at most
+// maxExpressionDepth parentheses and negations
+// String comparisons are case-insensitive, ordering comparisons are only defined on numeric fields
+// A comparison against a field which is not set (e.g. tx.to for a contract creation, or a log field for a transaction
+// without logs) is neither true nor false, as in SQL
+// A transaction matches when the expression is true for at least one of its logs, or for the transaction itself when it
+// emitted no logs
+type FilterExpression struct {
+	source string
+	root   exprNode
+}
+
+type exprKind int
+
+const (
+	exprNumber exprKind = iota
+	exprString
+)
+
+// exprField describes a field of the typed view
+// column is the SQL expression for the field, it is empty for fields which are not indexed and can't be pushed down
+type exprField struct {
+	kind   exprKind
+	column string
+}
+
+// exprFields are the fields available to filter expressions
+// receipt and log columns refer to the expr_rct and expr_log aliases joined in by FilterExpression.sqlCondition
+var exprFields = map[string]exprField{
+	"block.number":     {exprNumber, "header_cids.block_number"},
+	"block.hash":       {exprString, "header_cids.block_hash"},
+	"tx.hash":          {exprString, "transaction_cids.tx_hash"},
+	"tx.from":          {exprString, "NULLIF(transaction_cids.src, '')"},
+	"tx.to":            {exprString, "NULLIF(transaction_cids.dst, '')"},
+	"tx.index":         {exprNumber, "transaction_cids.index"},
+	"tx.type":          {exprNumber, "COALESCE(transaction_cids.tx_type, 0)"},
+	"tx.selector":      {exprString, "CASE WHEN length(transaction_cids.tx_data) >= 4 THEN '0x' || encode(substring(transaction_cids.tx_data from 1 for 4), 'hex') END"},
+	"tx.value":         {exprNumber, ""},
+	"receipt.status":   {exprNumber, "CASE WHEN COALESCE(expr_rct.post_state, '') = '' THEN expr_rct.post_status END"},
+	"receipt.contract": {exprString, "NULLIF(expr_rct.contract, '')"},
+	"log.address":      {exprString, "NULLIF(expr_log.address, '')"},
+	"log.topic0":       {exprString, "NULLIF(expr_log.topic0, '')"},
+	"log.topic1":       {exprString, "NULLIF(expr_log.topic1, '')"},
+	"log.topic2":       {exprString, "NULLIF(expr_log.topic2, '')"},
+	"log.topic3":       {exprString, "NULLIF(expr_log.topic3, '')"},
+}
+
+// compiledExpressions caches the most recently used parsed expressions by source, since they are evaluated for every payload
+var compiledExpressions, _ = lru.New(compiledExpressionsSize)
+
+// ParseFilterExpression parses and type checks a filter expression
+func ParseFilterExpression(source string) (*FilterExpression, error) {
+	if len(source) > MaxFilterExpressionLength {
+		return nil, fmt.Errorf("filter expression: longer than the maximum of %d bytes", MaxFilterExpressionLength)
+	}
+	tokens, err := lexExpression(source)
+	if err != nil {
+		return nil, err
+	}
+	p := &exprParser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, fmt.Errorf("filter expression: unexpected %q at offset %d", tok.text, tok.pos)
+	}
+	return &FilterExpression{source: source, root: root}, nil
+}
+
+func compileFilterExpression(source string) (*FilterExpression, error) {
+	if expr, ok := compiledExpressions.Get(source); ok {
+		return expr.(*FilterExpression), nil
+	}
+	expr, err := ParseFilterExpression(source)
+	if err != nil {
+		return nil, err
+	}
+	compiledExpressions.Add(source, expr)
+	return expr, nil
+}
+
+// String returns the source of the expression
+func (e *FilterExpression) String() string {
+	return e.source
+}
+
+// Pushable returns true if the expression can be evaluated entirely in SQL
+func (e *FilterExpression) Pushable() bool {
+	return e.root.pushable()
+}
+
+// sqlCondition returns a condition selecting the transaction_cids rows matching the pushable conjuncts of the expression
+// the query it is appended to must have eth.transaction_cids and eth.header_cids in scope
+// the returned bool is true if the whole expression was pushed down, otherwise the matches still need to be evaluated in Go
+func (e *FilterExpression) sqlCondition(id *int, args []interface{}) (string, []interface{}, bool) {
+	conjuncts := flattenAnd(e.root)
+	pushed := make([]string, 0, len(conjuncts))
+	for _, conjunct := range conjuncts {
+		if !conjunct.pushable() {
+			continue
+		}
+		var cond string
+		cond, args = conjunct.sql(id, args)
+		pushed = append(pushed, cond)
+	}
+	if len(pushed) == 0 {
+		return "", args, false
+	}
+	cond := `EXISTS (SELECT 1 FROM eth.receipt_cids AS expr_rct
+			LEFT JOIN eth.log_cids AS expr_log ON (expr_log.receipt_id = expr_rct.id)
+			WHERE expr_rct.tx_id = transaction_cids.id AND ` + strings.Join(pushed, " AND ") + `)`
+	return cond, args, len(pushed) == len(conjuncts)
+}
+
+// exprTx is the typed view of a transaction that expressions are evaluated against
+type exprTx struct {
+	blockNumber *big.Int
+	blockHash   common.Hash
+	index       int64
+	from        string
+	tx          *types.Transaction
+	rct         *types.Receipt // nil if unknown, ContractAddress is expected to be set for contract creations
+}
+
+// match evaluates the expression against the transaction and each of its logs
+func (e *FilterExpression) match(view *exprTx) bool {
+	if view.rct == nil || len(view.rct.Logs) == 0 {
+		return e.root.eval(view, nil) == ternTrue
+	}
+	for _, lg := range view.rct.Logs {
+		if e.root.eval(view, lg) == ternTrue {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPayload returns, for each transaction in the payload, whether or not it matches the expression
+func (e *FilterExpression) matchPayload(payload ConvertedPayload) []bool {
+	txs := payload.Block.Transactions()
+	matches := make([]bool, len(txs))
+	for i, trx := range txs {
+		view := &exprTx{
+			blockNumber: payload.Block.Number(),
+			blockHash:   payload.Block.Hash(),
+			index:       int64(i),
+			tx:          trx,
+		}
+		if i < len(payload.TxMetaData) {
+			view.from = payload.TxMetaData[i].Src
+		}
+		if i < len(payload.Receipts) {
+			view.rct = payload.Receipts[i]
+		}
+		matches[i] = e.match(view)
+	}
+	return matches
+}
+
+// value returns the value of the field for the view, as a *big.Int or lower case string, or nil if it is not set
+func (v *exprTx) value(field string, lg *types.Log) interface{} {
+	switch field {
+	case "block.number":
+		return v.blockNumber
+	case "block.hash":
+		return lowerHex(v.blockHash.Hex())
+	case "tx.hash":
+		return lowerHex(v.tx.Hash().Hex())
+	case "tx.from":
+		if v.from == "" {
+			return nil
+		}
+		return lowerHex(v.from)
+	case "tx.to":
+		if v.tx.To() == nil {
+			return nil
+		}
+		return lowerHex(v.tx.To().Hex())
+	case "tx.index":
+		return big.NewInt(v.index)
+	case "tx.type":
+		return big.NewInt(int64(v.tx.Type()))
+	case "tx.selector":
+		if len(v.tx.Data()) < 4 {
+			return nil
+		}
+		return hexutil.Encode(v.tx.Data()[:4])
+	case "tx.value":
+		return v.tx.Value()
+	case "receipt.status":
+		if v.rct == nil || len(v.rct.PostState) > 0 {
+			return nil
+		}
+		return new(big.Int).SetUint64(v.rct.Status)
+	case "receipt.contract":
+		if v.rct == nil || v.rct.ContractAddress == (common.Address{}) {
+			return nil
+		}
+		return lowerHex(v.rct.ContractAddress.Hex())
+	}
+	if lg == nil {
+		return nil
+	}
+	switch field {
+	case "log.address":
+		return lowerHex(lg.Address.Hex())
+	case "log.topic0", "log.topic1", "log.topic2", "log.topic3":
+		i := int(field[len(field)-1] - '0')
+		if i >= len(lg.Topics) {
+			return nil
+		}
+		return lowerHex(lg.Topics[i].Hex())
+	}
+	return nil
+}
+
+func lowerHex(s string) string {
+	return strings.ToLower(s)
+}
+
+// ternary is the result of evaluating an expression using three-valued logic
+type ternary int
+
+const (
+	ternFalse ternary = iota
+	ternTrue
+	ternUnknown
+)
+
+func ternaryOf(b bool) ternary {
+	if b {
+		return ternTrue
+	}
+	return ternFalse
+}
+
+type exprNode interface {
+	eval(view *exprTx, lg *types.Log) ternary
+	sql(id *int, args []interface{}) (string, []interface{})
+	pushable() bool
+}
+
+type exprAnd struct {
+	left, right exprNode
+}
+
+func (n *exprAnd) eval(view *exprTx, lg *types.Log) ternary {
+	left, right := n.left.eval(view, lg), n.right.eval(view, lg)
+	if left == ternFalse || right == ternFalse {
+		return ternFalse
+	}
+	if left == ternUnknown || right == ternUnknown {
+		return ternUnknown
+	}
+	return ternTrue
+}
+
+func (n *exprAnd) sql(id *int, args []interface{}) (string, []interface{}) {
+	left, args := n.left.sql(id, args)
+	right, args := n.right.sql(id, args)
+	return "(" + left + " AND " + right + ")", args
+}
+
+func (n *exprAnd) pushable() bool {
+	return n.left.pushable() && n.right.pushable()
+}
+
+type exprOr struct {
+	left, right exprNode
+}
+
+func (n *exprOr) eval(view *exprTx, lg *types.Log) ternary {
+	left, right := n.left.eval(view, lg), n.right.eval(view, lg)
+	if left == ternTrue || right == ternTrue {
+		return ternTrue
+	}
+	if left == ternUnknown || right == ternUnknown {
+		return ternUnknown
+	}
+	return ternFalse
+}
+
+func (n *exprOr) sql(id *int, args []interface{}) (string, []interface{}) {
+	left, args := n.left.sql(id, args)
+	right, args := n.right.sql(id, args)
+	return "(" + left + " OR " + right + ")", args
+}
+
+func (n *exprOr) pushable() bool {
+	return n.left.pushable() && n.right.pushable()
+}
+
+type exprNot struct {
+	operand exprNode
+}
+
+func (n *exprNot) eval(view *exprTx, lg *types.Log) ternary {
+	switch n.operand.eval(view, lg) {
+	case ternTrue:
+		return ternFalse
+	case ternFalse:
+		return ternTrue
+	default:
+		return ternUnknown
+	}
+}
+
+func (n *exprNot) sql(id *int, args []interface{}) (string, []interface{}) {
+	operand, args := n.operand.sql(id, args)
+	return "(NOT " + operand + ")", args
+}
+
+func (n *exprNot) pushable() bool {
+	return n.operand.pushable()
+}
+
+// exprComparison compares a field against one literal, or a list of literals for the in operator
+// literals are *big.Int for numeric fields and lower case strings for string fields
+type exprComparison struct {
+	field    string
+	op       string
+	literals []interface{}
+}
+
+func (n *exprComparison) eval(view *exprTx, lg *types.Log) ternary {
+	actual := view.value(n.field, lg)
+	if actual == nil {
+		return ternUnknown
+	}
+	if n.op == "in" {
+		for _, literal := range n.literals {
+			if compareValues(actual, literal) == 0 {
+				return ternTrue
+			}
+		}
+		return ternFalse
+	}
+	cmp := compareValues(actual, n.literals[0])
+	switch n.op {
+	case "==":
+		return ternaryOf(cmp == 0)
+	case "!=":
+		return ternaryOf(cmp != 0)
+	case "<":
+		return ternaryOf(cmp < 0)
+	case "<=":
+		return ternaryOf(cmp <= 0)
+	case ">":
+		return ternaryOf(cmp > 0)
+	default:
+		return ternaryOf(cmp >= 0)
+	}
+}
+
+func compareValues(actual, literal interface{}) int {
+	switch a := actual.(type) {
+	case *big.Int:
+		return a.Cmp(literal.(*big.Int))
+	default:
+		return strings.Compare(a.(string), literal.(string))
+	}
+}
+
+func (n *exprComparison) sql(id *int, args []interface{}) (string, []interface{}) {
+	field := exprFields[n.field]
+	column, cast := field.column, "NUMERIC"
+	if field.kind == exprString {
+		column, cast = "lower("+field.column+")", "TEXT"
+	}
+	var cond string
+	if n.op == "in" {
+		values := make([]string, len(n.literals))
+		for i, literal := range n.literals {
+			values[i] = literalString(literal)
+		}
+		cond = fmt.Sprintf(`(%s = ANY($%d::%s[]))`, column, *id, cast)
+		args = append(args, pq.Array(values))
+	} else {
+		op := n.op
+		if op == "!=" {
+			op = "<>"
+		}
+		cond = fmt.Sprintf(`(%s %s $%d::%s)`, column, op, *id, cast)
+		args = append(args, literalString(n.literals[0]))
+	}
+	*id++
+	return cond, args
+}
+
+func literalString(literal interface{}) string {
+	if num, ok := literal.(*big.Int); ok {
+		return num.String()
+	}
+	return literal.(string)
+}
+
+func (n *exprComparison) pushable() bool {
+	return exprFields[n.field].column != ""
+}
+
+// flattenAnd splits the expression into its top level conjuncts
+func flattenAnd(node exprNode) []exprNode {
+	if and, ok := node.(*exprAnd); ok {
+		return append(flattenAnd(and.left), flattenAnd(and.right)...)
+	}
+	return []exprNode{node}
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokNumber
+	tokString
+	tokOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func lexExpression(source string) ([]token, error) {
+	tokens := make([]token, 0)
+	for i := 0; i < len(source); {
+		c := source[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case isIdentStart(c):
+			start := i
+			for i < len(source) && (isIdentStart(source[i]) || isDigit(source[i]) || source[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{tokIdent, source[start:i], start})
+		case isDigit(c):
+			start := i
+			for i < len(source) && (isDigit(source[i]) || isIdentStart(source[i])) {
+				i++
+			}
+			tokens = append(tokens, token{tokNumber, source[start:i], start})
+		case c == '"' || c == '\'':
+			start := i
+			end := strings.IndexByte(source[i+1:], c)
+			if end < 0 {
+				return nil, fmt.Errorf("filter expression: unterminated string at offset %d", start)
+			}
+			tokens = append(tokens, token{tokString, source[i+1 : i+1+end], start})
+			i += end + 2
+		default:
+			op := ""
+			for _, candidate := range []string{"&&", "||", "==", "!=", "<=", ">=", "<", ">", "!", "(", ")", "[", "]", ","} {
+				if strings.HasPrefix(source[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("filter expression: unexpected character %q at offset %d", c, i)
+			}
+			tokens = append(tokens, token{tokOp, op, i})
+			i += len(op)
+		}
+	}
+	return append(tokens, token{tokEOF, "end of expression", len(source)}), nil
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+type exprParser struct {
+	tokens []token
+	next   int
+	depth  int
+}
+
+func (p *exprParser) peek() token {
+	return p.tokens[p.next]
+}
+
+func (p *exprParser) consume() token {
+	tok := p.tokens[p.next]
+	if tok.kind != tokEOF {
+		p.next++
+	}
+	return tok
+}
+
+func (p *exprParser) accept(op string) bool {
+	if tok := p.peek(); tok.kind == tokOp && tok.text == op {
+		p.next++
+		return true
+	}
+	return false
+}
+
+func (p *exprParser) expect(op string) error {
+	if !p.accept(op) {
+		tok := p.peek()
+		return fmt.Errorf("filter expression: expected %q but found %q at offset %d", op, tok.text, tok.pos)
+	}
+	return nil
+}
+
+func (p *exprParser) parseOr() (exprNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("||") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &exprOr{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseAnd() (exprNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("&&") {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &exprAnd{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseUnary() (exprNode, error) {
+	if p.depth >= maxExpressionDepth {
+		tok := p.peek()
+		return nil, fmt.Errorf("filter expression: nested deeper than %d levels at offset %d", maxExpressionDepth, tok.pos)
+	}
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.accept("!") {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &exprNot{operand: operand}, nil
+	}
+	if p.accept("(") {
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return node, p.expect(")")
+	}
+	return p.parseComparison()
+}
+
+func (p *exprParser) parseComparison() (exprNode, error) {
+	tok := p.consume()
+	if tok.kind != tokIdent {
+		return nil, fmt.Errorf("filter expression: expected a field but found %q at offset %d", tok.text, tok.pos)
+	}
+	field, ok := exprFields[tok.text]
+	if !ok {
+		return nil, fmt.Errorf("filter expression: unknown field %s at offset %d", tok.text, tok.pos)
+	}
+	node := &exprComparison{field: tok.text}
+	opTok := p.consume()
+	switch {
+	case opTok.kind == tokIdent && opTok.text == "in":
+		node.op = "in"
+		if err := p.expect("["); err != nil {
+			return nil, err
+		}
+		for {
+			literal, err := p.parseLiteral(field.kind)
+			if err != nil {
+				return nil, err
+			}
+			node.literals = append(node.literals, literal)
+			if !p.accept(",") {
+				break
+			}
+		}
+		return node, p.expect("]")
+	case opTok.kind == tokOp && (opTok.text == "==" || opTok.text == "!="):
+		node.op = opTok.text
+	case opTok.kind == tokOp && (opTok.text == "<" || opTok.text == "<=" || opTok.text == ">" || opTok.text == ">="):
+		if field.kind != exprNumber {
+			return nil, fmt.Errorf("filter expression: %s is not numeric and can't be compared with %s", tok.text, opTok.text)
+		}
+		node.op = opTok.text
+	default:
+		return nil, fmt.Errorf("filter expression: expected a comparison but found %q at offset %d", opTok.text, opTok.pos)
+	}
+	literal, err := p.parseLiteral(field.kind)
+	if err != nil {
+		return nil, err
+	}
+	node.literals = []interface{}{literal}
+	return node, nil
+}
+
+func (p *exprParser) parseLiteral(kind exprKind) (interface{}, error) {
+	tok := p.consume()
+	switch {
+	case kind == exprNumber && tok.kind == tokNumber:
+		num, ok := new(big.Int).SetString(tok.text, 0)
+		if !ok {
+			return nil, fmt.Errorf("filter expression: invalid number %s at offset %d", tok.text, tok.pos)
+		}
+		return num, nil
+	case kind == exprString && tok.kind == tokString:
+		return strings.ToLower(tok.text), nil
+	case kind == exprNumber:
+		return nil, fmt.Errorf("filter expression: expected a number but found %q at offset %d", tok.text, tok.pos)
+	default:
+		return nil, fmt.Errorf("filter expression: expected a string but found %q at offset %d", tok.text, tok.pos)
+	}
+}
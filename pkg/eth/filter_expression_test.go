@@ -0,0 +1,259 @@
+// VulcanizeDB
+// Copyright © 2022 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package eth_test
+
+import (
+	"fmt"
+	"math/big"
+	"math/rand"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/ipld-eth-server/pkg/eth"
+	"github.com/vulcanize/ipld-eth-server/pkg/eth/test_helpers"
+	"github.com/vulcanize/ipld-eth-server/pkg/shared"
+)
+
+var _ = Describe("FilterExpression", func() {
+	Describe("ParseFilterExpression", func() {
+		It("Rejects malformed and ill-typed expressions", func() {
+			for _, expression := range []string{
+				`tx.gasLimit == 1`,
+				`tx.value == "0x01"`,
+				`tx.from > "0x01"`,
+				`log.address == 1`,
+				`tx.to == "0x01`,
+				`(tx.index == 1`,
+				`tx.index in [1, 2`,
+				`tx.index == 1 tx.index == 2`,
+				`"0x01" == tx.to`,
+			} {
+				_, err := eth.ParseFilterExpression(expression)
+				Expect(err).To(HaveOccurred(), expression)
+			}
+		})
+
+		It("Rejects expressions which are too long or nested too deeply", func() {
+			_, err := eth.ParseFilterExpression(strings.Repeat(`tx.index == 1 || `, eth.MaxFilterExpressionLength/16) + `tx.index == 1`)
+			Expect(err).To(HaveOccurred())
+			_, err = eth.ParseFilterExpression(strings.Repeat(`!`, 1000) + `tx.index == 1`)
+			Expect(err).To(HaveOccurred())
+			_, err = eth.ParseFilterExpression(strings.Repeat(`(`, 1000) + `tx.index == 1` + strings.Repeat(`)`, 1000))
+			Expect(err).To(HaveOccurred())
+			_, err = eth.ParseFilterExpression(strings.Repeat(`(`, 8) + `tx.index == 1` + strings.Repeat(`)`, 8))
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("Only considers expressions over indexed fields pushable", func() {
+			expr, err := eth.ParseFilterExpression(`log.topic0 in ["0x01", "0x02"] && !(receipt.status == 0) || tx.type >= 0x2`)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(expr.Pushable()).To(BeTrue())
+
+			expr, err = eth.ParseFilterExpression(`tx.from == "0x01" && tx.value > 1000`)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(expr.Pushable()).To(BeFalse())
+		})
+	})
+
+	Describe("Filter", func() {
+		BeforeEach(func() {
+			filterer = eth.NewResponseFilterer()
+		})
+		withExpression := func(expression string) eth.SubscriptionSettings {
+			return eth.SubscriptionSettings{
+				Start:         big.NewInt(0),
+				End:           big.NewInt(1),
+				HeaderFilter:  eth.HeaderFilter{Off: true},
+				StateFilter:   eth.StateFilter{Off: true},
+				StorageFilter: eth.StorageFilter{Off: true},
+				Expression:    expression,
+			}
+		}
+
+		It("Applies the expression to each log of a transaction", func() {
+			expression := fmt.Sprintf(`log.address == "%s" && log.topic1 == "%s" && tx.from == "%s"`,
+				strings.ToLower(test_helpers.AnotherAddress1.Hex()), test_helpers.MockLog4.Topics[1].Hex(), test_helpers.SenderAddr.Hex())
+			iplds, err := filterer.Filter(withExpression(expression), test_helpers.MockConvertedPayload)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(iplds.Transactions)).To(Equal(1))
+			Expect(shared.IPLDsContainBytes(iplds.Transactions, test_helpers.Tx3)).To(BeTrue())
+			Expect(len(iplds.Receipts)).To(Equal(1))
+			Expect(shared.IPLDsContainBytes(iplds.Receipts, test_helpers.Rct3IPLD)).To(BeTrue())
+
+			// no single log has both the address and the topic
+			expression = fmt.Sprintf(`log.address == "%s" && log.topic0 == "%s"`,
+				test_helpers.AnotherAddress1.Hex(), test_helpers.MockLog4.Topics[1].Hex())
+			iplds, err = filterer.Filter(withExpression(expression), test_helpers.MockConvertedPayload)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(iplds.Transactions)).To(Equal(0))
+			Expect(len(iplds.Receipts)).To(Equal(0))
+		})
+
+		It("Does not match comparisons against fields which are not set", func() {
+			iplds, err := filterer.Filter(withExpression(`receipt.status == 0`), test_helpers.MockConvertedPayload)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(iplds.Transactions)).To(Equal(1))
+			Expect(shared.IPLDsContainBytes(iplds.Transactions, test_helpers.Tx4)).To(BeTrue())
+
+			// the contract creation has no recipient, so it matches neither the comparison nor its negation
+			iplds, err = filterer.Filter(withExpression(fmt.Sprintf(`!(tx.to == "%s")`, test_helpers.Address.Hex())), test_helpers.MockConvertedPayload)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(iplds.Transactions)).To(Equal(2))
+			Expect(shared.IPLDsContainBytes(iplds.Transactions, test_helpers.Tx2)).To(BeTrue())
+			Expect(shared.IPLDsContainBytes(iplds.Transactions, test_helpers.Tx4)).To(BeTrue())
+		})
+
+		It("Evaluates fields which are not indexed", func() {
+			iplds, err := filterer.Filter(withExpression(`tx.value >= 2000 || tx.index in [0]`), test_helpers.MockConvertedPayload)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(iplds.Transactions)).To(Equal(3))
+			Expect(shared.IPLDsContainBytes(iplds.Transactions, test_helpers.Tx1)).To(BeTrue())
+			Expect(shared.IPLDsContainBytes(iplds.Transactions, test_helpers.Tx2)).To(BeTrue())
+			Expect(shared.IPLDsContainBytes(iplds.Transactions, test_helpers.Tx4)).To(BeTrue())
+			Expect(len(iplds.Receipts)).To(Equal(3))
+		})
+	})
+
+	Describe("Fuzzing", func() {
+		var rnd *rand.Rand
+		BeforeEach(func() {
+			rnd = rand.New(rand.NewSource(GinkgoRandomSeed()))
+			filterer = eth.NewResponseFilterer()
+		})
+
+		numericFields := []string{"block.number", "tx.index", "tx.type", "tx.value", "receipt.status"}
+		stringFields := []string{"block.hash", "tx.hash", "tx.from", "tx.to", "tx.selector", "receipt.contract",
+			"log.address", "log.topic0", "log.topic1", "log.topic2", "log.topic3"}
+		literal := func(numeric bool) string {
+			switch {
+			case numeric && rnd.Intn(2) == 0:
+				return fmt.Sprintf("%d", rnd.Int63())
+			case numeric:
+				return fmt.Sprintf("0x%x", rnd.Int63())
+			case rnd.Intn(2) == 0:
+				return fmt.Sprintf(`"0x%x"`, rnd.Int63())
+			default:
+				return fmt.Sprintf(`'0x%X'`, rnd.Int63())
+			}
+		}
+		comparison := func() string {
+			numeric := rnd.Intn(2) == 0
+			field := stringFields[rnd.Intn(len(stringFields))]
+			ops := []string{"==", "!="}
+			if numeric {
+				field = numericFields[rnd.Intn(len(numericFields))]
+				ops = append(ops, "<", "<=", ">", ">=")
+			}
+			if rnd.Intn(4) == 0 {
+				literals := make([]string, 1+rnd.Intn(3))
+				for i := range literals {
+					literals[i] = literal(numeric)
+				}
+				return field + " in [" + strings.Join(literals, ", ") + "]"
+			}
+			return field + " " + ops[rnd.Intn(len(ops))] + " " + literal(numeric)
+		}
+		// expression generates a random well formed expression
+		var expression func(depth int) string
+		expression = func(depth int) string {
+			if depth == 0 {
+				return comparison()
+			}
+			switch rnd.Intn(5) {
+			case 0:
+				return expression(depth-1) + " && " + expression(depth-1)
+			case 1:
+				return expression(depth-1) + " || " + expression(depth-1)
+			case 2:
+				return "!" + expression(depth-1)
+			case 3:
+				return "(" + expression(depth-1) + ")"
+			default:
+				return comparison()
+			}
+		}
+		// mutate inserts, deletes or replaces random bytes of the source
+		mutate := func(source string) string {
+			alphabet := []byte(` !"&'()+,-.0123456789<=>[]_abcdefinorstxyz|` + "\x00\xff")
+			b := []byte(source)
+			for n := 1 + rnd.Intn(4); n > 0; n-- {
+				i := rnd.Intn(len(b) + 1)
+				c := alphabet[rnd.Intn(len(alphabet))]
+				switch rnd.Intn(3) {
+				case 0:
+					b = append(b[:i], append([]byte{c}, b[i:]...)...)
+				case 1:
+					if i < len(b) {
+						b = append(b[:i], b[i+1:]...)
+					}
+				default:
+					if i < len(b) {
+						b[i] = c
+					}
+				}
+			}
+			return string(b)
+		}
+		// check parses the source and, if it is valid, evaluates it, it fails if either panics
+		check := func(source string) error {
+			defer func() {
+				if r := recover(); r != nil {
+					Fail(fmt.Sprintf("filter expression %q panicked: %v", source, r))
+				}
+			}()
+			expr, err := eth.ParseFilterExpression(source)
+			if err != nil {
+				return err
+			}
+			Expect(expr.String()).To(Equal(source))
+			expr.Pushable()
+			settings := eth.SubscriptionSettings{
+				Start:         big.NewInt(0),
+				End:           big.NewInt(1),
+				HeaderFilter:  eth.HeaderFilter{Off: true},
+				StateFilter:   eth.StateFilter{Off: true},
+				StorageFilter: eth.StorageFilter{Off: true},
+				Expression:    source,
+			}
+			_, err = filterer.Filter(settings, test_helpers.MockConvertedPayload)
+			return err
+		}
+
+		It("Parses and evaluates every generated expression", func() {
+			for i := 0; i < 1000; i++ {
+				source := expression(1 + rnd.Intn(6))
+				Expect(check(source)).To(Succeed(), source)
+			}
+		})
+
+		It("Never panics on mutated expressions", func() {
+			for i := 0; i < 5000; i++ {
+				check(mutate(expression(rnd.Intn(4))))
+			}
+		})
+
+		It("Never panics on random input", func() {
+			for i := 0; i < 5000; i++ {
+				b := make([]byte, rnd.Intn(64))
+				rnd.Read(b)
+				check(string(b))
+			}
+		})
+	})
+})
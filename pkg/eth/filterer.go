@@ -52,7 +52,15 @@ func (s *ResponseFilterer) Filter(filter SubscriptionSettings, payload Converted
 		if err := s.filterHeaders(filter.HeaderFilter, response, payload); err != nil {
 			return nil, err
 		}
-		txHashes, err := s.filterTransactions(filter.TxFilter, response, payload)
+		var exprMatches []bool
+		if filter.Expression != "" {
+			expr, err := compileFilterExpression(filter.Expression)
+			if err != nil {
+				return nil, err
+			}
+			exprMatches = expr.matchPayload(payload)
+		}
+		txHashes, err := s.filterTransactions(filter.TxFilter, response, payload, exprMatches)
 		if err != nil {
 			return nil, err
 		}
@@ -60,7 +68,7 @@ func (s *ResponseFilterer) Filter(filter SubscriptionSettings, payload Converted
 		if filter.ReceiptFilter.MatchTxs {
 			filterTxs = txHashes
 		}
-		if err := s.filerReceipts(filter.ReceiptFilter, response, payload, filterTxs); err != nil {
+		if err := s.filerReceipts(filter.ReceiptFilter, response, payload, filterTxs, exprMatches); err != nil {
 			return nil, err
 		}
 		if err := s.filterStateAndStorage(filter.StateFilter, filter.StorageFilter, response, payload); err != nil {
@@ -114,15 +122,24 @@ func checkRange(start, end, actual int64) bool {
 	return false
 }
 
-func (s *ResponseFilterer) filterTransactions(trxFilter TxFilter, response *IPLDs, payload ConvertedPayload) ([]common.Hash, error) {
+// exprMatches, if not nil, holds the result of the subscription's filter expression for each transaction
+func (s *ResponseFilterer) filterTransactions(trxFilter TxFilter, response *IPLDs, payload ConvertedPayload, exprMatches []bool) ([]common.Hash, error) {
 	var trxHashes []common.Hash
 	if !trxFilter.Off {
 		trxLen := len(payload.Block.Body().Transactions)
 		trxHashes = make([]common.Hash, 0, trxLen)
 		response.Transactions = make([]ipfs.BlockModel, 0, trxLen)
 		for i, trx := range payload.Block.Body().Transactions {
+			var rct *types.Receipt
+			if i < len(payload.Receipts) {
+				rct = payload.Receipts[i]
+			}
+			if exprMatches != nil && !exprMatches[i] {
+				continue
+			}
 			// TODO: check if want corresponding receipt and if we do we must include this transaction
-			if checkTransactionAddrs(trxFilter.Src, trxFilter.Dst, payload.TxMetaData[i].Src, payload.TxMetaData[i].Dst) {
+			if checkTransactionAddrs(trxFilter.Src, trxFilter.Dst, payload.TxMetaData[i].Src, payload.TxMetaData[i].Dst) &&
+				checkTransactionFields(trxFilter, trx, rct) {
 				trxBuffer := new(bytes.Buffer)
 				if err := trx.EncodeRLP(trxBuffer); err != nil {
 					return nil, err
@@ -162,7 +179,58 @@ func checkTransactionAddrs(wantedSrc, wantedDst []string, actualSrc, actualDst s
 	return false
 }
 
-func (s *ResponseFilterer) filerReceipts(receiptFilter ReceiptFilter, response *IPLDs, payload ConvertedPayload, trxHashes []common.Hash) error {
+// checkTransactionFields returns true if the transaction satisfies the selector, value, creation, type and status filters
+func checkTransactionFields(trxFilter TxFilter, trx *types.Transaction, rct *types.Receipt) bool {
+	if len(trxFilter.MethodSelectors) > 0 {
+		data := trx.Data()
+		if len(data) < 4 {
+			return false
+		}
+		match := false
+		for _, selector := range trxFilter.MethodSelectors {
+			if bytes.Equal(common.FromHex(selector), data[:4]) {
+				match = true
+				break
+			}
+		}
+		if !match {
+			return false
+		}
+	}
+	if trxFilter.MinValue != nil && trx.Value().Cmp(trxFilter.MinValue) < 0 {
+		return false
+	}
+	if trxFilter.MaxValue != nil && trx.Value().Cmp(trxFilter.MaxValue) > 0 {
+		return false
+	}
+	if trxFilter.ContractCreation && trx.To() != nil {
+		return false
+	}
+	if len(trxFilter.Types) > 0 {
+		match := false
+		for _, ty := range trxFilter.Types {
+			if ty == uint64(trx.Type()) {
+				match = true
+				break
+			}
+		}
+		if !match {
+			return false
+		}
+	}
+	if trxFilter.FailedOnly && (rct == nil || !receiptFailed(rct)) {
+		return false
+	}
+	return true
+}
+
+// receiptFailed returns true if the receipt has a failed status
+// pre-Byzantium receipts carry a post-state root instead of a status and are never considered failed
+func receiptFailed(rct *types.Receipt) bool {
+	return len(rct.PostState) == 0 && rct.Status == types.ReceiptStatusFailed
+}
+
+func (s *ResponseFilterer) filerReceipts(receiptFilter ReceiptFilter, response *IPLDs, payload ConvertedPayload, trxHashes []common.Hash, exprMatches []bool) error {
 	if !receiptFilter.Off {
 		response.Receipts = make([]ipfs.BlockModel, 0, len(payload.Receipts))
 		rctLeafCID, rctIPLDData, err := GetRctLeafNodeData(payload.Receipts)
@@ -171,6 +239,9 @@ func (s *ResponseFilterer) filerReceipts(receiptFilter ReceiptFilter, response *
 		}
 
 		for idx, receipt := range payload.Receipts {
+			if exprMatches != nil && idx < len(exprMatches) && !exprMatches[idx] {
+				continue
+			}
 			// topics is always length 4
 			topics := make([][]string, 4)
 			contracts := make([]string, len(receipt.Logs))
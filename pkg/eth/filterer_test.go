@@ -18,7 +18,9 @@ package eth_test
 
 import (
 	"bytes"
+	"math/big"
 
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/statediff/indexer/ipfs"
 	sdtypes "github.com/ethereum/go-ethereum/statediff/types"
 
@@ -196,5 +198,57 @@ var _ = Describe("Filterer", func() {
 			Expect(len(iplds8.StateNodes)).To(Equal(0))
 			Expect(len(iplds8.Receipts)).To(Equal(0))
 		})
+
+		It("Applies the selector, value, creation, type and status transaction filters", func() {
+			txOnly := func(txFilter eth.TxFilter) eth.SubscriptionSettings {
+				return eth.SubscriptionSettings{
+					Start:         big.NewInt(0),
+					End:           big.NewInt(1),
+					HeaderFilter:  eth.HeaderFilter{Off: true},
+					TxFilter:      txFilter,
+					ReceiptFilter: eth.ReceiptFilter{Off: true},
+					StateFilter:   eth.StateFilter{Off: true},
+					StorageFilter: eth.StorageFilter{Off: true},
+				}
+			}
+
+			iplds1, err := filterer.Filter(txOnly(eth.TxFilter{MethodSelectors: []string{"0x00010203"}}), test_helpers.MockConvertedPayload)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(iplds1.Transactions)).To(Equal(1))
+			Expect(shared.IPLDsContainBytes(iplds1.Transactions, test_helpers.Tx3)).To(BeTrue())
+
+			iplds2, err := filterer.Filter(txOnly(eth.TxFilter{MinValue: big.NewInt(1500), MaxValue: big.NewInt(1999)}), test_helpers.MockConvertedPayload)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(iplds2.Transactions)).To(Equal(1))
+			Expect(shared.IPLDsContainBytes(iplds2.Transactions, test_helpers.Tx3)).To(BeTrue())
+
+			iplds3, err := filterer.Filter(txOnly(eth.TxFilter{MinValue: big.NewInt(2000)}), test_helpers.MockConvertedPayload)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(iplds3.Transactions)).To(Equal(2))
+			Expect(shared.IPLDsContainBytes(iplds3.Transactions, test_helpers.Tx2)).To(BeTrue())
+			Expect(shared.IPLDsContainBytes(iplds3.Transactions, test_helpers.Tx4)).To(BeTrue())
+
+			iplds4, err := filterer.Filter(txOnly(eth.TxFilter{ContractCreation: true}), test_helpers.MockConvertedPayload)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(iplds4.Transactions)).To(Equal(1))
+			Expect(shared.IPLDsContainBytes(iplds4.Transactions, test_helpers.Tx3)).To(BeTrue())
+
+			iplds5, err := filterer.Filter(txOnly(eth.TxFilter{Types: []uint64{types.DynamicFeeTxType}}), test_helpers.MockConvertedPayload)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(iplds5.Transactions)).To(Equal(0))
+
+			iplds6, err := filterer.Filter(txOnly(eth.TxFilter{Types: []uint64{types.LegacyTxType}}), test_helpers.MockConvertedPayload)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(iplds6.Transactions)).To(Equal(4))
+
+			iplds7, err := filterer.Filter(txOnly(eth.TxFilter{FailedOnly: true}), test_helpers.MockConvertedPayload)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(iplds7.Transactions)).To(Equal(1))
+			Expect(shared.IPLDsContainBytes(iplds7.Transactions, test_helpers.Tx4)).To(BeTrue())
+
+			iplds8, err := filterer.Filter(txOnly(eth.TxFilter{FailedOnly: true, ContractCreation: true}), test_helpers.MockConvertedPayload)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(iplds8.Transactions)).To(Equal(0))
+		})
 	})
 })
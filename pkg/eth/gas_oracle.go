@@ -0,0 +1,74 @@
+// VulcanizeDB
+// Copyright © 2022 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/gasprice"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// gasOracleConfig are the settings of the gas price oracle of geth's full nodes, falling back to a 1 gwei tip
+var gasOracleConfig = gasprice.Config{
+	Blocks:           20,
+	Percentile:       60,
+	MaxHeaderHistory: 1024,
+	MaxBlockHistory:  1024,
+	Default:          big.NewInt(params.GWei),
+	MaxPrice:         gasprice.DefaultMaxPrice,
+	IgnorePrice:      gasprice.DefaultIgnorePrice,
+}
+
+// oracleBackend adapts the Backend to the gasprice.OracleBackend interface
+// There is no pending block, and no chain head events: the oracle caches its suggestion by the hash of the latest header
+type oracleBackend struct {
+	*Backend
+}
+
+func (b oracleBackend) PendingBlockAndReceipts() (*types.Block, types.Receipts) {
+	return nil, nil
+}
+
+func (b oracleBackend) ChainConfig() *params.ChainConfig {
+	return b.Config.ChainConfig
+}
+
+func (b oracleBackend) SubscribeChainHeadEvent(chan<- core.ChainHeadEvent) event.Subscription {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		<-quit
+		return nil
+	})
+}
+
+// SuggestGasTipCap returns the tip suggested by geth's gas price oracle, sampling the tips of the transactions of the
+// latest indexed canonical blocks
+func (b *Backend) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	// the oracle doesn't handle a missing latest header
+	if _, err := b.HeaderByNumber(ctx, rpc.LatestBlockNumber); err != nil {
+		return nil, err
+	}
+	b.gasOracleOnce.Do(func() {
+		b.gasOracle = gasprice.NewOracle(oracleBackend{b}, gasOracleConfig)
+	})
+	return b.gasOracle.SuggestTipCap(ctx)
+}
@@ -0,0 +1,225 @@
+// VulcanizeDB
+// Copyright © 2022 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/sirupsen/logrus"
+
+	"github.com/vulcanize/ipld-eth-server/pkg/prom"
+)
+
+// HedgeableMethods are the methods which can be hedged
+var HedgeableMethods = []string{
+	"eth_getBlockByNumber",
+	"eth_getBlockByHash",
+	"eth_getTransactionCount",
+	"eth_getTransactionByHash",
+	"eth_getTransactionReceipt",
+	"eth_getLogs",
+	"eth_getBalance",
+	"eth_getStorageAt",
+	"eth_getCode",
+	"eth_call",
+}
+
+var errNullProxyAnswer = errors.New("proxy node returned null")
+
+// HedgeConfig holds the methods which are hedged, with the time the local lookup is given before the proxy node is called too
+type HedgeConfig struct {
+	Delays map[string]time.Duration
+}
+
+// ParseHedgeDelays parses "method=delay" entries, such as "eth_getBalance=50ms"
+func ParseHedgeDelays(entries []string) (map[string]time.Duration, error) {
+	delays := make(map[string]time.Duration, len(entries))
+	for _, entry := range entries {
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid hedge entry %q, expected method=delay", entry)
+		}
+		delay, err := time.ParseDuration(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid hedge delay for %s: %v", parts[0], err)
+		}
+		delays[strings.TrimSpace(parts[0])] = delay
+	}
+	return delays, nil
+}
+
+// Hedger races the local lookups of the hedged methods against the proxy node
+// The proxy node is only called once the local lookup has taken longer than the method's delay, or has failed
+type Hedger struct {
+	client *rpc.Client
+	delays map[string]time.Duration
+}
+
+// NewHedger creates a new Hedger calling out to the client
+func NewHedger(client *rpc.Client, conf HedgeConfig) (*Hedger, error) {
+	if client == nil {
+		return nil, errors.New("request hedging requires a proxy node")
+	}
+	supported := make(map[string]bool, len(HedgeableMethods))
+	for _, method := range HedgeableMethods {
+		supported[method] = true
+	}
+	delays := make(map[string]time.Duration, len(conf.Delays))
+	for method, delay := range conf.Delays {
+		if !supported[method] {
+			return nil, fmt.Errorf("method %s can't be hedged, hedgeable methods are %s", method, strings.Join(HedgeableMethods, ", "))
+		}
+		if delay < 0 {
+			return nil, fmt.Errorf("negative hedge delay for %s", method)
+		}
+		delays[method] = delay
+	}
+	return &Hedger{client: client, delays: delays}, nil
+}
+
+// Hedges returns whether the method is hedged, it is false for a nil Hedger
+func (h *Hedger) Hedges(method string) bool {
+	if h == nil {
+		return false
+	}
+	_, ok := h.delays[method]
+	return ok
+}
+
+// Methods returns the hedged methods, sorted
+func (h *Hedger) Methods() []string {
+	methods := make([]string, 0, len(h.delays))
+	for method := range h.delays {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+// Do runs the local lookup and, if it hasn't succeeded within the method's delay, calls the proxy node with the method and args as well
+// The first successful answer is stored in res, which must point to a value of the type returned by local, and the other side is cancelled
+// A local lookup returning a nil pointer or map is a miss, like an error
+// It returns which side answered, and the local error if neither did
+func (h *Hedger) Do(ctx context.Context, method string, local func(context.Context) (interface{}, error), res interface{}, args ...interface{}) (string, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	localAnswers := make(chan localAnswer, 1)
+	go func() {
+		v, err := local(ctx)
+		localAnswers <- localAnswer{v, err}
+	}()
+
+	proxyAnswers := make(chan proxyAnswer, 1)
+	proxyStarted := false
+	startProxy := func() {
+		proxyStarted = true
+		prom.IncHedgeRequests(method)
+		go func() {
+			var raw json.RawMessage
+			err := h.client.CallContext(ctx, &raw, method, args...)
+			if err == nil && (len(raw) == 0 || bytes.Equal(raw, []byte("null"))) {
+				err = errNullProxyAnswer
+			}
+			proxyAnswers <- proxyAnswer{raw, err}
+		}()
+	}
+
+	timer := time.NewTimer(h.delays[method])
+	defer timer.Stop()
+
+	var (
+		localErr  error
+		localDone bool
+		proxyDone bool
+	)
+	for {
+		select {
+		case a := <-localAnswers:
+			localDone = true
+			if a.err == nil && !isNilAnswer(a.value) {
+				reflect.ValueOf(res).Elem().Set(reflect.ValueOf(a.value))
+				prom.RecordHedgeWin(method, prom.HedgeLocal)
+				return prom.HedgeLocal, nil
+			}
+			localErr = a.err
+			if proxyDone {
+				prom.RecordHedgeWin(method, prom.HedgeFailed)
+				return prom.HedgeFailed, localErr
+			}
+			if !proxyStarted {
+				startProxy()
+			}
+		case <-timer.C:
+			if !proxyStarted {
+				startProxy()
+			}
+		case a := <-proxyAnswers:
+			proxyDone = true
+			err := a.err
+			if err == nil {
+				if err = json.Unmarshal(a.raw, res); err == nil {
+					winner := prom.HedgeProxy
+					if localDone {
+						winner = prom.HedgeFallback
+					}
+					prom.RecordHedgeWin(method, winner)
+					return winner, nil
+				}
+			}
+			logrus.Debugf("hedged %s call to the proxy node failed: %v", method, err)
+			if localDone {
+				prom.RecordHedgeWin(method, prom.HedgeFailed)
+				return prom.HedgeFailed, localErr
+			}
+		case <-ctx.Done():
+			prom.RecordHedgeWin(method, prom.HedgeFailed)
+			return prom.HedgeFailed, ctx.Err()
+		}
+	}
+}
+
+// isNilAnswer returns whether a local answer is a nil pointer or map
+func isNilAnswer(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Ptr, reflect.Map:
+		return rv.IsNil()
+	}
+	return false
+}
+
+type localAnswer struct {
+	value interface{}
+	err   error
+}
+
+type proxyAnswer struct {
+	raw json.RawMessage
+	err error
+}
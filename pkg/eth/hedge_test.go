@@ -0,0 +1,140 @@
+// VulcanizeDB
+// Copyright © 2022 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package eth_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/ipld-eth-server/pkg/eth"
+	"github.com/vulcanize/ipld-eth-server/pkg/prom"
+)
+
+// mockHedgeEthAPI is a fake proxy node counting the calls it receives
+type mockHedgeEthAPI struct {
+	calls int32
+}
+
+func (api *mockHedgeEthAPI) GetBalance(address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (*hexutil.Big, error) {
+	atomic.AddInt32(&api.calls, 1)
+	return (*hexutil.Big)(big.NewInt(2000)), nil
+}
+
+func (api *mockHedgeEthAPI) GetCode(address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (hexutil.Bytes, error) {
+	atomic.AddInt32(&api.calls, 1)
+	return nil, errors.New("unavailable")
+}
+
+var _ = Describe("Hedger", func() {
+	var (
+		api    *mockHedgeEthAPI
+		client *rpc.Client
+		hedger *eth.Hedger
+		number = rpc.BlockNumberOrHashWithNumber(1)
+		local  = (*hexutil.Big)(big.NewInt(1000))
+	)
+	BeforeEach(func() {
+		api = new(mockHedgeEthAPI)
+		srv := rpc.NewServer()
+		Expect(srv.RegisterName("eth", api)).To(Succeed())
+		client = rpc.DialInProc(srv)
+		var err error
+		hedger, err = eth.NewHedger(client, eth.HedgeConfig{Delays: map[string]time.Duration{
+			"eth_getBalance": 50 * time.Millisecond,
+			"eth_getCode":    time.Hour,
+		}})
+		Expect(err).ToNot(HaveOccurred())
+	})
+	AfterEach(func() {
+		client.Close()
+	})
+
+	It("Parses and validates the hedged methods", func() {
+		delays, err := eth.ParseHedgeDelays([]string{"eth_getBalance=50ms", " eth_call = 1s"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(delays).To(Equal(map[string]time.Duration{"eth_getBalance": 50 * time.Millisecond, "eth_call": time.Second}))
+		_, err = eth.ParseHedgeDelays([]string{"eth_getBalance"})
+		Expect(err).To(HaveOccurred())
+		_, err = eth.NewHedger(client, eth.HedgeConfig{Delays: map[string]time.Duration{"eth_sendRawTransaction": time.Second}})
+		Expect(err).To(HaveOccurred())
+
+		Expect(hedger.Hedges("eth_getBalance")).To(BeTrue())
+		Expect(hedger.Hedges("eth_getLogs")).To(BeFalse())
+		Expect(hedger.Methods()).To(Equal([]string{"eth_getBalance", "eth_getCode"}))
+		var nilHedger *eth.Hedger
+		Expect(nilHedger.Hedges("eth_getBalance")).To(BeFalse())
+	})
+
+	It("Returns the local answer when it is faster than the delay", func() {
+		var bal *hexutil.Big
+		winner, err := hedger.Do(context.Background(), "eth_getBalance", func(ctx context.Context) (interface{}, error) {
+			return local, nil
+		}, &bal, common.Address{}, number)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(winner).To(Equal(prom.HedgeLocal))
+		Expect(bal).To(Equal(local))
+		Consistently(func() int32 { return atomic.LoadInt32(&api.calls) }, 100*time.Millisecond).Should(BeZero())
+	})
+
+	It("Returns the proxy answer and cancels the local lookup when it is slower than the delay", func() {
+		cancelled := make(chan struct{})
+		var bal *hexutil.Big
+		winner, err := hedger.Do(context.Background(), "eth_getBalance", func(ctx context.Context) (interface{}, error) {
+			<-ctx.Done()
+			close(cancelled)
+			return nil, ctx.Err()
+		}, &bal, common.Address{}, number)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(winner).To(Equal(prom.HedgeProxy))
+		Expect(bal.ToInt().Int64()).To(Equal(int64(2000)))
+		Eventually(cancelled).Should(BeClosed())
+	})
+
+	It("Calls the proxy node right away when the local lookup misses", func() {
+		start := time.Now()
+		var bal *hexutil.Big
+		winner, err := hedger.Do(context.Background(), "eth_getBalance", func(ctx context.Context) (interface{}, error) {
+			return (*hexutil.Big)(nil), nil
+		}, &bal, common.Address{}, number)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(winner).To(Equal(prom.HedgeFallback))
+		Expect(bal.ToInt().Int64()).To(Equal(int64(2000)))
+		Expect(time.Since(start)).To(BeNumerically("<", 50*time.Millisecond))
+	})
+
+	It("Returns the local error when neither side answers", func() {
+		localErr := errors.New("not indexed")
+		var code hexutil.Bytes
+		winner, err := hedger.Do(context.Background(), "eth_getCode", func(ctx context.Context) (interface{}, error) {
+			return hexutil.Bytes(nil), localErr
+		}, &code, common.Address{}, number)
+		Expect(err).To(Equal(localErr))
+		Expect(winner).To(Equal(prom.HedgeFailed))
+		Expect(code).To(BeNil())
+		Expect(atomic.LoadInt32(&api.calls)).To(Equal(int32(1)))
+	})
+})
@@ -23,6 +23,7 @@ import (
 	sdtypes "github.com/ethereum/go-ethereum/statediff/types"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/statediff/indexer/postgres"
@@ -64,10 +65,12 @@ const (
 								FROM eth.uncle_cids
 									INNER JOIN public.blocks ON (uncle_cids.mh_key = blocks.key)
 								WHERE block_hash = $1`
-	RetrieveTransactionsByHashesPgStr = `SELECT cid, data
+	RetrieveTransactionsByHashesPgStr = `SELECT transaction_cids.cid, data, header_cids.block_hash, transaction_cids.index
 									FROM eth.transaction_cids
+										INNER JOIN eth.header_cids ON (transaction_cids.header_id = header_cids.id)
 										INNER JOIN public.blocks ON (transaction_cids.mh_key = blocks.key)
-									WHERE tx_hash = ANY($1::VARCHAR(66)[])`
+									WHERE tx_hash = ANY($1::VARCHAR(66)[])
+									AND header_cids.id = (SELECT canonical_header_id(block_number))`
 	RetrieveTransactionsByBlockHashPgStr = `SELECT transaction_cids.cid, data
 											FROM eth.transaction_cids
 												INNER JOIN eth.header_cids ON (transaction_cids.header_id = header_cids.id)
@@ -84,11 +87,13 @@ const (
 									FROM eth.transaction_cids
 										INNER JOIN public.blocks ON (transaction_cids.mh_key = blocks.key)
 									WHERE tx_hash = $1`
-	RetrieveReceiptsByTxHashesPgStr = `SELECT receipt_cids.leaf_cid, data
+	RetrieveReceiptsByTxHashesPgStr = `SELECT receipt_cids.leaf_cid, data, eth.transaction_cids.tx_hash
 									FROM eth.receipt_cids
 										INNER JOIN eth.transaction_cids ON (receipt_cids.tx_id = transaction_cids.id)
+										INNER JOIN eth.header_cids ON (transaction_cids.header_id = header_cids.id)
 										INNER JOIN public.blocks ON (receipt_cids.leaf_mh_key = blocks.key)
-									WHERE tx_hash = ANY($1::VARCHAR(66)[])`
+									WHERE tx_hash = ANY($1::VARCHAR(66)[])
+									AND header_cids.id = (SELECT canonical_header_id(block_number))`
 	RetrieveReceiptsByBlockHashPgStr = `SELECT receipt_cids.leaf_cid, data, eth.transaction_cids.tx_hash
 										FROM eth.receipt_cids
 											INNER JOIN eth.transaction_cids ON (receipt_cids.tx_id = transaction_cids.id)
@@ -119,6 +124,16 @@ const (
 												AND header_cids.id = (SELECT canonical_header_id(block_number))
 												ORDER BY block_number DESC
 												LIMIT 1`
+	RetrieveAccountsByLeafKeysAndBlockHashPgStr = `SELECT DISTINCT ON (state_leaf_key) state_leaf_key, state_cids.cid, data, state_cids.node_type
+												FROM eth.state_cids
+													INNER JOIN eth.header_cids ON (state_cids.header_id = header_cids.id)
+													INNER JOIN public.blocks ON (state_cids.mh_key = blocks.key)
+												WHERE state_leaf_key = ANY($1::VARCHAR(66)[])
+												AND block_number <= (SELECT block_number
+																	FROM eth.header_cids
+																	WHERE block_hash = $2)
+												AND header_cids.id = (SELECT canonical_header_id(block_number))
+												ORDER BY state_leaf_key, block_number DESC`
 	RetrieveAccountByLeafKeyAndBlockNumberPgStr = `SELECT state_cids.cid, data, state_cids.node_type
 													FROM eth.state_cids
 														INNER JOIN eth.header_cids ON (state_cids.header_id = header_cids.id)
@@ -150,6 +165,45 @@ const (
 																	AND header_cids.id = (SELECT canonical_header_id(block_number))
 																	ORDER BY block_number DESC
 																	LIMIT 1`
+	RetrieveStateDiffAccountsByBlockHashPgStr = `SELECT state_cids.state_leaf_key, state_cids.state_path, state_cids.node_type, state_cids.cid, data
+												FROM eth.state_cids
+													INNER JOIN eth.header_cids ON (state_cids.header_id = header_cids.id)
+													INNER JOIN public.blocks ON (state_cids.mh_key = blocks.key)
+												WHERE block_hash = $1
+												AND (state_cids.node_type = 2 OR (state_cids.node_type = 3 AND state_cids.state_leaf_key <> $2))
+												ORDER BY state_cids.state_path`
+	RetrieveStateDiffStorageByBlockHashPgStr = `SELECT state_cids.state_leaf_key, storage_cids.storage_leaf_key, storage_cids.storage_path,
+												storage_cids.node_type, storage_cids.cid, data
+												FROM eth.storage_cids
+													INNER JOIN eth.state_cids ON (storage_cids.state_id = state_cids.id)
+													INNER JOIN eth.header_cids ON (state_cids.header_id = header_cids.id)
+													INNER JOIN public.blocks ON (storage_cids.mh_key = blocks.key)
+												WHERE block_hash = $1
+												AND (storage_cids.node_type = 2 OR (storage_cids.node_type = 3 AND storage_cids.storage_leaf_key <> $2))
+												ORDER BY state_cids.state_path, storage_cids.storage_path`
+	RetrievePreviousAccountsByLeafKeysAndBlockHashPgStr = `SELECT DISTINCT ON (state_leaf_key) state_leaf_key, state_cids.node_type, data
+															FROM eth.state_cids
+																INNER JOIN eth.header_cids ON (state_cids.header_id = header_cids.id)
+																INNER JOIN public.blocks ON (state_cids.mh_key = blocks.key)
+															WHERE state_leaf_key = ANY($1::VARCHAR(66)[])
+															AND block_number < (SELECT block_number
+																				FROM eth.header_cids
+																				WHERE block_hash = $2)
+															AND header_cids.id = (SELECT canonical_header_id(block_number))
+															ORDER BY state_leaf_key, block_number DESC`
+	RetrievePreviousStorageByLeafKeysAndBlockHashPgStr = `SELECT DISTINCT ON (state_cids.state_leaf_key, storage_leaf_key)
+															state_cids.state_leaf_key, storage_cids.storage_leaf_key, storage_cids.node_type, data
+															FROM eth.storage_cids
+																INNER JOIN eth.state_cids ON (storage_cids.state_id = state_cids.id)
+																INNER JOIN eth.header_cids ON (state_cids.header_id = header_cids.id)
+																INNER JOIN public.blocks ON (storage_cids.mh_key = blocks.key)
+															WHERE (state_cids.state_leaf_key, storage_cids.storage_leaf_key) IN
+																(SELECT * FROM UNNEST($1::VARCHAR(66)[], $2::VARCHAR(66)[]))
+															AND block_number < (SELECT block_number
+																				FROM eth.header_cids
+																				WHERE block_hash = $3)
+															AND header_cids.id = (SELECT canonical_header_id(block_number))
+															ORDER BY state_cids.state_leaf_key, storage_cids.storage_leaf_key, block_number DESC`
 )
 
 var EmptyNodeValue = make([]byte, common.HashLength)
@@ -166,6 +220,13 @@ type ipldResult struct {
 	TxHash string `db:"tx_hash"`
 }
 
+type txIpldResult struct {
+	CID       string `db:"cid"`
+	Data      []byte `db:"data"`
+	BlockHash string `db:"block_hash"`
+	Index     uint64 `db:"index"`
+}
+
 type IPLDRetriever struct {
 	db *postgres.DB
 }
@@ -272,23 +333,28 @@ func (r *IPLDRetriever) RetrieveUncleByHash(hash common.Hash) (string, []byte, e
 	return uncleResult.CID, uncleResult.Data, r.db.Get(uncleResult, RetrieveUncleByHashPgStr, hash.Hex())
 }
 
-// RetrieveTransactionsByHashes returns the cids and rlp bytes for the transactions corresponding to the provided tx hashes
-func (r *IPLDRetriever) RetrieveTransactionsByHashes(hashes []common.Hash) ([]string, [][]byte, error) {
-	txResults := make([]ipldResult, 0)
+// RetrieveTransactionsByHashes returns the cids and rlp bytes for the canonical transactions corresponding to the provided tx hashes,
+// along with the hashes of the blocks they are included in and their indexes within those blocks
+func (r *IPLDRetriever) RetrieveTransactionsByHashes(hashes []common.Hash) ([]string, [][]byte, []common.Hash, []uint64, error) {
+	txResults := make([]txIpldResult, 0)
 	hashStrs := make([]string, len(hashes))
 	for i, hash := range hashes {
 		hashStrs[i] = hash.Hex()
 	}
 	if err := r.db.Select(&txResults, RetrieveTransactionsByHashesPgStr, pq.Array(hashStrs)); err != nil {
-		return nil, nil, err
+		return nil, nil, nil, nil, err
 	}
 	cids := make([]string, len(txResults))
 	txs := make([][]byte, len(txResults))
+	blockHashes := make([]common.Hash, len(txResults))
+	indexes := make([]uint64, len(txResults))
 	for i, res := range txResults {
 		cids[i] = res.CID
 		txs[i] = res.Data
+		blockHashes[i] = common.HexToHash(res.BlockHash)
+		indexes[i] = res.Index
 	}
-	return cids, txs, nil
+	return cids, txs, blockHashes, indexes, nil
 }
 
 // RetrieveTransactionsByBlockHash returns the cids and rlp bytes for the transactions corresponding to the provided block hash
@@ -344,27 +410,33 @@ func DecodeLeafNode(node []byte) ([]byte, error) {
 	return nodeElements[1].([]byte), nil
 }
 
-// RetrieveReceiptsByTxHashes returns the cids and rlp bytes for the receipts corresponding to the provided tx hashes
-func (r *IPLDRetriever) RetrieveReceiptsByTxHashes(hashes []common.Hash) ([]string, [][]byte, error) {
+// RetrieveReceiptsByTxHashes returns the cids, leaf node IPLD blocks and rlp bytes for the canonical receipts corresponding
+// to the provided tx hashes, along with the hashes of the transactions they belong to.
+// cid returned corresponds to the leaf node data which contains the receipt.
+func (r *IPLDRetriever) RetrieveReceiptsByTxHashes(hashes []common.Hash) ([]string, [][]byte, [][]byte, []common.Hash, error) {
 	rctResults := make([]rctIpldResult, 0)
 	hashStrs := make([]string, len(hashes))
 	for i, hash := range hashes {
 		hashStrs[i] = hash.Hex()
 	}
 	if err := r.db.Select(&rctResults, RetrieveReceiptsByTxHashesPgStr, pq.Array(hashStrs)); err != nil {
-		return nil, nil, err
+		return nil, nil, nil, nil, err
 	}
 	cids := make([]string, len(rctResults))
+	leafNodes := make([][]byte, len(rctResults))
 	rcts := make([][]byte, len(rctResults))
+	txs := make([]common.Hash, len(rctResults))
 	for i, res := range rctResults {
 		cids[i] = res.LeafCID
+		leafNodes[i] = res.Data
 		nodeVal, err := DecodeLeafNode(res.Data)
 		if err != nil {
-			return nil, nil, err
+			return nil, nil, nil, nil, err
 		}
 		rcts[i] = nodeVal
+		txs[i] = common.HexToHash(res.TxHash)
 	}
-	return cids, rcts, nil
+	return cids, leafNodes, rcts, txs, nil
 }
 
 // RetrieveReceiptsByBlockHash returns the cids and rlp bytes for the receipts corresponding to the provided block hash.
@@ -411,19 +483,19 @@ func (r *IPLDRetriever) RetrieveReceiptsByBlockNumber(number uint64) ([]string,
 	return cids, rcts, nil
 }
 
-// RetrieveReceiptByHash returns the cid and rlp bytes for the receipt corresponding to the provided tx hash.
+// RetrieveReceiptByHash returns the cid, leaf node IPLD block and rlp bytes for the receipt corresponding to the provided tx hash.
 // cid returned corresponds to the leaf node data which contains the receipt.
-func (r *IPLDRetriever) RetrieveReceiptByHash(hash common.Hash) (string, []byte, error) {
+func (r *IPLDRetriever) RetrieveReceiptByHash(hash common.Hash) (string, []byte, []byte, error) {
 	rctResult := new(rctIpldResult)
-	if err := r.db.Select(&rctResult, RetrieveReceiptByTxHashPgStr, hash.Hex()); err != nil {
-		return "", nil, err
+	if err := r.db.Get(rctResult, RetrieveReceiptByTxHashPgStr, hash.Hex()); err != nil {
+		return "", nil, nil, err
 	}
 
 	nodeVal, err := DecodeLeafNode(rctResult.Data)
 	if err != nil {
-		return "", nil, err
+		return "", nil, nil, err
 	}
-	return rctResult.LeafCID, nodeVal, nil
+	return rctResult.LeafCID, rctResult.Data, nodeVal, nil
 }
 
 type nodeInfo struct {
@@ -433,27 +505,58 @@ type nodeInfo struct {
 	StateLeafRemoved bool   `db:"state_leaf_removed"`
 }
 
-// RetrieveAccountByAddressAndBlockHash returns the cid and rlp bytes for the account corresponding to the provided address and block hash
+// RetrieveAccountByAddressAndBlockHash returns the cid, state leaf node IPLD block and rlp bytes for the account corresponding to the provided address and block hash
 // TODO: ensure this handles deleted accounts appropriately
-func (r *IPLDRetriever) RetrieveAccountByAddressAndBlockHash(address common.Address, hash common.Hash) (string, []byte, error) {
+func (r *IPLDRetriever) RetrieveAccountByAddressAndBlockHash(address common.Address, hash common.Hash) (string, []byte, []byte, error) {
 	accountResult := new(nodeInfo)
 	leafKey := crypto.Keccak256Hash(address.Bytes())
 	if err := r.db.Get(accountResult, RetrieveAccountByLeafKeyAndBlockHashPgStr, leafKey.Hex(), hash.Hex()); err != nil {
-		return "", nil, err
+		return "", nil, nil, err
 	}
 
 	if accountResult.NodeType == removedNode {
-		return "", EmptyNodeValue, nil
+		return "", EmptyNodeValue, EmptyNodeValue, nil
 	}
 
 	var i []interface{}
 	if err := rlp.DecodeBytes(accountResult.Data, &i); err != nil {
-		return "", nil, fmt.Errorf("error decoding state leaf node rlp: %s", err.Error())
+		return "", nil, nil, fmt.Errorf("error decoding state leaf node rlp: %s", err.Error())
 	}
 	if len(i) != 2 {
-		return "", nil, fmt.Errorf("eth IPLDRetriever expected state leaf node rlp to decode into two elements")
+		return "", nil, nil, fmt.Errorf("eth IPLDRetriever expected state leaf node rlp to decode into two elements")
 	}
-	return accountResult.CID, i[1].([]byte), nil
+	return accountResult.CID, accountResult.Data, i[1].([]byte), nil
+}
+
+type accountLeafInfo struct {
+	StateKey string `db:"state_leaf_key"`
+	nodeInfo
+}
+
+// RetrieveAccountsByLeafKeysAndBlockHash returns the cids and state leaf node IPLD blocks of the accounts with the
+// provided leaf keys at the provided block hash, along with their leaf keys
+// The accounts which don't exist at the block are left out
+func (r *IPLDRetriever) RetrieveAccountsByLeafKeysAndBlockHash(leafKeys []common.Hash, hash common.Hash) ([]string, [][]byte, []common.Hash, error) {
+	keyStrs := make([]string, len(leafKeys))
+	for i, key := range leafKeys {
+		keyStrs[i] = key.Hex()
+	}
+	accountResults := make([]accountLeafInfo, 0)
+	if err := r.db.Select(&accountResults, RetrieveAccountsByLeafKeysAndBlockHashPgStr, pq.Array(keyStrs), hash.Hex()); err != nil {
+		return nil, nil, nil, err
+	}
+	cids := make([]string, 0, len(accountResults))
+	leafNodes := make([][]byte, 0, len(accountResults))
+	keys := make([]common.Hash, 0, len(accountResults))
+	for _, res := range accountResults {
+		if res.NodeType == removedNode {
+			continue
+		}
+		cids = append(cids, res.CID)
+		leafNodes = append(leafNodes, res.Data)
+		keys = append(keys, common.HexToHash(res.StateKey))
+	}
+	return cids, leafNodes, keys, nil
 }
 
 // RetrieveAccountByAddressAndBlockNumber returns the cid and rlp bytes for the account corresponding to the provided address and block number
@@ -522,3 +625,145 @@ func (r *IPLDRetriever) RetrieveStorageAtByAddressAndStorageKeyAndBlockNumber(ad
 	}
 	return storageResult.CID, i[1].([]byte), nil
 }
+
+type stateDiffNode struct {
+	StateKey string `db:"state_leaf_key"`
+	Path     []byte `db:"state_path"`
+	NodeType int    `db:"node_type"`
+	CID      string `db:"cid"`
+	Data     []byte `db:"data"`
+}
+
+type stateDiffStorageNode struct {
+	StateKey   string `db:"state_leaf_key"`
+	StorageKey string `db:"storage_leaf_key"`
+	Path       []byte `db:"storage_path"`
+	NodeType   int    `db:"node_type"`
+	CID        string `db:"cid"`
+	Data       []byte `db:"data"`
+}
+
+// RetrieveStateDiffByBlockHash returns the accounts and storage slots changed in the block with the provided hash, as
+// recorded by its state and storage leaf nodes and removed nodes, along with their values before the block
+func (r *IPLDRetriever) RetrieveStateDiffByBlockHash(hash common.Hash) ([]StateDiffAccount, error) {
+	emptyKey := common.Hash{}.Hex()
+	accountNodes := make([]stateDiffNode, 0)
+	if err := r.db.Select(&accountNodes, RetrieveStateDiffAccountsByBlockHashPgStr, hash.Hex(), emptyKey); err != nil {
+		return nil, err
+	}
+	storageNodes := make([]stateDiffStorageNode, 0)
+	if err := r.db.Select(&storageNodes, RetrieveStateDiffStorageByBlockHashPgStr, hash.Hex(), emptyKey); err != nil {
+		return nil, err
+	}
+
+	stateKeys := make([]string, len(accountNodes))
+	for i, node := range accountNodes {
+		stateKeys[i] = node.StateKey
+	}
+	previousNodes := make([]stateDiffNode, 0)
+	if err := r.db.Select(&previousNodes, RetrievePreviousAccountsByLeafKeysAndBlockHashPgStr, pq.Array(stateKeys), hash.Hex()); err != nil {
+		return nil, err
+	}
+	previousAccounts := make(map[string]*types.StateAccount, len(previousNodes))
+	for _, node := range previousNodes {
+		if node.NodeType == removedNode {
+			continue
+		}
+		account, err := decodeStateAccount(node.Data)
+		if err != nil {
+			return nil, err
+		}
+		previousAccounts[node.StateKey] = account
+	}
+
+	storageStateKeys := make([]string, len(storageNodes))
+	storageKeys := make([]string, len(storageNodes))
+	for i, node := range storageNodes {
+		storageStateKeys[i] = node.StateKey
+		storageKeys[i] = node.StorageKey
+	}
+	previousStorageNodes := make([]stateDiffStorageNode, 0)
+	if err := r.db.Select(&previousStorageNodes, RetrievePreviousStorageByLeafKeysAndBlockHashPgStr,
+		pq.Array(storageStateKeys), pq.Array(storageKeys), hash.Hex()); err != nil {
+		return nil, err
+	}
+	previousStorage := make(map[[2]string][]byte, len(previousStorageNodes))
+	for _, node := range previousStorageNodes {
+		// the storage of an account which did not exist before the block was empty
+		if node.NodeType == removedNode || previousAccounts[node.StateKey] == nil {
+			continue
+		}
+		value, err := decodeStorageLeaf(node.Data)
+		if err != nil {
+			return nil, err
+		}
+		previousStorage[[2]string{node.StateKey, node.StorageKey}] = value
+	}
+
+	accounts := make([]StateDiffAccount, len(accountNodes))
+	indexes := make(map[string]int, len(accountNodes))
+	for i, node := range accountNodes {
+		accounts[i] = StateDiffAccount{
+			LeafKey:   common.HexToHash(node.StateKey),
+			Path:      node.Path,
+			NodeType:  ResolveToNodeType(node.NodeType),
+			CID:       node.CID,
+			IPLDBlock: node.Data,
+			Previous:  previousAccounts[node.StateKey],
+			Storage:   make([]StateDiffStorage, 0),
+		}
+		if node.NodeType != removedNode {
+			account, err := decodeStateAccount(node.Data)
+			if err != nil {
+				return nil, err
+			}
+			accounts[i].Account = account
+		}
+		indexes[node.StateKey] = i
+	}
+	for _, node := range storageNodes {
+		i, ok := indexes[node.StateKey]
+		if !ok {
+			continue
+		}
+		slot := StateDiffStorage{
+			LeafKey:   common.HexToHash(node.StorageKey),
+			Path:      node.Path,
+			NodeType:  ResolveToNodeType(node.NodeType),
+			CID:       node.CID,
+			IPLDBlock: node.Data,
+			Previous:  previousStorage[[2]string{node.StateKey, node.StorageKey}],
+		}
+		if node.NodeType != removedNode {
+			value, err := decodeStorageLeaf(node.Data)
+			if err != nil {
+				return nil, err
+			}
+			slot.Value = value
+		}
+		accounts[i].Storage = append(accounts[i].Storage, slot)
+	}
+	return accounts, nil
+}
+
+// decodeStateAccount decodes the account held by the state leaf node
+func decodeStateAccount(node []byte) (*types.StateAccount, error) {
+	accountRLP, err := DecodeLeafNode(node)
+	if err != nil {
+		return nil, err
+	}
+	account := new(types.StateAccount)
+	if err := rlp.DecodeBytes(accountRLP, account); err != nil {
+		return nil, fmt.Errorf("error decoding state account rlp: %s", err.Error())
+	}
+	return account, nil
+}
+
+// decodeStorageLeaf decodes the value held by the storage leaf node
+func decodeStorageLeaf(node []byte) ([]byte, error) {
+	valueRLP, err := DecodeLeafNode(node)
+	if err != nil {
+		return nil, err
+	}
+	return decodeStorageValue(valueRLP)
+}
@@ -0,0 +1,126 @@
+// VulcanizeDB
+// Copyright © 2022 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package eth_test
+
+import (
+	"database/sql"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/statediff/indexer"
+	"github.com/ethereum/go-ethereum/statediff/indexer/postgres"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/ipld-eth-server/pkg/eth"
+	"github.com/vulcanize/ipld-eth-server/pkg/eth/test_helpers"
+)
+
+var _ = Describe("IPLDRetriever", func() {
+	var (
+		db        *postgres.DB
+		retriever *eth.IPLDRetriever
+	)
+	BeforeEach(func() {
+		var (
+			err error
+			tx  *indexer.BlockTx
+		)
+		db, err = SetupDB()
+		Expect(err).ToNot(HaveOccurred())
+		pubAndIndexer, err := indexer.NewStateDiffIndexer(params.TestChainConfig, db)
+		Expect(err).ToNot(HaveOccurred())
+
+		tx, err = pubAndIndexer.PushBlock(test_helpers.MockBlock, test_helpers.MockReceipts, test_helpers.MockBlock.Difficulty())
+		Expect(err).ToNot(HaveOccurred())
+		for _, node := range test_helpers.MockStateNodes {
+			err = pubAndIndexer.PushStateNode(tx, node)
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		err = tx.Close(err)
+		Expect(err).ToNot(HaveOccurred())
+		retriever = eth.NewIPLDRetriever(db)
+	})
+	AfterEach(func() {
+		eth.TearDownDB(db)
+	})
+
+	Describe("RetrieveReceiptByHash", func() {
+		It("Retrieves the cid, leaf node IPLD block and rlp of the receipt of the transaction", func() {
+			cid, leafNode, rct, err := retriever.RetrieveReceiptByHash(test_helpers.MockTransactions[1].Hash())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cid).To(Equal(test_helpers.Rct2CID.String()))
+			Expect(leafNode).To(Equal(test_helpers.Rct2IPLD))
+			expectedRct, err := test_helpers.MockReceipts[1].MarshalBinary()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rct).To(Equal(expectedRct))
+		})
+
+		It("Fails with sql.ErrNoRows for an unknown transaction", func() {
+			_, _, _, err := retriever.RetrieveReceiptByHash(common.HexToHash("0x01"))
+			Expect(err).To(Equal(sql.ErrNoRows))
+		})
+	})
+
+	Describe("RetrieveReceiptsByTxHashes", func() {
+		It("Retrieves the cids, leaf node IPLD blocks and rlp of the receipts of the transactions, with their hashes", func() {
+			hashes := []common.Hash{
+				test_helpers.MockTransactions[0].Hash(),
+				common.HexToHash("0x01"),
+				test_helpers.MockTransactions[3].Hash(),
+			}
+			cids, leafNodes, rcts, txHashes, err := retriever.RetrieveReceiptsByTxHashes(hashes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(txHashes).To(ConsistOf(hashes[0], hashes[2]))
+			Expect(cids).To(HaveLen(2))
+			Expect(leafNodes).To(HaveLen(2))
+			Expect(rcts).To(HaveLen(2))
+
+			expected := map[common.Hash]struct {
+				cid      string
+				leafNode []byte
+				index    int
+			}{
+				hashes[0]: {test_helpers.Rct1CID.String(), test_helpers.Rct1IPLD, 0},
+				hashes[2]: {test_helpers.Rct4CID.String(), test_helpers.Rct4IPLD, 3},
+			}
+			for i, txHash := range txHashes {
+				Expect(cids[i]).To(Equal(expected[txHash].cid))
+				Expect(leafNodes[i]).To(Equal(expected[txHash].leafNode))
+				expectedRct, err := test_helpers.MockReceipts[expected[txHash].index].MarshalBinary()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(rcts[i]).To(Equal(expectedRct))
+			}
+		})
+	})
+
+	Describe("RetrieveAccountByAddressAndBlockHash", func() {
+		It("Retrieves the cid, leaf node IPLD block and rlp of the account at the block", func() {
+			cid, leafNode, account, err := retriever.RetrieveAccountByAddressAndBlockHash(test_helpers.ContractAddress, test_helpers.MockBlock.Hash())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cid).To(Equal(test_helpers.State1CID.String()))
+			Expect(leafNode).To(Equal(test_helpers.ContractLeafNode))
+			Expect(account).To(Equal(test_helpers.ContractAccount))
+		})
+
+		It("Fails with sql.ErrNoRows for an account which does not exist at the block", func() {
+			_, _, _, err := retriever.RetrieveAccountByAddressAndBlockHash(common.HexToAddress("0x01"), test_helpers.MockBlock.Hash())
+			Expect(err).To(Equal(sql.ErrNoRows))
+		})
+	})
+})
@@ -0,0 +1,281 @@
+// VulcanizeDB
+// Copyright © 2022 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/statediff/indexer/ipfs"
+	sdtypes "github.com/ethereum/go-ethereum/statediff/types"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// PayloadEncoding specifies how the IPLDs sent to a subscriber are serialized
+type PayloadEncoding string
+
+const (
+	// RLPEncoding sends the IPLDs as an rlp serialized IPLDs struct; this is the default
+	RLPEncoding PayloadEncoding = "rlp"
+	// JSONEncoding sends the IPLDs decoded into a DecodedIPLDs struct
+	JSONEncoding PayloadEncoding = "json"
+)
+
+// Valid returns true if the encoding is one supported by the server
+// An empty encoding is valid and defaults to RLPEncoding
+func (e PayloadEncoding) Valid() bool {
+	switch e {
+	case "", RLPEncoding, JSONEncoding:
+		return true
+	default:
+		return false
+	}
+}
+
+// DecodedIPLDs is the JSON friendly counterpart to IPLDs
+// Every object carries its decoded form alongside the CID and raw IPLD bytes it was decoded from
+type DecodedIPLDs struct {
+	BlockNumber     *hexutil.Big         `json:"blockNumber"`
+	TotalDifficulty *hexutil.Big         `json:"totalDifficulty"`
+	Header          *DecodedHeader       `json:"header,omitempty"`
+	Uncles          []DecodedHeader      `json:"uncles"`
+	Transactions    []DecodedTransaction `json:"transactions"`
+	Receipts        []DecodedReceipt     `json:"receipts"`
+	StateNodes      []DecodedStateNode   `json:"stateNodes"`
+	StorageNodes    []DecodedStorageNode `json:"storageNodes"`
+}
+
+// DecodedHeader holds a decoded header or uncle and its IPLD
+type DecodedHeader struct {
+	CID    string        `json:"cid"`
+	Data   hexutil.Bytes `json:"data"`
+	Header *types.Header `json:"header"`
+}
+
+// DecodedTransaction holds a decoded transaction and its IPLD
+type DecodedTransaction struct {
+	CID         string             `json:"cid"`
+	Data        hexutil.Bytes      `json:"data"`
+	Transaction *types.Transaction `json:"transaction"`
+}
+
+// DecodedReceipt holds a decoded receipt, including its logs, and the receipt trie leaf node IPLD it was decoded from
+// The fields which are not part of the consensus encoding of the receipt are derived from the payload, see
+// deriveReceiptFields
+type DecodedReceipt struct {
+	CID     string         `json:"cid"`
+	Data    hexutil.Bytes  `json:"data"`
+	Receipt *types.Receipt `json:"receipt"`
+}
+
+// DecodedAccount is the JSON representation of a state account
+type DecodedAccount struct {
+	Nonce       hexutil.Uint64 `json:"nonce"`
+	Balance     *hexutil.Big   `json:"balance"`
+	StorageRoot common.Hash    `json:"storageRoot"`
+	CodeHash    common.Hash    `json:"codeHash"`
+}
+
+// DecodedStateNode holds a state node and its IPLD
+// Account is only set for leaf nodes
+type DecodedStateNode struct {
+	Type         sdtypes.NodeType `json:"type"`
+	StateLeafKey common.Hash      `json:"stateLeafKey"`
+	Path         hexutil.Bytes    `json:"path"`
+	CID          string           `json:"cid"`
+	Data         hexutil.Bytes    `json:"data"`
+	Account      *DecodedAccount  `json:"account,omitempty"`
+}
+
+// DecodedStorageNode holds a storage node and its IPLD
+// Value is only set for leaf nodes
+type DecodedStorageNode struct {
+	Type           sdtypes.NodeType `json:"type"`
+	StateLeafKey   common.Hash      `json:"stateLeafKey"`
+	StorageLeafKey common.Hash      `json:"storageLeafKey"`
+	Path           hexutil.Bytes    `json:"path"`
+	CID            string           `json:"cid"`
+	Data           hexutil.Bytes    `json:"data"`
+	Value          *common.Hash     `json:"value,omitempty"`
+}
+
+// DecodeIPLDs decodes the rlp encoded IPLD objects into a DecodedIPLDs
+func DecodeIPLDs(iplds *IPLDs) (*DecodedIPLDs, error) {
+	decoded := &DecodedIPLDs{
+		BlockNumber:     (*hexutil.Big)(iplds.BlockNumber),
+		TotalDifficulty: (*hexutil.Big)(iplds.TotalDifficulty),
+		Uncles:          make([]DecodedHeader, len(iplds.Uncles)),
+		Transactions:    make([]DecodedTransaction, len(iplds.Transactions)),
+		Receipts:        make([]DecodedReceipt, len(iplds.Receipts)),
+		StateNodes:      make([]DecodedStateNode, len(iplds.StateNodes)),
+		StorageNodes:    make([]DecodedStorageNode, len(iplds.StorageNodes)),
+	}
+	if len(iplds.Header.Data) > 0 {
+		header, err := decodeHeader(iplds.Header)
+		if err != nil {
+			return nil, fmt.Errorf("header decoding error: %s", err.Error())
+		}
+		decoded.Header = &header
+	}
+	for i, uncleIPLD := range iplds.Uncles {
+		uncle, err := decodeHeader(uncleIPLD)
+		if err != nil {
+			return nil, fmt.Errorf("uncle decoding error: %s", err.Error())
+		}
+		decoded.Uncles[i] = uncle
+	}
+	txs := make(types.Transactions, len(iplds.Transactions))
+	for i, txIPLD := range iplds.Transactions {
+		tx := new(types.Transaction)
+		if err := tx.UnmarshalBinary(txIPLD.Data); err != nil {
+			return nil, fmt.Errorf("transaction decoding error: %s", err.Error())
+		}
+		txs[i] = tx
+		decoded.Transactions[i] = DecodedTransaction{
+			CID:         txIPLD.CID,
+			Data:        txIPLD.Data,
+			Transaction: tx,
+		}
+	}
+	rcts := make(types.Receipts, len(iplds.Receipts))
+	for i, rctIPLD := range iplds.Receipts {
+		nodeVal, err := DecodeLeafNode(rctIPLD.Data)
+		if err != nil {
+			return nil, fmt.Errorf("receipt leaf node decoding error: %s", err.Error())
+		}
+		rct := new(types.Receipt)
+		if err := rct.UnmarshalBinary(nodeVal); err != nil {
+			return nil, fmt.Errorf("receipt decoding error: %s", err.Error())
+		}
+		rcts[i] = rct
+		decoded.Receipts[i] = DecodedReceipt{
+			CID:     rctIPLD.CID,
+			Data:    rctIPLD.Data,
+			Receipt: rct,
+		}
+	}
+	if decoded.Header != nil {
+		deriveReceiptFields(rcts, txs, decoded.Header.Header)
+	}
+	for i, stateNode := range iplds.StateNodes {
+		decoded.StateNodes[i] = DecodedStateNode{
+			Type:         stateNode.Type,
+			StateLeafKey: stateNode.StateLeafKey,
+			Path:         stateNode.Path,
+			CID:          stateNode.IPLD.CID,
+			Data:         stateNode.IPLD.Data,
+		}
+		if stateNode.Type != sdtypes.Leaf {
+			continue
+		}
+		accountRLP, err := DecodeLeafNode(stateNode.IPLD.Data)
+		if err != nil {
+			return nil, fmt.Errorf("state leaf node decoding error: %s", err.Error())
+		}
+		var account types.StateAccount
+		if err := rlp.DecodeBytes(accountRLP, &account); err != nil {
+			return nil, fmt.Errorf("state account decoding error: %s", err.Error())
+		}
+		decoded.StateNodes[i].Account = &DecodedAccount{
+			Nonce:       hexutil.Uint64(account.Nonce),
+			Balance:     (*hexutil.Big)(account.Balance),
+			StorageRoot: account.Root,
+			CodeHash:    common.BytesToHash(account.CodeHash),
+		}
+	}
+	for i, storageNode := range iplds.StorageNodes {
+		decoded.StorageNodes[i] = DecodedStorageNode{
+			Type:           storageNode.Type,
+			StateLeafKey:   storageNode.StateLeafKey,
+			StorageLeafKey: storageNode.StorageLeafKey,
+			Path:           storageNode.Path,
+			CID:            storageNode.IPLD.CID,
+			Data:           storageNode.IPLD.Data,
+		}
+		if storageNode.Type != sdtypes.Leaf {
+			continue
+		}
+		valueRLP, err := DecodeLeafNode(storageNode.IPLD.Data)
+		if err != nil {
+			return nil, fmt.Errorf("storage leaf node decoding error: %s", err.Error())
+		}
+		_, content, _, err := rlp.Split(valueRLP)
+		if err != nil {
+			return nil, fmt.Errorf("storage value decoding error: %s", err.Error())
+		}
+		value := common.BytesToHash(content)
+		decoded.StorageNodes[i].Value = &value
+	}
+	return decoded, nil
+}
+
+// deriveReceiptFields fills in the fields of the receipts and their logs which are not part of their consensus encoding,
+// as geth's Receipts.DeriveFields does. Filtered payloads only carry some of the transactions and receipts of the block,
+// so the block fields are always derived, the positions in the block only once the receipts hash to its receipt root,
+// and the transaction fields only once the transactions hash to its transaction root as well
+func deriveReceiptFields(rcts types.Receipts, txs types.Transactions, header *types.Header) {
+	hash, number := header.Hash(), header.Number.Uint64()
+	complete := types.DeriveSha(rcts, trie.NewStackTrie(nil)) == header.ReceiptHash
+	withTxs := complete && len(txs) == len(rcts) && types.DeriveSha(txs, trie.NewStackTrie(nil)) == header.TxHash
+	var logIndex uint
+	for i, rct := range rcts {
+		rct.BlockHash = hash
+		rct.BlockNumber = new(big.Int).SetUint64(number)
+		if complete {
+			rct.TransactionIndex = uint(i)
+			rct.GasUsed = rct.CumulativeGasUsed
+			if i > 0 {
+				rct.GasUsed -= rcts[i-1].CumulativeGasUsed
+			}
+		}
+		if withTxs {
+			rct.TxHash = txs[i].Hash()
+			if txs[i].To() == nil {
+				if from, err := types.Sender(types.LatestSignerForChainID(txs[i].ChainId()), txs[i]); err == nil {
+					rct.ContractAddress = crypto.CreateAddress(from, txs[i].Nonce())
+				}
+			}
+		}
+		for _, log := range rct.Logs {
+			log.BlockNumber = number
+			log.BlockHash = hash
+			log.TxHash = rct.TxHash
+			if complete {
+				log.TxIndex = uint(i)
+				log.Index = logIndex
+				logIndex++
+			}
+		}
+	}
+}
+
+func decodeHeader(headerIPLD ipfs.BlockModel) (DecodedHeader, error) {
+	header := new(types.Header)
+	if err := rlp.DecodeBytes(headerIPLD.Data, header); err != nil {
+		return DecodedHeader{}, err
+	}
+	return DecodedHeader{
+		CID:    headerIPLD.CID,
+		Data:   headerIPLD.Data,
+		Header: header,
+	}, nil
+}
@@ -0,0 +1,125 @@
+// VulcanizeDB
+// Copyright © 2022 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package eth_test
+
+import (
+	"encoding/json"
+
+	"github.com/ethereum/go-ethereum/common"
+	sdtypes "github.com/ethereum/go-ethereum/statediff/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/ipld-eth-server/pkg/eth"
+	"github.com/vulcanize/ipld-eth-server/pkg/eth/test_helpers"
+)
+
+var _ = Describe("PayloadDecoder", func() {
+	Describe("DecodeIPLDs", func() {
+		It("Decodes the IPLDs while keeping their CIDs and raw data", func() {
+			decoded, err := eth.DecodeIPLDs(&test_helpers.MockIPLDs)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(decoded.BlockNumber.ToInt().Int64()).To(Equal(test_helpers.BlockNumber.Int64()))
+
+			Expect(decoded.Header).ToNot(BeNil())
+			Expect(decoded.Header.Header.Hash()).To(Equal(test_helpers.MockBlock.Hash()))
+			Expect(decoded.Header.CID).To(Equal(test_helpers.HeaderCID.String()))
+			Expect([]byte(decoded.Header.Data)).To(Equal(test_helpers.MockIPLDs.Header.Data))
+
+			Expect(len(decoded.Transactions)).To(Equal(4))
+			for i, tx := range decoded.Transactions {
+				Expect(tx.Transaction.Hash()).To(Equal(test_helpers.MockTransactions[i].Hash()))
+				Expect(tx.CID).To(Equal(test_helpers.MockIPLDs.Transactions[i].CID))
+			}
+
+			Expect(len(decoded.Receipts)).To(Equal(4))
+			for i, rct := range decoded.Receipts {
+				Expect(rct.Receipt.CumulativeGasUsed).To(Equal(test_helpers.MockReceipts[i].CumulativeGasUsed))
+				Expect(len(rct.Receipt.Logs)).To(Equal(len(test_helpers.MockReceipts[i].Logs)))
+				Expect(rct.CID).To(Equal(test_helpers.MockIPLDs.Receipts[i].CID))
+			}
+			Expect(decoded.Receipts[0].Receipt.Logs[0].Address).To(Equal(test_helpers.MockLog1.Address))
+			Expect(decoded.Receipts[0].Receipt.Logs[0].Topics).To(Equal(test_helpers.MockLog1.Topics))
+
+			Expect(len(decoded.StateNodes)).To(Equal(2))
+			accountNode := decoded.StateNodes[1]
+			Expect(accountNode.Type).To(Equal(sdtypes.Leaf))
+			Expect(accountNode.StateLeafKey.Bytes()).To(Equal(test_helpers.AccountLeafKey))
+			Expect(accountNode.Account).ToNot(BeNil())
+			Expect(accountNode.Account.Balance.ToInt()).To(Equal(test_helpers.AccountBalance))
+			Expect(accountNode.Account.CodeHash).To(Equal(test_helpers.AccountCodeHash))
+			Expect(accountNode.Account.StorageRoot).To(Equal(common.HexToHash(test_helpers.AccountRoot)))
+			Expect(accountNode.CID).To(Equal(test_helpers.State2CID.String()))
+
+			Expect(len(decoded.StorageNodes)).To(Equal(1))
+			Expect(decoded.StorageNodes[0].Value).ToNot(BeNil())
+			Expect(decoded.StorageNodes[0].CID).To(Equal(test_helpers.StorageCID.String()))
+			Expect([]byte(decoded.StorageNodes[0].Data)).To(Equal(test_helpers.StorageLeafNode))
+
+			_, err = json.Marshal(decoded)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("Derives the fields of the receipts from the transactions and header", func() {
+			decoded, err := eth.DecodeIPLDs(&test_helpers.MockIPLDs)
+			Expect(err).ToNot(HaveOccurred())
+			blockHash := test_helpers.MockBlock.Hash()
+			var logIndex uint
+			for i, decodedRct := range decoded.Receipts {
+				rct := decodedRct.Receipt
+				Expect(rct.TxHash).To(Equal(test_helpers.MockTransactions[i].Hash()))
+				Expect(rct.BlockHash).To(Equal(blockHash))
+				Expect(rct.BlockNumber).To(Equal(test_helpers.BlockNumber))
+				Expect(rct.TransactionIndex).To(Equal(uint(i)))
+				Expect(rct.GasUsed).To(Equal(test_helpers.MockReceipts[i].GasUsed))
+				for _, log := range rct.Logs {
+					Expect(log.TxHash).To(Equal(rct.TxHash))
+					Expect(log.TxIndex).To(Equal(uint(i)))
+					Expect(log.BlockHash).To(Equal(blockHash))
+					Expect(log.BlockNumber).To(Equal(test_helpers.BlockNumber.Uint64()))
+					Expect(log.Index).To(Equal(logIndex))
+					logIndex++
+				}
+			}
+			Expect(decoded.Receipts[2].Receipt.ContractAddress).To(Equal(test_helpers.ContractAddress))
+			Expect(decoded.Receipts[0].Receipt.ContractAddress).To(Equal(common.Address{}))
+		})
+
+		It("Only derives the block fields of the receipts of a filtered payload", func() {
+			iplds := test_helpers.MockIPLDs
+			iplds.Transactions = nil
+			iplds.Receipts = test_helpers.MockIPLDs.Receipts[1:2]
+			decoded, err := eth.DecodeIPLDs(&iplds)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(decoded.Receipts)).To(Equal(1))
+			rct := decoded.Receipts[0].Receipt
+			Expect(rct.BlockHash).To(Equal(test_helpers.MockBlock.Hash()))
+			Expect(rct.BlockNumber).To(Equal(test_helpers.BlockNumber))
+			Expect(rct.TxHash).To(Equal(common.Hash{}))
+			Expect(rct.Logs[0].BlockHash).To(Equal(test_helpers.MockBlock.Hash()))
+			Expect(rct.Logs[0].Index).To(Equal(uint(0)))
+		})
+
+		It("Leaves the header empty if it was filtered out", func() {
+			decoded, err := eth.DecodeIPLDs(&eth.IPLDs{BlockNumber: test_helpers.BlockNumber})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(decoded.Header).To(BeNil())
+			Expect(len(decoded.Transactions)).To(Equal(0))
+		})
+	})
+})
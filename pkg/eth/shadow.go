@@ -0,0 +1,352 @@
+// VulcanizeDB
+// Copyright © 2022 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/eth/filters"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/sirupsen/logrus"
+
+	"github.com/vulcanize/ipld-eth-server/pkg/prom"
+)
+
+// ShadowConfig defaults
+const (
+	DefaultShadowTimeout       = 10 * time.Second
+	DefaultShadowMaxConcurrent = 16
+)
+
+// maxShadowDiffs is the number of differences logged for a divergence
+const maxShadowDiffs = 10
+
+// ShadowConfig holds the shadow mode parameters
+type ShadowConfig struct {
+	// fraction of the requests answered locally which are shadowed, 0 disables shadowing
+	SampleRate float64
+	// methods shadowed, every supported method when empty
+	Methods []string
+	// timeout of each shadow call to the proxy node
+	Timeout time.Duration
+	// maximum number of concurrent shadow calls, requests sampled beyond it are skipped
+	MaxConcurrent int
+}
+
+// ShadowResults counts the outcomes of the shadowed requests of a method
+type ShadowResults struct {
+	Matches     uint64 `json:"matches"`
+	Divergences uint64 `json:"divergences"`
+	Errors      uint64 `json:"errors"`
+	Skipped     uint64 `json:"skipped"`
+}
+
+// Shadow compares a sample of the answers served locally with the proxy node's answers to the same requests
+// The comparison runs in the background and never affects the local answer
+type Shadow struct {
+	client  *rpc.Client
+	conf    ShadowConfig
+	methods map[string]bool
+	slots   chan struct{}
+
+	mu      sync.Mutex
+	results map[string]*ShadowResults
+}
+
+// NewShadow creates a new Shadow calling out to the client
+func NewShadow(client *rpc.Client, conf ShadowConfig) (*Shadow, error) {
+	if client == nil {
+		return nil, errors.New("shadow mode requires a proxy node")
+	}
+	if conf.SampleRate < 0 || conf.SampleRate > 1 {
+		return nil, fmt.Errorf("shadow sample rate must be between 0 and 1, got %v", conf.SampleRate)
+	}
+	if conf.Timeout <= 0 {
+		conf.Timeout = DefaultShadowTimeout
+	}
+	if conf.MaxConcurrent <= 0 {
+		conf.MaxConcurrent = DefaultShadowMaxConcurrent
+	}
+	s := &Shadow{
+		client:  client,
+		conf:    conf,
+		slots:   make(chan struct{}, conf.MaxConcurrent),
+		results: make(map[string]*ShadowResults),
+	}
+	if len(conf.Methods) > 0 {
+		s.methods = make(map[string]bool, len(conf.Methods))
+		for _, method := range conf.Methods {
+			s.methods[method] = true
+		}
+	}
+	return s, nil
+}
+
+// Results returns the outcomes of the shadowed requests, by method
+func (s *Shadow) Results() map[string]ShadowResults {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	results := make(map[string]ShadowResults, len(s.results))
+	for method, r := range s.results {
+		results[method] = *r
+	}
+	return results
+}
+
+// Compare samples the request and, if selected, calls the proxy node with the same method and arguments in the background
+// and compares its answer with the local one; it is a no-op on a nil Shadow
+// Requests for the latest or pending block aren't compared, since the proxy node may be at a different height
+func (s *Shadow) Compare(method string, local interface{}, args ...interface{}) {
+	if s == nil || (s.methods != nil && !s.methods[method]) || rand.Float64() >= s.conf.SampleRate {
+		return
+	}
+	if relativeToHead(args) {
+		return
+	}
+	for i, arg := range args {
+		if crit, ok := arg.(filters.FilterCriteria); ok {
+			args[i] = filterArg(crit)
+		}
+	}
+	select {
+	case s.slots <- struct{}{}:
+	default:
+		s.record(method, prom.ShadowSkipped)
+		return
+	}
+	go func() {
+		defer func() { <-s.slots }()
+		s.compare(method, local, args)
+	}()
+}
+
+func (s *Shadow) compare(method string, local interface{}, args []interface{}) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.conf.Timeout)
+	defer cancel()
+	var remote json.RawMessage
+	if err := s.client.CallContext(ctx, &remote, method, args...); err != nil {
+		logrus.Debugf("shadow %s call failed: %v", method, err)
+		s.record(method, prom.ShadowError)
+		return
+	}
+	localJSON, err := json.Marshal(local)
+	if err != nil {
+		logrus.Errorf("shadow %s failed to marshal the local answer: %v", method, err)
+		s.record(method, prom.ShadowError)
+		return
+	}
+	diffs, err := diffJSON(localJSON, remote)
+	if err != nil {
+		logrus.Errorf("shadow %s failed to compare the answers: %v", method, err)
+		s.record(method, prom.ShadowError)
+		return
+	}
+	if len(diffs) == 0 {
+		s.record(method, prom.ShadowMatch)
+		return
+	}
+	s.record(method, prom.ShadowDivergence)
+	params, _ := json.Marshal(args)
+	logrus.WithFields(logrus.Fields{
+		"method": method,
+		"params": string(params),
+		"diffs":  strings.Join(diffs, "; "),
+	}).Warn("local answer diverges from the proxy node")
+}
+
+func (s *Shadow) record(method, result string) {
+	prom.RecordShadowComparison(method, result)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.results[method]
+	if !ok {
+		r = new(ShadowResults)
+		s.results[method] = r
+	}
+	switch result {
+	case prom.ShadowMatch:
+		r.Matches++
+	case prom.ShadowDivergence:
+		r.Divergences++
+	case prom.ShadowError:
+		r.Errors++
+	case prom.ShadowSkipped:
+		r.Skipped++
+	}
+}
+
+// relativeToHead returns whether any of the arguments refers to the latest or pending block
+func relativeToHead(args []interface{}) bool {
+	for _, arg := range args {
+		switch a := arg.(type) {
+		case rpc.BlockNumber:
+			if a < 0 {
+				return true
+			}
+		case rpc.BlockNumberOrHash:
+			if number, ok := a.Number(); ok && number < 0 {
+				return true
+			}
+		case filters.FilterCriteria:
+			if a.BlockHash == nil && (a.FromBlock == nil || a.ToBlock == nil || a.FromBlock.Sign() < 0 || a.ToBlock.Sign() < 0) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// filterArg converts the criteria into the eth_getLogs argument understood by the proxy node
+func filterArg(crit filters.FilterCriteria) map[string]interface{} {
+	arg := map[string]interface{}{
+		"address": crit.Addresses,
+		"topics":  crit.Topics,
+	}
+	if crit.BlockHash != nil {
+		arg["blockHash"] = *crit.BlockHash
+		return arg
+	}
+	if crit.FromBlock != nil {
+		arg["fromBlock"] = (*hexutil.Big)(crit.FromBlock)
+	}
+	if crit.ToBlock != nil {
+		arg["toBlock"] = (*hexutil.Big)(crit.ToBlock)
+	}
+	return arg
+}
+
+// diffJSON compares the normalized documents and describes their differences
+func diffJSON(local, remote []byte) ([]string, error) {
+	l, err := normalizeJSON(local)
+	if err != nil {
+		return nil, err
+	}
+	r, err := normalizeJSON(remote)
+	if err != nil {
+		return nil, err
+	}
+	var diffs []string
+	diffValues("result", l, r, &diffs)
+	return diffs, nil
+}
+
+// normalizeJSON decodes the document, keeping numbers as they are written,
+// treating empty arrays as null, dropping null object members and lower-casing hex strings
+func normalizeJSON(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return normalizeValue(v), nil
+}
+
+func normalizeValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, member := range t {
+			if member = normalizeValue(member); member == nil {
+				delete(t, k)
+				continue
+			}
+			t[k] = member
+		}
+		return t
+	case []interface{}:
+		if len(t) == 0 {
+			return nil
+		}
+		for i := range t {
+			t[i] = normalizeValue(t[i])
+		}
+		return t
+	case string:
+		if strings.HasPrefix(t, "0x") || strings.HasPrefix(t, "0X") {
+			return strings.ToLower(t)
+		}
+		return t
+	default:
+		return v
+	}
+}
+
+// diffValues appends the differences between the values at the path, up to maxShadowDiffs of them
+func diffValues(path string, local, remote interface{}, diffs *[]string) {
+	if len(*diffs) >= maxShadowDiffs {
+		return
+	}
+	switch l := local.(type) {
+	case map[string]interface{}:
+		r, ok := remote.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := make(map[string]bool, len(l)+len(r))
+		for k := range l {
+			keys[k] = true
+		}
+		for k := range r {
+			keys[k] = true
+		}
+		sorted := make([]string, 0, len(keys))
+		for k := range keys {
+			sorted = append(sorted, k)
+		}
+		sort.Strings(sorted)
+		for _, k := range sorted {
+			diffValues(path+"."+k, l[k], r[k], diffs)
+		}
+		return
+	case []interface{}:
+		r, ok := remote.([]interface{})
+		if !ok {
+			break
+		}
+		if len(l) != len(r) {
+			*diffs = append(*diffs, fmt.Sprintf("%s: local has %d elements, proxy has %d", path, len(l), len(r)))
+			return
+		}
+		for i := range l {
+			diffValues(fmt.Sprintf("%s[%d]", path, i), l[i], r[i], diffs)
+		}
+		return
+	default:
+		if local == remote {
+			return
+		}
+	}
+	*diffs = append(*diffs, fmt.Sprintf("%s: local %s, proxy %s", path, describe(local), describe(remote)))
+}
+
+func describe(v interface{}) string {
+	if v == nil {
+		return "missing"
+	}
+	data, _ := json.Marshal(v)
+	return string(data)
+}
@@ -0,0 +1,160 @@
+// VulcanizeDB
+// Copyright © 2022 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package eth_test
+
+import (
+	"errors"
+	"math/big"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/filters"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/ipld-eth-server/pkg/eth"
+)
+
+var (
+	shadowAddress = common.HexToAddress("0xaE9BEa628c4Ce503DcFD7E305CaB4e29E7476592")
+	shadowLog     = &types.Log{
+		Address:     shadowAddress,
+		Topics:      []common.Hash{common.HexToHash("0x01")},
+		Data:        []byte{1, 2, 3},
+		BlockNumber: 1,
+	}
+)
+
+// mockShadowEthAPI is a fake proxy node answering a few eth methods
+type mockShadowEthAPI struct {
+	calls int32
+}
+
+func (api *mockShadowEthAPI) GetBalance(address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (*hexutil.Big, error) {
+	atomic.AddInt32(&api.calls, 1)
+	return (*hexutil.Big)(big.NewInt(1000)), nil
+}
+
+// GetCode answers with upper case hex, which is the same value as the lower case hex served locally
+func (api *mockShadowEthAPI) GetCode(address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (string, error) {
+	atomic.AddInt32(&api.calls, 1)
+	return "0xABCDEF", nil
+}
+
+func (api *mockShadowEthAPI) GetLogs(crit filters.FilterCriteria) ([]*types.Log, error) {
+	atomic.AddInt32(&api.calls, 1)
+	if crit.FromBlock == nil || crit.FromBlock.Int64() != 1 || len(crit.Addresses) != 1 || crit.Addresses[0] != shadowAddress {
+		return []*types.Log{}, nil
+	}
+	return []*types.Log{shadowLog}, nil
+}
+
+func (api *mockShadowEthAPI) GetTransactionCount(address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (*hexutil.Uint64, error) {
+	atomic.AddInt32(&api.calls, 1)
+	return nil, errors.New("unavailable")
+}
+
+var _ = Describe("Shadow", func() {
+	var (
+		api    *mockShadowEthAPI
+		client *rpc.Client
+		shadow *eth.Shadow
+		number = rpc.BlockNumberOrHashWithNumber(1)
+	)
+	BeforeEach(func() {
+		api = new(mockShadowEthAPI)
+		srv := rpc.NewServer()
+		Expect(srv.RegisterName("eth", api)).To(Succeed())
+		client = rpc.DialInProc(srv)
+		var err error
+		shadow, err = eth.NewShadow(client, eth.ShadowConfig{SampleRate: 1, Timeout: time.Second})
+		Expect(err).ToNot(HaveOccurred())
+	})
+	AfterEach(func() {
+		client.Close()
+	})
+
+	It("Rejects invalid configurations", func() {
+		_, err := eth.NewShadow(nil, eth.ShadowConfig{SampleRate: 1})
+		Expect(err).To(HaveOccurred())
+		_, err = eth.NewShadow(client, eth.ShadowConfig{SampleRate: 1.5})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("Is a no-op when nil", func() {
+		var nilShadow *eth.Shadow
+		nilShadow.Compare("eth_getBalance", (*hexutil.Big)(big.NewInt(1000)), shadowAddress, number)
+	})
+
+	It("Counts matching answers, normalizing hex strings", func() {
+		shadow.Compare("eth_getBalance", (*hexutil.Big)(big.NewInt(1000)), shadowAddress, number)
+		shadow.Compare("eth_getCode", hexutil.Bytes{0xab, 0xcd, 0xef}, shadowAddress, number)
+		Eventually(shadow.Results).Should(Equal(map[string]eth.ShadowResults{
+			"eth_getBalance": {Matches: 1},
+			"eth_getCode":    {Matches: 1},
+		}))
+	})
+
+	It("Counts diverging answers and failed proxy calls", func() {
+		shadow.Compare("eth_getBalance", (*hexutil.Big)(big.NewInt(999)), shadowAddress, number)
+		count := hexutil.Uint64(1)
+		shadow.Compare("eth_getTransactionCount", &count, shadowAddress, number)
+		Eventually(shadow.Results).Should(Equal(map[string]eth.ShadowResults{
+			"eth_getBalance":          {Divergences: 1},
+			"eth_getTransactionCount": {Errors: 1},
+		}))
+	})
+
+	It("Passes log filters on to the proxy node", func() {
+		crit := filters.FilterCriteria{
+			FromBlock: big.NewInt(1),
+			ToBlock:   big.NewInt(2),
+			Addresses: []common.Address{shadowAddress},
+		}
+		shadow.Compare("eth_getLogs", []*types.Log{shadowLog}, crit)
+		Eventually(shadow.Results).Should(Equal(map[string]eth.ShadowResults{
+			"eth_getLogs": {Matches: 1},
+		}))
+	})
+
+	It("Treats empty and null lists as equal", func() {
+		crit := filters.FilterCriteria{FromBlock: big.NewInt(5), ToBlock: big.NewInt(6)}
+		var logs []*types.Log
+		shadow.Compare("eth_getLogs", logs, crit)
+		Eventually(shadow.Results).Should(Equal(map[string]eth.ShadowResults{
+			"eth_getLogs": {Matches: 1},
+		}))
+	})
+
+	It("Skips requests relative to the chain head and methods which aren't shadowed", func() {
+		shadow.Compare("eth_getBalance", (*hexutil.Big)(big.NewInt(1000)), shadowAddress, rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber))
+		shadow.Compare("eth_getLogs", []*types.Log{}, filters.FilterCriteria{FromBlock: big.NewInt(1)})
+
+		limited, err := eth.NewShadow(client, eth.ShadowConfig{SampleRate: 1, Methods: []string{"eth_getCode"}})
+		Expect(err).ToNot(HaveOccurred())
+		limited.Compare("eth_getBalance", (*hexutil.Big)(big.NewInt(1000)), shadowAddress, number)
+
+		Consistently(func() int32 { return atomic.LoadInt32(&api.calls) }, 100*time.Millisecond).Should(BeZero())
+		Expect(shadow.Results()).To(BeEmpty())
+		Expect(limited.Results()).To(BeEmpty())
+	})
+})
@@ -0,0 +1,287 @@
+// VulcanizeDB
+// Copyright © 2022 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/statediff"
+	"github.com/sirupsen/logrus"
+
+	"github.com/vulcanize/ipld-eth-server/pkg/prom"
+)
+
+// StateDiffWriterConfig defaults
+const (
+	DefaultStateDiffWriteWorkers   = 4
+	DefaultStateDiffWriteQueueSize = 1000
+	DefaultStateDiffWriteMaxRange  = 100
+	DefaultStateDiffWriteTimeout   = 240 * time.Second
+)
+
+const (
+	writeStateDiffAtMethod  = "statediff_writeStateDiffAt"
+	writeStateDiffForMethod = "statediff_writeStateDiffFor"
+)
+
+// StateDiffWriterConfig caps the statediff writes requested on proxied cache misses
+type StateDiffWriterConfig struct {
+	// number of concurrent calls to the proxy node
+	Workers int
+	// maximum number of queued and in flight writes, further requests are dropped
+	QueueSize int
+	// maximum number of heights written for a single ranged request, such as an eth_getLogs miss
+	MaxRange int64
+	// timeout of each call to the proxy node
+	Timeout time.Duration
+}
+
+// ErrStateDiffWriteDropped is returned by Write when the queue is full
+var ErrStateDiffWriteDropped = errors.New("statediff write queue is full")
+
+// stateDiffWrite identifies a write, by height or by block hash, and the watched addresses it is restricted to
+type stateDiffWrite struct {
+	height    int64
+	hash      common.Hash
+	addresses string
+}
+
+// stateDiffJob is a queued or in flight write, waiters block on done and then read err
+type stateDiffJob struct {
+	addresses []common.Address
+	done      chan struct{}
+	err       error
+}
+
+// stateDiffParams returns the params of the statediff writes used to fill the index
+// If addresses are provided, only the state of those addresses is written
+func stateDiffParams(addresses []common.Address) statediff.Params {
+	return statediff.Params{
+		IntermediateStateNodes:   true,
+		IntermediateStorageNodes: true,
+		IncludeBlock:             true,
+		IncludeReceipts:          true,
+		IncludeTD:                true,
+		IncludeCode:              true,
+		WatchedAddresses:         addresses,
+	}
+}
+
+// addressesKey returns an order independent key for the addresses
+func addressesKey(addresses []common.Address) string {
+	if len(addresses) == 0 {
+		return ""
+	}
+	sorted := make([]common.Address, len(addresses))
+	copy(sorted, addresses)
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i][:], sorted[j][:]) < 0
+	})
+	key := make([]byte, 0, len(sorted)*common.AddressLength)
+	for _, addr := range sorted {
+		key = append(key, addr[:]...)
+	}
+	return string(key)
+}
+
+func (w stateDiffWrite) method() string {
+	if w.hash != (common.Hash{}) {
+		return writeStateDiffForMethod
+	}
+	return writeStateDiffAtMethod
+}
+
+// StateDiffWriter queues the statediff_writeStateDiffAt and statediff_writeStateDiffFor calls used to fill the index
+// on proxied cache misses, by the gap filler and by the watched address backfills; requests for a height or hash which
+// is already queued or being written are coalesced
+type StateDiffWriter struct {
+	client *rpc.Client
+	conf   StateDiffWriterConfig
+
+	queue    chan stateDiffWrite
+	quit     chan struct{}
+	stopOnce sync.Once
+
+	mu      sync.Mutex
+	pending map[stateDiffWrite]*stateDiffJob
+}
+
+// NewStateDiffWriter creates a new StateDiffWriter calling out to the client, and starts its workers
+func NewStateDiffWriter(client *rpc.Client, conf StateDiffWriterConfig) (*StateDiffWriter, error) {
+	if client == nil {
+		return nil, errors.New("statediff writes require a proxy node")
+	}
+	if conf.Workers < 0 || conf.QueueSize < 0 || conf.MaxRange < 0 || conf.Timeout < 0 {
+		return nil, errors.New("statediff write limits can't be negative")
+	}
+	if conf.Workers == 0 {
+		conf.Workers = DefaultStateDiffWriteWorkers
+	}
+	if conf.QueueSize == 0 {
+		conf.QueueSize = DefaultStateDiffWriteQueueSize
+	}
+	if conf.MaxRange == 0 {
+		conf.MaxRange = DefaultStateDiffWriteMaxRange
+	}
+	if conf.Timeout == 0 {
+		conf.Timeout = DefaultStateDiffWriteTimeout
+	}
+	w := &StateDiffWriter{
+		client:  client,
+		conf:    conf,
+		queue:   make(chan stateDiffWrite, conf.QueueSize),
+		quit:    make(chan struct{}),
+		pending: make(map[stateDiffWrite]*stateDiffJob),
+	}
+	for i := 0; i < conf.Workers; i++ {
+		go w.work()
+	}
+	return w, nil
+}
+
+// Stop shuts down the workers, queued writes are abandoned
+func (w *StateDiffWriter) Stop() {
+	w.stopOnce.Do(func() {
+		close(w.quit)
+	})
+}
+
+// Pending returns the number of queued and in flight writes
+func (w *StateDiffWriter) Pending() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.pending)
+}
+
+// WriteAt queues a write of the block at the height, it returns false if the write was dropped
+func (w *StateDiffWriter) WriteAt(height int64) bool {
+	return w.enqueue(stateDiffWrite{height: height}, nil) != nil
+}
+
+// WriteFor queues a write of the block with the hash, it returns false if the write was dropped
+func (w *StateDiffWriter) WriteFor(hash common.Hash) bool {
+	return w.enqueue(stateDiffWrite{hash: hash}, nil) != nil
+}
+
+// Write queues a write of the block at the height, restricted to the addresses if any are provided, and waits for it
+// to complete; the context only bounds the wait, the write itself is bounded by the configured timeout
+func (w *StateDiffWriter) Write(ctx context.Context, height uint64, addresses []common.Address) error {
+	job := w.enqueue(stateDiffWrite{height: int64(height), addresses: addressesKey(addresses)}, addresses)
+	if job == nil {
+		return ErrStateDiffWriteDropped
+	}
+	select {
+	case <-job.done:
+		return job.err
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-w.quit:
+		return errors.New("statediff writer stopped")
+	}
+}
+
+// WriteRange queues writes of the blocks from start to end (inclusive), capped at MaxRange heights from the start
+// It returns the number of writes queued or coalesced
+func (w *StateDiffWriter) WriteRange(start, end int64) int {
+	if end-start >= w.conf.MaxRange {
+		logrus.Warnf("statediff write range %d-%d exceeds the maximum of %d blocks, only writing up to %d", start, end, w.conf.MaxRange, start+w.conf.MaxRange-1)
+		end = start + w.conf.MaxRange - 1
+	}
+	var queued int
+	for height := start; height <= end; height++ {
+		if !w.WriteAt(height) {
+			break
+		}
+		queued++
+	}
+	return queued
+}
+
+// enqueue queues the write, or joins the one already pending, it returns nil if the write was dropped
+func (w *StateDiffWriter) enqueue(write stateDiffWrite, addresses []common.Address) *stateDiffJob {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if job, ok := w.pending[write]; ok {
+		prom.RecordStateDiffWrite(write.method(), prom.StateDiffWriteCoalesced)
+		return job
+	}
+	if len(w.pending) >= w.conf.QueueSize {
+		prom.RecordStateDiffWrite(write.method(), prom.StateDiffWriteDropped)
+		return nil
+	}
+	job := &stateDiffJob{addresses: addresses, done: make(chan struct{})}
+	w.pending[write] = job
+	// pending includes every queued write, so this never blocks
+	w.queue <- write
+	prom.SetStateDiffWritesPending(len(w.pending))
+	return job
+}
+
+func (w *StateDiffWriter) work() {
+	for {
+		select {
+		case write := <-w.queue:
+			w.write(write)
+		case <-w.quit:
+			return
+		}
+	}
+}
+
+// write calls out to the proxy statediffing geth client to fill in a gap in the index
+func (w *StateDiffWriter) write(write stateDiffWrite) {
+	w.mu.Lock()
+	job := w.pending[write]
+	w.mu.Unlock()
+	var err error
+	defer func() {
+		w.mu.Lock()
+		delete(w.pending, write)
+		prom.SetStateDiffWritesPending(len(w.pending))
+		w.mu.Unlock()
+		job.err = err
+		close(job.done)
+	}()
+	// we use a separate context than the one provided by the client
+	ctx, cancel := context.WithTimeout(context.Background(), w.conf.Timeout)
+	defer cancel()
+	var data json.RawMessage
+	params := stateDiffParams(job.addresses)
+	if write.method() == writeStateDiffForMethod {
+		err = w.client.CallContext(ctx, &data, writeStateDiffForMethod, write.hash, params)
+	} else {
+		err = w.client.CallContext(ctx, &data, writeStateDiffAtMethod, uint64(write.height), params)
+	}
+	if err != nil {
+		prom.RecordStateDiffWrite(write.method(), prom.StateDiffWriteFailure)
+		if write.method() == writeStateDiffForMethod {
+			logrus.Errorf("writeStateDiffFor %s failed with err %s", write.hash.Hex(), err.Error())
+		} else {
+			logrus.Errorf("writeStateDiffAt %d failed with err %s", write.height, err.Error())
+		}
+		return
+	}
+	prom.RecordStateDiffWrite(write.method(), prom.StateDiffWriteSuccess)
+}
@@ -0,0 +1,162 @@
+// VulcanizeDB
+// Copyright © 2022 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package eth_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/statediff"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/ipld-eth-server/pkg/eth"
+)
+
+// mockStateDiffAPI is a fake proxy node recording the writes it is asked to make, each write waits to be released
+type mockStateDiffAPI struct {
+	sync.Mutex
+	release   chan struct{}
+	heights   []uint64
+	hashes    []common.Hash
+	addresses [][]common.Address
+}
+
+func (api *mockStateDiffAPI) WriteStateDiffAt(height uint64, params statediff.Params) error {
+	<-api.release
+	api.Lock()
+	defer api.Unlock()
+	api.heights = append(api.heights, height)
+	api.addresses = append(api.addresses, params.WatchedAddresses)
+	return nil
+}
+
+func (api *mockStateDiffAPI) WriteStateDiffFor(hash common.Hash, params statediff.Params) error {
+	<-api.release
+	api.Lock()
+	defer api.Unlock()
+	api.hashes = append(api.hashes, hash)
+	return errors.New("write failed")
+}
+
+func (api *mockStateDiffAPI) writes() int {
+	api.Lock()
+	defer api.Unlock()
+	return len(api.heights) + len(api.hashes)
+}
+
+var _ = Describe("StateDiffWriter", func() {
+	var (
+		api    *mockStateDiffAPI
+		client *rpc.Client
+		writer *eth.StateDiffWriter
+	)
+	BeforeEach(func() {
+		api = &mockStateDiffAPI{release: make(chan struct{})}
+		srv := rpc.NewServer()
+		Expect(srv.RegisterName("statediff", api)).To(Succeed())
+		client = rpc.DialInProc(srv)
+		var err error
+		writer, err = eth.NewStateDiffWriter(client, eth.StateDiffWriterConfig{
+			Workers:   1,
+			QueueSize: 3,
+			MaxRange:  2,
+			Timeout:   time.Second,
+		})
+		Expect(err).ToNot(HaveOccurred())
+	})
+	AfterEach(func() {
+		writer.Stop()
+		close(api.release)
+		client.Close()
+	})
+
+	It("Coalesces duplicate writes and drops writes beyond the queue size", func() {
+		hash := common.HexToHash("0x1")
+		Expect(writer.WriteAt(1)).To(BeTrue())
+		Expect(writer.WriteAt(1)).To(BeTrue())
+		Expect(writer.WriteFor(hash)).To(BeTrue())
+		Expect(writer.WriteFor(hash)).To(BeTrue())
+		Expect(writer.WriteAt(2)).To(BeTrue())
+		Expect(writer.Pending()).To(Equal(3))
+		Expect(writer.WriteAt(3)).To(BeFalse())
+
+		for i := 0; i < 3; i++ {
+			api.release <- struct{}{}
+		}
+		Eventually(writer.Pending).Should(Equal(0))
+		Expect(api.writes()).To(Equal(3))
+		Expect(api.heights).To(ConsistOf(uint64(1), uint64(2)))
+		Expect(api.hashes).To(Equal([]common.Hash{hash}))
+
+		// once written, a height can be queued again
+		Expect(writer.WriteAt(1)).To(BeTrue())
+		Expect(writer.Pending()).To(Equal(1))
+	})
+
+	It("Joins blocking writes to the pending writes of the same height and addresses", func() {
+		addr := common.HexToAddress("0x2")
+		Expect(writer.WriteAt(5)).To(BeTrue())
+		results := make(chan error, 2)
+		go func() { results <- writer.Write(context.Background(), 5, nil) }()
+		go func() { results <- writer.Write(context.Background(), 5, []common.Address{addr}) }()
+		Eventually(writer.Pending).Should(Equal(2))
+		Expect(writer.WriteAt(6)).To(BeTrue())
+		Expect(writer.Write(context.Background(), 7, nil)).To(MatchError(eth.ErrStateDiffWriteDropped))
+
+		for i := 0; i < 3; i++ {
+			api.release <- struct{}{}
+		}
+		Eventually(results).Should(Receive(BeNil()))
+		Eventually(results).Should(Receive(BeNil()))
+		Eventually(writer.Pending).Should(Equal(0))
+		Expect(api.heights).To(ConsistOf(uint64(5), uint64(5), uint64(6)))
+		Expect(api.addresses).To(ConsistOf(BeNil(), BeNil(), Equal([]common.Address{addr})))
+	})
+
+	It("Stops waiting for a blocking write when the context is done", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		Expect(writer.Write(ctx, 1, nil)).To(MatchError(context.DeadlineExceeded))
+		// the write stays pending and later callers join it
+		Expect(writer.Pending()).To(Equal(1))
+		api.release <- struct{}{}
+		Eventually(writer.Pending).Should(Equal(0))
+	})
+
+	It("Caps the size of ranged writes", func() {
+		Expect(writer.WriteRange(10, 100)).To(Equal(2))
+		Expect(writer.Pending()).To(Equal(2))
+		for i := 0; i < 2; i++ {
+			api.release <- struct{}{}
+		}
+		Eventually(writer.Pending).Should(Equal(0))
+		Expect(api.heights).To(ConsistOf(uint64(10), uint64(11)))
+	})
+
+	It("Requires a proxy node and non-negative limits", func() {
+		_, err := eth.NewStateDiffWriter(nil, eth.StateDiffWriterConfig{})
+		Expect(err).To(HaveOccurred())
+		_, err = eth.NewStateDiffWriter(client, eth.StateDiffWriterConfig{QueueSize: -1})
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -17,6 +17,7 @@
 package eth
 
 import (
+	"fmt"
 	"math/big"
 
 	"github.com/spf13/viper"
@@ -33,6 +34,9 @@ type SubscriptionSettings struct {
 	ReceiptFilter ReceiptFilter
 	StateFilter   StateFilter
 	StorageFilter StorageFilter
+	Encoding      PayloadEncoding // defaults to RLPEncoding when left empty
+	// Expression is an optional filter expression (see FilterExpression) which transactions and receipts must also satisfy
+	Expression string
 }
 
 // HeaderFilter contains filter settings for headers
@@ -46,6 +50,13 @@ type TxFilter struct {
 	Off bool
 	Src []string
 	Dst []string
+	// The below filters are applied in addition to the Src and Dst filters
+	MethodSelectors  []string // 4-byte input selectors, hex encoded e.g. "0xa9059cbb"
+	MinValue         *big.Int // inclusive, nil for no lower bound
+	MaxValue         *big.Int // inclusive, nil for no upper bound
+	ContractCreation bool     // only return contract creations
+	Types            []uint64 // EIP-2718 tx types; empty for all types
+	FailedOnly       bool     // only return transactions whose receipt has a failed status
 }
 
 // ReceiptFilter contains filter settings for receipts
@@ -74,52 +85,93 @@ type StorageFilter struct {
 
 // Init is used to initialize a EthSubscription struct with env variables
 func NewEthSubscriptionConfig() (*SubscriptionSettings, error) {
+	return newSubscriptionSettings(viper.GetViper(), "watcher.ethSubscription.")
+}
+
+// NewSubscriptionSettingsFromConfig reads SubscriptionSettings from the provided config
+// It uses the same keys as the watcher.ethSubscription table, without that prefix
+func NewSubscriptionSettingsFromConfig(v *viper.Viper) (*SubscriptionSettings, error) {
+	return newSubscriptionSettings(v, "")
+}
+
+func newSubscriptionSettings(v *viper.Viper, prefix string) (*SubscriptionSettings, error) {
 	sc := new(SubscriptionSettings)
 	// Below default to false, which means we do not backfill by default
-	sc.BackFill = viper.GetBool("watcher.ethSubscription.historicalData")
-	sc.BackFillOnly = viper.GetBool("watcher.ethSubscription.historicalDataOnly")
+	sc.BackFill = v.GetBool(prefix + "historicalData")
+	sc.BackFillOnly = v.GetBool(prefix + "historicalDataOnly")
 	// Below default to 0
 	// 0 start means we start at the beginning and 0 end means we continue indefinitely
-	sc.Start = big.NewInt(viper.GetInt64("watcher.ethSubscription.startingBlock"))
-	sc.End = big.NewInt(viper.GetInt64("watcher.ethSubscription.endingBlock"))
+	sc.Start = big.NewInt(v.GetInt64(prefix + "startingBlock"))
+	sc.End = big.NewInt(v.GetInt64(prefix + "endingBlock"))
 	// Below default to false, which means we get all headers and no uncles by default
 	sc.HeaderFilter = HeaderFilter{
-		Off:    viper.GetBool("watcher.ethSubscription.headerFilter.off"),
-		Uncles: viper.GetBool("watcher.ethSubscription.headerFilter.uncles"),
+		Off:    v.GetBool(prefix + "headerFilter.off"),
+		Uncles: v.GetBool(prefix + "headerFilter.uncles"),
 	}
 	// Below defaults to false and two slices of length 0
 	// Which means we get all transactions by default
 	sc.TxFilter = TxFilter{
-		Off: viper.GetBool("watcher.ethSubscription.txFilter.off"),
-		Src: viper.GetStringSlice("watcher.ethSubscription.txFilter.src"),
-		Dst: viper.GetStringSlice("watcher.ethSubscription.txFilter.dst"),
+		Off:              v.GetBool(prefix + "txFilter.off"),
+		Src:              v.GetStringSlice(prefix + "txFilter.src"),
+		Dst:              v.GetStringSlice(prefix + "txFilter.dst"),
+		MethodSelectors:  v.GetStringSlice(prefix + "txFilter.methodSelectors"),
+		ContractCreation: v.GetBool(prefix + "txFilter.contractCreation"),
+		FailedOnly:       v.GetBool(prefix + "txFilter.failedOnly"),
+	}
+	for _, ty := range v.GetIntSlice(prefix + "txFilter.types") {
+		sc.TxFilter.Types = append(sc.TxFilter.Types, uint64(ty))
+	}
+	// Value bounds are given as base 10 strings since they can overflow an int64
+	if minValue := v.GetString(prefix + "txFilter.minValue"); minValue != "" {
+		var ok bool
+		if sc.TxFilter.MinValue, ok = new(big.Int).SetString(minValue, 10); !ok {
+			return nil, fmt.Errorf("invalid txFilter minValue: %s", minValue)
+		}
+	}
+	if maxValue := v.GetString(prefix + "txFilter.maxValue"); maxValue != "" {
+		var ok bool
+		if sc.TxFilter.MaxValue, ok = new(big.Int).SetString(maxValue, 10); !ok {
+			return nil, fmt.Errorf("invalid txFilter maxValue: %s", maxValue)
+		}
 	}
 	// By default all of the topic slices will be empty => match on any/all topics
 	topics := make([][]string, 4)
-	topics[0] = viper.GetStringSlice("watcher.ethSubscription.receiptFilter.topic0s")
-	topics[1] = viper.GetStringSlice("watcher.ethSubscription.receiptFilter.topic1s")
-	topics[2] = viper.GetStringSlice("watcher.ethSubscription.receiptFilter.topic2s")
-	topics[3] = viper.GetStringSlice("watcher.ethSubscription.receiptFilter.topic3s")
+	topics[0] = v.GetStringSlice(prefix + "receiptFilter.topic0s")
+	topics[1] = v.GetStringSlice(prefix + "receiptFilter.topic1s")
+	topics[2] = v.GetStringSlice(prefix + "receiptFilter.topic2s")
+	topics[3] = v.GetStringSlice(prefix + "receiptFilter.topic3s")
 	sc.ReceiptFilter = ReceiptFilter{
-		Off:          viper.GetBool("watcher.ethSubscription.receiptFilter.off"),
-		MatchTxs:     viper.GetBool("watcher.ethSubscription.receiptFilter.matchTxs"),
-		LogAddresses: viper.GetStringSlice("watcher.ethSubscription.receiptFilter.contracts"),
+		Off:          v.GetBool(prefix + "receiptFilter.off"),
+		MatchTxs:     v.GetBool(prefix + "receiptFilter.matchTxs"),
+		LogAddresses: v.GetStringSlice(prefix + "receiptFilter.contracts"),
 		Topics:       topics,
 	}
 	// Below defaults to two false, and a slice of length 0
 	// Which means we get all state leafs by default, but no intermediate nodes
 	sc.StateFilter = StateFilter{
-		Off:               viper.GetBool("watcher.ethSubscription.stateFilter.off"),
-		IntermediateNodes: viper.GetBool("watcher.ethSubscription.stateFilter.intermediateNodes"),
-		Addresses:         viper.GetStringSlice("watcher.ethSubscription.stateFilter.addresses"),
+		Off:               v.GetBool(prefix + "stateFilter.off"),
+		IntermediateNodes: v.GetBool(prefix + "stateFilter.intermediateNodes"),
+		Addresses:         v.GetStringSlice(prefix + "stateFilter.addresses"),
 	}
 	// Below defaults to two false, and two slices of length 0
 	// Which means we get all storage leafs by default, but no intermediate nodes
 	sc.StorageFilter = StorageFilter{
-		Off:               viper.GetBool("watcher.ethSubscription.storageFilter.off"),
-		IntermediateNodes: viper.GetBool("watcher.ethSubscription.storageFilter.intermediateNodes"),
-		Addresses:         viper.GetStringSlice("watcher.ethSubscription.storageFilter.addresses"),
-		StorageKeys:       viper.GetStringSlice("watcher.ethSubscription.storageFilter.storageKeys"),
+		Off:               v.GetBool(prefix + "storageFilter.off"),
+		IntermediateNodes: v.GetBool(prefix + "storageFilter.intermediateNodes"),
+		Addresses:         v.GetStringSlice(prefix + "storageFilter.addresses"),
+		StorageKeys:       v.GetStringSlice(prefix + "storageFilter.storageKeys"),
+	}
+	// Below defaults to an empty string, which means payloads are rlp encoded
+	sc.Encoding = PayloadEncoding(v.GetString(prefix + "encoding"))
+	if !sc.Encoding.Valid() {
+		return nil, fmt.Errorf("unsupported subscription payload encoding: %s", sc.Encoding)
+	}
+	// Below defaults to an empty string, which means no filter expression is applied
+	sc.Expression = v.GetString(prefix + "expression")
+	if sc.Expression != "" {
+		if _, err := ParseFilterExpression(sc.Expression); err != nil {
+			return nil, err
+		}
 	}
 	return sc, nil
 }
@@ -265,3 +265,53 @@ type LogResult struct {
 	TxnIndex    int64  `db:"txn_index"`
 	TxHash      string `db:"tx_hash"`
 }
+
+// HeaderPageResult is a canonical header of a page of headers
+type HeaderPageResult struct {
+	BlockNumber int64  `db:"block_number"`
+	BlockHash   string `db:"block_hash"`
+}
+
+// TxPageResult is a transaction of a page of transactions
+type TxPageResult struct {
+	BlockNumber int64  `db:"block_number"`
+	BlockHash   string `db:"block_hash"`
+	Index       int64  `db:"index"`
+	TxHash      string `db:"tx_hash"`
+}
+
+// TxPageKey is the position of a transaction in the pages of transactions
+type TxPageKey struct {
+	BlockNumber int64
+	Index       int64
+}
+
+// LogPageKey is the position of a log in the pages of logs
+type LogPageKey struct {
+	BlockNumber int64
+	TxIndex     int64
+	Index       int64
+}
+
+// StateDiffAccount is an account changed in a block, with its state leaf node and its values before and after the block
+type StateDiffAccount struct {
+	LeafKey   common.Hash
+	Path      []byte
+	NodeType  sdtypes.NodeType
+	CID       string
+	IPLDBlock []byte
+	Account   *types.StateAccount // nil if the account was removed
+	Previous  *types.StateAccount // nil if the account did not exist before the block
+	Storage   []StateDiffStorage
+}
+
+// StateDiffStorage is a storage slot changed in a block, with its storage leaf node and its values before and after the block
+type StateDiffStorage struct {
+	LeafKey   common.Hash
+	Path      []byte
+	NodeType  sdtypes.NodeType
+	CID       string
+	IPLDBlock []byte
+	Value     []byte // nil if the slot was removed
+	Previous  []byte // nil if the slot was empty before the block
+}
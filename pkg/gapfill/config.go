@@ -0,0 +1,82 @@
+// VulcanizeDB
+// Copyright © 2022 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package gapfill
+
+import (
+	"errors"
+	"time"
+)
+
+// Config defaults
+const (
+	DefaultInterval      = 10 * time.Minute
+	DefaultScanWindow    = 10000
+	DefaultWorkers       = 4
+	DefaultQueueSize     = 1000
+	DefaultMaxRetries    = 5
+	DefaultRetryInterval = 5 * time.Second
+	DefaultTimeout       = 240 * time.Second
+)
+
+// Config holds the gap filling scheduler's parameters
+type Config struct {
+	Enabled bool
+	// first height checked for gaps
+	Start uint64
+	// time between scans
+	Interval time.Duration
+	// number of heights checked by each query of a scan
+	ScanWindow uint64
+	// number of concurrent writes
+	Workers int
+	// maximum number of heights queued or being written
+	QueueSize int
+	// maximum number of writes per second, 0 means no limit
+	RateLimit float64
+	// number of times a failed write is retried before the height is left for the next scan
+	MaxRetries int
+	// delay before the first retry of a failed write, doubled for every following retry
+	RetryInterval time.Duration
+	// time to wait for each write
+	Timeout time.Duration
+}
+
+// withDefaults fills in the unset parameters and validates the rest
+func (c Config) withDefaults() (Config, error) {
+	if c.Interval == 0 {
+		c.Interval = DefaultInterval
+	}
+	if c.ScanWindow == 0 {
+		c.ScanWindow = DefaultScanWindow
+	}
+	if c.Workers == 0 {
+		c.Workers = DefaultWorkers
+	}
+	if c.QueueSize == 0 {
+		c.QueueSize = DefaultQueueSize
+	}
+	if c.RetryInterval == 0 {
+		c.RetryInterval = DefaultRetryInterval
+	}
+	if c.Timeout == 0 {
+		c.Timeout = DefaultTimeout
+	}
+	if c.Interval < 0 || c.Workers < 0 || c.QueueSize < 0 || c.RateLimit < 0 || c.MaxRetries < 0 || c.RetryInterval < 0 || c.Timeout < 0 {
+		return c, errors.New("gap fill parameters can't be negative")
+	}
+	return c, nil
+}
@@ -0,0 +1,125 @@
+// VulcanizeDB
+// Copyright © 2022 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package gapfill
+
+import (
+	"database/sql"
+
+	"github.com/ethereum/go-ethereum/statediff/indexer/postgres"
+)
+
+const (
+	// RetrieveHeightBoundsPgStr returns the lowest and highest indexed heights in a range
+	RetrieveHeightBoundsPgStr = `SELECT MIN(block_number), MAX(block_number) FROM eth.header_cids
+									WHERE block_number BETWEEN $1 AND $2`
+	// RetrieveMissingRangesPgStr returns the ranges of heights missing between the indexed heights of a range
+	RetrieveMissingRangesPgStr = `SELECT block_number + 1 AS start, next_number - 1 AS stop FROM (
+									SELECT block_number, LEAD(block_number) OVER (ORDER BY block_number) AS next_number
+									FROM (SELECT DISTINCT block_number FROM eth.header_cids WHERE block_number BETWEEN $1 AND $2) AS heights
+								) AS neighbours
+								WHERE next_number > block_number + 1`
+	// RetrieveNonCanonicalOnlyHeightsPgStr returns the heights in a range for which none of the indexed headers
+	// is the parent of a header indexed at the next height
+	RetrieveNonCanonicalOnlyHeightsPgStr = `SELECT DISTINCT header_cids.block_number FROM eth.header_cids
+									WHERE header_cids.block_number BETWEEN $1 AND $2
+									AND EXISTS (SELECT 1 FROM eth.header_cids AS children
+										WHERE children.block_number = header_cids.block_number + 1)
+									AND NOT EXISTS (SELECT 1 FROM eth.header_cids AS parents
+										INNER JOIN eth.header_cids AS children ON (children.parent_hash = parents.block_hash)
+										WHERE parents.block_number = header_cids.block_number
+										AND children.block_number = header_cids.block_number + 1)
+									ORDER BY header_cids.block_number`
+	// RetrieveLastBlockNumberPgStr returns the highest indexed height
+	RetrieveLastBlockNumberPgStr = `SELECT block_number FROM eth.header_cids ORDER BY block_number DESC LIMIT 1`
+)
+
+// Range is an inclusive range of block heights
+type Range struct {
+	Start uint64 `json:"start"`
+	End   uint64 `json:"end"`
+}
+
+// Len returns the number of heights in the range
+func (r Range) Len() uint64 {
+	return r.End - r.Start + 1
+}
+
+// Finder finds the heights which need to be filled in the index
+type Finder interface {
+	LastBlockNumber() (uint64, error)
+	MissingRanges(start, end uint64) ([]Range, error)
+	NonCanonicalOnlyHeights(start, end uint64) ([]uint64, error)
+}
+
+// DBFinder satisfies the Finder interface using the eth.header_cids table
+type DBFinder struct {
+	db *postgres.DB
+}
+
+// NewDBFinder returns a new DBFinder around the provided db
+func NewDBFinder(db *postgres.DB) *DBFinder {
+	return &DBFinder{
+		db: db,
+	}
+}
+
+// LastBlockNumber returns the highest indexed height
+func (f *DBFinder) LastBlockNumber() (uint64, error) {
+	var blockNumber uint64
+	err := f.db.Get(&blockNumber, RetrieveLastBlockNumberPgStr)
+	return blockNumber, err
+}
+
+// MissingRanges returns the ranges of heights between start and end (inclusive) which have no indexed headers
+func (f *DBFinder) MissingRanges(start, end uint64) ([]Range, error) {
+	var bounds struct {
+		Min sql.NullInt64 `db:"min"`
+		Max sql.NullInt64 `db:"max"`
+	}
+	if err := f.db.Get(&bounds, RetrieveHeightBoundsPgStr, start, end); err != nil {
+		return nil, err
+	}
+	if !bounds.Min.Valid {
+		return []Range{{Start: start, End: end}}, nil
+	}
+	var ranges []Range
+	if uint64(bounds.Min.Int64) > start {
+		ranges = append(ranges, Range{Start: start, End: uint64(bounds.Min.Int64) - 1})
+	}
+	var inner []struct {
+		Start uint64 `db:"start"`
+		Stop  uint64 `db:"stop"`
+	}
+	if err := f.db.Select(&inner, RetrieveMissingRangesPgStr, start, end); err != nil {
+		return nil, err
+	}
+	for _, r := range inner {
+		ranges = append(ranges, Range{Start: r.Start, End: r.Stop})
+	}
+	if uint64(bounds.Max.Int64) < end {
+		ranges = append(ranges, Range{Start: uint64(bounds.Max.Int64) + 1, End: end})
+	}
+	return ranges, nil
+}
+
+// NonCanonicalOnlyHeights returns the heights between start and end (inclusive) at which only non-canonical headers are indexed
+// A height qualifies when a header is indexed at the next height but none of the headers at the height are its parent
+func (f *DBFinder) NonCanonicalOnlyHeights(start, end uint64) ([]uint64, error) {
+	var heights []uint64
+	err := f.db.Select(&heights, RetrieveNonCanonicalOnlyHeightsPgStr, start, end)
+	return heights, err
+}
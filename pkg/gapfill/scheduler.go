@@ -0,0 +1,347 @@
+// VulcanizeDB
+// Copyright © 2022 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package gapfill
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vulcanize/ipld-eth-server/pkg/prom"
+)
+
+// maxRetryInterval caps the backoff between retries of a failed write
+const maxRetryInterval = 5 * time.Minute
+
+// Reason is why a height is filled
+type Reason string
+
+// Fill reasons
+const (
+	Missing          Reason = "missing"
+	NonCanonicalOnly Reason = "nonCanonicalOnly"
+)
+
+// Status describes the progress of the scheduler
+type Status struct {
+	Scanning     bool      `json:"scanning"`
+	Start        uint64    `json:"start"`        // first height checked for gaps
+	LastScan     time.Time `json:"lastScan"`     // when the last completed scan started
+	ScannedTo    uint64    `json:"scannedTo"`    // highest height checked by the last completed scan
+	Missing      uint64    `json:"missing"`      // missing heights found by the last completed scan
+	NonCanonical uint64    `json:"nonCanonical"` // heights with only non-canonical headers found by the last completed scan
+	Skipped      uint64    `json:"skipped"`      // heights found by the last completed scan which didn't fit in the queue
+	Lowest       uint64    `json:"lowest"`       // lowest queued or in flight height, if any
+	LastFilled   uint64    `json:"lastFilled"`   // height of the last write which completed, 0 before the first
+	Queued       int       `json:"queued"`
+	InFlight     int       `json:"inFlight"`
+	Filled       uint64    `json:"filled"`
+	Failed       uint64    `json:"failed"`
+	Retries      uint64    `json:"retries"`
+}
+
+// Writer writes the state diff at a height into the index, restricted to the addresses if any are provided
+// It is satisfied by the eth.StateDiffWriter, which coalesces the writes with those requested on proxied cache misses
+type Writer interface {
+	Write(ctx context.Context, height uint64, addresses []common.Address) error
+}
+
+// Scheduler periodically scans the index for gaps and fills them by writing the state diffs through the writer
+// Heights are queued at most once, the queue is bounded and the writes are spread over a pool of rate limited workers
+type Scheduler struct {
+	finder Finder
+	writer Writer
+	conf   Config
+
+	queue    chan uint64
+	scanNow  chan struct{}
+	quit     chan struct{}
+	stopOnce sync.Once
+	limiter  *time.Ticker
+
+	mu      sync.Mutex
+	pending map[uint64]Reason // queued and in flight heights
+	status  Status
+}
+
+// NewScheduler creates a new Scheduler which finds gaps with the finder and fills them through the writer
+func NewScheduler(finder Finder, writer Writer, conf Config) (*Scheduler, error) {
+	if writer == nil {
+		return nil, errors.New("no proxy node to fill gaps from")
+	}
+	conf, err := conf.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+	return &Scheduler{
+		finder:  finder,
+		writer:  writer,
+		conf:    conf,
+		queue:   make(chan uint64, conf.QueueSize),
+		scanNow: make(chan struct{}, 1),
+		quit:    make(chan struct{}),
+		pending: make(map[uint64]Reason),
+		status:  Status{Start: conf.Start},
+	}, nil
+}
+
+// Start spins up the scanner and the workers
+func (s *Scheduler) Start(wg *sync.WaitGroup) {
+	if s.conf.RateLimit > 0 {
+		s.limiter = time.NewTicker(time.Duration(float64(time.Second) / s.conf.RateLimit))
+	}
+	wg.Add(1 + s.conf.Workers)
+	go func() {
+		defer wg.Done()
+		s.scanLoop()
+	}()
+	for i := 0; i < s.conf.Workers; i++ {
+		go func() {
+			defer wg.Done()
+			s.work()
+		}()
+	}
+	log.Infof("gap filler started with %d workers, scanning from block %d every %s", s.conf.Workers, s.conf.Start, s.conf.Interval)
+}
+
+// Stop shuts down the scanner and the workers, writes in flight are abandoned
+func (s *Scheduler) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.quit)
+		if s.limiter != nil {
+			s.limiter.Stop()
+		}
+	})
+}
+
+// Scan triggers a scan, unless one is already running or pending
+func (s *Scheduler) Scan() {
+	select {
+	case s.scanNow <- struct{}{}:
+	default:
+	}
+}
+
+// Status returns the progress of the scheduler
+func (s *Scheduler) Status() Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status := s.status
+	status.Queued = len(s.queue)
+	status.InFlight = len(s.pending) - status.Queued
+	first := true
+	for height := range s.pending {
+		if first || height < status.Lowest {
+			status.Lowest, first = height, false
+		}
+	}
+	return status
+}
+
+func (s *Scheduler) scanLoop() {
+	ticker := time.NewTicker(s.conf.Interval)
+	defer ticker.Stop()
+	for {
+		if err := s.scan(); err != nil {
+			log.Errorf("gap scan failed: %v", err)
+		}
+		select {
+		case <-ticker.C:
+		case <-s.scanNow:
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// scan checks the index from the configured start up to the highest indexed height, window by window, queueing the gaps
+func (s *Scheduler) scan() error {
+	s.setScanning(true)
+	defer s.setScanning(false)
+	started := time.Now()
+	head, err := s.finder.LastBlockNumber()
+	if err != nil {
+		return err
+	}
+	var missing, nonCanonical, skipped uint64
+	for from := s.conf.Start; from <= head; from += s.conf.ScanWindow {
+		select {
+		case <-s.quit:
+			return nil
+		default:
+		}
+		to := from + s.conf.ScanWindow - 1
+		if to > head {
+			to = head
+		}
+		ranges, err := s.finder.MissingRanges(from, to)
+		if err != nil {
+			return err
+		}
+		for _, r := range ranges {
+			missing += r.Len()
+			for height := r.Start; height <= r.End; height++ {
+				if !s.enqueue(height, Missing) {
+					skipped++
+				}
+			}
+		}
+		heights, err := s.finder.NonCanonicalOnlyHeights(from, to)
+		if err != nil {
+			return err
+		}
+		nonCanonical += uint64(len(heights))
+		for _, height := range heights {
+			if !s.enqueue(height, NonCanonicalOnly) {
+				skipped++
+			}
+		}
+	}
+	s.mu.Lock()
+	s.status.LastScan = started
+	s.status.ScannedTo = head
+	s.status.Missing = missing
+	s.status.NonCanonical = nonCanonical
+	s.status.Skipped = skipped
+	s.mu.Unlock()
+	prom.SetGapFillScan(missing, nonCanonical, head)
+	if missing+nonCanonical > 0 {
+		log.Infof("gap scan up to block %d found %d missing and %d non-canonical-only heights, %d didn't fit in the queue", head, missing, nonCanonical, skipped)
+	}
+	return nil
+}
+
+func (s *Scheduler) setScanning(scanning bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status.Scanning = scanning
+}
+
+// enqueue queues the height unless it is already queued or in flight
+// it returns false if the queue is full
+func (s *Scheduler) enqueue(height uint64, reason Reason) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.pending[height]; ok {
+		return true
+	}
+	if len(s.pending) >= s.conf.QueueSize {
+		return false
+	}
+	s.pending[height] = reason
+	// pending includes every queued height, so this never blocks
+	s.queue <- height
+	s.updateQueueMetrics()
+	return true
+}
+
+// updateQueueMetrics needs to be called with the lock held
+func (s *Scheduler) updateQueueMetrics() {
+	queued := len(s.queue)
+	prom.SetGapFillQueue(queued, len(s.pending)-queued)
+}
+
+func (s *Scheduler) work() {
+	for {
+		select {
+		case height := <-s.queue:
+			s.mu.Lock()
+			reason := s.pending[height]
+			s.updateQueueMetrics()
+			s.mu.Unlock()
+			s.fill(height, reason)
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// fill writes the state diff at the height, retrying with backoff
+func (s *Scheduler) fill(height uint64, reason Reason) {
+	defer func() {
+		s.mu.Lock()
+		delete(s.pending, height)
+		s.updateQueueMetrics()
+		s.mu.Unlock()
+	}()
+	backoff := s.conf.RetryInterval
+	for attempt := 0; ; attempt++ {
+		if !s.wait() {
+			return
+		}
+		err := s.writeStateDiffAt(height)
+		if err == nil {
+			log.Debugf("gap filler wrote %s block %d", reason, height)
+			s.mu.Lock()
+			s.status.Filled++
+			s.status.LastFilled = height
+			s.mu.Unlock()
+			prom.IncGapFillFilled()
+			return
+		}
+		if attempt >= s.conf.MaxRetries {
+			log.Errorf("gap filler failed to write %s block %d after %d attempts: %v", reason, height, attempt+1, err)
+			s.mu.Lock()
+			s.status.Failed++
+			s.mu.Unlock()
+			prom.IncGapFillFailed()
+			return
+		}
+		log.Warnf("gap filler failed to write %s block %d, retrying in %s: %v", reason, height, backoff, err)
+		s.mu.Lock()
+		s.status.Retries++
+		s.mu.Unlock()
+		prom.IncGapFillRetries()
+		select {
+		case <-time.After(backoff):
+		case <-s.quit:
+			return
+		}
+		if backoff *= 2; backoff > maxRetryInterval {
+			backoff = maxRetryInterval
+		}
+	}
+}
+
+// wait blocks until the rate limit allows another write, it returns false if the scheduler is stopped
+func (s *Scheduler) wait() bool {
+	if s.limiter == nil {
+		select {
+		case <-s.quit:
+			return false
+		default:
+			return true
+		}
+	}
+	select {
+	case <-s.limiter.C:
+		return true
+	case <-s.quit:
+		return false
+	}
+}
+
+// writeStateDiffAt writes the state diff at the height, waiting at most the configured timeout
+func (s *Scheduler) writeStateDiffAt(height uint64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.conf.Timeout)
+	defer cancel()
+	return s.writer.Write(ctx, height, nil)
+}
@@ -0,0 +1,219 @@
+// VulcanizeDB
+// Copyright © 2022 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package gapfill_test
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/statediff"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/ipld-eth-server/pkg/eth"
+	"github.com/vulcanize/ipld-eth-server/pkg/gapfill"
+)
+
+type mockFinder struct {
+	head         uint64
+	missing      []gapfill.Range
+	nonCanonical []uint64
+}
+
+func (f *mockFinder) LastBlockNumber() (uint64, error) {
+	return f.head, nil
+}
+
+func (f *mockFinder) MissingRanges(start, end uint64) ([]gapfill.Range, error) {
+	var ranges []gapfill.Range
+	for _, r := range f.missing {
+		if r.End < start || r.Start > end {
+			continue
+		}
+		if r.Start < start {
+			r.Start = start
+		}
+		if r.End > end {
+			r.End = end
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges, nil
+}
+
+func (f *mockFinder) NonCanonicalOnlyHeights(start, end uint64) ([]uint64, error) {
+	var heights []uint64
+	for _, height := range f.nonCanonical {
+		if height >= start && height <= end {
+			heights = append(heights, height)
+		}
+	}
+	return heights, nil
+}
+
+// mockStateDiffAPI is a fake proxy node recording the heights it is asked to write
+// writes of the heights in failures fail that many times before succeeding
+type mockStateDiffAPI struct {
+	sync.Mutex
+	failures map[uint64]int
+	written  map[uint64]int
+	release  chan struct{}
+}
+
+func (api *mockStateDiffAPI) WriteStateDiffAt(height uint64, params statediff.Params) error {
+	if api.release != nil {
+		<-api.release
+	}
+	api.Lock()
+	defer api.Unlock()
+	api.written[height]++
+	if api.failures[height] > 0 {
+		api.failures[height]--
+		return errors.New("write failed")
+	}
+	return nil
+}
+
+func (api *mockStateDiffAPI) writes() map[uint64]int {
+	api.Lock()
+	defer api.Unlock()
+	writes := make(map[uint64]int, len(api.written))
+	for height, n := range api.written {
+		writes[height] = n
+	}
+	return writes
+}
+
+var _ = Describe("Scheduler", func() {
+	var (
+		api       *mockStateDiffAPI
+		client    *rpc.Client
+		writer    *eth.StateDiffWriter
+		finder    *mockFinder
+		conf      gapfill.Config
+		scheduler *gapfill.Scheduler
+		wg        *sync.WaitGroup
+	)
+	BeforeEach(func() {
+		api = &mockStateDiffAPI{failures: make(map[uint64]int), written: make(map[uint64]int)}
+		srv := rpc.NewServer()
+		Expect(srv.RegisterName("statediff", api)).To(Succeed())
+		client = rpc.DialInProc(srv)
+		var err error
+		writer, err = eth.NewStateDiffWriter(client, eth.StateDiffWriterConfig{Timeout: time.Second})
+		Expect(err).ToNot(HaveOccurred())
+		finder = &mockFinder{
+			head:         20,
+			missing:      []gapfill.Range{{Start: 3, End: 5}, {Start: 12, End: 12}},
+			nonCanonical: []uint64{8},
+		}
+		conf = gapfill.Config{
+			Start:         1,
+			Interval:      time.Hour,
+			ScanWindow:    4,
+			Workers:       2,
+			MaxRetries:    2,
+			RetryInterval: time.Millisecond,
+			Timeout:       time.Second,
+		}
+		wg = new(sync.WaitGroup)
+	})
+	AfterEach(func() {
+		scheduler.Stop()
+		if api.release != nil {
+			close(api.release)
+		}
+		wg.Wait()
+		writer.Stop()
+		client.Close()
+	})
+
+	It("Fills missing and non-canonical-only heights", func() {
+		var err error
+		scheduler, err = gapfill.NewScheduler(finder, writer, conf)
+		Expect(err).ToNot(HaveOccurred())
+		scheduler.Start(wg)
+
+		Eventually(func() uint64 { return scheduler.Status().Filled }).Should(Equal(uint64(5)))
+		Expect(api.writes()).To(Equal(map[uint64]int{3: 1, 4: 1, 5: 1, 8: 1, 12: 1}))
+		status := scheduler.Status()
+		Expect(status.ScannedTo).To(Equal(uint64(20)))
+		Expect(status.Missing).To(Equal(uint64(4)))
+		Expect(status.NonCanonical).To(Equal(uint64(1)))
+		Expect(status.Queued).To(Equal(0))
+		Expect(status.InFlight).To(Equal(0))
+	})
+
+	It("Retries failed writes with backoff before giving up", func() {
+		finder.missing = []gapfill.Range{{Start: 3, End: 4}}
+		finder.nonCanonical = nil
+		api.failures[3] = 1
+		api.failures[4] = 5
+		var err error
+		scheduler, err = gapfill.NewScheduler(finder, writer, conf)
+		Expect(err).ToNot(HaveOccurred())
+		scheduler.Start(wg)
+
+		Eventually(func() uint64 { return scheduler.Status().Failed }).Should(Equal(uint64(1)))
+		Eventually(func() uint64 { return scheduler.Status().Filled }).Should(Equal(uint64(1)))
+		Expect(api.writes()).To(Equal(map[uint64]int{3: 2, 4: 3}))
+		Expect(scheduler.Status().Retries).To(Equal(uint64(3)))
+		Expect(scheduler.Status().LastFilled).To(Equal(uint64(3)))
+	})
+
+	It("Deduplicates heights and bounds the queue", func() {
+		api.release = make(chan struct{})
+		conf.QueueSize = 3
+		conf.Workers = 1
+		var err error
+		scheduler, err = gapfill.NewScheduler(finder, writer, conf)
+		Expect(err).ToNot(HaveOccurred())
+		scheduler.Start(wg)
+
+		Eventually(func() uint64 { return scheduler.Status().Skipped }).Should(Equal(uint64(2)))
+		status := scheduler.Status()
+		Expect(status.Queued + status.InFlight).To(Equal(3))
+		Expect(status.Start).To(Equal(uint64(1)))
+		Expect(status.Lowest).To(Equal(uint64(3)))
+
+		// rescanning while the heights are still pending doesn't queue them again
+		scheduler.Scan()
+		Eventually(func() bool { return scheduler.Status().LastScan.After(status.LastScan) }).Should(BeTrue())
+		status = scheduler.Status()
+		Expect(status.Queued + status.InFlight).To(Equal(3))
+
+		for i := 0; i < 3; i++ {
+			api.release <- struct{}{}
+		}
+		Eventually(func() uint64 { return scheduler.Status().Filled }).Should(Equal(uint64(3)))
+		Expect(api.writes()).To(Equal(map[uint64]int{3: 1, 4: 1, 5: 1}))
+	})
+
+	It("Rejects negative parameters", func() {
+		conf.Workers = -1
+		var err error
+		_, err = gapfill.NewScheduler(finder, writer, conf)
+		Expect(err).To(HaveOccurred())
+		_, err = gapfill.NewScheduler(finder, nil, gapfill.Config{})
+		Expect(err).To(HaveOccurred())
+		scheduler, err = gapfill.NewScheduler(finder, writer, gapfill.Config{})
+		Expect(err).ToNot(HaveOccurred())
+	})
+})
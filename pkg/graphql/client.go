@@ -36,6 +36,136 @@ type GetLogs struct {
 	Responses []LogResponse `json:"getLogs"`
 }
 
+type PageInfoResp struct {
+	HasNextPage bool    `json:"hasNextPage"`
+	EndCursor   *string `json:"endCursor"`
+}
+
+type BlockResp struct {
+	Hash common.Hash `json:"hash"`
+}
+
+type BlocksConnectionResp struct {
+	Edges []struct {
+		Cursor string    `json:"cursor"`
+		Node   BlockResp `json:"node"`
+	} `json:"edges"`
+	PageInfo PageInfoResp `json:"pageInfo"`
+}
+
+type GetBlocksConnection struct {
+	Response BlocksConnectionResp `json:"blocksConnection"`
+}
+
+type TransactionsConnectionResp struct {
+	Edges []struct {
+		Cursor string          `json:"cursor"`
+		Node   TransactionResp `json:"node"`
+	} `json:"edges"`
+	PageInfo PageInfoResp `json:"pageInfo"`
+}
+
+type GetTransactionsConnection struct {
+	Response TransactionsConnectionResp `json:"transactionsConnection"`
+}
+
+type StorageDiffResp struct {
+	LeafKey       common.Hash  `json:"leafKey"`
+	NodeType      string       `json:"nodeType"`
+	Value         *common.Hash `json:"value"`
+	PreviousValue *common.Hash `json:"previousValue"`
+}
+
+type AccountDiffResp struct {
+	LeafKey         common.Hash       `json:"leafKey"`
+	NodeType        string            `json:"nodeType"`
+	Cid             string            `json:"cid"`
+	Balance         *hexutil.Big      `json:"balance"`
+	PreviousBalance *hexutil.Big      `json:"previousBalance"`
+	Nonce           *hexutil.Uint64   `json:"nonce"`
+	PreviousNonce   *hexutil.Uint64   `json:"previousNonce"`
+	Storage         []StorageDiffResp `json:"storage"`
+}
+
+type GetStateDiff struct {
+	Response struct {
+		StateDiff []AccountDiffResp `json:"stateDiff"`
+	} `json:"block"`
+}
+
+type AccountResp struct {
+	Address   common.Address `json:"address"`
+	Balance   hexutil.Big    `json:"balance"`
+	Cid       *string        `json:"cid"`
+	IpldBlock *hexutil.Bytes `json:"ipldBlock"`
+}
+
+type GetAccounts struct {
+	Response struct {
+		Accounts []AccountResp `json:"accounts"`
+	} `json:"block"`
+}
+
+type EstimateGas struct {
+	Response struct {
+		EstimateGas hexutil.Uint64 `json:"estimateGas"`
+	} `json:"block"`
+}
+
+type GasPriceResp struct {
+	GasPrice             hexutil.Big `json:"gasPrice"`
+	MaxPriorityFeePerGas hexutil.Big `json:"maxPriorityFeePerGas"`
+}
+
+type AccessTupleResp struct {
+	Address     common.Address `json:"address"`
+	StorageKeys []common.Hash  `json:"storageKeys"`
+}
+
+type TransactionFeesResp struct {
+	Hash                 common.Hash       `json:"hash"`
+	Type                 *int32            `json:"type"`
+	GasPrice             hexutil.Big       `json:"gasPrice"`
+	MaxFeePerGas         *hexutil.Big      `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas *hexutil.Big      `json:"maxPriorityFeePerGas"`
+	EffectiveGasPrice    *hexutil.Big      `json:"effectiveGasPrice"`
+	AccessList           []AccessTupleResp `json:"accessList"`
+}
+
+type BlockFeesResp struct {
+	BaseFeePerGas *hexutil.Big          `json:"baseFeePerGas"`
+	Transactions  []TransactionFeesResp `json:"transactions"`
+}
+
+type GetBlockFees struct {
+	Response BlockFeesResp `json:"block"`
+}
+
+type OmmerProvenanceResp struct {
+	Hash      common.Hash   `json:"hash"`
+	Cid       string        `json:"cid"`
+	IpldBlock hexutil.Bytes `json:"ipldBlock"`
+}
+
+type TransactionProvenanceResp struct {
+	Hash             common.Hash    `json:"hash"`
+	Cid              *string        `json:"cid"`
+	IpldBlock        *hexutil.Bytes `json:"ipldBlock"`
+	ReceiptCID       *string        `json:"receiptCID"`
+	ReceiptIpldBlock *hexutil.Bytes `json:"receiptIpldBlock"`
+}
+
+type BlockProvenanceResp struct {
+	Cid          string                      `json:"cid"`
+	IpldBlock    hexutil.Bytes               `json:"ipldBlock"`
+	Ommers       []OmmerProvenanceResp       `json:"ommers"`
+	Transactions []TransactionProvenanceResp `json:"transactions"`
+}
+
+type GetBlockProvenance struct {
+	Response BlockProvenanceResp `json:"block"`
+}
+
 type Client struct {
 	client *gqlclient.Client
 }
@@ -117,3 +247,304 @@ func (c *Client) GetStorageAt(ctx context.Context, hash common.Hash, address com
 	}
 	return &storageAt.Response, nil
 }
+
+func (c *Client) GetBlocksConnection(ctx context.Context, from, to uint64, first int32, after *string) (*BlocksConnectionResp, error) {
+	params := fmt.Sprintf(`from: %d, to: %d, first: %d`, from, to, first)
+	if after != nil {
+		params += fmt.Sprintf(`, after: "%s"`, *after)
+	}
+
+	getBlocksQuery := fmt.Sprintf(`query{
+			blocksConnection(%s) {
+				edges {
+					cursor
+					node {
+						hash
+					}
+				}
+				pageInfo {
+					hasNextPage
+					endCursor
+				}
+			}
+		}`, params)
+
+	req := gqlclient.NewRequest(getBlocksQuery)
+	req.Header.Set("Cache-Control", "no-cache")
+
+	var respData map[string]interface{}
+	err := c.client.Run(ctx, req, &respData)
+	if err != nil {
+		return nil, err
+	}
+
+	jsonStr, err := json.Marshal(respData)
+	if err != nil {
+		return nil, err
+	}
+
+	var blocks GetBlocksConnection
+	err = json.Unmarshal(jsonStr, &blocks)
+	if err != nil {
+		return nil, err
+	}
+	return &blocks.Response, nil
+}
+
+func (c *Client) GetTransactionsConnection(ctx context.Context, from, to uint64, first int32, after *string) (*TransactionsConnectionResp, error) {
+	params := fmt.Sprintf(`from: %d, to: %d, first: %d`, from, to, first)
+	if after != nil {
+		params += fmt.Sprintf(`, after: "%s"`, *after)
+	}
+
+	getTransactionsQuery := fmt.Sprintf(`query{
+			transactionsConnection(%s) {
+				edges {
+					cursor
+					node {
+						hash
+					}
+				}
+				pageInfo {
+					hasNextPage
+					endCursor
+				}
+			}
+		}`, params)
+
+	req := gqlclient.NewRequest(getTransactionsQuery)
+	req.Header.Set("Cache-Control", "no-cache")
+
+	var respData map[string]interface{}
+	err := c.client.Run(ctx, req, &respData)
+	if err != nil {
+		return nil, err
+	}
+
+	jsonStr, err := json.Marshal(respData)
+	if err != nil {
+		return nil, err
+	}
+
+	var txs GetTransactionsConnection
+	err = json.Unmarshal(jsonStr, &txs)
+	if err != nil {
+		return nil, err
+	}
+	return &txs.Response, nil
+}
+
+func (c *Client) GetStateDiff(ctx context.Context, number uint64) ([]AccountDiffResp, error) {
+	getStateDiffQuery := fmt.Sprintf(`query{
+			block(number: %d) {
+				stateDiff {
+					leafKey
+					nodeType
+					cid
+					balance
+					previousBalance
+					nonce
+					previousNonce
+					storage {
+						leafKey
+						nodeType
+						value
+						previousValue
+					}
+				}
+			}
+		}`, number)
+
+	req := gqlclient.NewRequest(getStateDiffQuery)
+	req.Header.Set("Cache-Control", "no-cache")
+
+	var respData map[string]interface{}
+	err := c.client.Run(ctx, req, &respData)
+	if err != nil {
+		return nil, err
+	}
+
+	jsonStr, err := json.Marshal(respData)
+	if err != nil {
+		return nil, err
+	}
+
+	var diff GetStateDiff
+	err = json.Unmarshal(jsonStr, &diff)
+	if err != nil {
+		return nil, err
+	}
+	return diff.Response.StateDiff, nil
+}
+
+func (c *Client) GetGasPrice(ctx context.Context) (*GasPriceResp, error) {
+	req := gqlclient.NewRequest(`query{ gasPrice maxPriorityFeePerGas }`)
+	req.Header.Set("Cache-Control", "no-cache")
+
+	var respData map[string]interface{}
+	err := c.client.Run(ctx, req, &respData)
+	if err != nil {
+		return nil, err
+	}
+
+	jsonStr, err := json.Marshal(respData)
+	if err != nil {
+		return nil, err
+	}
+
+	var price GasPriceResp
+	err = json.Unmarshal(jsonStr, &price)
+	if err != nil {
+		return nil, err
+	}
+	return &price, nil
+}
+
+func (c *Client) GetAccounts(ctx context.Context, number uint64, addresses []common.Address) ([]AccountResp, error) {
+	getAccountsQuery := fmt.Sprintf(`query($addresses: [Address!]!){
+			block(number: %d) {
+				accounts(addresses: $addresses) {
+					address
+					balance
+					cid
+					ipldBlock
+				}
+			}
+		}`, number)
+
+	req := gqlclient.NewRequest(getAccountsQuery)
+	req.Var("addresses", addresses)
+	req.Header.Set("Cache-Control", "no-cache")
+
+	var respData map[string]interface{}
+	err := c.client.Run(ctx, req, &respData)
+	if err != nil {
+		return nil, err
+	}
+
+	jsonStr, err := json.Marshal(respData)
+	if err != nil {
+		return nil, err
+	}
+
+	var accounts GetAccounts
+	err = json.Unmarshal(jsonStr, &accounts)
+	if err != nil {
+		return nil, err
+	}
+	return accounts.Response.Accounts, nil
+}
+
+func (c *Client) EstimateGas(ctx context.Context, number uint64, data map[string]interface{}, overrides []map[string]interface{}) (hexutil.Uint64, error) {
+	estimateGasQuery := fmt.Sprintf(`query($data: CallData!, $overrides: [AccountOverride!]){
+			block(number: %d) {
+				estimateGas(data: $data, overrides: $overrides)
+			}
+		}`, number)
+
+	req := gqlclient.NewRequest(estimateGasQuery)
+	req.Var("data", data)
+	req.Var("overrides", overrides)
+	req.Header.Set("Cache-Control", "no-cache")
+
+	var respData map[string]interface{}
+	err := c.client.Run(ctx, req, &respData)
+	if err != nil {
+		return 0, err
+	}
+
+	jsonStr, err := json.Marshal(respData)
+	if err != nil {
+		return 0, err
+	}
+
+	var estimate EstimateGas
+	err = json.Unmarshal(jsonStr, &estimate)
+	if err != nil {
+		return 0, err
+	}
+	return estimate.Response.EstimateGas, nil
+}
+
+func (c *Client) GetBlockFees(ctx context.Context, hash common.Hash) (*BlockFeesResp, error) {
+	getBlockFeesQuery := fmt.Sprintf(`query{
+			block(hash: "%s") {
+				baseFeePerGas
+				transactions {
+					hash
+					type
+					gasPrice
+					maxFeePerGas
+					maxPriorityFeePerGas
+					effectiveGasPrice
+					accessList {
+						address
+						storageKeys
+					}
+				}
+			}
+		}`, hash.Hex())
+
+	req := gqlclient.NewRequest(getBlockFeesQuery)
+	req.Header.Set("Cache-Control", "no-cache")
+
+	var respData map[string]interface{}
+	err := c.client.Run(ctx, req, &respData)
+	if err != nil {
+		return nil, err
+	}
+
+	jsonStr, err := json.Marshal(respData)
+	if err != nil {
+		return nil, err
+	}
+
+	var fees GetBlockFees
+	err = json.Unmarshal(jsonStr, &fees)
+	if err != nil {
+		return nil, err
+	}
+	return &fees.Response, nil
+}
+
+func (c *Client) GetBlockProvenance(ctx context.Context, hash common.Hash) (*BlockProvenanceResp, error) {
+	getBlockProvenanceQuery := fmt.Sprintf(`query{
+			block(hash: "%s") {
+				cid
+				ipldBlock
+				ommers {
+					hash
+					cid
+					ipldBlock
+				}
+				transactions {
+					hash
+					cid
+					ipldBlock
+					receiptCID
+					receiptIpldBlock
+				}
+			}
+		}`, hash.Hex())
+
+	req := gqlclient.NewRequest(getBlockProvenanceQuery)
+	req.Header.Set("Cache-Control", "no-cache")
+
+	var respData map[string]interface{}
+	err := c.client.Run(ctx, req, &respData)
+	if err != nil {
+		return nil, err
+	}
+
+	jsonStr, err := json.Marshal(respData)
+	if err != nil {
+		return nil, err
+	}
+
+	var provenance GetBlockProvenance
+	err = json.Unmarshal(jsonStr, &provenance)
+	if err != nil {
+		return nil, err
+	}
+	return &provenance.Response, nil
+}
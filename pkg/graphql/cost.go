@@ -0,0 +1,565 @@
+// VulcanizeDB
+// Copyright © 2022 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package graphql
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/graph-gophers/graphql-go"
+	qerrors "github.com/graph-gophers/graphql-go/errors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+
+	"github.com/vulcanize/ipld-eth-server/pkg/eth"
+)
+
+const (
+	// DefaultMaxDepth is the default limit of the nesting of the fields of an operation
+	DefaultMaxDepth = 10
+	// DefaultMaxCost is the default limit of the static cost of an operation
+	DefaultMaxCost = 50000
+	// DefaultListSize is the length assumed for the lists which have neither a page size nor a block range
+	DefaultListSize = 100
+	// DefaultMaxBlockRange is the default limit of the number of blocks of the ranges of blocks and logs
+	DefaultMaxBlockRange = 10000
+	// DefaultMaxSelections is the default limit of the number of selections of an operation, with its fragments expanded
+	DefaultMaxSelections = 10000
+)
+
+// DefaultFieldWeights are the costs of the fields which are more expensive to resolve than a single lookup
+// Leaf fields cost nothing and object fields cost 1, unless weighted
+var DefaultFieldWeights = map[string]int{
+	"Account.balance":            5,
+	"Account.transactionCount":   5,
+	"Account.code":               5,
+	"Account.storage":            10,
+	"Account.cid":                5,
+	"Account.ipldBlock":          5,
+	"Block.call":                 50,
+	"Block.estimateGas":          1000,
+	"Block.stateDiff":            20,
+	"Query.getStorageAt":         10,
+	"Query.gasPrice":             20,
+	"Query.maxPriorityFeePerGas": 20,
+}
+
+// listSizes are the known lengths of lists, which override the list size
+var listSizes = map[string]int{
+	"Block.ommers":                2,
+	"BlockConnection.edges":       1, // the page size is accounted for by the connection field
+	"TransactionConnection.edges": 1,
+	"LogConnection.edges":         1,
+}
+
+// listArgs are the list arguments whose length is the length of the list of the field
+var listArgs = map[string]string{
+	"Block.accounts": "addresses",
+}
+
+// pageSizes are the default page sizes of the connection fields
+var pageSizes = map[string]int{
+	"Query.blocksConnection":       maxBlocksPageSize,
+	"Query.transactionsConnection": maxTransactionsPageSize,
+	"Query.logsConnection":         maxLogsPageSize,
+}
+
+// Limits bounds the static cost and depth of the operations accepted by the handler
+// The cost of a field is its weight plus the cost of its selections, times the number of items it resolves to: the page
+// size of connections, the length of the block range of blocks and logs, and the list size for the other lists
+type Limits struct {
+	MaxDepth      int            // nesting of the fields, 0 for DefaultMaxDepth and negative for no limit
+	MaxCost       int            // static cost, 0 for DefaultMaxCost and negative for no limit
+	MaxBlockRange int            // blocks of the ranges of blocks and logs, 0 for DefaultMaxBlockRange and negative for no limit
+	MaxSelections int            // selections with the fragments expanded, 0 for DefaultMaxSelections and negative for no limit
+	ListSize      int            // length assumed for lists, 0 for DefaultListSize
+	Weights       map[string]int // weights of the fields keyed by Type.field, which override DefaultFieldWeights
+}
+
+// ParseFieldWeights parses Type.field=weight entries
+func ParseFieldWeights(entries []string) (map[string]int, error) {
+	weights := make(map[string]int, len(entries))
+	for _, entry := range entries {
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 || !strings.Contains(parts[0], ".") {
+			return nil, fmt.Errorf("invalid field weight %q, expected Type.field=weight", entry)
+		}
+		weight, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+		if err != nil || weight < 0 {
+			return nil, fmt.Errorf("invalid weight for %s: %s", parts[0], parts[1])
+		}
+		weights[strings.TrimSpace(parts[0])] = weight
+	}
+	return weights, nil
+}
+
+// fieldInfo is the static information of a field of the schema
+type fieldInfo struct {
+	typeName string // named type of the field, with the lists and non nulls unwrapped
+	list     bool
+	args     map[string]bool
+}
+
+// costAnalyzer computes the static cost and depth of operations against the schema
+// graph-gophers keeps its query parser and AST internal, so documents are parsed with graphql-go/graphql. Where the two
+// disagree the check fails closed: documents this parser rejects are never executed, and the executor validates the rest
+type costAnalyzer struct {
+	fields        map[string]map[string]fieldInfo // by type and field names
+	maxDepth      int
+	maxCost       int64
+	maxBlockRange int64
+	maxSelections int64
+	listSize      int64
+	weights       map[string]int
+	head          func() (int64, error) // number of the latest indexed block, which ends the open block ranges
+}
+
+func newCostAnalyzer(schema string, limits Limits, head func() (int64, error)) (*costAnalyzer, error) {
+	doc, err := parser.Parse(parser.ParseParams{Source: schema, Options: parser.ParseOptions{NoSource: true}})
+	if err != nil {
+		return nil, err
+	}
+	a := &costAnalyzer{
+		fields:        make(map[string]map[string]fieldInfo),
+		maxDepth:      limits.MaxDepth,
+		maxCost:       int64(limits.MaxCost),
+		maxBlockRange: int64(limits.MaxBlockRange),
+		maxSelections: int64(limits.MaxSelections),
+		listSize:      int64(limits.ListSize),
+		weights:       make(map[string]int, len(DefaultFieldWeights)+len(limits.Weights)),
+		head:          head,
+	}
+	if a.maxDepth == 0 {
+		a.maxDepth = DefaultMaxDepth
+	}
+	if a.maxCost == 0 {
+		a.maxCost = DefaultMaxCost
+	}
+	if a.maxBlockRange == 0 {
+		a.maxBlockRange = DefaultMaxBlockRange
+	}
+	if a.maxSelections == 0 {
+		a.maxSelections = DefaultMaxSelections
+	}
+	if a.listSize <= 0 {
+		a.listSize = DefaultListSize
+	}
+	for name, weight := range DefaultFieldWeights {
+		a.weights[name] = weight
+	}
+	for name, weight := range limits.Weights {
+		a.weights[name] = weight
+	}
+
+	for _, def := range doc.Definitions {
+		var name string
+		var defs []*ast.FieldDefinition
+		switch def := def.(type) {
+		case *ast.ObjectDefinition:
+			name, defs = def.Name.Value, def.Fields
+		case *ast.InterfaceDefinition:
+			name, defs = def.Name.Value, def.Fields
+		default:
+			continue
+		}
+		fields := make(map[string]fieldInfo, len(defs))
+		for _, field := range defs {
+			info := fieldInfo{args: make(map[string]bool, len(field.Arguments))}
+			for _, arg := range field.Arguments {
+				info.args[arg.Name.Value] = true
+			}
+			info.typeName, info.list = unwrapType(field.Type)
+			fields[field.Name.Value] = info
+		}
+		a.fields[name] = fields
+	}
+	return a, nil
+}
+
+func unwrapType(t ast.Type) (string, bool) {
+	switch t := t.(type) {
+	case *ast.NonNull:
+		return unwrapType(t.Type)
+	case *ast.List:
+		name, _ := unwrapType(t.Type)
+		return name, true
+	case *ast.Named:
+		return t.Name.Value, false
+	}
+	return "", false
+}
+
+// check returns the type of the operation to execute in the document, query, mutation or subscription, or the error
+// rejecting it if the document can't be parsed, has no such operation, or if its depth, cost, block ranges or expanded
+// selections exceed the limits
+func (a *costAnalyzer) check(query, operationName string, variables map[string]interface{}) (string, *qerrors.QueryError) {
+	doc, err := parser.Parse(parser.ParseParams{Source: query, Options: parser.ParseOptions{NoSource: true}})
+	if err != nil {
+		return "", &qerrors.QueryError{Message: fmt.Sprintf("unable to parse the query: %v", err)}
+	}
+	var op *ast.OperationDefinition
+	fragments := make(map[string]*ast.FragmentDefinition)
+	for _, def := range doc.Definitions {
+		switch def := def.(type) {
+		case *ast.OperationDefinition:
+			if op == nil && (operationName == "" || (def.Name != nil && def.Name.Value == operationName)) {
+				op = def
+			}
+		case *ast.FragmentDefinition:
+			fragments[def.Name.Value] = def
+		}
+	}
+	if op == nil {
+		if operationName != "" {
+			return "", &qerrors.QueryError{Message: fmt.Sprintf("no operation with name %q", operationName)}
+		}
+		return "", &qerrors.QueryError{Message: "no operation in the query"}
+	}
+	w := &costWalker{
+		analyzer:  a,
+		fragments: fragments,
+		variables: variables,
+		defaults:  make(map[string]ast.Value),
+		visiting:  make(map[string]bool),
+		walked:    make(map[string]fragmentCost),
+	}
+	for _, def := range op.VariableDefinitions {
+		if def.DefaultValue != nil {
+			w.defaults[def.Variable.Name.Value] = def.DefaultValue
+		}
+	}
+	root := "Query"
+	switch op.Operation {
+	case ast.OperationTypeMutation:
+		root = "Mutation"
+	case ast.OperationTypeSubscription:
+		root = "Subscription"
+	}
+	cost, depth := w.selectionSet(root, op.SelectionSet, 1)
+
+	if w.err != nil {
+		return "", w.err
+	}
+	if a.maxSelections > 0 && w.selections > a.maxSelections {
+		return "", &qerrors.QueryError{
+			Message:    fmt.Sprintf("query expands to %d selections, exceeding the maximum of %d", w.selections, a.maxSelections),
+			Extensions: map[string]interface{}{"selections": w.selections, "maxSelections": a.maxSelections},
+		}
+	}
+	if a.maxDepth > 0 && depth > a.maxDepth {
+		return "", &qerrors.QueryError{
+			Message:    fmt.Sprintf("query depth %d exceeds the maximum depth of %d", depth, a.maxDepth),
+			Extensions: map[string]interface{}{"depth": depth, "maxDepth": a.maxDepth},
+		}
+	}
+	if a.maxCost > 0 && cost > a.maxCost {
+		return "", &qerrors.QueryError{
+			Message:    fmt.Sprintf("query cost %d exceeds the maximum cost of %d", cost, a.maxCost),
+			Extensions: map[string]interface{}{"cost": cost, "maxCost": a.maxCost},
+		}
+	}
+	return op.Operation, nil
+}
+
+// costWalker walks the selections of an operation
+type costWalker struct {
+	analyzer   *costAnalyzer
+	fragments  map[string]*ast.FragmentDefinition
+	variables  map[string]interface{}
+	defaults   map[string]ast.Value
+	visiting   map[string]bool         // fragments being walked, cycles are left for the schema to reject
+	walked     map[string]fragmentCost // fragments already walked, so that each is walked once however often it is spread
+	selections int64                   // selections walked so far, counting those of each fragment spread
+	head       *int64                  // number of the latest indexed block, once it has been looked up
+	err        *qerrors.QueryError
+}
+
+// fragmentCost is the cost of the selections of a fragment, their depth below the spread, and their number once expanded
+// The selections of a fragment are those of its type condition, so they are the same wherever it is spread
+type fragmentCost struct {
+	cost       int64
+	depth      int
+	selections int64
+}
+
+// selectionSet returns the cost and the depth of the selections of an object of the type, at the depth
+func (w *costWalker) selectionSet(typeName string, set *ast.SelectionSet, depth int) (int64, int) {
+	if set == nil {
+		return 0, depth - 1
+	}
+	var cost int64
+	maxDepth := depth - 1
+	add := func(c int64, d int) {
+		cost = saturatingAdd(cost, c)
+		if d > maxDepth {
+			maxDepth = d
+		}
+	}
+	for _, sel := range set.Selections {
+		w.selections = saturatingAdd(w.selections, 1)
+		switch sel := sel.(type) {
+		case *ast.Field:
+			add(w.field(typeName, sel, depth))
+		case *ast.InlineFragment:
+			fragmentType := typeName
+			if sel.TypeCondition != nil {
+				fragmentType = sel.TypeCondition.Name.Value
+			}
+			add(w.selectionSet(fragmentType, sel.SelectionSet, depth))
+		case *ast.FragmentSpread:
+			name := sel.Name.Value
+			fragment, ok := w.fragments[name]
+			if !ok || w.visiting[name] {
+				continue
+			}
+			if walked, ok := w.walked[name]; ok {
+				w.selections = saturatingAdd(w.selections, walked.selections)
+				add(walked.cost, depth-1+walked.depth)
+				continue
+			}
+			selections := w.selections
+			w.visiting[name] = true
+			c, d := w.selectionSet(fragment.TypeCondition.Name.Value, fragment.SelectionSet, depth)
+			delete(w.visiting, name)
+			w.walked[name] = fragmentCost{cost: c, depth: d - (depth - 1), selections: w.selections - selections}
+			add(c, d)
+		}
+	}
+	return cost, maxDepth
+}
+
+func (w *costWalker) field(typeName string, field *ast.Field, depth int) (int64, int) {
+	name := field.Name.Value
+	if strings.HasPrefix(name, "__") {
+		// introspection is served from memory, but its selections are still expanded by the executor
+		w.selectionSet("", field.SelectionSet, depth+1)
+		return 0, 0
+	}
+	info, ok := w.analyzer.fields[typeName][name]
+	if !ok {
+		// unknown fields are left for the schema to reject
+		w.selectionSet("", field.SelectionSet, depth+1)
+		return 0, depth
+	}
+	key := typeName + "." + name
+	weight, weighted := w.analyzer.weights[key]
+	if !weighted {
+		if _, object := w.analyzer.fields[info.typeName]; object {
+			weight = 1
+		}
+	}
+	cost, childDepth := w.selectionSet(info.typeName, field.SelectionSet, depth+1)
+	if childDepth < depth {
+		childDepth = depth
+	}
+	return saturatingMul(w.multiplier(key, info, field), saturatingAdd(int64(weight), cost)), childDepth
+}
+
+// multiplier returns the number of items the field is assumed to resolve to
+func (w *costWalker) multiplier(key string, info fieldInfo, field *ast.Field) int64 {
+	if info.args["first"] {
+		if first, ok := w.intArg(field, "first"); ok && first > 0 {
+			return first
+		}
+		if size, ok := pageSizes[key]; ok {
+			return int64(size)
+		}
+		return w.analyzer.listSize
+	}
+	if !info.list {
+		return 1
+	}
+	if size, ok := listSizes[key]; ok {
+		return int64(size)
+	}
+	if name, ok := listArgs[key]; ok {
+		if size, ok := w.listArgLen(field, name); ok {
+			return int64(size)
+		}
+	}
+	if from, to, ok := w.blockRange(key, field); ok {
+		if to < from {
+			return 0
+		}
+		size := saturatingAdd(to-from, 1)
+		if max := w.analyzer.maxBlockRange; max > 0 && size > max && w.err == nil {
+			w.err = &qerrors.QueryError{
+				Message:    fmt.Sprintf("block range of %d blocks exceeds the maximum of %d", size, max),
+				Extensions: map[string]interface{}{"blockRange": size, "maxBlockRange": max},
+			}
+		}
+		return size
+	}
+	return w.analyzer.listSize
+}
+
+// blockRange returns the range of blocks the field resolves to, if it is one of the block ranges
+// The bounds which are missing, null or negative are the latest block, like their resolvers
+func (w *costWalker) blockRange(key string, field *ast.Field) (int64, int64, bool) {
+	var from, to interface{}
+	switch key {
+	case "Query.blocks":
+		from, _ = w.arg(field, "from")
+		to, _ = w.arg(field, "to")
+	case "Query.logs":
+		filter, _ := w.arg(field, "filter")
+		criteria, _ := filter.(map[string]interface{})
+		from, to = criteria["fromBlock"], criteria["toBlock"]
+	default:
+		return 0, 0, false
+	}
+	return w.blockNumber(from), w.blockNumber(to), true
+}
+
+func (w *costWalker) blockNumber(value interface{}) int64 {
+	if number, ok := numberValue(value); ok && number >= 0 {
+		return number
+	}
+	if w.head == nil {
+		head, err := w.analyzer.head()
+		if err != nil {
+			head = math.MaxInt64
+			if w.err == nil {
+				w.err = &qerrors.QueryError{Message: fmt.Sprintf("unable to price the block range: %v", err)}
+			}
+		}
+		w.head = &head
+	}
+	return *w.head
+}
+
+// intArg returns the value of the integer argument of the field
+func (w *costWalker) intArg(field *ast.Field, name string) (int64, bool) {
+	value, _ := w.arg(field, name)
+	return numberValue(value)
+}
+
+// listArgLen returns the length of the list argument of the field
+func (w *costWalker) listArgLen(field *ast.Field, name string) (int, bool) {
+	value, _ := w.arg(field, name)
+	list, ok := value.([]interface{})
+	return len(list), ok
+}
+
+// arg returns the value of the argument of the field, given as a literal or as a variable, as it would be decoded from
+// the variables: nil when it is null, and numbers as strings or float64s
+func (w *costWalker) arg(field *ast.Field, name string) (interface{}, bool) {
+	for _, arg := range field.Arguments {
+		if arg.Name.Value == name {
+			return w.value(arg.Value)
+		}
+	}
+	return nil, false
+}
+
+func (w *costWalker) value(value ast.Value) (interface{}, bool) {
+	switch v := value.(type) {
+	case *ast.Variable:
+		if raw, ok := w.variables[v.Name.Value]; ok {
+			return raw, true
+		}
+		if def, ok := w.defaults[v.Name.Value]; ok {
+			return w.value(def)
+		}
+	case *ast.IntValue:
+		return v.Value, true
+	case *ast.StringValue:
+		return v.Value, true
+	case *ast.ListValue:
+		list := make([]interface{}, 0, len(v.Values))
+		for _, item := range v.Values {
+			raw, _ := w.value(item)
+			list = append(list, raw)
+		}
+		return list, true
+	case *ast.ObjectValue:
+		object := make(map[string]interface{}, len(v.Fields))
+		for _, field := range v.Fields {
+			if raw, ok := w.value(field.Value); ok {
+				object[field.Name.Value] = raw
+			}
+		}
+		return object, true
+	}
+	return nil, false
+}
+
+// numberValue converts the value of a variable or literal, Longs may be given as numbers or as strings
+func numberValue(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return int64(v), true
+	case json.Number:
+		n, err := v.Int64()
+		return n, err == nil
+	case string:
+		n, err := strconv.ParseInt(v, 0, 64)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+func saturatingAdd(a, b int64) int64 {
+	if a > math.MaxInt64-b {
+		return math.MaxInt64
+	}
+	return a + b
+}
+
+func saturatingMul(a, b int64) int64 {
+	if a != 0 && b > math.MaxInt64/a {
+		return math.MaxInt64
+	}
+	return a * b
+}
+
+// queryHandler answers the GraphQL queries posted over http, once their cost has been checked, with a new set of
+// loaders for each of them
+type queryHandler struct {
+	backend  *eth.Backend
+	schema   *graphql.Schema
+	analyzer *costAnalyzer
+}
+
+func (h *queryHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var params struct {
+		Query         string                 `json:"query"`
+		OperationName string                 `json:"operationName"`
+		Variables     map[string]interface{} `json:"variables"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var response *graphql.Response
+	if _, err := h.analyzer.check(params.Query, params.OperationName, params.Variables); err != nil {
+		response = &graphql.Response{Errors: []*qerrors.QueryError{err}}
+	} else {
+		response = h.schema.Exec(withLoaders(r.Context(), h.backend), params.Query, params.OperationName, params.Variables)
+	}
+	responseJSON, err := json.Marshal(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(responseJSON)
+}
@@ -0,0 +1,178 @@
+// VulcanizeDB
+// Copyright © 2022 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package graphql_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/ipld-eth-server/pkg/graphql"
+)
+
+var _ = Describe("Cost analysis", func() {
+	const fanOut = `{ blocks(from: 0, to: 999) { transactions { logs { account { storage(slot: "0x0000000000000000000000000000000000000000000000000000000000000000") } } } } }`
+
+	It("rejects the queries fanning out into too many lookups", func() {
+		Expect(graphql.CheckCost(graphql.Limits{}, fanOut, nil)).
+			To(MatchError("query cost 120101000 exceeds the maximum cost of 50000"))
+		Expect(graphql.CheckCost(graphql.Limits{MaxCost: -1}, fanOut, nil)).To(Succeed())
+	})
+
+	It("multiplies the connections by their page size", func() {
+		const page = `query($n: Int) { blocksConnection(from: 0, first: $n) { edges { node { number } } } }`
+		limits := graphql.Limits{MaxCost: 250}
+		Expect(graphql.CheckCost(limits, page, map[string]interface{}{"n": float64(50)})).To(Succeed())
+		Expect(graphql.CheckCost(limits, page, map[string]interface{}{"n": float64(100)})).
+			To(MatchError("query cost 300 exceeds the maximum cost of 250"))
+		// the page size defaults to the maximum
+		Expect(graphql.CheckCost(limits, page, nil)).
+			To(MatchError("query cost 300 exceeds the maximum cost of 250"))
+	})
+
+	It("applies the field weights", func() {
+		const query = `{ transaction(hash: "0x0000000000000000000000000000000000000000000000000000000000000000") { hash } }`
+		Expect(graphql.CheckCost(graphql.Limits{MaxCost: 10}, query, nil)).To(Succeed())
+		limits := graphql.Limits{MaxCost: 10, Weights: map[string]int{"Query.transaction": 20}}
+		Expect(graphql.CheckCost(limits, query, nil)).
+			To(MatchError("query cost 20 exceeds the maximum cost of 10"))
+	})
+
+	It("multiplies the accounts by the number of addresses", func() {
+		const address = `"0x0000000000000000000000000000000000000001"`
+		limits := graphql.Limits{MaxCost: 12}
+		Expect(graphql.CheckCost(limits, `{ block { accounts(addresses: [`+address+`]) { balance } } }`, nil)).To(Succeed())
+		Expect(graphql.CheckCost(limits, `{ block { accounts(addresses: [`+address+`, `+address+`]) { balance } } }`, nil)).
+			To(MatchError("query cost 13 exceeds the maximum cost of 12"))
+		const query = `query($a: [Address!]!) { block { accounts(addresses: $a) { balance } } }`
+		addresses := []interface{}{address, address, address}
+		Expect(graphql.CheckCost(limits, query, map[string]interface{}{"a": addresses})).
+			To(MatchError("query cost 19 exceeds the maximum cost of 12"))
+	})
+
+	It("limits the depth of the queries", func() {
+		limits := graphql.Limits{MaxDepth: 3}
+		Expect(graphql.CheckCost(limits, `{ block { parent { parent { number } } } }`, nil)).
+			To(MatchError("query depth 4 exceeds the maximum depth of 3"))
+		Expect(graphql.CheckCost(limits, `{ block { ...F } } fragment F on Block { parent { number } }`, nil)).To(Succeed())
+		Expect(graphql.CheckCost(graphql.Limits{MaxDepth: 1}, `{ __schema { types { fields { name } } } }`, nil)).To(Succeed())
+	})
+
+	It("walks each fragment once wherever it is spread", func() {
+		const query = `{ block { ...F parent { ...F } } } fragment F on Block { parent { number } }`
+		Expect(graphql.CheckCost(graphql.Limits{MaxDepth: 3}, query, nil)).
+			To(MatchError("query depth 4 exceeds the maximum depth of 3"))
+		Expect(graphql.CheckCost(graphql.Limits{MaxCost: 3}, query, nil)).
+			To(MatchError("query cost 4 exceeds the maximum cost of 3"))
+		Expect(graphql.CheckCost(graphql.Limits{MaxSelections: 8}, query, nil)).To(Succeed())
+		Expect(graphql.CheckCost(graphql.Limits{MaxSelections: 7}, query, nil)).
+			To(MatchError("query expands to 8 selections, exceeding the maximum of 7"))
+	})
+
+	It("rejects the nested fragments expanding into too many selections", func() {
+		// each fragment spreads the next one twice, doubling the selections the executor expands at each level
+		var query strings.Builder
+		query.WriteString("{ ...F0 }\n")
+		for i := 0; i < 60; i++ {
+			fmt.Fprintf(&query, "fragment F%d on Query { ...F%d ...F%d }\n", i, i+1, i+1)
+		}
+		query.WriteString("fragment F60 on Query { block { number } }\n")
+		start := time.Now()
+		err := graphql.CheckCost(graphql.Limits{}, query.String(), nil)
+		Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+		Expect(err).To(MatchError(ContainSubstring("selections, exceeding the maximum of 10000")))
+		Expect(graphql.CheckCost(graphql.Limits{}, `{ __schema { ...S } } fragment S on __Schema { types { ...T } } fragment T on __Type { name ofType { ...U } } fragment U on __Type { name }`, nil)).To(Succeed())
+		Expect(graphql.CheckCost(graphql.Limits{MaxSelections: 5}, `{ __schema { ...S } } fragment S on __Schema { types { ...T } } fragment T on __Type { name ofType { ...U } } fragment U on __Type { name }`, nil)).
+			To(MatchError("query expands to 8 selections, exceeding the maximum of 5"))
+	})
+
+	It("prices the open block ranges up to the head", func() {
+		limits := graphql.Limits{MaxCost: -1, MaxBlockRange: 1000}
+		Expect(graphql.CheckCostAt(limits, 999, `{ blocks(from: 0) { number } }`, nil)).To(Succeed())
+		Expect(graphql.CheckCostAt(limits, 1000, `{ blocks(from: 0) { number } }`, nil)).
+			To(MatchError("block range of 1001 blocks exceeds the maximum of 1000"))
+		const query = `query($t: Long) { blocks(from: 0, to: $t) { number } }`
+		Expect(graphql.CheckCostAt(limits, 20000000, query, map[string]interface{}{"t": nil})).
+			To(MatchError("block range of 20000001 blocks exceeds the maximum of 1000"))
+		Expect(graphql.CheckCostAt(limits, 20000000, query, map[string]interface{}{"t": "0x3e7"})).To(Succeed())
+		Expect(graphql.CheckCostAt(limits, 20000000, query, nil)).
+			To(MatchError("block range of 20000001 blocks exceeds the maximum of 1000"))
+		Expect(graphql.CheckCostAt(graphql.Limits{MaxCost: 500}, 999, `{ blocks(from: 0) { hash parent { number } } }`, nil)).
+			To(MatchError("query cost 2000 exceeds the maximum cost of 500"))
+	})
+
+	It("limits the block range of logs", func() {
+		limits := graphql.Limits{MaxCost: -1}
+		Expect(graphql.CheckCostAt(limits, 20000000, `{ logs(filter: {fromBlock: 0, toBlock: 20000000}) { index } }`, nil)).
+			To(MatchError("block range of 20000001 blocks exceeds the maximum of 10000"))
+		Expect(graphql.CheckCostAt(limits, 20000000, `{ logs(filter: {fromBlock: 0}) { index } }`, nil)).
+			To(MatchError("block range of 20000001 blocks exceeds the maximum of 10000"))
+		Expect(graphql.CheckCostAt(limits, 20000000, `{ logs(filter: {}) { index } }`, nil)).To(Succeed())
+		const query = `query($f: FilterCriteria!) { logs(filter: $f) { index } }`
+		Expect(graphql.CheckCostAt(limits, 20000000, query, map[string]interface{}{
+			"f": map[string]interface{}{"fromBlock": float64(19999000)},
+		})).To(Succeed())
+		Expect(graphql.CheckCostAt(limits, 20000000, query, map[string]interface{}{
+			"f": map[string]interface{}{"fromBlock": float64(0), "toBlock": "20000000"},
+		})).To(MatchError("block range of 20000001 blocks exceeds the maximum of 10000"))
+		Expect(graphql.CheckCostAt(graphql.Limits{MaxCost: -1, MaxBlockRange: -1}, 20000000,
+			`{ logs(filter: {fromBlock: 0}) { index } }`, nil)).To(Succeed())
+	})
+
+	It("rejects the open block ranges when the head can't be looked up", func() {
+		Expect(graphql.CheckCostWithoutHead(graphql.Limits{}, `{ blocks(from: 0) { number } }`)).
+			To(MatchError("unable to price the block range: no head"))
+		Expect(graphql.CheckCostWithoutHead(graphql.Limits{}, `{ blocks(from: 0, to: 10) { number } }`)).To(Succeed())
+	})
+
+	It("rejects the documents it can't parse", func() {
+		err := graphql.CheckCost(graphql.Limits{}, `{ blocks(from: 0 { number } }`, nil)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(HavePrefix("unable to parse the query"))
+	})
+
+	It("parses field weights", func() {
+		weights, err := graphql.ParseFieldWeights([]string{"Account.storage=20", " Block.call = 5"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(weights).To(Equal(map[string]int{"Account.storage": 20, "Block.call": 5}))
+		_, err = graphql.ParseFieldWeights([]string{"storage=20"})
+		Expect(err).To(HaveOccurred())
+		_, err = graphql.ParseFieldWeights([]string{"Account.storage=-1"})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("answers rejected queries with an error explaining their cost", func() {
+		handler, err := graphql.NewMockHandler(new(graphql.MockHeads), time.Second, graphql.Limits{})
+		Expect(err).ToNot(HaveOccurred())
+		body, err := json.Marshal(map[string]string{"query": fanOut})
+		Expect(err).ToNot(HaveOccurred())
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewReader(body)))
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Body.String()).To(MatchJSON(`{"errors":[{
+			"message": "query cost 120101000 exceeds the maximum cost of 50000",
+			"extensions": {"cost": 120101000, "maxCost": 50000}
+		}]}`))
+	})
+})
@@ -0,0 +1,208 @@
+// VulcanizeDB
+// Copyright © 2022 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package graphql
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/vulcanize/ipld-eth-server/pkg/eth"
+	"github.com/vulcanize/ipld-eth-server/pkg/gapfill"
+)
+
+// MockHeads is a head source serving the headers pushed to it
+type MockHeads struct {
+	mu        sync.Mutex
+	headers   []*types.Header
+	blockLogs map[common.Hash][]*types.Log
+}
+
+// Push indexes the header at the next height, with the logs of its block
+func (m *MockHeads) Push(header *types.Header, logs ...*types.Log) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.blockLogs == nil {
+		m.blockLogs = make(map[common.Hash][]*types.Log)
+	}
+	m.headers = append(m.headers, header)
+	m.blockLogs[header.Hash()] = logs
+}
+
+func (m *MockHeads) lastBlockNumber() (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return int64(len(m.headers)) - 1, nil
+}
+
+func (m *MockHeads) headerByNumber(_ context.Context, number int64) (*types.Header, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.headers[number], nil
+}
+
+func (m *MockHeads) logs(_ context.Context, hash common.Hash, addresses []common.Address, _ [][]common.Hash) ([]*types.Log, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var matches []*types.Log
+	for _, log := range m.blockLogs[hash] {
+		for _, address := range addresses {
+			if log.Address == address {
+				matches = append(matches, log)
+				break
+			}
+		}
+	}
+	return matches, nil
+}
+
+// NewMockChainHandler returns the handler for a chain with the config, forwarding its mutations to the client and
+// reporting the progress of the gap filler
+func NewMockChainHandler(chainConfig *params.ChainConfig, client *rpc.Client, gapFiller *gapfill.Scheduler) (http.Handler, error) {
+	backend := &eth.Backend{Config: &eth.Config{ChainConfig: chainConfig}}
+	return newHandler(backend, client, gapFiller, newHeadFeed(new(MockHeads), time.Second), Limits{})
+}
+
+// NewMockHandler returns the handler with its subscriptions driven by the mock heads
+func NewMockHandler(heads *MockHeads, interval time.Duration, limits Limits) (http.Handler, error) {
+	return newHandler(nil, nil, nil, newHeadFeed(heads, interval), limits)
+}
+
+var (
+	EncodeCursor = encodeCursor
+	DecodeCursor = decodeCursor
+	PageSize     = pageSize
+)
+
+// CheckCost returns the error rejecting the query under the limits, if any, with the index empty
+func CheckCost(limits Limits, query string, variables map[string]interface{}) error {
+	return CheckCostAt(limits, -1, query, variables)
+}
+
+// CheckCostAt returns the error rejecting the query under the limits, if any, with the head at the height
+func CheckCostAt(limits Limits, head int64, query string, variables map[string]interface{}) error {
+	analyzer, err := newCostAnalyzer(schema, limits, func() (int64, error) { return head, nil })
+	if err != nil {
+		return err
+	}
+	if _, err := analyzer.check(query, "", variables); err != nil {
+		return errors.New(err.Message)
+	}
+	return nil
+}
+
+// CheckCostWithoutHead returns the error rejecting the query under the limits when the head can't be looked up
+func CheckCostWithoutHead(limits Limits, query string) error {
+	analyzer, err := newCostAnalyzer(schema, limits, func() (int64, error) { return 0, errors.New("no head") })
+	if err != nil {
+		return err
+	}
+	if _, err := analyzer.check(query, "", nil); err != nil {
+		return errors.New(err.Message)
+	}
+	return nil
+}
+
+// OperationType returns the type of the operation of the document the analyzer finds, if it accepts it
+func OperationType(document, operationName string) (string, error) {
+	analyzer, err := newCostAnalyzer(schema, Limits{}, func() (int64, error) { return 0, nil })
+	if err != nil {
+		return "", err
+	}
+	operation, qerr := analyzer.check(document, operationName, nil)
+	if qerr != nil {
+		return "", errors.New(qerr.Message)
+	}
+	return operation, nil
+}
+
+var NewBatchLoader = newBatchLoader
+
+var StateOverride = stateOverride
+
+// Load returns the value loaded for the key
+func (l *batchLoader) Load(ctx context.Context, key common.Hash) (interface{}, error) {
+	return l.load(ctx, key)
+}
+
+// NewMockAccountsBlock returns the block of the header with a set of loaders whose state leaf nodes are served from the
+// leaves, keyed by leaf key, and whose contract code is served from the code, along with the number of lookups of the
+// leaves made so far
+func NewMockAccountsBlock(header *types.Header, leaves map[common.Hash][]byte, code [][]byte) (context.Context, *Block, func() int) {
+	db := rawdb.NewMemoryDatabase()
+	for _, c := range code {
+		rawdb.WriteCode(db, crypto.Keccak256Hash(c), c)
+	}
+	backend := &eth.Backend{StateDatabase: state.NewDatabase(db)}
+	ctx := withLoaders(context.Background(), backend)
+	l := loadersFrom(ctx)
+	var mu sync.Mutex
+	lookups := 0
+	l.retrieveAccounts = func(leafKeys []common.Hash, blockHash common.Hash) ([]string, [][]byte, []common.Hash, error) {
+		mu.Lock()
+		lookups++
+		mu.Unlock()
+		if blockHash != header.Hash() {
+			return nil, nil, nil, nil
+		}
+		var cids []string
+		var leafNodes [][]byte
+		var keys []common.Hash
+		for _, key := range leafKeys {
+			if leaf, ok := leaves[key]; ok {
+				cids = append(cids, "cid-"+key.Hex())
+				leafNodes = append(leafNodes, leaf)
+				keys = append(keys, key)
+			}
+		}
+		return cids, leafNodes, keys, nil
+	}
+	numberOrHash := rpc.BlockNumberOrHashWithHash(header.Hash(), false)
+	l.headersByNumber.load(ctx, numberOrHashKey(numberOrHash), func() (interface{}, error) {
+		return header, nil
+	})
+	count := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return lookups
+	}
+	return ctx, &Block{backend: backend, numberOrHash: &numberOrHash}, count
+}
+
+var NewMemoLoader = newMemoLoader
+
+var EndpointHandler = newEndpointHandler
+
+// Load returns the value loaded for the key, fetched unless it is already loaded or being loaded
+func (m *memoLoader) Load(ctx context.Context, key string, fetch func() (interface{}, error)) (interface{}, error) {
+	return m.load(ctx, key, fetch)
+}
+
+// LoadMany returns the values loaded for the keys
+func (l *batchLoader) LoadMany(ctx context.Context, keys []common.Hash) ([]interface{}, error) {
+	return l.loadMany(ctx, keys)
+}
@@ -22,22 +22,34 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"math/big"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/eth/filters"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/rpc"
 
 	"github.com/vulcanize/ipld-eth-server/pkg/eth"
+	"github.com/vulcanize/ipld-eth-server/pkg/gapfill"
 )
 
+// maxAccounts is the number of addresses accepted at most by Block.accounts
+const maxAccounts = 100
+
+// emptyCodeHash is the code hash of the accounts without code
+var emptyCodeHash = crypto.Keccak256Hash(nil)
+
 var (
 	errBlockInvariant = errors.New("block objects must be instantiated with at least one of num or hash")
+	errNoProxy        = errors.New("no proxied node to forward the transaction to")
 )
 
 // Account represents an Ethereum account at a particular block.
@@ -47,10 +59,27 @@ type Account struct {
 	blockNrOrHash rpc.BlockNumberOrHash
 }
 
-// getState fetches the StateDB object for an account.
-func (a *Account) getState(ctx context.Context) (*state.StateDB, error) {
+// withState runs fn with a StateDB object for an account. When the request has loaders, the state root is looked up
+// once for all its account resolvers, and each of them reads its own StateDB object opened on that root.
+func (a *Account) withState(ctx context.Context, fn func(*state.StateDB)) error {
+	if l := loadersFrom(ctx); l != nil {
+		root, err := l.stateRoot(ctx, a.backend, a.blockNrOrHash)
+		if err != nil {
+			return err
+		}
+		state, err := state.New(root, a.backend.StateDatabase, nil)
+		if err != nil {
+			return err
+		}
+		fn(state)
+		return nil
+	}
 	state, _, err := a.backend.StateAndHeaderByNumberOrHash(ctx, a.blockNrOrHash)
-	return state, err
+	if err != nil {
+		return err
+	}
+	fn(state)
+	return nil
 }
 
 func (a *Account) Address(ctx context.Context) (common.Address, error) {
@@ -58,35 +87,90 @@ func (a *Account) Address(ctx context.Context) (common.Address, error) {
 }
 
 func (a *Account) Balance(ctx context.Context) (hexutil.Big, error) {
-	state, err := a.getState(ctx)
-	if err != nil {
+	entry, err := a.resolveAccount(ctx)
+	if err != nil || entry == nil {
 		return hexutil.Big{}, err
 	}
-	return hexutil.Big(*state.GetBalance(a.address)), nil
+	return hexutil.Big(*entry.account.Balance), nil
 }
 
 func (a *Account) TransactionCount(ctx context.Context) (hexutil.Uint64, error) {
-	state, err := a.getState(ctx)
-	if err != nil {
+	entry, err := a.resolveAccount(ctx)
+	if err != nil || entry == nil {
 		return 0, err
 	}
-	return hexutil.Uint64(state.GetNonce(a.address)), nil
+	return hexutil.Uint64(entry.account.Nonce), nil
 }
 
 func (a *Account) Code(ctx context.Context) (hexutil.Bytes, error) {
-	state, err := a.getState(ctx)
-	if err != nil {
+	entry, err := a.resolveAccount(ctx)
+	if err != nil || entry == nil {
 		return hexutil.Bytes{}, err
 	}
-	return hexutil.Bytes(state.GetCode(a.address)), nil
+	codeHash := common.BytesToHash(entry.account.CodeHash)
+	if codeHash == emptyCodeHash {
+		return hexutil.Bytes{}, nil
+	}
+	return a.backend.StateDatabase.ContractCode(crypto.Keccak256Hash(a.address.Bytes()), codeHash)
 }
 
 func (a *Account) Storage(ctx context.Context, args struct{ Slot common.Hash }) (common.Hash, error) {
-	state, err := a.getState(ctx)
+	var value common.Hash
+	err := a.withState(ctx, func(state *state.StateDB) {
+		value = state.GetState(a.address, args.Slot)
+	})
+	return value, err
+}
+
+// resolveAccount returns the state leaf node of the account, with the account it holds, nil if it does not exist.
+// When the request has loaders, the leaf nodes of the accounts of a block are fetched in batches.
+func (a *Account) resolveAccount(ctx context.Context) (*accountEntry, error) {
+	var (
+		header *types.Header
+		err    error
+	)
+	if l := loadersFrom(ctx); l != nil {
+		header, err = l.headerByNumberOrHash(ctx, a.backend, a.blockNrOrHash)
+	} else {
+		header, err = a.backend.HeaderByNumberOrHash(ctx, a.blockNrOrHash)
+	}
 	if err != nil {
-		return common.Hash{}, err
+		return nil, err
+	}
+	if l := loadersFrom(ctx); l != nil {
+		return l.account(ctx, header.Hash(), a.address)
+	}
+	cid, ipldBlock, accountRLP, err := a.backend.IPLDRetriever.RetrieveAccountByAddressAndBlockHash(a.address, header.Hash())
+	if err == sql.ErrNoRows || (err == nil && cid == "") {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	account := new(types.StateAccount)
+	if err := rlp.DecodeBytes(accountRLP, account); err != nil {
+		return nil, err
+	}
+	return &accountEntry{account: account, cid: cid, ipldBlock: ipldBlock}, nil
+}
+
+// Cid returns the cid of the state leaf node of the account.
+func (a *Account) Cid(ctx context.Context) (*string, error) {
+	entry, err := a.resolveAccount(ctx)
+	if err != nil || entry == nil {
+		return nil, err
 	}
-	return state.GetState(a.address, args.Slot), nil
+	return &entry.cid, nil
+}
+
+// IpldBlock returns the IPLD block of the state leaf node of the account.
+func (a *Account) IpldBlock(ctx context.Context) (*hexutil.Bytes, error) {
+	entry, err := a.resolveAccount(ctx)
+	if err != nil || entry == nil {
+		return nil, err
+	}
+	ret := hexutil.Bytes(entry.ipldBlock)
+	return &ret, nil
 }
 
 // Log represents an individual log message. All arguments are mandatory.
@@ -152,16 +236,34 @@ func (l *Log) ReceiptCID(_ context.Context) string {
 // Transaction represents an Ethereum transaction.
 // backend and hash are mandatory; all others will be fetched when required.
 type Transaction struct {
-	backend *eth.Backend
-	hash    common.Hash
-	tx      *types.Transaction
-	block   *Block
-	index   uint64
+	backend   *eth.Backend
+	hash      common.Hash
+	tx        *types.Transaction
+	block     *Block
+	index     uint64
+	cid       string
+	ipldBlock []byte
 }
 
 // resolve returns the internal transaction object, fetching it if needed.
 func (t *Transaction) resolve(ctx context.Context) (*types.Transaction, error) {
 	if t.tx == nil {
+		if l := loadersFrom(ctx); l != nil {
+			entry, err := l.transaction(ctx, t.hash)
+			if err != nil || entry == nil {
+				return nil, err
+			}
+			t.tx = entry.tx
+			blockNrOrHash := rpc.BlockNumberOrHashWithHash(entry.blockHash, false)
+			t.block = &Block{
+				backend:      t.backend,
+				numberOrHash: &blockNrOrHash,
+				hash:         entry.blockHash,
+			}
+			t.index = entry.index
+			t.cid, t.ipldBlock = entry.cid, entry.ipldBlock
+			return t.tx, nil
+		}
 		tx, blockHash, _, index := rawdb.ReadTransaction(t.backend.ChainDb(), t.hash)
 		if tx != nil {
 			t.tx = tx
@@ -204,6 +306,41 @@ func (t *Transaction) GasPrice(ctx context.Context) (hexutil.Big, error) {
 	return hexutil.Big(*tx.GasPrice()), nil
 }
 
+// MaxFeePerGas returns the fee cap of an EIP-1559 transaction, nil for the other transactions.
+func (t *Transaction) MaxFeePerGas(ctx context.Context) (*hexutil.Big, error) {
+	tx, err := t.resolve(ctx)
+	if err != nil || tx == nil || tx.Type() != types.DynamicFeeTxType {
+		return nil, err
+	}
+	return (*hexutil.Big)(tx.GasFeeCap()), nil
+}
+
+// MaxPriorityFeePerGas returns the tip cap of an EIP-1559 transaction, nil for the other transactions.
+func (t *Transaction) MaxPriorityFeePerGas(ctx context.Context) (*hexutil.Big, error) {
+	tx, err := t.resolve(ctx)
+	if err != nil || tx == nil || tx.Type() != types.DynamicFeeTxType {
+		return nil, err
+	}
+	return (*hexutil.Big)(tx.GasTipCap()), nil
+}
+
+// EffectiveGasPrice returns the price paid per unit of gas by the transaction, computed from the base fee of its block.
+// Legacy and access list transactions have both their caps set to their gas price, so they pay their gas price.
+func (t *Transaction) EffectiveGasPrice(ctx context.Context) (*hexutil.Big, error) {
+	tx, err := t.resolve(ctx)
+	if err != nil || tx == nil || t.block == nil {
+		return nil, err
+	}
+	header, err := t.block.resolveHeader(ctx)
+	if err != nil || header == nil {
+		return nil, err
+	}
+	if header.BaseFee == nil {
+		return (*hexutil.Big)(tx.GasPrice()), nil
+	}
+	return (*hexutil.Big)(math.BigMin(new(big.Int).Add(tx.GasTipCap(), header.BaseFee), tx.GasFeeCap())), nil
+}
+
 func (t *Transaction) Value(ctx context.Context) (hexutil.Big, error) {
 	tx, err := t.resolve(ctx)
 	if err != nil || tx == nil {
@@ -243,7 +380,7 @@ func (t *Transaction) From(ctx context.Context, args BlockNumberArgs) (*Account,
 	}
 	var signer types.Signer = types.HomesteadSigner{}
 	if tx.Protected() {
-		signer = types.NewEIP155Signer(tx.ChainId())
+		signer = types.LatestSignerForChainID(tx.ChainId())
 	}
 	from, _ := types.Sender(signer, tx)
 
@@ -280,6 +417,13 @@ func (t *Transaction) getReceipt(ctx context.Context) (*types.Receipt, error) {
 	if t.block == nil {
 		return nil, nil
 	}
+	if l := loadersFrom(ctx); l != nil {
+		entry, err := l.receipt(ctx, t.hash)
+		if err != nil || entry == nil {
+			return nil, err
+		}
+		return entry.receipt, nil
+	}
 	receipts, err := t.block.resolveReceipts(ctx)
 	if err != nil {
 		return nil, err
@@ -369,6 +513,128 @@ func (t *Transaction) V(ctx context.Context) (hexutil.Big, error) {
 	return hexutil.Big(*v), nil
 }
 
+// Type returns the EIP-2718 type of the transaction.
+func (t *Transaction) Type(ctx context.Context) (*int32, error) {
+	tx, err := t.resolve(ctx)
+	if err != nil || tx == nil {
+		return nil, err
+	}
+	txType := int32(tx.Type())
+	return &txType, nil
+}
+
+// AccessList returns the EIP-2930 access list of the transaction.
+func (t *Transaction) AccessList(ctx context.Context) (*[]*AccessTuple, error) {
+	tx, err := t.resolve(ctx)
+	if err != nil || tx == nil {
+		return nil, err
+	}
+	accessList := tx.AccessList()
+	ret := make([]*AccessTuple, 0, len(accessList))
+	for _, tuple := range accessList {
+		ret = append(ret, &AccessTuple{
+			address:     tuple.Address,
+			storageKeys: tuple.StorageKeys,
+		})
+	}
+	return &ret, nil
+}
+
+// resolveIPLD returns the cid and IPLD block of the transaction, fetching them if needed.
+func (t *Transaction) resolveIPLD(ctx context.Context) (string, []byte, error) {
+	if t.cid == "" {
+		if l := loadersFrom(ctx); l != nil {
+			entry, err := l.transaction(ctx, t.hash)
+			if err != nil || entry == nil {
+				return "", nil, err
+			}
+			t.cid, t.ipldBlock = entry.cid, entry.ipldBlock
+		} else {
+			cid, ipldBlock, err := t.backend.IPLDRetriever.RetrieveTransactionByTxHash(t.hash)
+			if err == sql.ErrNoRows {
+				return "", nil, nil
+			}
+			if err != nil {
+				return "", nil, err
+			}
+			t.cid, t.ipldBlock = cid, ipldBlock
+		}
+	}
+	return t.cid, t.ipldBlock, nil
+}
+
+// resolveReceiptIPLD returns the cid and receipt trie leaf node IPLD block of the receipt of the transaction, empty if
+// it has not been mined.
+func (t *Transaction) resolveReceiptIPLD(ctx context.Context) (string, []byte, error) {
+	if _, err := t.resolve(ctx); err != nil || t.block == nil {
+		return "", nil, err
+	}
+	if l := loadersFrom(ctx); l != nil {
+		entry, err := l.receipt(ctx, t.hash)
+		if err != nil || entry == nil {
+			return "", nil, err
+		}
+		return entry.cid, entry.ipldBlock, nil
+	}
+	cid, ipldBlock, _, err := t.backend.IPLDRetriever.RetrieveReceiptByHash(t.hash)
+	if err == sql.ErrNoRows {
+		return "", nil, nil
+	}
+	return cid, ipldBlock, err
+}
+
+// Cid returns the cid of the transaction.
+func (t *Transaction) Cid(ctx context.Context) (*string, error) {
+	cid, _, err := t.resolveIPLD(ctx)
+	if err != nil || cid == "" {
+		return nil, err
+	}
+	return &cid, nil
+}
+
+// IpldBlock returns the IPLD block of the transaction.
+func (t *Transaction) IpldBlock(ctx context.Context) (*hexutil.Bytes, error) {
+	_, ipldBlock, err := t.resolveIPLD(ctx)
+	if err != nil || ipldBlock == nil {
+		return nil, err
+	}
+	ret := hexutil.Bytes(ipldBlock)
+	return &ret, nil
+}
+
+// ReceiptCID returns the cid of the receipt trie leaf node of the transaction's receipt.
+func (t *Transaction) ReceiptCID(ctx context.Context) (*string, error) {
+	cid, _, err := t.resolveReceiptIPLD(ctx)
+	if err != nil || cid == "" {
+		return nil, err
+	}
+	return &cid, nil
+}
+
+// ReceiptIpldBlock returns the IPLD block of the receipt trie leaf node of the transaction's receipt.
+func (t *Transaction) ReceiptIpldBlock(ctx context.Context) (*hexutil.Bytes, error) {
+	_, ipldBlock, err := t.resolveReceiptIPLD(ctx)
+	if err != nil || ipldBlock == nil {
+		return nil, err
+	}
+	ret := hexutil.Bytes(ipldBlock)
+	return &ret, nil
+}
+
+// AccessTuple is an entry of the access list of a transaction.
+type AccessTuple struct {
+	address     common.Address
+	storageKeys []common.Hash
+}
+
+func (at *AccessTuple) Address(_ context.Context) common.Address {
+	return at.address
+}
+
+func (at *AccessTuple) StorageKeys(_ context.Context) []common.Hash {
+	return at.storageKeys
+}
+
 type BlockType int
 
 // Block represents an Ethereum block.
@@ -381,6 +647,9 @@ type Block struct {
 	header       *types.Header
 	block        *types.Block
 	receipts     []*types.Receipt
+	ommer        bool // the block is an uncle, stored as such
+	cid          string
+	ipldBlock    []byte
 }
 
 // resolve returns the internal Block object representing this block, fetching
@@ -413,7 +682,19 @@ func (b *Block) resolveHeader(ctx context.Context) (*types.Header, error) {
 	}
 	var err error
 	if b.header == nil {
-		if b.hash != (common.Hash{}) {
+		if l := loadersFrom(ctx); l != nil {
+			if b.hash != (common.Hash{}) {
+				var entry *headerEntry
+				if entry, err = l.header(ctx, b.hash); err == nil {
+					b.header = entry.header
+					if !b.ommer {
+						b.cid, b.ipldBlock = entry.cid, entry.ipldBlock
+					}
+				}
+			} else {
+				b.header, err = l.headerByNumberOrHash(ctx, b.backend, *b.numberOrHash)
+			}
+		} else if b.hash != (common.Hash{}) {
 			b.header, err = b.backend.HeaderByHash(ctx, b.hash)
 		} else {
 			b.header, err = b.backend.HeaderByNumberOrHash(ctx, *b.numberOrHash)
@@ -426,6 +707,18 @@ func (b *Block) resolveHeader(ctx context.Context) (*types.Header, error) {
 // if necessary.
 func (b *Block) resolveReceipts(ctx context.Context) ([]*types.Receipt, error) {
 	if b.receipts == nil {
+		if l := loadersFrom(ctx); l != nil {
+			block, err := b.resolve(ctx)
+			if err != nil || block == nil {
+				return nil, err
+			}
+			receipts, err := l.receiptsOf(ctx, block.Transactions())
+			if err != nil {
+				return nil, err
+			}
+			b.receipts = receipts
+			return b.receipts, nil
+		}
 		hash := b.hash
 		if hash == (common.Hash{}) {
 			header, err := b.resolveHeader(ctx)
@@ -443,6 +736,54 @@ func (b *Block) resolveReceipts(ctx context.Context) ([]*types.Receipt, error) {
 	return b.receipts, nil
 }
 
+// resolveIPLD returns the cid and IPLD block of the header of this block, or of the uncle for an ommer, fetching them
+// if necessary.
+func (b *Block) resolveIPLD(ctx context.Context) (string, []byte, error) {
+	if b.cid != "" {
+		return b.cid, b.ipldBlock, nil
+	}
+	hash := b.hash
+	if hash == (common.Hash{}) {
+		header, err := b.resolveHeader(ctx)
+		if err != nil {
+			return "", nil, err
+		}
+		hash = header.Hash()
+	}
+	var (
+		cid       string
+		ipldBlock []byte
+		err       error
+	)
+	if b.ommer {
+		cid, ipldBlock, err = b.backend.IPLDRetriever.RetrieveUncleByHash(hash)
+	} else if l := loadersFrom(ctx); l != nil {
+		var entry *headerEntry
+		if entry, err = l.header(ctx, hash); err == nil {
+			cid, ipldBlock = entry.cid, entry.ipldBlock
+		}
+	} else {
+		cid, ipldBlock, err = b.backend.IPLDRetriever.RetrieveHeaderByHash(hash)
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	b.cid, b.ipldBlock = cid, ipldBlock
+	return cid, ipldBlock, nil
+}
+
+// Cid returns the cid of the header of this block, or of the uncle for an ommer.
+func (b *Block) Cid(ctx context.Context) (string, error) {
+	cid, _, err := b.resolveIPLD(ctx)
+	return cid, err
+}
+
+// IpldBlock returns the IPLD block of the header of this block, or of the uncle for an ommer.
+func (b *Block) IpldBlock(ctx context.Context) (hexutil.Bytes, error) {
+	_, ipldBlock, err := b.resolveIPLD(ctx)
+	return ipldBlock, err
+}
+
 func (b *Block) Number(ctx context.Context) (hexutil.Uint64, error) {
 	header, err := b.resolveHeader(ctx)
 	if err != nil {
@@ -479,6 +820,15 @@ func (b *Block) GasUsed(ctx context.Context) (hexutil.Uint64, error) {
 	return hexutil.Uint64(header.GasUsed), nil
 }
 
+// BaseFeePerGas returns the EIP-1559 base fee of the block, nil for the blocks before London.
+func (b *Block) BaseFeePerGas(ctx context.Context) (*hexutil.Big, error) {
+	header, err := b.resolveHeader(ctx)
+	if err != nil || header.BaseFee == nil {
+		return nil, err
+	}
+	return (*hexutil.Big)(header.BaseFee), nil
+}
+
 func (b *Block) Parent(ctx context.Context) (*Block, error) {
 	// If the block header hasn't been fetched, and we'll need it, fetch it.
 	if b.numberOrHash == nil && b.header == nil {
@@ -581,7 +931,9 @@ func (b *Block) Ommers(ctx context.Context) (*[]*Block, error) {
 		ret = append(ret, &Block{
 			backend:      b.backend,
 			numberOrHash: &blockNumberOrHash,
+			hash:         uncle.Hash(),
 			header:       uncle,
+			ommer:        true,
 		})
 	}
 	return &ret, nil
@@ -715,7 +1067,9 @@ func (b *Block) OmmerAt(ctx context.Context, args struct{ Index int32 }) (*Block
 	return &Block{
 		backend:      b.backend,
 		numberOrHash: &blockNumberOrHash,
+		hash:         uncle.Hash(),
 		header:       uncle,
+		ommer:        true,
 	}, nil
 }
 
@@ -796,6 +1150,81 @@ func (b *Block) Account(ctx context.Context, args struct {
 	}, nil
 }
 
+// Accounts returns the accounts with the addresses at the block, whose state leaf nodes are looked up in a batch.
+func (b *Block) Accounts(ctx context.Context, args struct {
+	Addresses []common.Address
+}) ([]*Account, error) {
+	if len(args.Addresses) > maxAccounts {
+		return nil, fmt.Errorf("at most %d addresses are accepted, got %d", maxAccounts, len(args.Addresses))
+	}
+	if b.numberOrHash == nil {
+		_, err := b.resolveHeader(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	accounts := make([]*Account, len(args.Addresses))
+	for i, address := range args.Addresses {
+		accounts[i] = &Account{
+			backend:       b.backend,
+			address:       address,
+			blockNrOrHash: *b.numberOrHash,
+		}
+	}
+	return accounts, nil
+}
+
+// StorageSlot is the value of a storage slot in an AccountOverride.
+type StorageSlot struct {
+	Slot  common.Hash
+	Value common.Hash
+}
+
+// AccountOverride replaces the fields of an account in the state of `call` or `estimateGas`.
+type AccountOverride struct {
+	Address   common.Address
+	Nonce     *hexutil.Uint64
+	Code      *hexutil.Bytes
+	Balance   *hexutil.Big
+	State     *[]StorageSlot
+	StateDiff *[]StorageSlot
+}
+
+// stateOverride converts the account overrides into the state override applied by the eth calls.
+func stateOverride(overrides *[]AccountOverride) (*eth.StateOverride, error) {
+	if overrides == nil {
+		return nil, nil
+	}
+	storage := func(slots *[]StorageSlot) *map[common.Hash]common.Hash {
+		if slots == nil {
+			return nil
+		}
+		values := make(map[common.Hash]common.Hash, len(*slots))
+		for _, slot := range *slots {
+			values[slot.Slot] = slot.Value
+		}
+		return &values
+	}
+	diff := make(eth.StateOverride, len(*overrides))
+	for _, override := range *overrides {
+		if _, ok := diff[override.Address]; ok {
+			return nil, fmt.Errorf("account %s is overridden more than once", override.Address.Hex())
+		}
+		account := eth.OverrideAccount{
+			Nonce:     override.Nonce,
+			Code:      override.Code,
+			State:     storage(override.State),
+			StateDiff: storage(override.StateDiff),
+		}
+		if override.Balance != nil {
+			balance := override.Balance
+			account.Balance = &balance
+		}
+		diff[override.Address] = account
+	}
+	return &diff, nil
+}
+
 // CallData encapsulates arguments to `call` or `estimateGas`.
 // All arguments are optional.
 type CallData struct {
@@ -827,7 +1256,8 @@ func (c *CallResult) Status() hexutil.Uint64 {
 }
 
 func (b *Block) Call(ctx context.Context, args struct {
-	Data eth.CallArgs
+	Data      eth.CallArgs
+	Overrides *[]AccountOverride
 }) (*CallResult, error) {
 	if b.numberOrHash == nil {
 		_, err := b.resolve(ctx)
@@ -835,7 +1265,11 @@ func (b *Block) Call(ctx context.Context, args struct {
 			return nil, err
 		}
 	}
-	result, err := eth.DoCall(ctx, b.backend, args.Data, *b.numberOrHash, nil, 5*time.Second, b.backend.RPCGasCap().Uint64())
+	overrides, err := stateOverride(args.Overrides)
+	if err != nil {
+		return nil, err
+	}
+	result, err := eth.DoCall(ctx, b.backend, args.Data, *b.numberOrHash, overrides, 5*time.Second, b.backend.RPCGasCap().Uint64())
 	if err != nil {
 		return nil, err
 	}
@@ -851,9 +1285,29 @@ func (b *Block) Call(ctx context.Context, args struct {
 	}, nil
 }
 
+// EstimateGas returns the lowest gas limit with which the call succeeds at the block, with the overrides applied.
+func (b *Block) EstimateGas(ctx context.Context, args struct {
+	Data      eth.CallArgs
+	Overrides *[]AccountOverride
+}) (hexutil.Uint64, error) {
+	if b.numberOrHash == nil {
+		_, err := b.resolveHeader(ctx)
+		if err != nil {
+			return 0, err
+		}
+	}
+	overrides, err := stateOverride(args.Overrides)
+	if err != nil {
+		return 0, err
+	}
+	return eth.DoEstimateGas(ctx, b.backend, args.Data, *b.numberOrHash, overrides, b.backend.RPCGasCap().Uint64())
+}
+
 // Resolver is the top-level object in the GraphQL hierarchy.
 type Resolver struct {
-	backend *eth.Backend
+	backend   *eth.Backend
+	client    *rpc.Client
+	gapFiller *gapfill.Scheduler
 }
 
 func (r *Resolver) Block(ctx context.Context, args struct {
@@ -1122,3 +1576,90 @@ func decomposeGQLLogs(logCIDs []eth.LogResult) []logsCID {
 
 	return logs
 }
+
+// SendRawTransaction decodes the transaction and checks its signature against the chain, then forwards it to the
+// proxied node and returns its hash.
+func (r *Resolver) SendRawTransaction(ctx context.Context, args struct{ Data hexutil.Bytes }) (common.Hash, error) {
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(args.Data); err != nil {
+		return common.Hash{}, err
+	}
+	signer := types.LatestSignerForChainID(r.backend.Config.ChainConfig.ChainID)
+	if _, err := types.Sender(signer, tx); err != nil {
+		return common.Hash{}, err
+	}
+	if r.client == nil {
+		return common.Hash{}, errNoProxy
+	}
+	if err := r.client.CallContext(ctx, nil, "eth_sendRawTransaction", args.Data); err != nil {
+		return common.Hash{}, err
+	}
+	return tx.Hash(), nil
+}
+
+// GasPrice returns the tip suggested by the gas price oracle, plus the base fee of the latest indexed block.
+func (r *Resolver) GasPrice(ctx context.Context) (hexutil.Big, error) {
+	tipCap, err := r.backend.SuggestGasTipCap(ctx)
+	if err != nil {
+		return hexutil.Big{}, err
+	}
+	head, err := r.backend.HeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if err != nil {
+		return hexutil.Big{}, err
+	}
+	if head.BaseFee != nil {
+		tipCap.Add(tipCap, head.BaseFee)
+	}
+	return hexutil.Big(*tipCap), nil
+}
+
+// MaxPriorityFeePerGas returns the tip suggested by the gas price oracle.
+func (r *Resolver) MaxPriorityFeePerGas(ctx context.Context) (hexutil.Big, error) {
+	tipCap, err := r.backend.SuggestGasTipCap(ctx)
+	if err != nil {
+		return hexutil.Big{}, err
+	}
+	return hexutil.Big(*tipCap), nil
+}
+
+// ChainID returns the chain ID of the configured chain.
+func (r *Resolver) ChainID() hexutil.Big {
+	return hexutil.Big(*r.backend.Config.ChainConfig.ChainID)
+}
+
+// SyncState represents the progress of the gap filler returned from the `syncing` accessor.
+type SyncState struct {
+	status gapfill.Status
+}
+
+func (s *SyncState) StartingBlock() hexutil.Uint64 {
+	return hexutil.Uint64(s.status.Start)
+}
+
+func (s *SyncState) CurrentBlock() hexutil.Uint64 {
+	if s.status.LastFilled < s.status.Start {
+		return hexutil.Uint64(s.status.Start)
+	}
+	return hexutil.Uint64(s.status.LastFilled)
+}
+
+func (s *SyncState) HighestBlock() hexutil.Uint64 {
+	// heights are queued before the first scan completes
+	if s.status.ScannedTo < s.status.Lowest {
+		return hexutil.Uint64(s.status.Lowest)
+	}
+	return hexutil.Uint64(s.status.ScannedTo)
+}
+
+// Syncing returns the progress of the gap filler, or nil if it is disabled or has no heights left to fill: none queued
+// or in flight, and none skipped by its last scan for not fitting in the queue.
+func (r *Resolver) Syncing() *SyncState {
+	if r.gapFiller == nil {
+		return nil
+	}
+	status := r.gapFiller.Status()
+	if status.Queued+status.InFlight == 0 && status.Skipped == 0 {
+		return nil
+	}
+	return &SyncState{status}
+}
@@ -25,17 +25,22 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/ethereum/go-ethereum/statediff"
 	"github.com/ethereum/go-ethereum/statediff/indexer"
+	"github.com/ethereum/go-ethereum/statediff/indexer/ipfs/ipld"
 	"github.com/ethereum/go-ethereum/statediff/indexer/node"
 	"github.com/ethereum/go-ethereum/statediff/indexer/postgres"
 	sdtypes "github.com/ethereum/go-ethereum/statediff/types"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/multiformats/go-multihash"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
@@ -78,6 +83,8 @@ var _ = Describe("GraphQL", func() {
 		ctx             = context.Background()
 		blockHash       common.Hash
 		contractAddress common.Address
+		londonBlock     *types.Block
+		londonReceipts  types.Receipts
 	)
 
 	It("test init", func() {
@@ -176,7 +183,16 @@ var _ = Describe("GraphQL", func() {
 		err = tx.Close(err)
 		Expect(err).ToNot(HaveOccurred())
 
-		graphQLServer, err = graphql.New(backend, gqlEndPoint, nil, []string{"*"}, rpc.HTTPTimeouts{})
+		// London activates after the test chain, with a block holding a transaction of each type
+		chainConfig.LondonBlock = new(big.Int).Add(blocks[len(blocks)-1].Number(), big.NewInt(1))
+		londonBlock, londonReceipts = newLondonBlock(blocks[len(blocks)-1])
+		tx, err = transformer.PushBlock(londonBlock, londonReceipts, mockTD)
+		Expect(err).ToNot(HaveOccurred())
+
+		err = tx.Close(err)
+		Expect(err).ToNot(HaveOccurred())
+
+		graphQLServer, err = graphql.New(backend, nil, nil, gqlEndPoint, nil, []string{"*"}, rpc.HTTPTimeouts{}, graphql.Limits{})
 		Expect(err).ToNot(HaveOccurred())
 
 		err = graphQLServer.Start(nil)
@@ -269,4 +285,325 @@ var _ = Describe("GraphQL", func() {
 			Expect(storageRes.Value).To(Equal(common.Hash{}))
 		})
 	})
+
+	Describe("blocksConnection", func() {
+		It("Pages through the canonical blocks", func() {
+			var (
+				hashes []common.Hash
+				after  *string
+			)
+			for page := 0; ; page++ {
+				res, err := client.GetBlocksConnection(ctx, 0, 5, 2, after)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(len(res.Edges)).To(Equal(2))
+				for _, edge := range res.Edges {
+					hashes = append(hashes, edge.Node.Hash)
+				}
+				Expect(*res.PageInfo.EndCursor).To(Equal(res.Edges[1].Cursor))
+				if !res.PageInfo.HasNextPage {
+					Expect(page).To(Equal(2))
+					break
+				}
+				after = res.PageInfo.EndCursor
+			}
+			Expect(hashes).To(Equal(blockHashes))
+		})
+	})
+
+	Describe("stateDiff", func() {
+		It("Retrieves the accounts changed in the block with their previous values", func() {
+			diff, err := client.GetStateDiff(ctx, 1)
+			Expect(err).ToNot(HaveOccurred())
+
+			accounts := make(map[common.Hash]graphql.AccountDiffResp)
+			for _, account := range diff {
+				Expect(account.NodeType).To(Equal("Leaf"))
+				Expect(account.Cid).ToNot(BeEmpty())
+				accounts[account.LeafKey] = account
+			}
+
+			// the test bank sends account #1 some ether in block 1
+			account1 := accounts[crypto.Keccak256Hash(test_helpers.Account1Addr.Bytes())]
+			Expect(account1.Balance.ToInt().Int64()).To(Equal(int64(10000)))
+			Expect(account1.PreviousBalance).To(BeNil())
+
+			bank := accounts[crypto.Keccak256Hash(test_helpers.TestBankAddress.Bytes())]
+			Expect(*bank.Nonce).To(Equal(hexutil.Uint64(1)))
+			Expect(*bank.PreviousNonce).To(Equal(hexutil.Uint64(0)))
+			Expect(bank.PreviousBalance.ToInt().Cmp(test_helpers.TestBankFunds)).To(Equal(0))
+		})
+	})
+
+	Describe("accounts", func() {
+		It("Retrieves the accounts with the addresses at the block, in their order", func() {
+			missing := common.HexToAddress("0x0000000000000000000000000000000000000abc")
+			addresses := []common.Address{test_helpers.Account1Addr, missing, test_helpers.TestBankAddress}
+			accounts, err := client.GetAccounts(ctx, 1, addresses)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(accounts).To(HaveLen(3))
+			for i, account := range accounts {
+				Expect(account.Address).To(Equal(addresses[i]))
+			}
+			Expect(accounts[0].Balance.ToInt().Int64()).To(Equal(int64(10000)))
+			Expect(accounts[0].Cid).ToNot(BeNil())
+			Expect(accounts[1].Balance.ToInt().Sign()).To(Equal(0))
+			Expect(accounts[1].Cid).To(BeNil())
+			Expect(accounts[2].Cid).ToNot(BeNil())
+		})
+
+		It("Retrieves the state leaf nodes of the accounts, and null for the accounts which do not exist", func() {
+			missing := common.HexToAddress("0x0000000000000000000000000000000000000abc")
+			accounts, err := client.GetAccounts(ctx, 1, []common.Address{test_helpers.Account1Addr, missing})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(accounts).To(HaveLen(2))
+
+			leafCID, err := ipld.RawdataToCid(ipld.MEthStateTrie, *accounts[0].IpldBlock, multihash.KECCAK_256)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(*accounts[0].Cid).To(Equal(leafCID.String()))
+			accountRLP, err := eth.DecodeLeafNode(*accounts[0].IpldBlock)
+			Expect(err).ToNot(HaveOccurred())
+			var account types.StateAccount
+			Expect(rlp.DecodeBytes(accountRLP, &account)).To(Succeed())
+			Expect(account.Balance.Int64()).To(Equal(int64(10000)))
+
+			Expect(accounts[1].Cid).To(BeNil())
+			Expect(accounts[1].IpldBlock).To(BeNil())
+		})
+	})
+
+	Describe("estimateGas", func() {
+		It("Estimates the gas of a transfer, on the state with the overrides applied", func() {
+			sender := common.HexToAddress("0x0000000000000000000000000000000000000abc")
+			data := map[string]interface{}{
+				"from":     sender,
+				"to":       test_helpers.Account1Addr,
+				"value":    "0x1",
+				"gasPrice": "0x1",
+			}
+			_, err := client.EstimateGas(ctx, 1, data, nil)
+			Expect(err).To(MatchError(ContainSubstring("insufficient funds for transfer")))
+
+			overrides := []map[string]interface{}{{"address": sender, "balance": "0xde0b6b3a7640000"}}
+			gas, err := client.EstimateGas(ctx, 1, data, overrides)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(gas).To(Equal(hexutil.Uint64(params.TxGas)))
+		})
+	})
+
+	Describe("gasPrice", func() {
+		It("Suggests a tip sampled from the latest blocks, and adds the base fee to it for the gas price", func() {
+			price, err := client.GetGasPrice(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(price.MaxPriorityFeePerGas.ToInt().Sign()).To(Equal(1))
+			Expect(price.GasPrice.ToInt().Cmp(price.MaxPriorityFeePerGas.ToInt())).ToNot(Equal(-1))
+		})
+	})
+
+	Describe("EIP-1559 fields", func() {
+		It("Retrieves the fees of each type of transaction of a post-London block", func() {
+			fees, err := client.GetBlockFees(ctx, londonBlock.Hash())
+			Expect(err).ToNot(HaveOccurred())
+			baseFee := londonBlock.BaseFee()
+			Expect(fees.BaseFeePerGas.ToInt().Int64()).To(Equal(baseFee.Int64()))
+
+			txs := londonBlock.Transactions()
+			Expect(fees.Transactions).To(HaveLen(len(txs)))
+			for i, tx := range txs {
+				res := fees.Transactions[i]
+				Expect(res.Hash).To(Equal(tx.Hash()))
+				Expect(*res.Type).To(Equal(int32(tx.Type())))
+				Expect(res.GasPrice.ToInt().Int64()).To(Equal(tx.GasPrice().Int64()))
+				effective := math.BigMin(new(big.Int).Add(tx.GasTipCap(), baseFee), tx.GasFeeCap())
+				Expect(res.EffectiveGasPrice.ToInt().Int64()).To(Equal(effective.Int64()))
+				if tx.Type() == types.DynamicFeeTxType {
+					Expect(res.MaxFeePerGas.ToInt().Int64()).To(Equal(tx.GasFeeCap().Int64()))
+					Expect(res.MaxPriorityFeePerGas.ToInt().Int64()).To(Equal(tx.GasTipCap().Int64()))
+				} else {
+					Expect(res.MaxFeePerGas).To(BeNil())
+					Expect(res.MaxPriorityFeePerGas).To(BeNil())
+				}
+			}
+
+			// the legacy and access list transactions pay their gas price
+			Expect(fees.Transactions[0].EffectiveGasPrice.ToInt().Int64()).To(Equal(txs[0].GasPrice().Int64()))
+			Expect(fees.Transactions[1].EffectiveGasPrice.ToInt().Int64()).To(Equal(txs[1].GasPrice().Int64()))
+			// the first dynamic fee transaction is capped by its fee cap, the second pays its full tip
+			Expect(fees.Transactions[2].EffectiveGasPrice.ToInt().Int64()).To(Equal(txs[2].GasFeeCap().Int64()))
+			Expect(fees.Transactions[3].EffectiveGasPrice.ToInt().Int64()).
+				To(Equal(new(big.Int).Add(baseFee, txs[3].GasTipCap()).Int64()))
+
+			Expect(fees.Transactions[0].AccessList).To(BeEmpty())
+			Expect(fees.Transactions[1].AccessList).To(Equal([]graphql.AccessTupleResp{{
+				Address:     txs[1].AccessList()[0].Address,
+				StorageKeys: txs[1].AccessList()[0].StorageKeys,
+			}}))
+			Expect(fees.Transactions[2].AccessList).To(BeEmpty())
+		})
+
+		It("Leaves the EIP-1559 fields of the blocks before London null", func() {
+			fees, err := client.GetBlockFees(ctx, blockHashes[1])
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fees.BaseFeePerGas).To(BeNil())
+			Expect(fees.Transactions).To(HaveLen(1))
+
+			res := fees.Transactions[0]
+			Expect(*res.Type).To(Equal(int32(types.LegacyTxType)))
+			Expect(res.MaxFeePerGas).To(BeNil())
+			Expect(res.MaxPriorityFeePerGas).To(BeNil())
+			Expect(res.EffectiveGasPrice.ToInt().Int64()).To(Equal(res.GasPrice.ToInt().Int64()))
+			Expect(res.AccessList).To(BeEmpty())
+		})
+	})
+
+	Describe("IPLD provenance", func() {
+		It("Retrieves the IPLD blocks of the header, ommers, transactions and receipts of a block, with their cids", func() {
+			provenance, err := client.GetBlockProvenance(ctx, londonBlock.Hash())
+			Expect(err).ToNot(HaveOccurred())
+
+			headerRLP, err := rlp.EncodeToBytes(londonBlock.Header())
+			Expect(err).ToNot(HaveOccurred())
+			headerCID, err := ipld.RawdataToCid(ipld.MEthHeader, headerRLP, multihash.KECCAK_256)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(provenance.Cid).To(Equal(headerCID.String()))
+			Expect(provenance.IpldBlock).To(Equal(hexutil.Bytes(headerRLP)))
+
+			Expect(provenance.Ommers).To(HaveLen(1))
+			ommer := londonBlock.Uncles()[0]
+			ommerRLP, err := rlp.EncodeToBytes(ommer)
+			Expect(err).ToNot(HaveOccurred())
+			ommerCID, err := ipld.RawdataToCid(ipld.MEthHeader, ommerRLP, multihash.KECCAK_256)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(provenance.Ommers[0].Hash).To(Equal(ommer.Hash()))
+			Expect(provenance.Ommers[0].Cid).To(Equal(ommerCID.String()))
+			Expect(provenance.Ommers[0].IpldBlock).To(Equal(hexutil.Bytes(ommerRLP)))
+
+			rctCIDs, rctLeafNodes, err := eth.GetRctLeafNodeData(londonReceipts)
+			Expect(err).ToNot(HaveOccurred())
+			txs := londonBlock.Transactions()
+			Expect(provenance.Transactions).To(HaveLen(len(txs)))
+			for i, tx := range txs {
+				res := provenance.Transactions[i]
+				txBytes, err := tx.MarshalBinary()
+				Expect(err).ToNot(HaveOccurred())
+				txCID, err := ipld.RawdataToCid(ipld.MEthTx, txBytes, multihash.KECCAK_256)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(res.Hash).To(Equal(tx.Hash()))
+				Expect(*res.Cid).To(Equal(txCID.String()))
+				Expect(*res.IpldBlock).To(Equal(hexutil.Bytes(txBytes)))
+				Expect(*res.ReceiptCID).To(Equal(rctCIDs[i].String()))
+				Expect(*res.ReceiptIpldBlock).To(Equal(hexutil.Bytes(rctLeafNodes[i])))
+			}
+		})
+	})
+
+	Describe("transactionsConnection", func() {
+		It("Pages through the transactions of the canonical blocks", func() {
+			var expected []common.Hash
+			for _, block := range blocks {
+				for _, tx := range block.Transactions() {
+					expected = append(expected, tx.Hash())
+				}
+			}
+
+			var (
+				hashes []common.Hash
+				after  *string
+			)
+			for {
+				res, err := client.GetTransactionsConnection(ctx, 0, 5, 1, after)
+				Expect(err).ToNot(HaveOccurred())
+				for _, edge := range res.Edges {
+					hashes = append(hashes, edge.Node.Hash)
+				}
+				if !res.PageInfo.HasNextPage {
+					break
+				}
+				after = res.PageInfo.EndCursor
+			}
+			Expect(hashes).To(Equal(expected))
+		})
+	})
 })
+
+// newLondonBlock returns a post-London child of the parent with an ommer, and with a legacy, an access list and two
+// dynamic fee transactions, the first dynamic fee transaction being capped by its fee cap and the second paying its
+// full tip
+func newLondonBlock(parent *types.Block) (*types.Block, types.Receipts) {
+	baseFee := big.NewInt(params.InitialBaseFee)
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).Add(parent.Number(), big.NewInt(1)),
+		Difficulty: parent.Difficulty(),
+		GasLimit:   parent.GasLimit(),
+		Time:       parent.Time() + 1,
+		BaseFee:    baseFee,
+	}
+	ommer := &types.Header{
+		ParentHash: parent.ParentHash(),
+		Number:     parent.Number(),
+		Difficulty: parent.Difficulty(),
+		GasLimit:   parent.GasLimit(),
+		Time:       parent.Time(),
+		Extra:      []byte("ommer"),
+	}
+	chainID := params.TestChainConfig.ChainID
+	to := test_helpers.Account2Addr
+	txData := []types.TxData{
+		&types.LegacyTx{
+			Nonce:    0,
+			GasPrice: new(big.Int).Mul(baseFee, big.NewInt(2)),
+			Gas:      params.TxGas,
+			To:       &to,
+			Value:    big.NewInt(1),
+		},
+		&types.AccessListTx{
+			ChainID:  chainID,
+			Nonce:    1,
+			GasPrice: new(big.Int).Add(baseFee, big.NewInt(500)),
+			Gas:      params.TxGas + params.TxAccessListAddressGas + params.TxAccessListStorageKeyGas,
+			To:       &to,
+			Value:    big.NewInt(1),
+			AccessList: types.AccessList{{
+				Address:     test_helpers.ContractAddr,
+				StorageKeys: []common.Hash{common.HexToHash(test_helpers.IndexOne)},
+			}},
+		},
+		&types.DynamicFeeTx{
+			ChainID:   chainID,
+			Nonce:     2,
+			GasTipCap: baseFee,
+			GasFeeCap: new(big.Int).Add(baseFee, big.NewInt(300)),
+			Gas:       params.TxGas,
+			To:        &to,
+			Value:     big.NewInt(1),
+		},
+		&types.DynamicFeeTx{
+			ChainID:   chainID,
+			Nonce:     3,
+			GasTipCap: big.NewInt(100),
+			GasFeeCap: new(big.Int).Mul(baseFee, big.NewInt(3)),
+			Gas:       params.TxGas,
+			To:        &to,
+			Value:     big.NewInt(1),
+		},
+	}
+	signer := types.NewLondonSigner(chainID)
+	txs := make(types.Transactions, len(txData))
+	receipts := make(types.Receipts, len(txData))
+	var gasUsed uint64
+	for i, data := range txData {
+		tx, err := types.SignNewTx(test_helpers.TestBankKey, signer, data)
+		Expect(err).ToNot(HaveOccurred())
+		txs[i] = tx
+		gasUsed += tx.Gas()
+		receipts[i] = &types.Receipt{
+			Type:              tx.Type(),
+			Status:            types.ReceiptStatusSuccessful,
+			CumulativeGasUsed: gasUsed,
+			GasUsed:           tx.Gas(),
+			Logs:              []*types.Log{},
+			TxHash:            tx.Hash(),
+		}
+	}
+	return types.NewBlock(header, txs, []*types.Header{ommer}, receipts, new(trie.Trie)), receipts
+}
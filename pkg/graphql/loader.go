@@ -0,0 +1,436 @@
+// VulcanizeDB
+// Copyright © 2022 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package graphql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"runtime/debug"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/sirupsen/logrus"
+
+	"github.com/vulcanize/ipld-eth-server/pkg/eth"
+)
+
+const (
+	// loaderWait is the time a batch collects keys before being fetched
+	loaderWait = time.Millisecond
+	// loaderMaxBatch is the number of keys fetched at most by a single batch
+	loaderMaxBatch = 100
+)
+
+// batchFetch fetches the values of the keys of a batch, keys without a value are left out of the returned map
+type batchFetch func(keys []common.Hash) (map[common.Hash]interface{}, error)
+
+// errLoadAborted fails the loads of the keys whose fetch panicked
+var errLoadAborted = errors.New("load aborted")
+
+// loadResult is the value loaded for a key, available once done is closed
+type loadResult struct {
+	done  chan struct{}
+	value interface{}
+	err   error
+}
+
+// batchLoader collects the keys loaded concurrently by the resolvers of a request into batches fetched with a single
+// query, and caches their results for the rest of the request
+type batchLoader struct {
+	fetch    batchFetch
+	wait     time.Duration
+	maxBatch int
+
+	mu      sync.Mutex
+	results map[common.Hash]*loadResult
+	batch   *loadBatch
+}
+
+func newBatchLoader(fetch batchFetch, wait time.Duration, maxBatch int) *batchLoader {
+	return &batchLoader{
+		fetch:    fetch,
+		wait:     wait,
+		maxBatch: maxBatch,
+		results:  make(map[common.Hash]*loadResult),
+	}
+}
+
+// load returns the value of the key, nil if it has none
+func (l *batchLoader) load(ctx context.Context, key common.Hash) (interface{}, error) {
+	values, err := l.loadMany(ctx, []common.Hash{key})
+	if err != nil {
+		return nil, err
+	}
+	return values[0], nil
+}
+
+// loadMany returns the values of the keys in their order, nil for the keys without one
+func (l *batchLoader) loadMany(ctx context.Context, keys []common.Hash) ([]interface{}, error) {
+	results := make([]*loadResult, len(keys))
+	l.mu.Lock()
+	for i, key := range keys {
+		res, ok := l.results[key]
+		if !ok {
+			res = &loadResult{done: make(chan struct{})}
+			l.results[key] = res
+			l.enqueue(key, res)
+		}
+		results[i] = res
+	}
+	l.mu.Unlock()
+
+	values := make([]interface{}, len(keys))
+	for i, res := range results {
+		select {
+		case <-res.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if res.err != nil {
+			return nil, res.err
+		}
+		values[i] = res.value
+	}
+	return values, nil
+}
+
+// loadBatch is the set of keys fetched together
+type loadBatch struct {
+	results map[common.Hash]*loadResult
+}
+
+// enqueue adds the key to the pending batch, starting a new batch if there is none and dispatching it once full or
+// once its wait is over. It must be called with the lock held.
+func (l *batchLoader) enqueue(key common.Hash, res *loadResult) {
+	if l.batch == nil {
+		batch := &loadBatch{results: make(map[common.Hash]*loadResult)}
+		l.batch = batch
+		time.AfterFunc(l.wait, func() {
+			l.mu.Lock()
+			pending := l.batch == batch
+			if pending {
+				l.batch = nil
+			}
+			l.mu.Unlock()
+			if pending {
+				l.dispatch(batch)
+			}
+		})
+	}
+	l.batch.results[key] = res
+	if len(l.batch.results) >= l.maxBatch {
+		batch := l.batch
+		l.batch = nil
+		go l.dispatch(batch)
+	}
+}
+
+// dispatch fetches the keys of the batch and completes their results. It runs on a goroutine of its own, so a panic of
+// fetch is recovered and fails the loads of the batch with errLoadAborted.
+func (l *batchLoader) dispatch(batch *loadBatch) {
+	keys := make([]common.Hash, 0, len(batch.results))
+	for key, res := range batch.results {
+		keys = append(keys, key)
+		res.err = errLoadAborted
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			logrus.Errorf("graphql batch loader fetch of %d keys panicked: %v\n%s", len(keys), r, debug.Stack())
+		}
+		for _, res := range batch.results {
+			close(res.done)
+		}
+	}()
+	values, err := l.fetch(keys)
+	for key, res := range batch.results {
+		res.value, res.err = values[key], err
+	}
+}
+
+// memoLoader caches the value loaded for each key, so that the resolvers of a request loading the same key share a
+// single lookup
+type memoLoader struct {
+	mu      sync.Mutex
+	results map[string]*loadResult
+}
+
+func newMemoLoader() *memoLoader {
+	return &memoLoader{results: make(map[string]*loadResult)}
+}
+
+// load returns the value of the key, fetching it unless it is already loaded or being loaded
+func (m *memoLoader) load(ctx context.Context, key string, fetch func() (interface{}, error)) (interface{}, error) {
+	m.mu.Lock()
+	res, ok := m.results[key]
+	if !ok {
+		res = &loadResult{done: make(chan struct{})}
+		m.results[key] = res
+	}
+	m.mu.Unlock()
+	if !ok {
+		// the waiters are released with errLoadAborted if fetch panics
+		res.err = errLoadAborted
+		func() {
+			defer close(res.done)
+			res.value, res.err = fetch()
+		}()
+	}
+	select {
+	case <-res.done:
+		return res.value, res.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// txEntry is a canonical transaction with its position in the chain and its IPLD block
+type txEntry struct {
+	tx        *types.Transaction
+	blockHash common.Hash
+	index     uint64
+	cid       string
+	ipldBlock []byte
+}
+
+// receiptEntry is a receipt with the IPLD block of its receipt trie leaf node
+type receiptEntry struct {
+	receipt   *types.Receipt
+	cid       string
+	ipldBlock []byte
+}
+
+// headerEntry is a header with its IPLD block
+type headerEntry struct {
+	header    *types.Header
+	cid       string
+	ipldBlock []byte
+}
+
+// accountEntry is the state leaf node of an account, with the account it holds
+type accountEntry struct {
+	account   *types.StateAccount
+	cid       string
+	ipldBlock []byte
+}
+
+// loaders batch and cache the backend lookups of the resolvers of a request
+type loaders struct {
+	transactions    *batchLoader // *txEntry by tx hash
+	receipts        *batchLoader // *receiptEntry by tx hash
+	headers         *batchLoader // *headerEntry by block hash
+	headersByNumber *memoLoader  // *types.Header by block number or hash
+	stateRoots      *memoLoader  // common.Hash state root by block number or hash
+
+	// retrieveAccounts returns the cids and state leaf nodes of the accounts existing at the block, with their leaf keys
+	retrieveAccounts func(leafKeys []common.Hash, blockHash common.Hash) ([]string, [][]byte, []common.Hash, error)
+	accountsMu       sync.Mutex
+	accounts         map[common.Hash]*batchLoader // *accountEntry by leaf key, by block hash
+}
+
+func newLoaders(backend *eth.Backend) *loaders {
+	return &loaders{
+		retrieveAccounts: func(leafKeys []common.Hash, blockHash common.Hash) ([]string, [][]byte, []common.Hash, error) {
+			return backend.IPLDRetriever.RetrieveAccountsByLeafKeysAndBlockHash(leafKeys, blockHash)
+		},
+		accounts: make(map[common.Hash]*batchLoader),
+		transactions: newBatchLoader(func(hashes []common.Hash) (map[common.Hash]interface{}, error) {
+			cids, txBytes, blockHashes, indexes, err := backend.IPLDRetriever.RetrieveTransactionsByHashes(hashes)
+			if err != nil {
+				return nil, err
+			}
+			entries := make(map[common.Hash]interface{}, len(txBytes))
+			for i, bytes := range txBytes {
+				tx := new(types.Transaction)
+				if err := tx.UnmarshalBinary(bytes); err != nil {
+					return nil, err
+				}
+				entries[tx.Hash()] = &txEntry{tx: tx, blockHash: blockHashes[i], index: indexes[i], cid: cids[i], ipldBlock: bytes}
+			}
+			return entries, nil
+		}, loaderWait, loaderMaxBatch),
+		receipts: newBatchLoader(func(hashes []common.Hash) (map[common.Hash]interface{}, error) {
+			cids, leafNodes, rctBytes, txHashes, err := backend.IPLDRetriever.RetrieveReceiptsByTxHashes(hashes)
+			if err != nil {
+				return nil, err
+			}
+			rcts := make(map[common.Hash]interface{}, len(rctBytes))
+			for i, bytes := range rctBytes {
+				rct := new(types.Receipt)
+				if err := rct.UnmarshalBinary(bytes); err != nil {
+					return nil, err
+				}
+				rct.TxHash = txHashes[i]
+				rcts[rct.TxHash] = &receiptEntry{receipt: rct, cid: cids[i], ipldBlock: leafNodes[i]}
+			}
+			return rcts, nil
+		}, loaderWait, loaderMaxBatch),
+		headers: newBatchLoader(func(hashes []common.Hash) (map[common.Hash]interface{}, error) {
+			cids, headerRLPs, err := backend.IPLDRetriever.RetrieveHeadersByHashes(hashes)
+			if err != nil {
+				return nil, err
+			}
+			headers := make(map[common.Hash]interface{}, len(headerRLPs))
+			for i, headerRLP := range headerRLPs {
+				header := new(types.Header)
+				if err := rlp.DecodeBytes(headerRLP, header); err != nil {
+					return nil, err
+				}
+				headers[header.Hash()] = &headerEntry{header: header, cid: cids[i], ipldBlock: headerRLP}
+			}
+			return headers, nil
+		}, loaderWait, loaderMaxBatch),
+		headersByNumber: newMemoLoader(),
+		stateRoots:      newMemoLoader(),
+	}
+}
+
+// numberOrHashKey returns the key of the block number or hash in the memo loaders
+func numberOrHashKey(blockNrOrHash rpc.BlockNumberOrHash) string {
+	return blockNrOrHash.String() + ":" + strconv.FormatBool(blockNrOrHash.RequireCanonical)
+}
+
+type loadersKey struct{}
+
+// withLoaders returns the context of a request with a new set of loaders for its resolvers
+func withLoaders(ctx context.Context, backend *eth.Backend) context.Context {
+	return context.WithValue(ctx, loadersKey{}, newLoaders(backend))
+}
+
+// loadersFrom returns the loaders of the request, nil if it has none and its resolvers must hit the backend directly
+func loadersFrom(ctx context.Context) *loaders {
+	l, _ := ctx.Value(loadersKey{}).(*loaders)
+	return l
+}
+
+// transaction returns the canonical transaction with the hash, nil if there is none
+func (l *loaders) transaction(ctx context.Context, hash common.Hash) (*txEntry, error) {
+	v, err := l.transactions.load(ctx, hash)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	return v.(*txEntry), nil
+}
+
+// receipt returns the receipt of the canonical transaction with the hash, nil if there is none
+func (l *loaders) receipt(ctx context.Context, hash common.Hash) (*receiptEntry, error) {
+	v, err := l.receipts.load(ctx, hash)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	return v.(*receiptEntry), nil
+}
+
+// receiptsOf returns the receipts of the transactions in their order
+func (l *loaders) receiptsOf(ctx context.Context, txs types.Transactions) ([]*types.Receipt, error) {
+	hashes := make([]common.Hash, len(txs))
+	for i, tx := range txs {
+		hashes[i] = tx.Hash()
+	}
+	values, err := l.receipts.loadMany(ctx, hashes)
+	if err != nil {
+		return nil, err
+	}
+	rcts := make([]*types.Receipt, len(values))
+	for i, v := range values {
+		if v == nil {
+			return nil, sql.ErrNoRows
+		}
+		rcts[i] = v.(*receiptEntry).receipt
+	}
+	return rcts, nil
+}
+
+// header returns the header with the hash, failing as the backend does when there is none
+func (l *loaders) header(ctx context.Context, hash common.Hash) (*headerEntry, error) {
+	v, err := l.headers.load(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return nil, sql.ErrNoRows
+	}
+	return v.(*headerEntry), nil
+}
+
+// headerByNumberOrHash returns the header for the block number or hash
+func (l *loaders) headerByNumberOrHash(ctx context.Context, backend *eth.Backend, blockNrOrHash rpc.BlockNumberOrHash) (*types.Header, error) {
+	v, err := l.headersByNumber.load(ctx, numberOrHashKey(blockNrOrHash), func() (interface{}, error) {
+		return backend.HeaderByNumberOrHash(ctx, blockNrOrHash)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*types.Header), nil
+}
+
+// stateRoot returns the root of the state at the block number or hash
+func (l *loaders) stateRoot(ctx context.Context, backend *eth.Backend, blockNrOrHash rpc.BlockNumberOrHash) (common.Hash, error) {
+	v, err := l.stateRoots.load(ctx, numberOrHashKey(blockNrOrHash), func() (interface{}, error) {
+		_, header, err := backend.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+		if err != nil {
+			return nil, err
+		}
+		return header.Root, nil
+	})
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return v.(common.Hash), nil
+}
+
+// account returns the state leaf node of the account at the block with the hash, with the account it holds, nil if it
+// does not exist
+func (l *loaders) account(ctx context.Context, blockHash common.Hash, address common.Address) (*accountEntry, error) {
+	l.accountsMu.Lock()
+	accounts, ok := l.accounts[blockHash]
+	if !ok {
+		accounts = newBatchLoader(func(leafKeys []common.Hash) (map[common.Hash]interface{}, error) {
+			cids, leafNodes, keys, err := l.retrieveAccounts(leafKeys, blockHash)
+			if err != nil {
+				return nil, err
+			}
+			entries := make(map[common.Hash]interface{}, len(keys))
+			for i, key := range keys {
+				accountRLP, err := eth.DecodeLeafNode(leafNodes[i])
+				if err != nil {
+					return nil, err
+				}
+				account := new(types.StateAccount)
+				if err := rlp.DecodeBytes(accountRLP, account); err != nil {
+					return nil, err
+				}
+				entries[key] = &accountEntry{account: account, cid: cids[i], ipldBlock: leafNodes[i]}
+			}
+			return entries, nil
+		}, loaderWait, loaderMaxBatch)
+		l.accounts[blockHash] = accounts
+	}
+	l.accountsMu.Unlock()
+
+	v, err := accounts.load(ctx, crypto.Keccak256Hash(address.Bytes()))
+	if err != nil || v == nil {
+		return nil, err
+	}
+	return v.(*accountEntry), nil
+}
@@ -0,0 +1,276 @@
+// VulcanizeDB
+// Copyright © 2022 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package graphql_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/ipld-eth-server/pkg/graphql"
+)
+
+var _ = Describe("Batch loader", func() {
+	var (
+		mu      sync.Mutex
+		batches [][]common.Hash
+		fail    error
+		fetch   func(keys []common.Hash) (map[common.Hash]interface{}, error)
+	)
+
+	BeforeEach(func() {
+		batches = nil
+		fail = nil
+		// every key but the zero hash has its own bytes as value
+		fetch = func(keys []common.Hash) (map[common.Hash]interface{}, error) {
+			mu.Lock()
+			batches = append(batches, keys)
+			mu.Unlock()
+			if fail != nil {
+				return nil, fail
+			}
+			values := make(map[common.Hash]interface{})
+			for _, key := range keys {
+				if key != (common.Hash{}) {
+					values[key] = key.Bytes()
+				}
+			}
+			return values, nil
+		}
+	})
+
+	fetched := func() [][]common.Hash {
+		mu.Lock()
+		defer mu.Unlock()
+		return batches
+	}
+
+	It("fetches the keys loaded concurrently in a single batch", func() {
+		loader := graphql.NewBatchLoader(fetch, 10*time.Millisecond, 100)
+		keys := []common.Hash{common.HexToHash("0x1"), common.HexToHash("0x2"), common.HexToHash("0x1")}
+		var wg sync.WaitGroup
+		for _, key := range keys {
+			wg.Add(1)
+			go func(key common.Hash) {
+				defer GinkgoRecover()
+				defer wg.Done()
+				value, err := loader.Load(context.Background(), key)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(value).To(Equal(key.Bytes()))
+			}(key)
+		}
+		wg.Wait()
+		Expect(fetched()).To(HaveLen(1))
+		Expect(fetched()[0]).To(ConsistOf(keys[0], keys[1]))
+	})
+
+	It("caches the loaded values", func() {
+		loader := graphql.NewBatchLoader(fetch, time.Millisecond, 100)
+		key := common.HexToHash("0x1")
+		_, err := loader.Load(context.Background(), key)
+		Expect(err).ToNot(HaveOccurred())
+		values, err := loader.LoadMany(context.Background(), []common.Hash{key, common.HexToHash("0x2")})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(values).To(Equal([]interface{}{key.Bytes(), common.HexToHash("0x2").Bytes()}))
+		Expect(fetched()).To(HaveLen(2))
+		Expect(fetched()[1]).To(Equal([]common.Hash{common.HexToHash("0x2")}))
+	})
+
+	It("returns nil for the keys without a value", func() {
+		loader := graphql.NewBatchLoader(fetch, time.Millisecond, 100)
+		values, err := loader.LoadMany(context.Background(), []common.Hash{{}, common.HexToHash("0x1")})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(values[0]).To(BeNil())
+		Expect(values[1]).To(Equal(common.HexToHash("0x1").Bytes()))
+	})
+
+	It("splits the batches at the maximum batch size", func() {
+		loader := graphql.NewBatchLoader(fetch, time.Hour, 2)
+		keys := []common.Hash{common.HexToHash("0x1"), common.HexToHash("0x2"), common.HexToHash("0x3"), common.HexToHash("0x4")}
+		_, err := loader.LoadMany(context.Background(), keys)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fetched()).To(HaveLen(2))
+		Expect(fetched()[0]).To(HaveLen(2))
+		Expect(fetched()[1]).To(HaveLen(2))
+	})
+
+	It("fails the loads of a failed batch", func() {
+		fail = errors.New("fetch failed")
+		loader := graphql.NewBatchLoader(fetch, time.Millisecond, 100)
+		_, err := loader.Load(context.Background(), common.HexToHash("0x1"))
+		Expect(err).To(MatchError("fetch failed"))
+	})
+
+	It("fails the loads of a batch whose fetch panicked", func() {
+		loader := graphql.NewBatchLoader(func(keys []common.Hash) (map[common.Hash]interface{}, error) {
+			panic("boom")
+		}, time.Millisecond, 100)
+		_, err := loader.LoadMany(context.Background(), []common.Hash{common.HexToHash("0x1"), common.HexToHash("0x2")})
+		Expect(err).To(MatchError("load aborted"))
+		_, err = loader.Load(context.Background(), common.HexToHash("0x2"))
+		Expect(err).To(MatchError("load aborted"))
+	})
+
+	It("fails the loads of a full batch whose fetch panicked", func() {
+		loader := graphql.NewBatchLoader(func(keys []common.Hash) (map[common.Hash]interface{}, error) {
+			panic("boom")
+		}, time.Hour, 1)
+		_, err := loader.Load(context.Background(), common.HexToHash("0x1"))
+		Expect(err).To(MatchError("load aborted"))
+	})
+
+	It("stops waiting once the context is done", func() {
+		loader := graphql.NewBatchLoader(fetch, time.Hour, 100)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := loader.Load(ctx, common.HexToHash("0x1"))
+		Expect(err).To(MatchError(context.Canceled))
+	})
+})
+
+var _ = Describe("Memo loader", func() {
+	It("shares the value fetched for a key", func() {
+		loader := graphql.NewMemoLoader()
+		fetches := 0
+		fetch := func() (interface{}, error) {
+			fetches++
+			return "value", nil
+		}
+		Expect(loader.Load(context.Background(), "key", fetch)).To(Equal("value"))
+		Expect(loader.Load(context.Background(), "key", fetch)).To(Equal("value"))
+		Expect(fetches).To(Equal(1))
+	})
+
+	It("releases the loads waiting on a fetch which panicked", func() {
+		loader := graphql.NewMemoLoader()
+		fetching := make(chan struct{})
+		release := make(chan struct{})
+		go func() {
+			defer GinkgoRecover()
+			defer func() {
+				Expect(recover()).To(Equal("boom"))
+			}()
+			loader.Load(context.Background(), "key", func() (interface{}, error) {
+				close(fetching)
+				<-release
+				panic("boom")
+			})
+		}()
+		<-fetching
+		errs := make(chan error, 1)
+		go func() {
+			_, err := loader.Load(context.Background(), "key", func() (interface{}, error) {
+				return "value", nil
+			})
+			errs <- err
+		}()
+		close(release)
+		Eventually(errs).Should(Receive(MatchError("load aborted")))
+	})
+})
+
+var _ = Describe("Account loader", func() {
+	var (
+		header   = &types.Header{Number: big.NewInt(1)}
+		eoa      = common.HexToAddress("0x0000000000000000000000000000000000000001")
+		contract = common.HexToAddress("0x0000000000000000000000000000000000000002")
+		missing  = common.HexToAddress("0x0000000000000000000000000000000000000003")
+		code     = []byte{0x60, 0x00, 0x60, 0x00, 0xf3}
+	)
+
+	leaf := func(address common.Address, account types.StateAccount) []byte {
+		accountRLP, err := rlp.EncodeToBytes(account)
+		Expect(err).ToNot(HaveOccurred())
+		key := crypto.Keccak256Hash(address.Bytes())
+		node, err := rlp.EncodeToBytes([]interface{}{append([]byte{0x20}, key.Bytes()...), accountRLP})
+		Expect(err).ToNot(HaveOccurred())
+		return node
+	}
+
+	It("resolves the fields of the accounts of a block from a single batch of leaf nodes", func() {
+		leaves := map[common.Hash][]byte{
+			crypto.Keccak256Hash(eoa.Bytes()): leaf(eoa, types.StateAccount{
+				Nonce:    7,
+				Balance:  big.NewInt(1000),
+				Root:     types.EmptyRootHash,
+				CodeHash: crypto.Keccak256(nil),
+			}),
+			crypto.Keccak256Hash(contract.Bytes()): leaf(contract, types.StateAccount{
+				Nonce:    1,
+				Balance:  big.NewInt(0),
+				Root:     types.EmptyRootHash,
+				CodeHash: crypto.Keccak256(code),
+			}),
+		}
+		ctx, block, lookups := graphql.NewMockAccountsBlock(header, leaves, [][]byte{code})
+		accounts, err := block.Accounts(ctx, struct{ Addresses []common.Address }{
+			Addresses: []common.Address{eoa, contract, missing},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(accounts).To(HaveLen(3))
+
+		type fields struct {
+			balance hexutil.Big
+			nonce   hexutil.Uint64
+			code    hexutil.Bytes
+			cid     *string
+		}
+		results := make([]fields, len(accounts))
+		var wg sync.WaitGroup
+		for i, account := range accounts {
+			wg.Add(1)
+			go func(i int, account *graphql.Account) {
+				defer wg.Done()
+				defer GinkgoRecover()
+				var err error
+				results[i].balance, err = account.Balance(ctx)
+				Expect(err).ToNot(HaveOccurred())
+				results[i].nonce, err = account.TransactionCount(ctx)
+				Expect(err).ToNot(HaveOccurred())
+				results[i].code, err = account.Code(ctx)
+				Expect(err).ToNot(HaveOccurred())
+				results[i].cid, err = account.Cid(ctx)
+				Expect(err).ToNot(HaveOccurred())
+			}(i, account)
+		}
+		wg.Wait()
+		Expect(lookups()).To(Equal(1))
+
+		Expect(results[0].balance.ToInt()).To(Equal(big.NewInt(1000)))
+		Expect(results[0].nonce).To(Equal(hexutil.Uint64(7)))
+		Expect(results[0].code).To(BeEmpty())
+		Expect(*results[0].cid).To(Equal("cid-" + crypto.Keccak256Hash(eoa.Bytes()).Hex()))
+
+		Expect(results[1].nonce).To(Equal(hexutil.Uint64(1)))
+		Expect(results[1].code).To(Equal(hexutil.Bytes(code)))
+
+		Expect(results[2].balance.ToInt().Sign()).To(BeZero())
+		Expect(results[2].nonce).To(BeZero())
+		Expect(results[2].code).To(BeEmpty())
+		Expect(results[2].cid).To(BeNil())
+	})
+})
@@ -0,0 +1,137 @@
+// VulcanizeDB
+// Copyright © 2022 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package graphql_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/ipld-eth-server/pkg/graphql"
+)
+
+// mockProxy records the raw transactions sent to it
+type mockProxy struct {
+	mu  sync.Mutex
+	txs []hexutil.Bytes
+}
+
+func (m *mockProxy) SendRawTransaction(_ context.Context, data hexutil.Bytes) (common.Hash, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.txs = append(m.txs, data)
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(data); err != nil {
+		return common.Hash{}, err
+	}
+	return tx.Hash(), nil
+}
+
+var _ = Describe("sendRawTransaction", func() {
+	const mutation = `mutation($data: Bytes!) { sendRawTransaction(data: $data) }`
+
+	var (
+		proxy   *mockProxy
+		handler http.Handler
+	)
+
+	BeforeEach(func() {
+		proxy = new(mockProxy)
+		server := rpc.NewServer()
+		Expect(server.RegisterName("eth", proxy)).To(Succeed())
+		var err error
+		handler, err = graphql.NewMockChainHandler(params.TestChainConfig, rpc.DialInProc(server), nil)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	signedTx := func(chainID *big.Int) hexutil.Bytes {
+		key, err := crypto.GenerateKey()
+		Expect(err).ToNot(HaveOccurred())
+		tx, err := types.SignNewTx(key, types.LatestSignerForChainID(chainID), &types.DynamicFeeTx{
+			ChainID:   chainID,
+			Nonce:     1,
+			GasTipCap: big.NewInt(1),
+			GasFeeCap: big.NewInt(100),
+			Gas:       21000,
+			To:        &common.Address{},
+			Value:     big.NewInt(1),
+		})
+		Expect(err).ToNot(HaveOccurred())
+		data, err := tx.MarshalBinary()
+		Expect(err).ToNot(HaveOccurred())
+		return data
+	}
+
+	send := func(h http.Handler, data hexutil.Bytes) map[string]interface{} {
+		body, err := json.Marshal(map[string]interface{}{"query": mutation, "variables": map[string]interface{}{"data": data}})
+		Expect(err).ToNot(HaveOccurred())
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewReader(body)))
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		var res map[string]interface{}
+		Expect(json.Unmarshal(rec.Body.Bytes(), &res)).To(Succeed())
+		return res
+	}
+
+	errorMessage := func(res map[string]interface{}) string {
+		Expect(res).To(HaveKey("errors"))
+		return res["errors"].([]interface{})[0].(map[string]interface{})["message"].(string)
+	}
+
+	It("forwards the transaction to the proxied node and returns its hash", func() {
+		data := signedTx(params.TestChainConfig.ChainID)
+		tx := new(types.Transaction)
+		Expect(tx.UnmarshalBinary(data)).To(Succeed())
+
+		res := send(handler, data)
+		Expect(res).ToNot(HaveKey("errors"))
+		Expect(res["data"]).To(Equal(map[string]interface{}{"sendRawTransaction": tx.Hash().Hex()}))
+		Expect(proxy.txs).To(Equal([]hexutil.Bytes{data}))
+	})
+
+	It("rejects the transactions signed for another chain", func() {
+		res := send(handler, signedTx(big.NewInt(5)))
+		Expect(errorMessage(res)).To(Equal(types.ErrInvalidChainId.Error()))
+		Expect(proxy.txs).To(BeEmpty())
+	})
+
+	It("rejects the data which does not decode into a transaction", func() {
+		res := send(handler, hexutil.Bytes{0x01, 0x02})
+		Expect(errorMessage(res)).ToNot(BeEmpty())
+		Expect(proxy.txs).To(BeEmpty())
+	})
+
+	It("fails without a proxied node", func() {
+		h, err := graphql.NewMockChainHandler(params.TestChainConfig, nil, nil)
+		Expect(err).ToNot(HaveOccurred())
+		res := send(h, signedTx(params.TestChainConfig.ChainID))
+		Expect(errorMessage(res)).To(Equal("no proxied node to forward the transaction to"))
+	})
+})
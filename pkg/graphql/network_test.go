@@ -0,0 +1,164 @@
+// VulcanizeDB
+// Copyright © 2022 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package graphql_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/statediff"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/ipld-eth-server/pkg/eth"
+	"github.com/vulcanize/ipld-eth-server/pkg/gapfill"
+	"github.com/vulcanize/ipld-eth-server/pkg/graphql"
+)
+
+// gapFinder is an index missing the heights of its gaps
+type gapFinder struct {
+	head uint64
+	gaps []gapfill.Range
+}
+
+func (f *gapFinder) LastBlockNumber() (uint64, error) {
+	return f.head, nil
+}
+
+func (f *gapFinder) MissingRanges(start, end uint64) ([]gapfill.Range, error) {
+	var ranges []gapfill.Range
+	for _, r := range f.gaps {
+		if r.Start >= start && r.End <= end {
+			ranges = append(ranges, r)
+		}
+	}
+	return ranges, nil
+}
+
+func (f *gapFinder) NonCanonicalOnlyHeights(start, end uint64) ([]uint64, error) {
+	return nil, nil
+}
+
+// blockingStateDiffAPI is a proxy node whose writes wait to be released
+type blockingStateDiffAPI struct {
+	release chan struct{}
+}
+
+func (api *blockingStateDiffAPI) WriteStateDiffAt(height uint64, params statediff.Params) error {
+	<-api.release
+	return nil
+}
+
+var _ = Describe("Network metadata", func() {
+	query := func(h http.Handler, q string) map[string]interface{} {
+		body, err := json.Marshal(map[string]interface{}{"query": q})
+		Expect(err).ToNot(HaveOccurred())
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewReader(body)))
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		var res map[string]interface{}
+		Expect(json.Unmarshal(rec.Body.Bytes(), &res)).To(Succeed())
+		Expect(res).ToNot(HaveKey("errors"))
+		return res["data"].(map[string]interface{})
+	}
+
+	It("returns the chain ID of the configured chain", func() {
+		handler, err := graphql.NewMockChainHandler(params.RinkebyChainConfig, nil, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(query(handler, `{ chainID }`)).To(Equal(map[string]interface{}{"chainID": "0x4"}))
+	})
+
+	It("is not syncing without a gap filler", func() {
+		handler, err := graphql.NewMockChainHandler(params.TestChainConfig, nil, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(query(handler, `{ syncing { currentBlock } }`)).To(Equal(map[string]interface{}{"syncing": nil}))
+	})
+
+	It("reports the progress of the gap filler while it fills the gaps", func() {
+		api := &blockingStateDiffAPI{release: make(chan struct{})}
+		server := rpc.NewServer()
+		Expect(server.RegisterName("statediff", api)).To(Succeed())
+		client := rpc.DialInProc(server)
+		defer client.Close()
+		finder := &gapFinder{head: 20, gaps: []gapfill.Range{{Start: 7, End: 8}}}
+		writer, err := eth.NewStateDiffWriter(client, eth.StateDiffWriterConfig{Timeout: time.Second})
+		Expect(err).ToNot(HaveOccurred())
+		defer writer.Stop()
+		gapFiller, err := gapfill.NewScheduler(finder, writer, gapfill.Config{Start: 5, Workers: 1, Interval: time.Hour, Timeout: time.Second})
+		Expect(err).ToNot(HaveOccurred())
+		wg := new(sync.WaitGroup)
+		gapFiller.Start(wg)
+		defer func() {
+			gapFiller.Stop()
+			wg.Wait()
+		}()
+		handler, err := graphql.NewMockChainHandler(params.TestChainConfig, nil, gapFiller)
+		Expect(err).ToNot(HaveOccurred())
+
+		const q = `{ syncing { startingBlock currentBlock highestBlock } }`
+		Eventually(func() map[string]interface{} { return query(handler, q) }).Should(Equal(map[string]interface{}{
+			"syncing": map[string]interface{}{"startingBlock": "0x5", "currentBlock": "0x5", "highestBlock": "0x14"},
+		}))
+
+		api.release <- struct{}{}
+		Eventually(func() map[string]interface{} { return query(handler, q) }).Should(Equal(map[string]interface{}{
+			"syncing": map[string]interface{}{"startingBlock": "0x5", "currentBlock": "0x7", "highestBlock": "0x14"},
+		}))
+
+		api.release <- struct{}{}
+		Eventually(func() map[string]interface{} { return query(handler, q) }).Should(Equal(map[string]interface{}{"syncing": nil}))
+	})
+
+	It("keeps syncing while the heights skipped by the last scan are left to fill", func() {
+		api := &blockingStateDiffAPI{release: make(chan struct{})}
+		server := rpc.NewServer()
+		Expect(server.RegisterName("statediff", api)).To(Succeed())
+		client := rpc.DialInProc(server)
+		defer client.Close()
+		finder := &gapFinder{head: 20, gaps: []gapfill.Range{{Start: 7, End: 8}}}
+		writer, err := eth.NewStateDiffWriter(client, eth.StateDiffWriterConfig{Timeout: time.Second})
+		Expect(err).ToNot(HaveOccurred())
+		defer writer.Stop()
+		gapFiller, err := gapfill.NewScheduler(finder, writer, gapfill.Config{Start: 5, Workers: 1, QueueSize: 1, Interval: time.Hour, Timeout: time.Second})
+		Expect(err).ToNot(HaveOccurred())
+		wg := new(sync.WaitGroup)
+		gapFiller.Start(wg)
+		defer func() {
+			gapFiller.Stop()
+			wg.Wait()
+		}()
+		handler, err := graphql.NewMockChainHandler(params.TestChainConfig, nil, gapFiller)
+		Expect(err).ToNot(HaveOccurred())
+
+		const q = `{ syncing { currentBlock } }`
+		Eventually(func() uint64 { return gapFiller.Status().Skipped }).Should(Equal(uint64(1)))
+		api.release <- struct{}{}
+		Eventually(func() uint64 { return gapFiller.Status().Filled }).Should(Equal(uint64(1)))
+		Expect(query(handler, q)).To(Equal(map[string]interface{}{"syncing": map[string]interface{}{"currentBlock": "0x7"}}))
+
+		finder.gaps = []gapfill.Range{{Start: 8, End: 8}}
+		gapFiller.Scan()
+		api.release <- struct{}{}
+		Eventually(func() map[string]interface{} { return query(handler, q) }).Should(Equal(map[string]interface{}{"syncing": nil}))
+	})
+})
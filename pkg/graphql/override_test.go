@@ -0,0 +1,76 @@
+// VulcanizeDB
+// Copyright © 2022 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package graphql_test
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/ipld-eth-server/pkg/eth"
+	"github.com/vulcanize/ipld-eth-server/pkg/graphql"
+)
+
+var _ = Describe("Account overrides", func() {
+	var (
+		address = common.HexToAddress("0x0000000000000000000000000000000000000001")
+		slot    = common.HexToHash("0x01")
+		value   = common.HexToHash("0x02")
+	)
+
+	It("converts the overrides into a state override", func() {
+		nonce := hexutil.Uint64(7)
+		code := hexutil.Bytes{0x60, 0x00}
+		balance := (*hexutil.Big)(big.NewInt(100))
+		slots := []graphql.StorageSlot{{Slot: slot, Value: value}}
+		other := common.HexToAddress("0x0000000000000000000000000000000000000002")
+
+		diff, err := graphql.StateOverride(&[]graphql.AccountOverride{
+			{Address: address, Nonce: &nonce, Code: &code, Balance: balance, State: &slots},
+			{Address: other, StateDiff: &slots},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(*diff).To(HaveLen(2))
+
+		account := (*diff)[address]
+		Expect(*account.Nonce).To(Equal(nonce))
+		Expect(*account.Code).To(Equal(code))
+		Expect(*account.Balance).To(Equal(balance))
+		Expect(*account.State).To(Equal(map[common.Hash]common.Hash{slot: value}))
+		Expect(account.StateDiff).To(BeNil())
+
+		account = (*diff)[other]
+		Expect(account.Nonce).To(BeNil())
+		Expect(account.Balance).To(BeNil())
+		Expect(account.State).To(BeNil())
+		Expect(*account.StateDiff).To(Equal(map[common.Hash]common.Hash{slot: value}))
+	})
+
+	It("leaves the state untouched without overrides", func() {
+		diff, err := graphql.StateOverride(nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(diff).To(Equal((*eth.StateOverride)(nil)))
+	})
+
+	It("rejects the accounts overridden more than once", func() {
+		_, err := graphql.StateOverride(&[]graphql.AccountOverride{{Address: address}, {Address: address}})
+		Expect(err).To(MatchError("account " + address.Hex() + " is overridden more than once"))
+	})
+})
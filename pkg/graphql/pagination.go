@@ -0,0 +1,415 @@
+// VulcanizeDB
+// Copyright © 2022 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package graphql
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/vulcanize/ipld-eth-server/pkg/eth"
+)
+
+// Maximum page sizes of the connections, which are also their default page sizes
+const (
+	maxBlocksPageSize       = 100
+	maxTransactionsPageSize = 1000
+	maxLogsPageSize         = 1000
+)
+
+// Kinds of cursors, a cursor can only be used with the connection which issued it
+const (
+	blocksCursor       = "blocks"
+	transactionsCursor = "transactions"
+	logsCursor         = "logs"
+)
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// encodeCursor returns the opaque cursor of the item at the provided keyset position
+func encodeCursor(kind string, keys ...int64) string {
+	cursor := kind
+	for _, key := range keys {
+		cursor += ":" + strconv.FormatInt(key, 10)
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(cursor))
+}
+
+// decodeCursor returns the keyset position encoded in the cursor, which must be of the provided kind
+func decodeCursor(cursor, kind string, n int) ([]int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+	parts := strings.Split(string(raw), ":")
+	if len(parts) != n+1 || parts[0] != kind {
+		return nil, errInvalidCursor
+	}
+	keys := make([]int64, n)
+	for i, part := range parts[1:] {
+		if keys[i], err = strconv.ParseInt(part, 10, 64); err != nil {
+			return nil, errInvalidCursor
+		}
+	}
+	return keys, nil
+}
+
+// pageSize returns the number of items requested, which defaults to and may not exceed the maximum
+func pageSize(first *int32, max int) (int, error) {
+	if first == nil {
+		return max, nil
+	}
+	if *first < 1 || int(*first) > max {
+		return 0, fmt.Errorf("first must be between 1 and %d", max)
+	}
+	return int(*first), nil
+}
+
+// PageInfo describes the position of a page of a connection
+type PageInfo struct {
+	hasNextPage     bool
+	hasPreviousPage bool
+	startCursor     *string
+	endCursor       *string
+}
+
+func newPageInfo(cursors []string, hasNextPage, hasPreviousPage bool) *PageInfo {
+	info := &PageInfo{hasNextPage: hasNextPage, hasPreviousPage: hasPreviousPage}
+	if len(cursors) > 0 {
+		info.startCursor = &cursors[0]
+		info.endCursor = &cursors[len(cursors)-1]
+	}
+	return info
+}
+
+func (p *PageInfo) HasNextPage(_ context.Context) bool {
+	return p.hasNextPage
+}
+
+func (p *PageInfo) HasPreviousPage(_ context.Context) bool {
+	return p.hasPreviousPage
+}
+
+func (p *PageInfo) StartCursor(_ context.Context) *string {
+	return p.startCursor
+}
+
+func (p *PageInfo) EndCursor(_ context.Context) *string {
+	return p.endCursor
+}
+
+// BlockEdge is a block of a page of blocks
+type BlockEdge struct {
+	cursor string
+	node   *Block
+}
+
+func (e *BlockEdge) Cursor(_ context.Context) string {
+	return e.cursor
+}
+
+func (e *BlockEdge) Node(_ context.Context) *Block {
+	return e.node
+}
+
+// BlockConnection is a page of blocks
+type BlockConnection struct {
+	edges    []*BlockEdge
+	pageInfo *PageInfo
+}
+
+func (c *BlockConnection) Edges(_ context.Context) []*BlockEdge {
+	return c.edges
+}
+
+func (c *BlockConnection) PageInfo(_ context.Context) *PageInfo {
+	return c.pageInfo
+}
+
+// TransactionEdge is a transaction of a page of transactions
+type TransactionEdge struct {
+	cursor string
+	node   *Transaction
+}
+
+func (e *TransactionEdge) Cursor(_ context.Context) string {
+	return e.cursor
+}
+
+func (e *TransactionEdge) Node(_ context.Context) *Transaction {
+	return e.node
+}
+
+// TransactionConnection is a page of transactions
+type TransactionConnection struct {
+	edges    []*TransactionEdge
+	pageInfo *PageInfo
+}
+
+func (c *TransactionConnection) Edges(_ context.Context) []*TransactionEdge {
+	return c.edges
+}
+
+func (c *TransactionConnection) PageInfo(_ context.Context) *PageInfo {
+	return c.pageInfo
+}
+
+// LogEdge is a log of a page of logs
+type LogEdge struct {
+	cursor string
+	node   *Log
+}
+
+func (e *LogEdge) Cursor(_ context.Context) string {
+	return e.cursor
+}
+
+func (e *LogEdge) Node(_ context.Context) *Log {
+	return e.node
+}
+
+// LogConnection is a page of logs
+type LogConnection struct {
+	edges    []*LogEdge
+	pageInfo *PageInfo
+}
+
+func (c *LogConnection) Edges(_ context.Context) []*LogEdge {
+	return c.edges
+}
+
+func (c *LogConnection) PageInfo(_ context.Context) *PageInfo {
+	return c.pageInfo
+}
+
+// lastBlockNumber returns the provided block number, or the most recent indexed block number if none is provided
+func (r *Resolver) lastBlockNumber(number *hexutil.Uint64) (int64, error) {
+	if number != nil {
+		return int64(*number), nil
+	}
+	return r.backend.Retriever.RetrieveLastBlockNumber()
+}
+
+// BlocksConnection pages through the canonical blocks between two numbers, inclusive
+func (r *Resolver) BlocksConnection(ctx context.Context, args struct {
+	From  hexutil.Uint64
+	To    *hexutil.Uint64
+	First *int32
+	After *string
+}) (*BlockConnection, error) {
+	limit, err := pageSize(args.First, maxBlocksPageSize)
+	if err != nil {
+		return nil, err
+	}
+	from := int64(args.From)
+	if args.After != nil {
+		keys, err := decodeCursor(*args.After, blocksCursor, 1)
+		if err != nil {
+			return nil, err
+		}
+		if keys[0] >= from {
+			from = keys[0] + 1
+		}
+	}
+	to, err := r.lastBlockNumber(args.To)
+	if err != nil {
+		return nil, err
+	}
+
+	var headers []eth.HeaderPageResult
+	if from <= to {
+		// one more header tells whether there is a next page
+		if headers, err = r.backend.Retriever.RetrieveCanonicalHeaderPage(from, to, limit+1); err != nil {
+			return nil, err
+		}
+	}
+	hasNextPage := len(headers) > limit
+	if hasNextPage {
+		headers = headers[:limit]
+	}
+	edges := make([]*BlockEdge, len(headers))
+	cursors := make([]string, len(headers))
+	for i, header := range headers {
+		hash := common.HexToHash(header.BlockHash)
+		numberOrHash := rpc.BlockNumberOrHashWithHash(hash, false)
+		cursors[i] = encodeCursor(blocksCursor, header.BlockNumber)
+		edges[i] = &BlockEdge{
+			cursor: cursors[i],
+			node: &Block{
+				backend:      r.backend,
+				numberOrHash: &numberOrHash,
+				hash:         hash,
+			},
+		}
+	}
+	return &BlockConnection{
+		edges:    edges,
+		pageInfo: newPageInfo(cursors, hasNextPage, args.After != nil),
+	}, nil
+}
+
+// TransactionsConnection pages through the transactions of the canonical blocks between two numbers, inclusive
+func (r *Resolver) TransactionsConnection(ctx context.Context, args struct {
+	From  hexutil.Uint64
+	To    *hexutil.Uint64
+	First *int32
+	After *string
+}) (*TransactionConnection, error) {
+	limit, err := pageSize(args.First, maxTransactionsPageSize)
+	if err != nil {
+		return nil, err
+	}
+	from := int64(args.From)
+	after := eth.TxPageKey{BlockNumber: from, Index: -1}
+	if args.After != nil {
+		keys, err := decodeCursor(*args.After, transactionsCursor, 2)
+		if err != nil {
+			return nil, err
+		}
+		after = eth.TxPageKey{BlockNumber: keys[0], Index: keys[1]}
+	}
+	to, err := r.lastBlockNumber(args.To)
+	if err != nil {
+		return nil, err
+	}
+
+	var txs []eth.TxPageResult
+	if from <= to {
+		if txs, err = r.backend.Retriever.RetrieveCanonicalTxPage(from, to, after, limit+1); err != nil {
+			return nil, err
+		}
+	}
+	hasNextPage := len(txs) > limit
+	if hasNextPage {
+		txs = txs[:limit]
+	}
+	edges := make([]*TransactionEdge, len(txs))
+	cursors := make([]string, len(txs))
+	for i, tx := range txs {
+		cursors[i] = encodeCursor(transactionsCursor, tx.BlockNumber, tx.Index)
+		edges[i] = &TransactionEdge{
+			cursor: cursors[i],
+			node: &Transaction{
+				backend: r.backend,
+				hash:    common.HexToHash(tx.TxHash),
+			},
+		}
+	}
+	return &TransactionConnection{
+		edges:    edges,
+		pageInfo: newPageInfo(cursors, hasNextPage, args.After != nil),
+	}, nil
+}
+
+// LogsConnection pages through the log entries of the canonical blocks matching the provided filter
+func (r *Resolver) LogsConnection(ctx context.Context, args struct {
+	Filter FilterCriteria
+	First  *int32
+	After  *string
+}) (*LogConnection, error) {
+	limit, err := pageSize(args.First, maxLogsPageSize)
+	if err != nil {
+		return nil, err
+	}
+	// the range defaults to the latest block, as it does for logs
+	latest, err := r.backend.Retriever.RetrieveLastBlockNumber()
+	if err != nil {
+		return nil, err
+	}
+	from, to := latest, latest
+	if args.Filter.FromBlock != nil {
+		from = int64(*args.Filter.FromBlock)
+	}
+	if args.Filter.ToBlock != nil {
+		to = int64(*args.Filter.ToBlock)
+	}
+	after := eth.LogPageKey{BlockNumber: from, TxIndex: -1, Index: -1}
+	if args.After != nil {
+		keys, err := decodeCursor(*args.After, logsCursor, 3)
+		if err != nil {
+			return nil, err
+		}
+		after = eth.LogPageKey{BlockNumber: keys[0], TxIndex: keys[1], Index: keys[2]}
+	}
+
+	var filter eth.ReceiptFilter
+	if args.Filter.Addresses != nil {
+		for _, addr := range *args.Filter.Addresses {
+			filter.LogAddresses = append(filter.LogAddresses, addr.String())
+		}
+	}
+	if args.Filter.Topics != nil {
+		for i, topicSet := range *args.Filter.Topics {
+			if i > 3 {
+				// don't allow more than 4 topics
+				break
+			}
+			topics := make([]string, len(topicSet))
+			for j, topic := range topicSet {
+				topics[j] = topic.String()
+			}
+			filter.Topics = append(filter.Topics, topics)
+		}
+	}
+
+	var results []eth.LogResult
+	if from <= to {
+		if results, err = r.backend.Retriever.RetrieveCanonicalLogPage(filter, from, to, after, limit+1); err != nil {
+			return nil, err
+		}
+	}
+	hasNextPage := len(results) > limit
+	if hasNextPage {
+		results = results[:limit]
+	}
+	logs := decomposeGQLLogs(results)
+	edges := make([]*LogEdge, len(results))
+	cursors := make([]string, len(results))
+	for i, result := range results {
+		blockNumber, err := strconv.ParseInt(result.BlockNumber, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		cursors[i] = encodeCursor(logsCursor, blockNumber, result.TxnIndex, result.Index)
+		edges[i] = &LogEdge{
+			cursor: cursors[i],
+			node: &Log{
+				backend:    r.backend,
+				log:        logs[i].Log,
+				cid:        logs[i].CID,
+				receiptCID: logs[i].RctCID,
+				ipldBlock:  logs[i].LogLeafData,
+				transaction: &Transaction{
+					backend: r.backend,
+					hash:    logs[i].Log.TxHash,
+				},
+				status: logs[i].RctStatus,
+			},
+		}
+	}
+	return &LogConnection{
+		edges:    edges,
+		pageInfo: newPageInfo(cursors, hasNextPage, args.After != nil),
+	}, nil
+}
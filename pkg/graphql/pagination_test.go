@@ -0,0 +1,89 @@
+// VulcanizeDB
+// Copyright © 2022 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package graphql_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/ipld-eth-server/pkg/graphql"
+)
+
+var _ = Describe("Pagination", func() {
+	Describe("cursors", func() {
+		It("round trip their keyset position", func() {
+			cursor := graphql.EncodeCursor("logs", 12, 0, 3)
+			keys, err := graphql.DecodeCursor(cursor, "logs", 3)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(keys).To(Equal([]int64{12, 0, 3}))
+		})
+
+		It("are only accepted by the connection which issued them", func() {
+			cursor := graphql.EncodeCursor("blocks", 12)
+			_, err := graphql.DecodeCursor(cursor, "transactions", 2)
+			Expect(err).To(MatchError("invalid cursor"))
+			_, err = graphql.DecodeCursor("not a cursor", "blocks", 1)
+			Expect(err).To(MatchError("invalid cursor"))
+		})
+	})
+
+	Describe("page sizes", func() {
+		It("default to the maximum", func() {
+			size, err := graphql.PageSize(nil, 100)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(size).To(Equal(100))
+		})
+
+		It("are bounded", func() {
+			for _, first := range []int32{0, -1, 101} {
+				first := first
+				_, err := graphql.PageSize(&first, 100)
+				Expect(err).To(MatchError("first must be between 1 and 100"))
+			}
+			first := int32(100)
+			size, err := graphql.PageSize(&first, 100)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(size).To(Equal(100))
+		})
+	})
+
+	It("rejects pages over the maximum size and invalid cursors before querying", func() {
+		handler, err := graphql.NewMockHandler(new(graphql.MockHeads), time.Second, graphql.Limits{})
+		Expect(err).ToNot(HaveOccurred())
+		query := func(q string) string {
+			body, err := json.Marshal(map[string]string{"query": q})
+			Expect(err).ToNot(HaveOccurred())
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewReader(body)))
+			Expect(rec.Code).To(Equal(http.StatusOK))
+			return rec.Body.String()
+		}
+		Expect(query(`{ blocksConnection(from: 0, first: 101) { pageInfo { hasNextPage } } }`)).
+			To(ContainSubstring("first must be between 1 and 100"))
+		Expect(query(`{ logsConnection(filter: {}, first: 1001) { pageInfo { hasNextPage } } }`)).
+			To(ContainSubstring("first must be between 1 and 1000"))
+		cursor := graphql.EncodeCursor("blocks", 1)
+		Expect(query(`{ transactionsConnection(from: 0, after: "` + cursor + `") { pageInfo { hasNextPage } } }`)).
+			To(ContainSubstring("invalid cursor"))
+	})
+})
@@ -16,7 +16,24 @@
 
 package graphql
 
-const schema string = `
+// schema serves the queries and mutations; subscriptions are served by subscriptionSchema, since graphql-go resolves
+// every root type with the same resolver and the logs fields of Query and Subscription have different resolvers
+const schema = schemaTypes + `
+    schema {
+        query: Query
+        mutation: Mutation
+    }
+`
+
+// subscriptionSchema serves the subscriptions sent over websockets, its query root is never executed
+const subscriptionSchema = schemaTypes + `
+    schema {
+        query: Subscription
+        subscription: Subscription
+    }
+`
+
+const schemaTypes string = `
     # Bytes32 is a 32 byte binary string, represented as 0x-prefixed hexadecimal.
     scalar Bytes32
     # Address is a 20 byte Ethereum address, represented as 0x-prefixed hexadecimal.
@@ -31,10 +48,6 @@ const schema string = `
     # Long is a 64 bit unsigned integer.
     scalar Long
 
-    schema {
-        query: Query
-    }
-
     # Account is an Ethereum account at a particular block.
     type Account {
         # Address is the address owning the account.
@@ -51,6 +64,14 @@ const schema string = `
         # Storage provides access to the storage of a contract account, indexed
         # by its 32 byte slot identifier.
         storage(slot: Bytes32!): Bytes32!
+
+        # CID for the state trie leaf node IPLD block of the account. This will be
+        # null if the account does not exist.
+        cid: String
+
+        # State trie leaf node IPLD block of the account. This will be null if the
+        # account does not exist.
+        ipldBlock: Bytes
     }
 
     # Log is an Ethereum event log.
@@ -80,6 +101,12 @@ const schema string = `
         status: Int!
     }
 
+    # AccessTuple is an entry of the access list of an EIP-2930 transaction.
+    type AccessTuple {
+        address: Address!
+        storageKeys: [Bytes32!]!
+    }
+
     # Transaction is an Ethereum transaction.
     type Transaction {
         # Hash is the hash of this transaction.
@@ -99,6 +126,12 @@ const schema string = `
         value: BigInt!
         # GasPrice is the price offered to miners for gas, in wei per unit.
         gasPrice: BigInt!
+        # MaxFeePerGas is the maximum fee per gas offered to include the transaction, in wei.
+        # This is null for transactions which are not EIP-1559 transactions.
+        maxFeePerGas: BigInt
+        # MaxPriorityFeePerGas is the maximum miner tip per gas offered to include the
+        # transaction, in wei. This is null for transactions which are not EIP-1559 transactions.
+        maxPriorityFeePerGas: BigInt
         # Gas is the maximum amount of gas this transaction can consume.
         gas: Long!
         # InputData is the data supplied to the target of the transaction.
@@ -119,6 +152,11 @@ const schema string = `
         # this transaction. If the transaction has not yet been mined, this field
         # will be null.
         cumulativeGasUsed: Long
+        # EffectiveGasPrice is the price actually paid per unit of gas, in wei. Before
+        # EIP-1559 this is the gas price, after it this is baseFeePerGas +
+        # min(maxFeePerGas - baseFeePerGas, maxPriorityFeePerGas). If the transaction
+        # has not yet been mined, this field will be null.
+        effectiveGasPrice: BigInt
         # CreatedContract is the account that was created by a contract creation
         # transaction. If the transaction was not a contract creation transaction,
         # or it has not yet been mined, this field will be null.
@@ -129,6 +167,25 @@ const schema string = `
         r: BigInt!
         s: BigInt!
         v: BigInt!
+        # Type is the EIP-2718 type of the transaction, 0 for legacy transactions.
+        type: Int
+        # AccessList is the EIP-2930 access list of the transaction, empty for legacy
+        # transactions.
+        accessList: [AccessTuple!]
+
+        # CID for the transaction IPLD block.
+        cid: String
+
+        # Transaction IPLD block.
+        ipldBlock: Bytes
+
+        # ReceiptCID for the receipt trie leaf node IPLD block of the transaction's
+        # receipt. If the transaction has not yet been mined, this field will be null.
+        receiptCID: String
+
+        # Receipt trie leaf node IPLD block of the transaction's receipt. If the
+        # transaction has not yet been mined, this field will be null.
+        receiptIpldBlock: Bytes
     }
 
     # BlockFilterCriteria encapsulates log filter criteria for a filter applied
@@ -178,6 +235,9 @@ const schema string = `
         gasLimit: Long!
         # GasUsed is the amount of gas that was used executing transactions in this block.
         gasUsed: Long!
+        # BaseFeePerGas is the fee per unit of gas burned by the protocol in this block.
+        # This is null for the blocks before EIP-1559.
+        baseFeePerGas: BigInt
         # Timestamp is the unix timestamp at which this block was mined.
         timestamp: Long!
         # LogsBloom is a bloom filter that can be used to check if a block may
@@ -215,8 +275,72 @@ const schema string = `
         logs(filter: BlockFilterCriteria!): [Log!]!
         # Account fetches an Ethereum account at the current block's state.
         account(address: Address!): Account!
-        # Call executes a local call operation at the current block's state.
-        call(data: CallData!): CallResult
+        # Accounts fetches Ethereum accounts at the current block's state, in
+        # the order of their addresses. Their state trie leaf nodes are looked
+        # up together. At most 100 addresses are accepted.
+        accounts(addresses: [Address!]!): [Account!]!
+        # Call executes a local call operation at the current block's state,
+        # with the overrides applied to it.
+        call(data: CallData!, overrides: [AccountOverride!]): CallResult
+        # EstimateGas estimates the amount of gas that will be required for
+        # successful execution of a transaction at the current block's state,
+        # with the overrides applied to it.
+        estimateGas(data: CallData!, overrides: [AccountOverride!]): Long!
+
+        # CID for the header IPLD block of this block, or for the uncle IPLD block
+        # of an ommer.
+        cid: String!
+
+        # Header IPLD block of this block, or uncle IPLD block of an ommer.
+        ipldBlock: Bytes!
+
+        # StateDiff is the list of accounts changed in this block, with their
+        # changed storage slots, as recorded by the state and storage nodes
+        # indexed for this block.
+        stateDiff: [AccountDiff!]!
+    }
+
+    # AccountDiff is an account changed in a block. Its values are null when
+    # the account was removed by the block, and its previous values are null
+    # when the account did not exist before the block.
+    type AccountDiff {
+        # LeafKey is the state leaf key of the account, the hash of its address.
+        leafKey: Bytes32!
+        # Path is the path of the state node of the account.
+        path: Bytes!
+        # NodeType is the type of the state node of the account, Leaf or Removed.
+        nodeType: String!
+        # CID for the state node IPLD block of the account.
+        cid: String!
+        # State node IPLD block of the account.
+        ipldBlock: Bytes!
+        balance: BigInt
+        previousBalance: BigInt
+        nonce: Long
+        previousNonce: Long
+        codeHash: Bytes32
+        previousCodeHash: Bytes32
+        storageRoot: Bytes32
+        # Storage is the list of storage slots of the account changed in the block.
+        storage: [StorageDiff!]!
+    }
+
+    # StorageDiff is a storage slot changed in a block. Its value is null when
+    # the slot was removed by the block, and its previous value is null when
+    # the slot was empty before the block.
+    type StorageDiff {
+        # LeafKey is the storage leaf key of the slot, the hash of its slot key.
+        leafKey: Bytes32!
+        # Path is the path of the storage node of the slot.
+        path: Bytes!
+        # NodeType is the type of the storage node of the slot, Leaf or Removed.
+        nodeType: String!
+        # CID for the storage node IPLD block of the slot.
+        cid: String!
+        # Storage node IPLD block of the slot.
+        ipldBlock: Bytes!
+        value: Bytes32
+        previousValue: Bytes32
     }
 
     # CallData represents the data associated with a local contract call.
@@ -236,6 +360,32 @@ const schema string = `
         data: Bytes
     }
 
+    # AccountOverride replaces the fields of an account in the state a local
+    # call operation is executed on. Unset fields are left untouched, state and
+    # stateDiff can't be set together.
+    input AccountOverride {
+        # Address is the address of the overridden account.
+        address: Address!
+        # Nonce replaces the nonce of the account.
+        nonce: Long
+        # Code replaces the code of the account.
+        code: Bytes
+        # Balance replaces the balance of the account, in wei.
+        balance: BigInt
+        # State replaces the whole storage of the account with the slots.
+        state: [StorageSlot!]
+        # StateDiff replaces the slots in the storage of the account.
+        stateDiff: [StorageSlot!]
+    }
+
+    # StorageSlot is the value of a storage slot.
+    input StorageSlot {
+        # Slot is the 32 byte slot identifier.
+        slot: Bytes32!
+        # Value is the value of the slot.
+        value: Bytes32!
+    }
+
     # CallResult is the result of a local call operation.
     type CallResult {
         # Data is the return data of the called contract.
@@ -302,5 +452,113 @@ const schema string = `
 
         # Get contract logs by block hash and contract address.
         getLogs(blockHash: Bytes32!, contract: Address): [Log!]
+
+        # BlocksConnection pages through the canonical blocks between two
+        # numbers, inclusive. If to is not supplied, it defaults to the most
+        # recent known block. At most 100 blocks are returned per page.
+        blocksConnection(from: Long!, to: Long, first: Int, after: String): BlockConnection!
+
+        # TransactionsConnection pages through the transactions of the
+        # canonical blocks between two numbers, inclusive. If to is not
+        # supplied, it defaults to the most recent known block. At most 1000
+        # transactions are returned per page.
+        transactionsConnection(from: Long!, to: Long, first: Int, after: String): TransactionConnection!
+
+        # LogsConnection pages through the log entries of the canonical blocks
+        # matching the provided filter. At most 1000 log entries are returned
+        # per page.
+        logsConnection(filter: FilterCriteria!, first: Int, after: String): LogConnection!
+
+        # GasPrice returns the node's estimate of a gas price sufficient to
+        # ensure a transaction is mined in a timely fashion, sampled from the
+        # latest indexed blocks.
+        gasPrice: BigInt!
+
+        # MaxPriorityFeePerGas returns the node's estimate of a gas tip
+        # sufficient to ensure a transaction is mined in a timely fashion,
+        # sampled from the latest indexed blocks.
+        maxPriorityFeePerGas: BigInt!
+
+        # Syncing returns the progress of the filling of the gaps in the index,
+        # or null if there are no gaps left to fill: none queued or being
+        # filled, and none the last scan found but couldn't queue.
+        syncing: SyncState
+
+        # ChainID returns the current chain ID for transaction replay protection.
+        chainID: BigInt!
+    }
+
+    # SyncState contains the progress of the filling of the gaps in the index.
+    type SyncState {
+        # StartingBlock is the first block number checked for gaps.
+        startingBlock: Long!
+        # CurrentBlock is the last block number filled, startingBlock until
+        # the first gap is filled. Gaps are filled concurrently, so blocks
+        # below it may still be missing.
+        currentBlock: Long!
+        # HighestBlock is the highest block number checked for gaps.
+        highestBlock: Long!
+    }
+
+    # PageInfo describes a page of a connection. Pages are requested with
+    # first, the number of items to return, and after, the cursor of the item
+    # the page follows.
+    type PageInfo {
+        # HasNextPage is true if more items follow the page.
+        hasNextPage: Boolean!
+        # HasPreviousPage is true if the page follows a cursor.
+        hasPreviousPage: Boolean!
+        # StartCursor is the cursor of the first item of the page.
+        startCursor: String
+        # EndCursor is the cursor of the last item of the page, to request the
+        # next page after.
+        endCursor: String
+    }
+
+    type BlockEdge {
+        cursor: String!
+        node: Block!
+    }
+
+    type BlockConnection {
+        edges: [BlockEdge!]!
+        pageInfo: PageInfo!
+    }
+
+    type TransactionEdge {
+        cursor: String!
+        node: Transaction!
+    }
+
+    type TransactionConnection {
+        edges: [TransactionEdge!]!
+        pageInfo: PageInfo!
+    }
+
+    type LogEdge {
+        cursor: String!
+        node: Log!
+    }
+
+    type LogConnection {
+        edges: [LogEdge!]!
+        pageInfo: PageInfo!
+    }
+
+    type Mutation {
+        # SendRawTransaction validates and decodes an RLP-encoded transaction,
+        # forwards it to the proxied node for broadcast, and returns its hash.
+        sendRawTransaction(data: Bytes!): Bytes32!
+    }
+
+    # Subscriptions are sent over websockets with the graphql-ws protocol, they
+    # are driven by the canonical headers newly indexed.
+    type Subscription {
+        # NewBlock emits each block once its header has been indexed.
+        newBlock: Block!
+
+        # Logs emits the log entries matching the filter from each newly
+        # indexed block.
+        logs(filter: BlockFilterCriteria!): Log!
     }
 `
@@ -20,37 +20,50 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"net/url"
+	"strings"
+	"time"
 
 	"github.com/ethereum/go-ethereum/cmd/utils"
 	"github.com/ethereum/go-ethereum/node"
 	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/gorilla/websocket"
 	"github.com/graph-gophers/graphql-go"
-	"github.com/graph-gophers/graphql-go/relay"
 	"github.com/sirupsen/logrus"
 
 	"github.com/vulcanize/ipld-eth-server/pkg/eth"
+	"github.com/vulcanize/ipld-eth-server/pkg/gapfill"
 )
 
+// subscriptionResolverTimeout bounds the resolution of the fields of each subscription event
+const subscriptionResolverTimeout = 10 * time.Second
+
 // Service encapsulates a GraphQL service.
 type Service struct {
-	endpoint string           // The host:port endpoint for this service.
-	cors     []string         // Allowed CORS domains
-	vhosts   []string         // Recognised vhosts
-	timeouts rpc.HTTPTimeouts // Timeout settings for HTTP requests.
-	backend  *eth.Backend     // The backend that queries will operate onn.
-	handler  http.Handler     // The `http.Handler` used to answer queries.
-	listener net.Listener     // The listening socket.
+	endpoint  string             // The host:port endpoint for this service.
+	cors      []string           // Allowed CORS domains
+	vhosts    []string           // Recognised vhosts
+	timeouts  rpc.HTTPTimeouts   // Timeout settings for HTTP requests.
+	backend   *eth.Backend       // The backend that queries will operate onn.
+	client    *rpc.Client        // The client mutations are forwarded to, nil if there is no proxied node.
+	gapFiller *gapfill.Scheduler // The gap filler reported by syncing, nil if it is disabled.
+	limits    Limits             // Limits of the cost and depth of the queries.
+	handler   http.Handler       // The `http.Handler` used to answer queries.
+	listener  net.Listener       // The listening socket.
 }
 
 // New constructs a new GraphQL service instance.
-func New(backend *eth.Backend, endpoint string, cors, vhosts []string, timeouts rpc.HTTPTimeouts) (*Service, error) {
+func New(backend *eth.Backend, client *rpc.Client, gapFiller *gapfill.Scheduler, endpoint string, cors, vhosts []string, timeouts rpc.HTTPTimeouts, limits Limits) (*Service, error) {
 	return &Service{
-		endpoint: endpoint,
-		cors:     cors,
-		vhosts:   vhosts,
-		timeouts: timeouts,
-		backend:  backend,
+		endpoint:  endpoint,
+		cors:      cors,
+		vhosts:    vhosts,
+		timeouts:  timeouts,
+		backend:   backend,
+		client:    client,
+		gapFiller: gapFiller,
+		limits:    limits,
 	}, nil
 }
 
@@ -64,15 +77,13 @@ func (s *Service) APIs() []rpc.API { return nil }
 // layer was also initialized to spawn any goroutines required by the service.
 func (s *Service) Start(server *p2p.Server) error {
 	var err error
-	s.handler, err = NewHandler(s.backend)
+	s.handler, err = NewHandler(s.backend, s.client, s.gapFiller, s.limits)
 	if err != nil {
 		return err
 	}
 
-	handler := node.NewHTTPHandlerStack(s.handler, s.cors, s.vhosts)
-
 	// start http server
-	_, addr, err := node.StartHTTPEndpoint(s.endpoint, rpc.DefaultHTTPTimeouts, handler)
+	_, addr, err := node.StartHTTPEndpoint(s.endpoint, rpc.DefaultHTTPTimeouts, newEndpointHandler(s.handler, s.cors, s.vhosts))
 	if err != nil {
 		utils.Fatalf("Could not start RPC api: %v", err)
 	}
@@ -81,24 +92,89 @@ func (s *Service) Start(server *p2p.Server) error {
 	return nil
 }
 
-// newHandler returns a new `http.Handler` that will answer GraphQL queries.
+// NewHandler returns a new `http.Handler` that will answer GraphQL queries.
 // It additionally exports an interactive query browser on the / endpoint.
-func NewHandler(backend *eth.Backend) (http.Handler, error) {
-	q := Resolver{backend}
+// Websocket upgrades of the GraphQL endpoint are served with the graphql-ws protocol,
+// subscriptions are driven by the headers newly indexed.
+// Operations whose static cost or depth exceed the limits are rejected before being executed.
+// Mutations are forwarded to the proxied node through the client, and fail if it is nil.
+// The syncing field reports the progress of the gap filler, if it is not nil.
+func NewHandler(backend *eth.Backend, client *rpc.Client, gapFiller *gapfill.Scheduler, limits Limits) (http.Handler, error) {
+	return newHandler(backend, client, gapFiller, newHeadFeed(backendHeads{backend}, newHeadPollInterval), limits)
+}
+
+func newHandler(backend *eth.Backend, client *rpc.Client, gapFiller *gapfill.Scheduler, feed *headFeed, limits Limits) (http.Handler, error) {
+	analyzer, err := newCostAnalyzer(schema, limits, feed.source.lastBlockNumber)
+	if err != nil {
+		return nil, err
+	}
+	q := Resolver{backend: backend, client: client, gapFiller: gapFiller}
 
 	s, err := graphql.ParseSchema(schema, &q)
 	if err != nil {
 		return nil, err
 	}
-	h := &relay.Handler{Schema: s}
+	sub := SubscriptionResolver{backend: backend, feed: feed}
+	ss, err := graphql.ParseSchema(subscriptionSchema, &sub, graphql.SubscribeResolverTimeout(subscriptionResolverTimeout))
+	if err != nil {
+		return nil, err
+	}
+	h := &queryHandler{backend: backend, schema: s, analyzer: analyzer}
+	ws := newWSHandler(backend, s, ss, analyzer)
+	gh := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if websocket.IsWebSocketUpgrade(r) {
+			ws.ServeHTTP(w, r)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
 
 	mux := http.NewServeMux()
 	mux.Handle("/", GraphiQL{})
-	mux.Handle("/graphql", h)
-	mux.Handle("/graphql/", h)
+	mux.Handle("/graphql", gh)
+	mux.Handle("/graphql/", gh)
 	return mux, nil
 }
 
+// newEndpointHandler wraps the handler in the cors, vhosts and gzip stack of the rpc endpoints. Websocket upgrades are
+// checked against the vhosts and the origins allowed by cors, but never gzipped: the connection can't be hijacked
+// through the gzip writer.
+func newEndpointHandler(h http.Handler, cors, vhosts []string) http.Handler {
+	stack := node.NewHTTPHandlerStack(h, cors, vhosts)
+	// without cors the stack only filters the vhosts, and it doesn't gzip the requests which don't accept it
+	ws := node.NewHTTPHandlerStack(originHandler(h, cors), nil, vhosts)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if websocket.IsWebSocketUpgrade(r) {
+			r.Header.Del("Accept-Encoding")
+			ws.ServeHTTP(w, r)
+			return
+		}
+		stack.ServeHTTP(w, r)
+	})
+}
+
+// originHandler rejects the websocket upgrades from browser origins which are neither the endpoint's nor allowed by cors
+func originHandler(h http.Handler, cors []string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			h.ServeHTTP(w, r)
+			return
+		}
+		if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+			h.ServeHTTP(w, r)
+			return
+		}
+		for _, allowed := range cors {
+			if allowed == "*" || strings.EqualFold(allowed, origin) {
+				h.ServeHTTP(w, r)
+				return
+			}
+		}
+		http.Error(w, "origin not allowed", http.StatusForbidden)
+	})
+}
+
 // Stop terminates all goroutines belonging to the service, blocking until they
 // are all terminated.
 func (s *Service) Stop() error {
@@ -0,0 +1,187 @@
+// VulcanizeDB
+// Copyright © 2022 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package graphql
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/vulcanize/ipld-eth-server/pkg/eth"
+)
+
+// AccountDiff is an account changed in a block
+type AccountDiff struct {
+	diff eth.StateDiffAccount
+}
+
+// LeafKey returns the state leaf key of the account, the hash of its address.
+func (a *AccountDiff) LeafKey(_ context.Context) common.Hash {
+	return a.diff.LeafKey
+}
+
+// Path returns the path of the state leaf node of the account.
+func (a *AccountDiff) Path(_ context.Context) hexutil.Bytes {
+	return a.diff.Path
+}
+
+// NodeType returns the type of the state node of the account, Leaf or Removed.
+func (a *AccountDiff) NodeType(_ context.Context) string {
+	return string(a.diff.NodeType)
+}
+
+// Cid returns the cid of the state node of the account.
+func (a *AccountDiff) Cid(_ context.Context) string {
+	return a.diff.CID
+}
+
+// IpldBlock returns the IPLD block of the state node of the account.
+func (a *AccountDiff) IpldBlock(_ context.Context) hexutil.Bytes {
+	return a.diff.IPLDBlock
+}
+
+func (a *AccountDiff) Balance(_ context.Context) *hexutil.Big {
+	return accountBalance(a.diff.Account)
+}
+
+func (a *AccountDiff) PreviousBalance(_ context.Context) *hexutil.Big {
+	return accountBalance(a.diff.Previous)
+}
+
+func (a *AccountDiff) Nonce(_ context.Context) *hexutil.Uint64 {
+	return accountNonce(a.diff.Account)
+}
+
+func (a *AccountDiff) PreviousNonce(_ context.Context) *hexutil.Uint64 {
+	return accountNonce(a.diff.Previous)
+}
+
+func (a *AccountDiff) CodeHash(_ context.Context) *common.Hash {
+	return accountCodeHash(a.diff.Account)
+}
+
+func (a *AccountDiff) PreviousCodeHash(_ context.Context) *common.Hash {
+	return accountCodeHash(a.diff.Previous)
+}
+
+func (a *AccountDiff) StorageRoot(_ context.Context) *common.Hash {
+	if a.diff.Account == nil {
+		return nil
+	}
+	return &a.diff.Account.Root
+}
+
+// Storage returns the storage slots of the account changed in the block.
+func (a *AccountDiff) Storage(_ context.Context) []*StorageDiff {
+	ret := make([]*StorageDiff, len(a.diff.Storage))
+	for i, slot := range a.diff.Storage {
+		ret[i] = &StorageDiff{slot}
+	}
+	return ret
+}
+
+func accountBalance(account *types.StateAccount) *hexutil.Big {
+	if account == nil {
+		return nil
+	}
+	return (*hexutil.Big)(account.Balance)
+}
+
+func accountNonce(account *types.StateAccount) *hexutil.Uint64 {
+	if account == nil {
+		return nil
+	}
+	nonce := hexutil.Uint64(account.Nonce)
+	return &nonce
+}
+
+func accountCodeHash(account *types.StateAccount) *common.Hash {
+	if account == nil {
+		return nil
+	}
+	codeHash := common.BytesToHash(account.CodeHash)
+	return &codeHash
+}
+
+// StorageDiff is a storage slot changed in a block
+type StorageDiff struct {
+	diff eth.StateDiffStorage
+}
+
+// LeafKey returns the storage leaf key of the slot, the hash of its key.
+func (s *StorageDiff) LeafKey(_ context.Context) common.Hash {
+	return s.diff.LeafKey
+}
+
+// Path returns the path of the storage leaf node of the slot.
+func (s *StorageDiff) Path(_ context.Context) hexutil.Bytes {
+	return s.diff.Path
+}
+
+// NodeType returns the type of the storage node of the slot, Leaf or Removed.
+func (s *StorageDiff) NodeType(_ context.Context) string {
+	return string(s.diff.NodeType)
+}
+
+// Cid returns the cid of the storage node of the slot.
+func (s *StorageDiff) Cid(_ context.Context) string {
+	return s.diff.CID
+}
+
+// IpldBlock returns the IPLD block of the storage node of the slot.
+func (s *StorageDiff) IpldBlock(_ context.Context) hexutil.Bytes {
+	return s.diff.IPLDBlock
+}
+
+func (s *StorageDiff) Value(_ context.Context) *common.Hash {
+	return storageValue(s.diff.Value)
+}
+
+func (s *StorageDiff) PreviousValue(_ context.Context) *common.Hash {
+	return storageValue(s.diff.Previous)
+}
+
+func storageValue(value []byte) *common.Hash {
+	if value == nil {
+		return nil
+	}
+	hash := common.BytesToHash(value)
+	return &hash
+}
+
+// StateDiff returns the accounts and storage slots changed in this block.
+func (b *Block) StateDiff(ctx context.Context) ([]*AccountDiff, error) {
+	hash := b.hash
+	if hash == (common.Hash{}) {
+		header, err := b.resolveHeader(ctx)
+		if err != nil {
+			return nil, err
+		}
+		hash = header.Hash()
+	}
+	accounts, err := b.backend.IPLDRetriever.RetrieveStateDiffByBlockHash(hash)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]*AccountDiff, len(accounts))
+	for i, account := range accounts {
+		ret[i] = &AccountDiff{account}
+	}
+	return ret, nil
+}
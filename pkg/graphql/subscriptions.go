@@ -0,0 +1,229 @@
+// VulcanizeDB
+// Copyright © 2022 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package graphql
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/filters"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/sirupsen/logrus"
+
+	"github.com/vulcanize/ipld-eth-server/pkg/eth"
+)
+
+const (
+	// newHeadPollInterval is the time between two checks of the index for new headers
+	newHeadPollInterval = time.Second
+	// headBufferSize is the number of headers buffered for each subscription, a subscription falling further behind misses headers
+	headBufferSize = 64
+)
+
+// headSource provides the canonical headers of the index, and the logs of their blocks
+type headSource interface {
+	lastBlockNumber() (int64, error)
+	headerByNumber(ctx context.Context, number int64) (*types.Header, error)
+	logs(ctx context.Context, hash common.Hash, addresses []common.Address, topics [][]common.Hash) ([]*types.Log, error)
+}
+
+// backendHeads is the headSource backed by the index
+type backendHeads struct {
+	backend *eth.Backend
+}
+
+func (s backendHeads) lastBlockNumber() (int64, error) {
+	return s.backend.Retriever.RetrieveLastBlockNumber()
+}
+
+func (s backendHeads) headerByNumber(ctx context.Context, number int64) (*types.Header, error) {
+	return s.backend.HeaderByNumber(ctx, rpc.BlockNumber(number))
+}
+
+func (s backendHeads) logs(ctx context.Context, hash common.Hash, addresses []common.Address, topics [][]common.Hash) ([]*types.Log, error) {
+	return filters.NewBlockFilter(s.backend, hash, addresses, topics).Logs(ctx)
+}
+
+// headFeed polls the source for newly indexed headers and sends them to its subscriptions
+// Polling only runs while there are subscriptions; each height is sent once, with the header which was canonical when it was polled
+type headFeed struct {
+	source   headSource
+	interval time.Duration
+
+	mu   sync.Mutex
+	subs map[chan *types.Header]struct{}
+	quit chan struct{} // open while polling
+}
+
+func newHeadFeed(source headSource, interval time.Duration) *headFeed {
+	return &headFeed{
+		source:   source,
+		interval: interval,
+		subs:     make(map[chan *types.Header]struct{}),
+	}
+}
+
+// subscribe returns a channel receiving the headers indexed from now on, and the function cancelling the subscription
+func (f *headFeed) subscribe() (<-chan *types.Header, func()) {
+	ch := make(chan *types.Header, headBufferSize)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.subs[ch] = struct{}{}
+	if f.quit == nil {
+		f.quit = make(chan struct{})
+		go f.poll(f.quit)
+	}
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			delete(f.subs, ch)
+			if len(f.subs) == 0 && f.quit != nil {
+				close(f.quit)
+				f.quit = nil
+			}
+		})
+	}
+}
+
+func (f *headFeed) poll(quit chan struct{}) {
+	last, err := f.source.lastBlockNumber()
+	started := err == nil
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-quit:
+			return
+		}
+		head, err := f.source.lastBlockNumber()
+		if err != nil {
+			logrus.Debugf("graphql subscriptions unable to retrieve the last block number: %v", err)
+			continue
+		}
+		if !started {
+			last, started = head, true
+			continue
+		}
+		for number := last + 1; number <= head; number++ {
+			header, err := f.source.headerByNumber(context.Background(), number)
+			if err != nil || header == nil {
+				// retried on the next poll
+				logrus.Debugf("graphql subscriptions unable to retrieve the header at height %d: %v", number, err)
+				break
+			}
+			f.publish(header)
+			last = number
+		}
+	}
+}
+
+func (f *headFeed) publish(header *types.Header) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for ch := range f.subs {
+		select {
+		case ch <- header:
+		default:
+			logrus.Warnf("graphql subscription is falling behind, dropping header %d", header.Number.Uint64())
+		}
+	}
+}
+
+// SubscriptionResolver is the root of the Subscription type
+type SubscriptionResolver struct {
+	backend *eth.Backend
+	feed    *headFeed
+}
+
+// NewBlock emits each block once its header has been indexed
+func (r *SubscriptionResolver) NewBlock(ctx context.Context) (<-chan *Block, error) {
+	headers, unsubscribe := r.feed.subscribe()
+	blocks := make(chan *Block)
+	go func() {
+		defer close(blocks)
+		defer unsubscribe()
+		for {
+			select {
+			case header := <-headers:
+				hash := header.Hash()
+				numberOrHash := rpc.BlockNumberOrHashWithHash(hash, false)
+				block := &Block{
+					backend:      r.backend,
+					numberOrHash: &numberOrHash,
+					hash:         hash,
+					header:       header,
+				}
+				select {
+				case blocks <- block:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return blocks, nil
+}
+
+// Logs emits the log entries matching the filter from each newly indexed block
+func (r *SubscriptionResolver) Logs(ctx context.Context, args struct{ Filter BlockFilterCriteria }) (<-chan *Log, error) {
+	var addresses []common.Address
+	if args.Filter.Addresses != nil {
+		addresses = *args.Filter.Addresses
+	}
+	var topics [][]common.Hash
+	if args.Filter.Topics != nil {
+		topics = *args.Filter.Topics
+	}
+	headers, unsubscribe := r.feed.subscribe()
+	logs := make(chan *Log)
+	go func() {
+		defer close(logs)
+		defer unsubscribe()
+		for {
+			select {
+			case header := <-headers:
+				matches, err := r.feed.source.logs(ctx, header.Hash(), addresses, topics)
+				if err != nil {
+					logrus.Errorf("graphql logs subscription unable to retrieve the logs of block %d: %v", header.Number.Uint64(), err)
+					continue
+				}
+				for _, log := range matches {
+					select {
+					case logs <- &Log{
+						backend:     r.backend,
+						transaction: &Transaction{backend: r.backend, hash: log.TxHash},
+						log:         log,
+					}:
+					case <-ctx.Done():
+						return
+					}
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return logs, nil
+}
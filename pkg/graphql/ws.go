@@ -0,0 +1,268 @@
+// VulcanizeDB
+// Copyright © 2022 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/graph-gophers/graphql-go"
+	qerrors "github.com/graph-gophers/graphql-go/errors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/sirupsen/logrus"
+
+	"github.com/vulcanize/ipld-eth-server/pkg/eth"
+)
+
+// wsProtocol is the websocket subprotocol spoken on the GraphQL endpoint, defined by subscriptions-transport-ws
+const wsProtocol = "graphql-ws"
+
+const (
+	wsWriteTimeout      = 10 * time.Second
+	wsKeepAliveInterval = 15 * time.Second
+)
+
+// Message types of the graphql-ws protocol
+const (
+	gqlConnectionInit      = "connection_init"
+	gqlConnectionAck       = "connection_ack"
+	gqlConnectionError     = "connection_error"
+	gqlConnectionKeepAlive = "ka"
+	gqlConnectionTerminate = "connection_terminate"
+	gqlStart               = "start"
+	gqlStop                = "stop"
+	gqlData                = "data"
+	gqlError               = "error"
+	gqlComplete            = "complete"
+)
+
+var (
+	errNotInitialised   = errors.New("connection has not been initialised")
+	errDuplicateOpID    = errors.New("operation id is already in use")
+	errUnknownMessage   = errors.New("unknown message type")
+	errMalformedMessage = errors.New("malformed message")
+)
+
+type wsMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+type wsStartPayload struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+type wsErrorPayload struct {
+	Message string `json:"message"`
+}
+
+// wsHandler answers the GraphQL operations sent over websockets
+// Subscriptions are executed against the subscription schema, queries and mutations against the query schema
+type wsHandler struct {
+	backend       *eth.Backend
+	queries       *graphql.Schema
+	subscriptions *graphql.Schema
+	analyzer      *costAnalyzer
+	keepAlive     time.Duration
+	upgrader      websocket.Upgrader
+}
+
+func newWSHandler(backend *eth.Backend, queries, subscriptions *graphql.Schema, analyzer *costAnalyzer) *wsHandler {
+	return &wsHandler{
+		backend:       backend,
+		queries:       queries,
+		subscriptions: subscriptions,
+		analyzer:      analyzer,
+		keepAlive:     wsKeepAliveInterval,
+		upgrader: websocket.Upgrader{
+			Subprotocols: []string{wsProtocol},
+			// origins are checked by the service, as they are for the http requests
+			CheckOrigin: func(*http.Request) bool { return true },
+		},
+	}
+}
+
+func (h *wsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// the upgrader has already replied
+		logrus.Debugf("graphql websocket upgrade failed: %v", err)
+		return
+	}
+	s := &wsSession{
+		handler: h,
+		conn:    conn,
+		ops:     make(map[string]context.CancelFunc),
+	}
+	s.serve()
+}
+
+// wsSession is a websocket connection and the operations running on it
+type wsSession struct {
+	handler *wsHandler
+	conn    *websocket.Conn
+	writeMu sync.Mutex
+
+	mu  sync.Mutex
+	ops map[string]context.CancelFunc
+	wg  sync.WaitGroup
+}
+
+func (s *wsSession) serve() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		cancel()
+		s.wg.Wait()
+		s.conn.Close()
+	}()
+	initialised := false
+	for {
+		_, data, err := s.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		var msg wsMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			s.write("", gqlConnectionError, wsErrorPayload{errMalformedMessage.Error()})
+			continue
+		}
+		switch msg.Type {
+		case gqlConnectionInit:
+			s.write("", gqlConnectionAck, nil)
+			if !initialised {
+				initialised = true
+				if s.handler.keepAlive > 0 {
+					s.write("", gqlConnectionKeepAlive, nil)
+					s.wg.Add(1)
+					go s.keepAlive(ctx)
+				}
+			}
+		case gqlStart:
+			if !initialised {
+				s.write(msg.ID, gqlError, wsErrorPayload{errNotInitialised.Error()})
+				continue
+			}
+			s.start(ctx, msg)
+		case gqlStop:
+			s.stop(msg.ID)
+		case gqlConnectionTerminate:
+			return
+		default:
+			s.write(msg.ID, gqlError, wsErrorPayload{errUnknownMessage.Error()})
+		}
+	}
+}
+
+func (s *wsSession) keepAlive(ctx context.Context) {
+	defer s.wg.Done()
+	ticker := time.NewTicker(s.handler.keepAlive)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.write("", gqlConnectionKeepAlive, nil)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// start runs the operation until it completes or is stopped, sending its results as data messages followed by complete
+func (s *wsSession) start(ctx context.Context, msg wsMessage) {
+	var payload wsStartPayload
+	if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+		s.write(msg.ID, gqlError, wsErrorPayload{errMalformedMessage.Error()})
+		return
+	}
+	s.mu.Lock()
+	if _, ok := s.ops[msg.ID]; ok {
+		s.mu.Unlock()
+		s.write(msg.ID, gqlError, wsErrorPayload{errDuplicateOpID.Error()})
+		return
+	}
+	opCtx, cancel := context.WithCancel(ctx)
+	s.ops[msg.ID] = cancel
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer s.stop(msg.ID)
+		operation, rejection := s.handler.analyzer.check(payload.Query, payload.OperationName, payload.Variables)
+		if rejection != nil {
+			s.write(msg.ID, gqlData, &graphql.Response{Errors: []*qerrors.QueryError{rejection}})
+			s.write(msg.ID, gqlComplete, nil)
+			return
+		}
+		if operation != ast.OperationTypeSubscription {
+			res := s.handler.queries.Exec(withLoaders(opCtx, s.handler.backend), payload.Query, payload.OperationName, payload.Variables)
+			s.write(msg.ID, gqlData, res)
+			s.write(msg.ID, gqlComplete, nil)
+			return
+		}
+		responses, err := s.handler.subscriptions.Subscribe(opCtx, payload.Query, payload.OperationName, payload.Variables)
+		if err != nil {
+			s.write(msg.ID, gqlError, wsErrorPayload{err.Error()})
+			return
+		}
+		for res := range responses {
+			// a stopped subscription may still report its cancellation
+			if opCtx.Err() == nil {
+				s.write(msg.ID, gqlData, res)
+			}
+		}
+		if ctx.Err() == nil {
+			s.write(msg.ID, gqlComplete, nil)
+		}
+	}()
+}
+
+// stop cancels the operation, if it is still running
+func (s *wsSession) stop(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if cancel, ok := s.ops[id]; ok {
+		cancel()
+		delete(s.ops, id)
+	}
+}
+
+func (s *wsSession) write(id, typ string, payload interface{}) {
+	msg := wsMessage{ID: id, Type: typ}
+	if payload != nil {
+		raw, err := json.Marshal(payload)
+		if err != nil {
+			logrus.Errorf("graphql websocket unable to encode %s message: %v", typ, err)
+			return
+		}
+		msg.Payload = raw
+	}
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	s.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	if err := s.conn.WriteJSON(msg); err != nil {
+		logrus.Debugf("graphql websocket unable to write %s message: %v", typ, err)
+	}
+}
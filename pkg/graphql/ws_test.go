@@ -0,0 +1,187 @@
+// VulcanizeDB
+// Copyright © 2022 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package graphql_test
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/gorilla/websocket"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/ipld-eth-server/pkg/graphql"
+)
+
+type wsMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+var _ = Describe("GraphQL websockets", func() {
+	var (
+		heads  *graphql.MockHeads
+		server *httptest.Server
+		conn   *websocket.Conn
+	)
+
+	read := func() wsMessage {
+		var msg wsMessage
+		for {
+			conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+			Expect(conn.ReadJSON(&msg)).To(Succeed())
+			if msg.Type != "ka" {
+				return msg
+			}
+		}
+	}
+	start := func(id, query string) {
+		payload, err := json.Marshal(map[string]interface{}{"query": query})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(conn.WriteJSON(wsMessage{ID: id, Type: "start", Payload: payload})).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		heads = new(graphql.MockHeads)
+		heads.Push(&types.Header{Number: big.NewInt(0)})
+		handler, err := graphql.NewMockHandler(heads, 10*time.Millisecond, graphql.Limits{})
+		Expect(err).ToNot(HaveOccurred())
+		server = httptest.NewServer(handler)
+
+		dialer := websocket.Dialer{Subprotocols: []string{"graphql-ws"}}
+		url := "ws" + strings.TrimPrefix(server.URL, "http") + "/graphql"
+		conn, _, err = dialer.Dial(url, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(conn.Subprotocol()).To(Equal("graphql-ws"))
+		Expect(conn.WriteJSON(wsMessage{Type: "connection_init"})).To(Succeed())
+		Expect(read().Type).To(Equal("connection_ack"))
+	})
+
+	AfterEach(func() {
+		conn.Close()
+		server.Close()
+	})
+
+	It("answers queries", func() {
+		start("1", "{ __typename }")
+		msg := read()
+		Expect(msg.ID).To(Equal("1"))
+		Expect(msg.Type).To(Equal("data"))
+		Expect(msg.Payload).To(MatchJSON(`{"data":{"__typename":"Query"}}`))
+		Expect(read()).To(Equal(wsMessage{ID: "1", Type: "complete"}))
+	})
+
+	It("reports invalid subscriptions", func() {
+		start("1", "subscription { newBlock { unknown } }")
+		msg := read()
+		Expect(msg.Type).To(Equal("data"))
+		Expect(string(msg.Payload)).To(ContainSubstring(`Cannot query field \"unknown\"`))
+		Expect(read()).To(Equal(wsMessage{ID: "1", Type: "complete"}))
+	})
+
+	It("sends the newly indexed blocks", func() {
+		start("1", "subscription { newBlock { number hash } }")
+		// give the feed time to observe the current head
+		time.Sleep(50 * time.Millisecond)
+		header := &types.Header{Number: big.NewInt(1)}
+		heads.Push(header)
+		msg := read()
+		Expect(msg.ID).To(Equal("1"))
+		Expect(msg.Type).To(Equal("data"))
+		Expect(msg.Payload).To(MatchJSON(`{"data":{"newBlock":{"number":"0x1","hash":"` + header.Hash().Hex() + `"}}}`))
+	})
+
+	It("sends the matching logs of the newly indexed blocks", func() {
+		address := common.HexToAddress("0x1")
+		start("1", `subscription { logs(filter: {addresses: ["`+address.Hex()+`"]}) { index data } }`)
+		time.Sleep(50 * time.Millisecond)
+		heads.Push(&types.Header{Number: big.NewInt(1)},
+			&types.Log{Address: common.HexToAddress("0x2"), Index: 0},
+			&types.Log{Address: address, Index: 1, Data: []byte{1}})
+		msg := read()
+		Expect(msg.Type).To(Equal("data"))
+		Expect(msg.Payload).To(MatchJSON(`{"data":{"logs":{"index":1,"data":"0x01"}}}`))
+	})
+
+	It("completes stopped subscriptions", func() {
+		start("1", "subscription { newBlock { number } }")
+		Expect(conn.WriteJSON(wsMessage{ID: "1", Type: "stop"})).To(Succeed())
+		Expect(read()).To(Equal(wsMessage{ID: "1", Type: "complete"}))
+	})
+
+	Describe("upgrades through the endpoint", func() {
+		var endpoint *httptest.Server
+
+		BeforeEach(func() {
+			handler, err := graphql.NewMockHandler(heads, 10*time.Millisecond, graphql.Limits{})
+			Expect(err).ToNot(HaveOccurred())
+			endpoint = httptest.NewServer(graphql.EndpointHandler(handler, nil, []string{"localhost"}))
+		})
+
+		AfterEach(func() {
+			endpoint.Close()
+		})
+
+		dial := func(host string) (*http.Response, error) {
+			dialer := websocket.Dialer{Subprotocols: []string{"graphql-ws"}}
+			url := "ws" + strings.TrimPrefix(endpoint.URL, "http") + "/graphql"
+			c, resp, err := dialer.Dial(url, http.Header{"Host": {host}, "Accept-Encoding": {"gzip"}})
+			if err == nil {
+				c.Close()
+			}
+			return resp, err
+		}
+
+		It("accepts the allowed vhosts", func() {
+			_, err := dial("localhost:8083")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("rejects the other vhosts", func() {
+			resp, err := dial("attacker.example:8083")
+			Expect(err).To(MatchError(websocket.ErrBadHandshake))
+			Expect(resp.StatusCode).To(Equal(http.StatusForbidden))
+		})
+	})
+
+	Describe("OperationType", func() {
+		It("finds the type of the operation to execute", func() {
+			Expect(graphql.OperationType("{ block { number } }", "")).To(Equal("query"))
+			Expect(graphql.OperationType("# subscription\n subscription { newBlock { number } }", "")).To(Equal("subscription"))
+			Expect(graphql.OperationType(`fragment F on Block { number } subscription S @live { newBlock { ...F } }`, "")).To(Equal("subscription"))
+			doc := `query Q($h: Bytes32 = "{") { block(hash: $h) { number } } subscription S { newBlock { number } }`
+			Expect(graphql.OperationType(doc, "Q")).To(Equal("query"))
+			Expect(graphql.OperationType(doc, "S")).To(Equal("subscription"))
+		})
+
+		It("rejects the documents it can't parse or without the operation", func() {
+			_, err := graphql.OperationType(`{ block { number }`, "")
+			Expect(err).To(HaveOccurred())
+			_, err = graphql.OperationType(`query Q { block { number } }`, "S")
+			Expect(err).To(MatchError(`no operation with name "S"`))
+			_, err = graphql.OperationType(`fragment F on Block { number }`, "")
+			Expect(err).To(MatchError("no operation in the query"))
+		})
+	})
+})
@@ -17,6 +17,7 @@
 package net
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/ethereum/go-ethereum/common/hexutil"
@@ -52,11 +53,11 @@ func NewPublicNetAPI(networkID uint64, client *rpc.Client) *PublicNetAPI {
 }
 
 // Listening returns an indication if the node is listening for network connections.
-func (pna *PublicNetAPI) Listening() bool {
+func (pna *PublicNetAPI) Listening(ctx context.Context) bool {
 	// in this case it is actually whether or not the proxied node is listening
 	if pna.rpc != nil {
 		var listening bool
-		if err := pna.rpc.Call(&listening, "net_listening"); err == nil {
+		if err := pna.rpc.CallContext(ctx, &listening, "net_listening"); err == nil {
 			return listening
 		}
 	}
@@ -64,11 +65,11 @@ func (pna *PublicNetAPI) Listening() bool {
 }
 
 // PeerCount returns the number of connected peers
-func (pna *PublicNetAPI) PeerCount() hexutil.Uint {
+func (pna *PublicNetAPI) PeerCount(ctx context.Context) hexutil.Uint {
 	// in this case it is actually the peer count of the proxied node
 	if pna.rpc != nil {
 		var num hexutil.Uint
-		if err := pna.rpc.Call(&num, "net_peerCount"); err == nil {
+		if err := pna.rpc.CallContext(ctx, &num, "net_peerCount"); err == nil {
 			return num
 		}
 	}
@@ -76,13 +77,13 @@ func (pna *PublicNetAPI) PeerCount() hexutil.Uint {
 }
 
 // Version returns the current ethereum protocol version.
-func (pna *PublicNetAPI) Version() string {
+func (pna *PublicNetAPI) Version(ctx context.Context) string {
 	if pna.networkVersion != 0 {
 		return fmt.Sprintf("%d", pna.networkVersion)
 	}
 	if pna.rpc != nil {
 		var version string
-		if err := pna.rpc.Call(&version, "net_version"); err == nil {
+		if err := pna.rpc.CallContext(ctx, &version, "net_version"); err == nil {
 			return version
 		}
 	}
@@ -17,12 +17,29 @@
 package net_test
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
 	"github.com/vulcanize/ipld-eth-server/pkg/net"
+	"github.com/vulcanize/ipld-eth-server/pkg/upstream"
 )
 
+// mockNetAPI is a fake proxy node answering the net namespace
+type mockNetAPI struct{}
+
+func (mockNetAPI) PeerCount() hexutil.Uint {
+	return 25
+}
+
 var _ = Describe("API", func() {
 	var (
 		api *net.PublicNetAPI
@@ -32,16 +49,43 @@ var _ = Describe("API", func() {
 	})
 	Describe("net_listening", func() {
 		It("Retrieves whether or not the node is listening to the p2p network", func() {
-			listening := api.Listening()
+			listening := api.Listening(context.Background())
 			Expect(listening).To(BeFalse())
 		})
 	})
 
 	Describe("net_version", func() {
 		It("Retrieves the network id", func() {
-			version := api.Version()
+			version := api.Version(context.Background())
 			Expect(version).To(Equal("1"))
 		})
 	})
-	// TODO: test PeerCount with mock proxy node
+
+	Describe("net_peerCount", func() {
+		It("Retrieves the peer count of the proxy node", func() {
+			srv := rpc.NewServer()
+			Expect(srv.RegisterName("net", mockNetAPI{})).To(Succeed())
+			client := rpc.DialInProc(srv)
+			defer client.Close()
+			api = net.NewPublicNetAPI(1, client)
+			Expect(api.PeerCount(context.Background())).To(Equal(hexutil.Uint(25)))
+		})
+
+		It("Doesn't call the proxy node while its circuit breaker is open", func() {
+			var requests int32
+			down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&requests, 1)
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}))
+			defer down.Close()
+			pool, err := upstream.NewPool("read", []upstream.NodeConfig{{Name: "down", URL: down.URL, Weight: 1}},
+				upstream.PoolConfig{BreakerThreshold: 1, BreakerCooldown: time.Hour})
+			Expect(err).ToNot(HaveOccurred())
+			api = net.NewPublicNetAPI(1, pool.Client())
+			Expect(api.PeerCount(context.Background())).To(Equal(hexutil.Uint(0)))
+			Expect(api.PeerCount(context.Background())).To(Equal(hexutil.Uint(0)))
+			Expect(atomic.LoadInt32(&requests)).To(Equal(int32(1)))
+			Expect(pool.Status().Breaker).To(Equal(upstream.BreakerOpen))
+		})
+	})
 })
@@ -0,0 +1,126 @@
+// VulcanizeDB
+// Copyright © 2022 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package prom
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const subsystemGapFill = "gap_fill"
+
+var (
+	gapFillMissing      prometheus.Gauge
+	gapFillNonCanonical prometheus.Gauge
+	gapFillScannedTo    prometheus.Gauge
+	gapFillQueued       prometheus.Gauge
+	gapFillInFlight     prometheus.Gauge
+	gapFillFilled       prometheus.Counter
+	gapFillFailed       prometheus.Counter
+	gapFillRetries      prometheus.Counter
+)
+
+func initGapFillMetrics() {
+	gapFillMissing = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: subsystemGapFill,
+		Name:      "missing",
+		Help:      "number of missing heights found by the last gap scan",
+	})
+	gapFillNonCanonical = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: subsystemGapFill,
+		Name:      "non_canonical",
+		Help:      "number of heights with only non-canonical headers found by the last gap scan",
+	})
+	gapFillScannedTo = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: subsystemGapFill,
+		Name:      "scanned_to",
+		Help:      "highest height checked by the last gap scan",
+	})
+	gapFillQueued = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: subsystemGapFill,
+		Name:      "queued",
+		Help:      "number of heights waiting to be filled",
+	})
+	gapFillInFlight = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: subsystemGapFill,
+		Name:      "in_flight",
+		Help:      "number of heights being filled",
+	})
+	gapFillFilled = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystemGapFill,
+		Name:      "filled",
+		Help:      "number of heights filled",
+	})
+	gapFillFailed = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystemGapFill,
+		Name:      "failed",
+		Help:      "number of heights which could not be filled after retrying",
+	})
+	gapFillRetries = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystemGapFill,
+		Name:      "retries",
+		Help:      "number of retried statediff_writeStateDiffAt calls",
+	})
+}
+
+// SetGapFillScan records the results of a gap scan
+func SetGapFillScan(missing, nonCanonical, scannedTo uint64) {
+	if !metrics {
+		return
+	}
+	gapFillMissing.Set(float64(missing))
+	gapFillNonCanonical.Set(float64(nonCanonical))
+	gapFillScannedTo.Set(float64(scannedTo))
+}
+
+// SetGapFillQueue records the number of queued and in flight heights
+func SetGapFillQueue(queued, inFlight int) {
+	if !metrics {
+		return
+	}
+	gapFillQueued.Set(float64(queued))
+	gapFillInFlight.Set(float64(inFlight))
+}
+
+// IncGapFillFilled counts a filled height
+func IncGapFillFilled() {
+	if metrics {
+		gapFillFilled.Inc()
+	}
+}
+
+// IncGapFillFailed counts a height which could not be filled
+func IncGapFillFailed() {
+	if metrics {
+		gapFillFailed.Inc()
+	}
+}
+
+// IncGapFillRetries counts a retried write
+func IncGapFillRetries() {
+	if metrics {
+		gapFillRetries.Inc()
+	}
+}
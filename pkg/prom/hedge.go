@@ -0,0 +1,70 @@
+// VulcanizeDB
+// Copyright © 2022 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package prom
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const subsystemHedge = "hedge"
+
+// Winners of hedged requests
+const (
+	// the local index answered first
+	HedgeLocal = "local"
+	// the proxy node answered before the local index
+	HedgeProxy = "proxy"
+	// the local lookup failed and the proxy node answered
+	HedgeFallback = "fallback"
+	// neither answered
+	HedgeFailed = "failed"
+)
+
+var (
+	hedgeWins     *prometheus.CounterVec
+	hedgeRequests *prometheus.CounterVec
+)
+
+func initHedgeMetrics() {
+	hedgeWins = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystemHedge,
+		Name:      "wins",
+		Help:      "hedged requests by method and winning side",
+	}, []string{"method", "winner"})
+	hedgeRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystemHedge,
+		Name:      "proxy_requests",
+		Help:      "hedged requests sent to the proxy node, by method",
+	}, []string{"method"})
+}
+
+// RecordHedgeWin counts a hedged request with the side which answered it
+func RecordHedgeWin(method, winner string) {
+	if metrics {
+		hedgeWins.WithLabelValues(method, winner).Inc()
+	}
+}
+
+// IncHedgeRequests counts a hedged request sent to the proxy node
+func IncHedgeRequests(method string) {
+	if metrics {
+		hedgeRequests.WithLabelValues(method).Inc()
+	}
+}
@@ -69,6 +69,12 @@ func Init() {
 		Name:      "count",
 		Help:      "unix socket connection count",
 	})
+
+	initGapFillMetrics()
+	initStateDiffWriteMetrics()
+	initUpstreamMetrics()
+	initShadowMetrics()
+	initHedgeMetrics()
 }
 
 // RegisterDBCollector create metric colletor for given connection
@@ -0,0 +1,50 @@
+// VulcanizeDB
+// Copyright © 2022 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package prom
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const subsystemShadow = "shadow"
+
+// Outcomes of shadowed requests
+const (
+	ShadowMatch      = "match"
+	ShadowDivergence = "divergence"
+	ShadowError      = "error"
+	ShadowSkipped    = "skipped"
+)
+
+var shadowComparisons *prometheus.CounterVec
+
+func initShadowMetrics() {
+	shadowComparisons = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystemShadow,
+		Name:      "comparisons",
+		Help:      "local answers compared with the proxy node's, by method and outcome",
+	}, []string{"method", "result"})
+}
+
+// RecordShadowComparison counts a shadowed request with its outcome
+func RecordShadowComparison(method, result string) {
+	if metrics {
+		shadowComparisons.WithLabelValues(method, result).Inc()
+	}
+}
@@ -0,0 +1,66 @@
+// VulcanizeDB
+// Copyright © 2022 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package prom
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const subsystemStateDiffWrites = "statediff_writes"
+
+// Outcomes of the statediff writes requested on proxied cache misses
+const (
+	StateDiffWriteSuccess   = "success"
+	StateDiffWriteFailure   = "failure"
+	StateDiffWriteCoalesced = "coalesced"
+	StateDiffWriteDropped   = "dropped"
+)
+
+var (
+	stateDiffWrites        *prometheus.CounterVec
+	stateDiffWritesPending prometheus.Gauge
+)
+
+func initStateDiffWriteMetrics() {
+	stateDiffWrites = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystemStateDiffWrites,
+		Name:      "count",
+		Help:      "statediff writes requested on proxied cache misses, by method and outcome",
+	}, []string{"method", "result"})
+	stateDiffWritesPending = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: subsystemStateDiffWrites,
+		Name:      "pending",
+		Help:      "number of queued and in flight statediff writes",
+	})
+}
+
+// RecordStateDiffWrite counts a statediff write request with its outcome
+func RecordStateDiffWrite(method, result string) {
+	if metrics {
+		stateDiffWrites.WithLabelValues(method, result).Inc()
+	}
+}
+
+// SetStateDiffWritesPending records the number of queued and in flight statediff writes
+func SetStateDiffWritesPending(pending int) {
+	if metrics {
+		stateDiffWritesPending.Set(float64(pending))
+	}
+}
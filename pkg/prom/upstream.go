@@ -0,0 +1,103 @@
+// VulcanizeDB
+// Copyright © 2022 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package prom
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const subsystemUpstream = "upstream"
+
+var (
+	upstreamHealthy   *prometheus.GaugeVec
+	upstreamRequests  *prometheus.CounterVec
+	upstreamFailovers *prometheus.CounterVec
+	upstreamBreaker   *prometheus.GaugeVec
+)
+
+// breaker states exported by the upstream_breaker_state metric
+var breakerStates = map[string]float64{
+	"closed":    0,
+	"half-open": 1,
+	"open":      2,
+}
+
+func initUpstreamMetrics() {
+	upstreamHealthy = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: subsystemUpstream,
+		Name:      "healthy",
+		Help:      "whether the upstream node is healthy (1) or not (0)",
+	}, []string{"pool", "node"})
+	upstreamRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystemUpstream,
+		Name:      "requests",
+		Help:      "requests sent to the upstream node, by result",
+	}, []string{"pool", "node", "result"})
+	upstreamFailovers = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystemUpstream,
+		Name:      "failovers",
+		Help:      "requests retried on another node of the pool",
+	}, []string{"pool"})
+	upstreamBreaker = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: subsystemUpstream,
+		Name:      "breaker_state",
+		Help:      "state of the pool's circuit breaker: closed (0), half-open (1) or open (2)",
+	}, []string{"pool"})
+}
+
+// SetUpstreamHealthy records the health of an upstream node
+func SetUpstreamHealthy(pool, node string, healthy bool) {
+	if !metrics {
+		return
+	}
+	var value float64
+	if healthy {
+		value = 1
+	}
+	upstreamHealthy.WithLabelValues(pool, node).Set(value)
+}
+
+// RecordUpstreamRequest counts a request sent to an upstream node
+func RecordUpstreamRequest(pool, node string, success bool) {
+	if !metrics {
+		return
+	}
+	result := "success"
+	if !success {
+		result = "failure"
+	}
+	upstreamRequests.WithLabelValues(pool, node, result).Inc()
+}
+
+// SetUpstreamBreakerState records the state of a pool's circuit breaker
+func SetUpstreamBreakerState(pool, state string) {
+	if metrics {
+		upstreamBreaker.WithLabelValues(pool).Set(breakerStates[state])
+	}
+}
+
+// IncUpstreamFailovers counts a request retried on another node of the pool
+func IncUpstreamFailovers(pool string) {
+	if metrics {
+		upstreamFailovers.WithLabelValues(pool).Inc()
+	}
+}
@@ -0,0 +1,66 @@
+// VulcanizeDB
+// Copyright © 2022 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/rpc"
+	log "github.com/sirupsen/logrus"
+)
+
+// bearerScheme prefixes the token in the Authorization header
+const bearerScheme = "Bearer "
+
+// StartAdminHTTPEndpoint starts an HTTP RPC endpoint serving the provided (non-public) APIs
+// Every request must carry the token as a bearer token in its Authorization header
+func StartAdminHTTPEndpoint(endpoint string, apis []rpc.API, token string) (*rpc.Server, error) {
+	srv := rpc.NewServer()
+	for _, api := range apis {
+		if err := srv.RegisterName(api.Namespace, api.Service); err != nil {
+			return nil, err
+		}
+		log.Debugf("admin HTTP registered namespace %s", api.Namespace)
+	}
+	handler := NewTokenAuthHandler(token, node.NewHTTPHandlerStack(srv, nil, []string{"*"}))
+
+	_, addr, err := node.StartHTTPEndpoint(endpoint, rpc.DefaultHTTPTimeouts, handler)
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("admin HTTP endpoint opened http://%v/", addr)
+
+	return srv, nil
+}
+
+// NewTokenAuthHandler wraps the handler so that it rejects requests which do not present the bearer token
+func NewTokenAuthHandler(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		if token == "" || !strings.HasPrefix(header, bearerScheme) ||
+			subtle.ConstantTimeCompare([]byte(header[len(bearerScheme):]), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, fmt.Sprintf("%d %s", http.StatusUnauthorized, http.StatusText(http.StatusUnauthorized)), http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,62 @@
+// VulcanizeDB
+// Copyright © 2022 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package rpc_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/ipld-eth-server/pkg/rpc"
+)
+
+var _ = Describe("NewTokenAuthHandler", func() {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	status := func(token, authorization string) int {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		rec := httptest.NewRecorder()
+		rpc.NewTokenAuthHandler(token, next).ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	It("accepts the token presented with the Bearer scheme", func() {
+		Expect(status("secret", "Bearer secret")).To(Equal(http.StatusOK))
+	})
+
+	It("rejects a bare token without the Bearer scheme", func() {
+		Expect(status("secret", "secret")).To(Equal(http.StatusUnauthorized))
+	})
+
+	It("rejects a wrong, missing or differently schemed token", func() {
+		Expect(status("secret", "Bearer wrong")).To(Equal(http.StatusUnauthorized))
+		Expect(status("secret", "")).To(Equal(http.StatusUnauthorized))
+		Expect(status("secret", "Basic secret")).To(Equal(http.StatusUnauthorized))
+		Expect(status("secret", "Bearer ")).To(Equal(http.StatusUnauthorized))
+	})
+
+	It("rejects every request when no token is configured", func() {
+		Expect(status("", "Bearer ")).To(Equal(http.StatusUnauthorized))
+	})
+})
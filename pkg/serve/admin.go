@@ -0,0 +1,287 @@
+// VulcanizeDB
+// Copyright © 2022 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package serve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vulcanize/ipld-eth-server/pkg/eth"
+	"github.com/vulcanize/ipld-eth-server/pkg/gapfill"
+	"github.com/vulcanize/ipld-eth-server/pkg/upstream"
+)
+
+// AdminAPIName is the namespace used for the subscription administration API
+const AdminAPIName = "vdbadmin"
+
+// AdminAPIVersion is the version of the subscription administration API
+const AdminAPIVersion = "0.0.1"
+
+var (
+	// ErrSubscriptionLimit is sent to subscribers when the maximum number of concurrent subscriptions is reached
+	ErrSubscriptionLimit = errors.New("eth ipld server has reached its maximum number of concurrent subscriptions")
+	// ErrBackfillLimit is sent to subscribers when the maximum number of concurrent backfills is reached
+	ErrBackfillLimit = errors.New("eth ipld server has reached its maximum number of concurrent backfills")
+	// ErrClosedByAdmin is sent to subscribers which are force-closed through the admin API
+	ErrClosedByAdmin = errors.New("subscription closed by the eth ipld server administrator")
+
+	errGapFillDisabled = errors.New("gap filling is disabled")
+	errShadowDisabled  = errors.New("shadow mode is disabled")
+)
+
+// SubscriptionInfo describes an active subscription
+type SubscriptionInfo struct {
+	ID                 rpc.ID      `json:"id"`
+	SettingsHash       common.Hash `json:"settingsHash"` // the subscription type, the hash of the rlp-serialized settings
+	StartTime          time.Time   `json:"startTime"`
+	Delivered          uint64      `json:"delivered"`          // payloads sent to the subscriber
+	Dropped            uint64      `json:"dropped"`            // payloads dropped because the subscriber's channel was full
+	LastDeliveredBlock int64       `json:"lastDeliveredBlock"` // height of the last payload sent to the subscriber
+	Lag                int64       `json:"lag"`                // blocks between the latest streamed block and the last delivered block
+	Buffered           int         `json:"buffered"`           // payloads waiting in the subscriber's channel
+	Backfilling        bool        `json:"backfilling"`
+}
+
+// SubscriptionLimits caps the number of concurrent subscriptions and backfills; 0 means no limit
+type SubscriptionLimits struct {
+	MaxSubscriptions int `json:"maxSubscriptions"`
+	MaxBackfills     int `json:"maxBackfills"`
+}
+
+// subscriptionStats is the bookkeeping kept for each subscription
+// the counters are updated atomically since backfills send outside of the subscription lock
+type subscriptionStats struct {
+	sub           Subscription
+	settingsHash  common.Hash
+	startTime     time.Time
+	delivered     uint64
+	dropped       uint64
+	lastDelivered int64
+	backfilling   int32
+	// closed is closed when the subscription is removed, to stop any backfill still running for it
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func newSubscriptionStats(sub Subscription, settingsHash common.Hash) *subscriptionStats {
+	return &subscriptionStats{
+		sub:          sub,
+		settingsHash: settingsHash,
+		startTime:    time.Now(),
+		closed:       make(chan struct{}),
+	}
+}
+
+// record counts a payload at the given height as delivered or dropped
+func (st *subscriptionStats) record(sent bool, height int64) {
+	if st == nil {
+		return
+	}
+	if !sent {
+		atomic.AddUint64(&st.dropped, 1)
+		return
+	}
+	atomic.AddUint64(&st.delivered, 1)
+	if height > atomic.LoadInt64(&st.lastDelivered) {
+		atomic.StoreInt64(&st.lastDelivered, height)
+	}
+}
+
+// deliveredFrom reports whether a payload at or above the height was delivered, assuming it was when there are no stats
+func (st *subscriptionStats) deliveredFrom(height int64) bool {
+	if st == nil {
+		return true
+	}
+	return atomic.LoadInt64(&st.lastDelivered) >= height
+}
+
+func (st *subscriptionStats) close() {
+	st.closeOnce.Do(func() {
+		close(st.closed)
+	})
+}
+
+func (st *subscriptionStats) info(id rpc.ID, head int64) SubscriptionInfo {
+	info := SubscriptionInfo{
+		ID:                 id,
+		SettingsHash:       st.settingsHash,
+		StartTime:          st.startTime,
+		Delivered:          atomic.LoadUint64(&st.delivered),
+		Dropped:            atomic.LoadUint64(&st.dropped),
+		LastDeliveredBlock: atomic.LoadInt64(&st.lastDelivered),
+		Buffered:           len(st.sub.PayloadChan),
+		Backfilling:        atomic.LoadInt32(&st.backfilling) == 1,
+	}
+	if info.LastDeliveredBlock > 0 && head > info.LastDeliveredBlock {
+		info.Lag = head - info.LastDeliveredBlock
+	}
+	return info
+}
+
+// SubscriptionInfos lists the active subscriptions, ordered by start time
+func (sap *Service) SubscriptionInfos() []SubscriptionInfo {
+	sap.Lock()
+	defer sap.Unlock()
+	infos := make([]SubscriptionInfo, 0, len(sap.subscriptionStats))
+	for id, st := range sap.subscriptionStats {
+		infos = append(infos, st.info(id, sap.chain.head))
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].StartTime.Before(infos[j].StartTime) })
+	return infos
+}
+
+// CloseSubscription force-closes the subscription with the given id
+func (sap *Service) CloseSubscription(id rpc.ID) error {
+	sap.Lock()
+	st, ok := sap.subscriptionStats[id]
+	sap.Unlock()
+	if !ok {
+		return fmt.Errorf("eth ipld server has no subscription %s", id)
+	}
+	log.Infof("closing eth ipld subscription %s at the administrator's request", id)
+	sap.Unsubscribe(id)
+	sendNonBlockingErr(st.sub, ErrClosedByAdmin)
+	sendNonBlockingQuit(st.sub)
+	return nil
+}
+
+// Limits returns the current subscription limits
+func (sap *Service) Limits() SubscriptionLimits {
+	sap.Lock()
+	defer sap.Unlock()
+	return sap.limits
+}
+
+// SetLimits updates the subscription limits, they only apply to new subscriptions
+func (sap *Service) SetLimits(limits SubscriptionLimits) error {
+	if limits.MaxSubscriptions < 0 || limits.MaxBackfills < 0 {
+		return errors.New("subscription limits can't be negative")
+	}
+	sap.Lock()
+	defer sap.Unlock()
+	sap.limits = limits
+	return nil
+}
+
+// checkLimits returns an error if a new subscription would exceed the limits
+// checkLimits needs to be called with subscription access locked
+func (sap *Service) checkLimits(backfill bool) error {
+	if sap.limits.MaxSubscriptions > 0 && len(sap.subscriptionStats) >= sap.limits.MaxSubscriptions {
+		return ErrSubscriptionLimit
+	}
+	if backfill && sap.limits.MaxBackfills > 0 && sap.backfills >= sap.limits.MaxBackfills {
+		return ErrBackfillLimit
+	}
+	return nil
+}
+
+// AdminServerAPI is the subscription administration api for the watcher
+// It is not public and is only served over IPC and the token authenticated admin endpoint
+type AdminServerAPI struct {
+	s *Service
+}
+
+// NewAdminServerAPI creates a new AdminServerAPI for the provided Service
+func NewAdminServerAPI(s *Service) *AdminServerAPI {
+	return &AdminServerAPI{
+		s: s,
+	}
+}
+
+// Subscriptions lists the active subscriptions
+func (api *AdminServerAPI) Subscriptions() []SubscriptionInfo {
+	return api.s.SubscriptionInfos()
+}
+
+// CloseSubscription force-closes the subscription with the given id
+func (api *AdminServerAPI) CloseSubscription(id rpc.ID) (bool, error) {
+	if err := api.s.CloseSubscription(id); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Limits returns the maximum number of concurrent subscriptions and backfills
+func (api *AdminServerAPI) Limits() SubscriptionLimits {
+	return api.s.Limits()
+}
+
+// SetLimits sets the maximum number of concurrent subscriptions and backfills
+func (api *AdminServerAPI) SetLimits(limits SubscriptionLimits) (SubscriptionLimits, error) {
+	if err := api.s.SetLimits(limits); err != nil {
+		return SubscriptionLimits{}, err
+	}
+	return api.s.Limits(), nil
+}
+
+// WatchedAddresses lists the addresses watched by the statediffing proxy node
+func (api *AdminServerAPI) WatchedAddresses() []WatchedAddress {
+	return api.s.watched.List()
+}
+
+// AddWatchedAddresses watches the addresses on the statediffing proxy node
+// If a range is provided, state for the newly added addresses is backfilled over it with statediff_writeStateDiffAt
+func (api *AdminServerAPI) AddWatchedAddresses(ctx context.Context, args []WatchAddressArg, fill *BackfillRange) ([]WatchedAddress, error) {
+	return api.s.watched.Add(ctx, args, fill)
+}
+
+// RemoveWatchedAddresses stops watching the addresses on the statediffing proxy node
+func (api *AdminServerAPI) RemoveWatchedAddresses(ctx context.Context, addresses []common.Address) ([]WatchedAddress, error) {
+	return api.s.watched.Remove(ctx, addresses)
+}
+
+// GapFillStatus returns the progress of the gap filler
+func (api *AdminServerAPI) GapFillStatus() (gapfill.Status, error) {
+	if api.s.gapFiller == nil {
+		return gapfill.Status{}, errGapFillDisabled
+	}
+	return api.s.gapFiller.Status(), nil
+}
+
+// ScanGaps triggers a gap scan, unless one is already running
+func (api *AdminServerAPI) ScanGaps() (bool, error) {
+	if api.s.gapFiller == nil {
+		return false, errGapFillDisabled
+	}
+	api.s.gapFiller.Scan()
+	return true, nil
+}
+
+// Upstreams returns the state of the upstream nodes in the read and statediff pools
+func (api *AdminServerAPI) Upstreams() ([]upstream.PoolStatus, error) {
+	if api.s.upstreams == nil {
+		return nil, errors.New("no upstream nodes are configured")
+	}
+	return api.s.upstreams.Status(), nil
+}
+
+// ShadowResults returns the outcomes of the requests shadowed to the proxy node, by method
+func (api *AdminServerAPI) ShadowResults() (map[string]eth.ShadowResults, error) {
+	if api.s.shadow == nil {
+		return nil, errShadowDisabled
+	}
+	return api.s.shadow.Results(), nil
+}
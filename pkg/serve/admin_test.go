@@ -0,0 +1,165 @@
+// VulcanizeDB
+// Copyright © 2022 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package serve_test
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/rpc"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/ipld-eth-server/pkg/eth"
+	"github.com/vulcanize/ipld-eth-server/pkg/eth/test_helpers"
+	"github.com/vulcanize/ipld-eth-server/pkg/serve"
+)
+
+// blockingRetriever serves the blocks from 1 up to last (1 when unset), holding every retrieval until it is released
+type blockingRetriever struct {
+	release chan struct{}
+	last    int64
+}
+
+func (r *blockingRetriever) RetrieveFirstBlockNumber() (int64, error) {
+	return 1, nil
+}
+
+func (r *blockingRetriever) RetrieveLastBlockNumber() (int64, error) {
+	if r.last == 0 {
+		return 1, nil
+	}
+	return r.last, nil
+}
+
+func (r *blockingRetriever) Retrieve(filter eth.SubscriptionSettings, blockNumber int64) ([]eth.CIDWrapper, bool, error) {
+	<-r.release
+	return []eth.CIDWrapper{{BlockNumber: big.NewInt(blockNumber)}}, false, nil
+}
+
+type mockFetcher struct{}
+
+func (f mockFetcher) Fetch(cids eth.CIDWrapper) (*eth.IPLDs, error) {
+	iplds := test_helpers.MockIPLDs
+	iplds.BlockNumber = cids.BlockNumber
+	return &iplds, nil
+}
+
+type subscriber struct {
+	payloads chan serve.SubscriptionPayload
+	quit     chan bool
+}
+
+func newSubscriber(buffer int) subscriber {
+	return subscriber{
+		payloads: make(chan serve.SubscriptionPayload, buffer),
+		quit:     make(chan bool, 1),
+	}
+}
+
+var _ = Describe("Subscription administration", func() {
+	var (
+		service   *serve.Service
+		retriever *blockingRetriever
+		settings  eth.SubscriptionSettings
+	)
+	BeforeEach(func() {
+		retriever = &blockingRetriever{release: make(chan struct{})}
+		service = serve.NewTestService(eth.NewResponseFilterer(), retriever, mockFetcher{})
+		settings = eth.SubscriptionSettings{
+			Start:         big.NewInt(0),
+			End:           big.NewInt(0),
+			StateFilter:   eth.StateFilter{Off: true},
+			StorageFilter: eth.StorageFilter{Off: true},
+		}
+	})
+	AfterEach(func() {
+		close(retriever.release)
+	})
+
+	It("Lists subscriptions with their delivery counts", func() {
+		fast, slow := newSubscriber(10), newSubscriber(0)
+		service.Subscribe(rpc.ID("fast"), fast.payloads, fast.quit, settings)
+		service.Subscribe(rpc.ID("slow"), slow.payloads, slow.quit, settings)
+		service.FilterAndServe(test_helpers.MockConvertedPayload)
+
+		infos := service.SubscriptionInfos()
+		Expect(len(infos)).To(Equal(2))
+		byID := make(map[rpc.ID]serve.SubscriptionInfo)
+		for _, info := range infos {
+			byID[info.ID] = info
+		}
+		Expect(byID["fast"].SettingsHash).To(Equal(byID["slow"].SettingsHash))
+		Expect(byID["fast"].Delivered).To(Equal(uint64(1)))
+		Expect(byID["fast"].Dropped).To(Equal(uint64(0)))
+		Expect(byID["fast"].Buffered).To(Equal(1))
+		Expect(byID["fast"].LastDeliveredBlock).To(Equal(test_helpers.BlockNumber.Int64()))
+		Expect(byID["slow"].Delivered).To(Equal(uint64(0)))
+		Expect(byID["slow"].Dropped).To(Equal(uint64(1)))
+
+		service.Unsubscribe(rpc.ID("slow"))
+		Expect(len(service.SubscriptionInfos())).To(Equal(1))
+	})
+
+	It("Force-closes a subscription", func() {
+		sub := newSubscriber(10)
+		service.Subscribe(rpc.ID("sub"), sub.payloads, sub.quit, settings)
+		Expect(service.CloseSubscription(rpc.ID("sub"))).To(Succeed())
+		Expect((<-sub.payloads).Err).To(Equal(serve.ErrClosedByAdmin.Error()))
+		Expect(<-sub.quit).To(BeTrue())
+		Expect(len(service.SubscriptionInfos())).To(Equal(0))
+		Expect(len(service.Subscriptions)).To(Equal(0))
+
+		Expect(service.CloseSubscription(rpc.ID("unknown"))).ToNot(Succeed())
+	})
+
+	It("Caps the number of concurrent subscriptions", func() {
+		Expect(service.SetLimits(serve.SubscriptionLimits{MaxSubscriptions: 1})).To(Succeed())
+		first, second := newSubscriber(10), newSubscriber(10)
+		service.Subscribe(rpc.ID("first"), first.payloads, first.quit, settings)
+		service.Subscribe(rpc.ID("second"), second.payloads, second.quit, settings)
+		Expect((<-second.payloads).Err).To(Equal(serve.ErrSubscriptionLimit.Error()))
+		Expect(<-second.quit).To(BeTrue())
+		Expect(len(first.quit)).To(Equal(0))
+
+		Expect(service.SetLimits(serve.SubscriptionLimits{MaxSubscriptions: -1})).ToNot(Succeed())
+	})
+
+	It("Caps the number of concurrent backfills", func() {
+		Expect(service.SetLimits(serve.SubscriptionLimits{MaxBackfills: 1})).To(Succeed())
+		settings.BackFillOnly = true
+		first, second := newSubscriber(10), newSubscriber(10)
+		service.Subscribe(rpc.ID("first"), first.payloads, first.quit, settings)
+		infos := service.SubscriptionInfos()
+		Expect(len(infos)).To(Equal(1))
+		Expect(infos[0].Backfilling).To(BeTrue())
+
+		service.Subscribe(rpc.ID("second"), second.payloads, second.quit, settings)
+		Expect((<-second.payloads).Err).To(Equal(serve.ErrBackfillLimit.Error()))
+
+		// once the backfill completes the backfill-only subscription is done
+		retriever.release <- struct{}{}
+		Expect((<-first.payloads).Flag).To(Equal(serve.EmptyFlag))
+		Expect((<-first.payloads).BackFillComplete()).To(BeTrue())
+		Eventually(service.SubscriptionInfos).Should(BeEmpty())
+
+		third := newSubscriber(10)
+		service.Subscribe(rpc.ID("third"), third.payloads, third.quit, settings)
+		Expect(len(third.quit)).To(Equal(0))
+		Expect(len(service.SubscriptionInfos())).To(Equal(1))
+	})
+})
@@ -22,16 +22,19 @@ import (
 	"math/big"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/params"
-	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/ethereum/go-ethereum/statediff/indexer/postgres"
 	"github.com/spf13/viper"
 
 	"github.com/vulcanize/ipld-eth-server/pkg/eth"
+	"github.com/vulcanize/ipld-eth-server/pkg/gapfill"
+	"github.com/vulcanize/ipld-eth-server/pkg/graphql"
 	"github.com/vulcanize/ipld-eth-server/pkg/prom"
 	ethServerShared "github.com/vulcanize/ipld-eth-server/pkg/shared"
+	"github.com/vulcanize/ipld-eth-server/pkg/upstream"
 )
 
 // Env variables
@@ -44,6 +47,19 @@ const (
 	SERVER_MAX_OPEN_CONNECTIONS = "SERVER_MAX_OPEN_CONNECTIONS"
 	SERVER_MAX_CONN_LIFETIME    = "SERVER_MAX_CONN_LIFETIME"
 
+	SERVER_ADMIN_TOKEN       = "SERVER_ADMIN_TOKEN"
+	SERVER_MAX_SUBSCRIPTIONS = "SERVER_MAX_SUBSCRIPTIONS"
+	SERVER_MAX_BACKFILLS     = "SERVER_MAX_BACKFILLS"
+
+	SERVER_WATCHED_ADDRESSES_PATH = "SERVER_WATCHED_ADDRESSES_PATH"
+
+	SERVER_GRAPHQL_MAX_DEPTH       = "SERVER_GRAPHQL_MAX_DEPTH"
+	SERVER_GRAPHQL_MAX_COST        = "SERVER_GRAPHQL_MAX_COST"
+	SERVER_GRAPHQL_MAX_BLOCK_RANGE = "SERVER_GRAPHQL_MAX_BLOCK_RANGE"
+	SERVER_GRAPHQL_MAX_SELECTIONS  = "SERVER_GRAPHQL_MAX_SELECTIONS"
+	SERVER_GRAPHQL_LIST_SIZE       = "SERVER_GRAPHQL_LIST_SIZE"
+	SERVER_GRAPHQL_FIELD_WEIGHTS   = "SERVER_GRAPHQL_FIELD_WEIGHTS"
+
 	ETH_DEFAULT_SENDER_ADDR = "ETH_DEFAULT_SENDER_ADDR"
 	ETH_RPC_GAS_CAP         = "ETH_RPC_GAS_CAP"
 	ETH_CHAIN_CONFIG        = "ETH_CHAIN_CONFIG"
@@ -51,6 +67,35 @@ const (
 	ETH_FORWARD_ETH_CALLS   = "ETH_FORWARD_ETH_CALLS"
 	ETH_PROXY_ON_ERROR      = "ETH_PROXY_ON_ERROR"
 
+	ETH_UPSTREAM_HEALTH_INTERVAL        = "ETH_UPSTREAM_HEALTH_INTERVAL"
+	ETH_UPSTREAM_HEALTH_TIMEOUT         = "ETH_UPSTREAM_HEALTH_TIMEOUT"
+	ETH_UPSTREAM_HEALTH_MAX_FAILURES    = "ETH_UPSTREAM_HEALTH_MAX_FAILURES"
+	ETH_UPSTREAM_HEALTH_MAX_LAG         = "ETH_UPSTREAM_HEALTH_MAX_LAG"
+	ETH_UPSTREAM_HEALTH_REQUEST_TIMEOUT = "ETH_UPSTREAM_HEALTH_REQUEST_TIMEOUT"
+	ETH_UPSTREAM_BREAKER_THRESHOLD      = "ETH_UPSTREAM_BREAKER_THRESHOLD"
+	ETH_UPSTREAM_BREAKER_COOLDOWN       = "ETH_UPSTREAM_BREAKER_COOLDOWN"
+
+	ETH_STATEDIFF_WRITE_WORKERS    = "ETH_STATEDIFF_WRITE_WORKERS"
+	ETH_STATEDIFF_WRITE_QUEUE_SIZE = "ETH_STATEDIFF_WRITE_QUEUE_SIZE"
+	ETH_STATEDIFF_WRITE_MAX_RANGE  = "ETH_STATEDIFF_WRITE_MAX_RANGE"
+	ETH_STATEDIFF_WRITE_TIMEOUT    = "ETH_STATEDIFF_WRITE_TIMEOUT"
+
+	ETH_SHADOW_SAMPLE_RATE    = "ETH_SHADOW_SAMPLE_RATE"
+	ETH_SHADOW_METHODS        = "ETH_SHADOW_METHODS"
+	ETH_SHADOW_TIMEOUT        = "ETH_SHADOW_TIMEOUT"
+	ETH_SHADOW_MAX_CONCURRENT = "ETH_SHADOW_MAX_CONCURRENT"
+
+	ETH_HEDGE_METHODS = "ETH_HEDGE_METHODS"
+
+	GAPFILL_ENABLED        = "GAPFILL_ENABLED"
+	GAPFILL_START          = "GAPFILL_START"
+	GAPFILL_INTERVAL       = "GAPFILL_INTERVAL"
+	GAPFILL_WORKERS        = "GAPFILL_WORKERS"
+	GAPFILL_QUEUE_SIZE     = "GAPFILL_QUEUE_SIZE"
+	GAPFILL_RATE_LIMIT     = "GAPFILL_RATE_LIMIT"
+	GAPFILL_MAX_RETRIES    = "GAPFILL_MAX_RETRIES"
+	GAPFILL_RETRY_INTERVAL = "GAPFILL_RETRY_INTERVAL"
+
 	VALIDATOR_ENABLED         = "VALIDATOR_ENABLED"
 	VALIDATOR_EVERY_NTH_BLOCK = "VALIDATOR_EVERY_NTH_BLOCK"
 )
@@ -70,8 +115,25 @@ type Config struct {
 	IPCEnabled  bool
 	IPCEndpoint string
 
+	AdminEnabled  bool
+	AdminEndpoint string
+	AdminToken    string
+
+	SSEEnabled     bool
+	SSEEndpoint    string
+	SSECorsOrigins []string
+
+	GRPCEnabled  bool
+	GRPCEndpoint string
+
+	MaxSubscriptions int
+	MaxBackfills     int
+
+	WatchedAddressesPath string
+
 	EthGraphqlEnabled  bool
 	EthGraphqlEndpoint string
+	EthGraphqlLimits   graphql.Limits
 
 	IpldGraphqlEnabled          bool
 	IpldGraphqlEndpoint         string
@@ -83,14 +145,25 @@ type Config struct {
 	DefaultSender    *common.Address
 	RPCGasCap        *big.Int
 	EthHttpEndpoint  string
-	Client           *rpc.Client
+	Upstreams        *upstream.Pools
 	SupportStateDiff bool
 	ForwardEthCalls  bool
 	ProxyOnError     bool
 
+	// limits on the statediff writes requested on proxied cache misses
+	StateDiffWrites eth.StateDiffWriterConfig
+
+	// sampling of the local answers compared with the proxy node's
+	Shadow eth.ShadowConfig
+
+	// methods raced against the proxy node, with their hedge delays
+	Hedge eth.HedgeConfig
+
 	// Cache configuration.
 	GroupCache *ethServerShared.GroupCacheConfig
 
+	GapFill gapfill.Config
+
 	StateValidationEnabled       bool
 	StateValidationEveryNthBlock uint64
 }
@@ -107,19 +180,65 @@ func NewConfig() (*Config, error) {
 	viper.BindEnv("ethereum.supportsStateDiff", ETH_SUPPORTS_STATEDIFF)
 	viper.BindEnv("ethereum.forwardEthCalls", ETH_FORWARD_ETH_CALLS)
 	viper.BindEnv("ethereum.proxyOnError", ETH_PROXY_ON_ERROR)
+	viper.BindEnv("ethereum.upstreamHealth.interval", ETH_UPSTREAM_HEALTH_INTERVAL)
+	viper.BindEnv("ethereum.upstreamHealth.timeout", ETH_UPSTREAM_HEALTH_TIMEOUT)
+	viper.BindEnv("ethereum.upstreamHealth.maxFailures", ETH_UPSTREAM_HEALTH_MAX_FAILURES)
+	viper.BindEnv("ethereum.upstreamHealth.maxLag", ETH_UPSTREAM_HEALTH_MAX_LAG)
+	viper.BindEnv("ethereum.upstreamHealth.requestTimeout", ETH_UPSTREAM_HEALTH_REQUEST_TIMEOUT)
+	viper.BindEnv("ethereum.upstreamHealth.breakerThreshold", ETH_UPSTREAM_BREAKER_THRESHOLD)
+	viper.BindEnv("ethereum.upstreamHealth.breakerCooldown", ETH_UPSTREAM_BREAKER_COOLDOWN)
 
 	c.dbInit()
-	ethHTTP := viper.GetString("ethereum.httpPath")
-	ethHTTPEndpoint := fmt.Sprintf("http://%s", ethHTTP)
-	nodeInfo, cli, err := getEthNodeAndClient(ethHTTPEndpoint)
+	nodeInfo := getEthNodeInfo()
+	nodes, poolConfig, err := upstream.NewConfigs()
+	if err != nil {
+		return nil, err
+	}
+	c.Upstreams, err = upstream.NewPools(nodes, poolConfig)
 	if err != nil {
 		return nil, err
 	}
-	c.Client = cli
+	readURLs := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		if node.HasRole(upstream.ReadRole) {
+			readURLs = append(readURLs, node.URL)
+		}
+	}
 	c.SupportStateDiff = viper.GetBool("ethereum.supportsStateDiff")
 	c.ForwardEthCalls = viper.GetBool("ethereum.forwardEthCalls")
 	c.ProxyOnError = viper.GetBool("ethereum.proxyOnError")
-	c.EthHttpEndpoint = ethHTTPEndpoint
+	if c.SupportStateDiff && c.Upstreams.StateDiff == nil {
+		return nil, fmt.Errorf("ethereum.supportsStateDiff is set but no upstream serves the %s role", upstream.StateDiffRole)
+	}
+
+	viper.BindEnv("ethereum.stateDiffWrites.workers", ETH_STATEDIFF_WRITE_WORKERS)
+	viper.BindEnv("ethereum.stateDiffWrites.queueSize", ETH_STATEDIFF_WRITE_QUEUE_SIZE)
+	viper.BindEnv("ethereum.stateDiffWrites.maxRange", ETH_STATEDIFF_WRITE_MAX_RANGE)
+	viper.BindEnv("ethereum.stateDiffWrites.timeout", ETH_STATEDIFF_WRITE_TIMEOUT)
+	c.StateDiffWrites = eth.StateDiffWriterConfig{
+		Workers:   viper.GetInt("ethereum.stateDiffWrites.workers"),
+		QueueSize: viper.GetInt("ethereum.stateDiffWrites.queueSize"),
+		MaxRange:  viper.GetInt64("ethereum.stateDiffWrites.maxRange"),
+		Timeout:   viper.GetDuration("ethereum.stateDiffWrites.timeout"),
+	}
+
+	viper.BindEnv("ethereum.shadow.sampleRate", ETH_SHADOW_SAMPLE_RATE)
+	viper.BindEnv("ethereum.shadow.methods", ETH_SHADOW_METHODS)
+	viper.BindEnv("ethereum.shadow.timeout", ETH_SHADOW_TIMEOUT)
+	viper.BindEnv("ethereum.shadow.maxConcurrent", ETH_SHADOW_MAX_CONCURRENT)
+	c.Shadow = eth.ShadowConfig{
+		SampleRate:    viper.GetFloat64("ethereum.shadow.sampleRate"),
+		Methods:       viper.GetStringSlice("ethereum.shadow.methods"),
+		Timeout:       viper.GetDuration("ethereum.shadow.timeout"),
+		MaxConcurrent: viper.GetInt("ethereum.shadow.maxConcurrent"),
+	}
+
+	viper.BindEnv("ethereum.hedge.methods", ETH_HEDGE_METHODS)
+	c.Hedge.Delays, err = eth.ParseHedgeDelays(viper.GetStringSlice("ethereum.hedge.methods"))
+	if err != nil {
+		return nil, err
+	}
+	c.EthHttpEndpoint = strings.Join(readURLs, ",")
 
 	// websocket server
 	wsEnabled := viper.GetBool("eth.server.ws")
@@ -147,6 +266,55 @@ func NewConfig() (*Config, error) {
 	}
 	c.IPCEnabled = ipcEnabled
 
+	// admin server
+	viper.BindEnv("eth.server.adminToken", SERVER_ADMIN_TOKEN)
+	adminEnabled := viper.GetBool("eth.server.admin")
+	if adminEnabled {
+		adminPath := viper.GetString("eth.server.adminPath")
+		if adminPath == "" {
+			adminPath = "127.0.0.1:8084"
+		}
+		c.AdminEndpoint = adminPath
+		c.AdminToken = viper.GetString("eth.server.adminToken")
+		if c.AdminToken == "" {
+			return nil, errors.New("eth.server.adminToken is required when the admin server is enabled")
+		}
+	}
+	c.AdminEnabled = adminEnabled
+
+	// server-sent events server
+	sseEnabled := viper.GetBool("eth.server.sse")
+	if sseEnabled {
+		ssePath := viper.GetString("eth.server.ssePath")
+		if ssePath == "" {
+			ssePath = "127.0.0.1:8085"
+		}
+		c.SSEEndpoint = ssePath
+		c.SSECorsOrigins = viper.GetStringSlice("eth.server.sseCors")
+	}
+	c.SSEEnabled = sseEnabled
+
+	// gRPC server
+	grpcEnabled := viper.GetBool("eth.server.grpc")
+	if grpcEnabled {
+		grpcPath := viper.GetString("eth.server.grpcPath")
+		if grpcPath == "" {
+			grpcPath = "127.0.0.1:8086"
+		}
+		c.GRPCEndpoint = grpcPath
+	}
+	c.GRPCEnabled = grpcEnabled
+
+	// subscription limits, 0 means no limit
+	viper.BindEnv("eth.server.maxSubscriptions", SERVER_MAX_SUBSCRIPTIONS)
+	viper.BindEnv("eth.server.maxBackfills", SERVER_MAX_BACKFILLS)
+	c.MaxSubscriptions = viper.GetInt("eth.server.maxSubscriptions")
+	c.MaxBackfills = viper.GetInt("eth.server.maxBackfills")
+
+	// file the addresses watched by the statediffing proxy node are persisted to, empty to keep them in memory only
+	viper.BindEnv("eth.server.watchedAddressesPath", SERVER_WATCHED_ADDRESSES_PATH)
+	c.WatchedAddressesPath = viper.GetString("eth.server.watchedAddressesPath")
+
 	// http server
 	httpEnabled := viper.GetBool("eth.server.http")
 	if httpEnabled {
@@ -169,6 +337,23 @@ func NewConfig() (*Config, error) {
 	}
 	c.EthGraphqlEnabled = ethGraphqlEnabled
 
+	// limits of the static cost and depth of the eth graphql queries
+	viper.BindEnv("eth.server.graphqlMaxDepth", SERVER_GRAPHQL_MAX_DEPTH)
+	viper.BindEnv("eth.server.graphqlMaxCost", SERVER_GRAPHQL_MAX_COST)
+	viper.BindEnv("eth.server.graphqlMaxBlockRange", SERVER_GRAPHQL_MAX_BLOCK_RANGE)
+	viper.BindEnv("eth.server.graphqlMaxSelections", SERVER_GRAPHQL_MAX_SELECTIONS)
+	viper.BindEnv("eth.server.graphqlListSize", SERVER_GRAPHQL_LIST_SIZE)
+	viper.BindEnv("eth.server.graphqlFieldWeights", SERVER_GRAPHQL_FIELD_WEIGHTS)
+	c.EthGraphqlLimits.MaxDepth = viper.GetInt("eth.server.graphqlMaxDepth")
+	c.EthGraphqlLimits.MaxCost = viper.GetInt("eth.server.graphqlMaxCost")
+	c.EthGraphqlLimits.MaxBlockRange = viper.GetInt("eth.server.graphqlMaxBlockRange")
+	c.EthGraphqlLimits.MaxSelections = viper.GetInt("eth.server.graphqlMaxSelections")
+	c.EthGraphqlLimits.ListSize = viper.GetInt("eth.server.graphqlListSize")
+	c.EthGraphqlLimits.Weights, err = graphql.ParseFieldWeights(viper.GetStringSlice("eth.server.graphqlFieldWeights"))
+	if err != nil {
+		return nil, err
+	}
+
 	// ipld graphql endpoint
 	ipldGraphqlEnabled := viper.GetBool("ipld.server.graphql")
 	if ipldGraphqlEnabled {
@@ -226,6 +411,8 @@ func NewConfig() (*Config, error) {
 
 	c.loadGroupCacheConfig()
 
+	c.loadGapFillConfig()
+
 	c.loadValidatorConfig()
 
 	return c, err
@@ -283,6 +470,33 @@ func (c *Config) loadGroupCacheConfig() {
 	c.GroupCache = &gcc
 }
 
+func (c *Config) loadGapFillConfig() {
+	viper.BindEnv("gapfill.enabled", GAPFILL_ENABLED)
+	viper.BindEnv("gapfill.start", GAPFILL_START)
+	viper.BindEnv("gapfill.interval", GAPFILL_INTERVAL)
+	viper.BindEnv("gapfill.workers", GAPFILL_WORKERS)
+	viper.BindEnv("gapfill.queueSize", GAPFILL_QUEUE_SIZE)
+	viper.BindEnv("gapfill.rateLimit", GAPFILL_RATE_LIMIT)
+	viper.BindEnv("gapfill.maxRetries", GAPFILL_MAX_RETRIES)
+	viper.BindEnv("gapfill.retryInterval", GAPFILL_RETRY_INTERVAL)
+
+	c.GapFill = gapfill.Config{
+		Enabled:       viper.GetBool("gapfill.enabled"),
+		Start:         viper.GetUint64("gapfill.start"),
+		Interval:      viper.GetDuration("gapfill.interval"),
+		ScanWindow:    viper.GetUint64("gapfill.scanWindow"),
+		Workers:       viper.GetInt("gapfill.workers"),
+		QueueSize:     viper.GetInt("gapfill.queueSize"),
+		RateLimit:     viper.GetFloat64("gapfill.rateLimit"),
+		MaxRetries:    gapfill.DefaultMaxRetries,
+		RetryInterval: viper.GetDuration("gapfill.retryInterval"),
+		Timeout:       viper.GetDuration("gapfill.timeout"),
+	}
+	if viper.IsSet("gapfill.maxRetries") {
+		c.GapFill.MaxRetries = viper.GetInt("gapfill.maxRetries")
+	}
+}
+
 func (c *Config) loadValidatorConfig() {
 	viper.BindEnv("validator.enabled", VALIDATOR_ENABLED)
 	viper.BindEnv("validator.everyNthBlock", VALIDATOR_EVERY_NTH_BLOCK)
@@ -0,0 +1,78 @@
+// VulcanizeDB
+// Copyright © 2022 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package serve
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/vulcanize/ipld-eth-server/pkg/eth"
+)
+
+// ChainTracker exposes the unexported chainTracker to the serve_test package
+type ChainTracker struct {
+	ct *chainTracker
+}
+
+func NewChainTracker(depth int64, lookup func(hash common.Hash) (*types.Header, error)) *ChainTracker {
+	return &ChainTracker{ct: newChainTracker(depth, lookup)}
+}
+
+func (t *ChainTracker) Add(header *types.Header) (int64, *Reorg) {
+	return t.ct.add(header)
+}
+
+// NewTestService builds a Service around the provided components without a database or backend
+func NewTestService(filterer eth.Filterer, retriever eth.Retriever, fetcher eth.Fetcher) *Service {
+	return &Service{
+		Filterer:          filterer,
+		Retriever:         retriever,
+		IPLDFetcher:       fetcher,
+		QuitChan:          make(chan bool),
+		Subscriptions:     make(map[common.Hash]map[rpc.ID]Subscription),
+		SubscriptionTypes: make(map[common.Hash]eth.SubscriptionSettings),
+		serveWg:           new(sync.WaitGroup),
+		subscriptionStats: make(map[rpc.ID]*subscriptionStats),
+		chain: newChainTracker(ReorgTrackingDepth, func(hash common.Hash) (*types.Header, error) {
+			return nil, errors.New("no headers available")
+		}),
+	}
+}
+
+func (sap *Service) FilterAndServe(payload eth.ConvertedPayload) {
+	sap.filterAndServe(payload)
+}
+
+// SendReorg notifies the subscriptions of the reorg as filterAndServe does when it detects one
+func (sap *Service) SendReorg(forkHeight int64, reorg *Reorg) {
+	sap.Lock()
+	defer sap.Unlock()
+	sap.sendReorg(forkHeight, reorg)
+}
+
+// SetBackfillRetries sets the number of retries of the failed backfill writes and the delay before the first one
+func (wa *WatchedAddresses) SetBackfillRetries(maxRetries int, retryInterval time.Duration) {
+	wa.Lock()
+	defer wa.Unlock()
+	wa.maxRetries = maxRetries
+	wa.retryInterval = retryInterval
+}
@@ -16,7 +16,36 @@
 
 package serve
 
-import log "github.com/sirupsen/logrus"
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/rlp"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vulcanize/ipld-eth-server/pkg/eth"
+)
+
+// newSubscriptionPayload packages the IPLDs into a SubscriptionPayload using the requested encoding
+func newSubscriptionPayload(iplds *eth.IPLDs, encoding eth.PayloadEncoding) (SubscriptionPayload, error) {
+	payload := SubscriptionPayload{Err: "", Flag: EmptyFlag, Height: iplds.BlockNumber.Int64()}
+	switch encoding {
+	case "", eth.RLPEncoding:
+		data, err := rlp.EncodeToBytes(iplds)
+		if err != nil {
+			return SubscriptionPayload{}, err
+		}
+		payload.Data = data
+	case eth.JSONEncoding:
+		decoded, err := eth.DecodeIPLDs(iplds)
+		if err != nil {
+			return SubscriptionPayload{}, err
+		}
+		payload.Decoded = decoded
+	default:
+		return SubscriptionPayload{}, fmt.Errorf("unsupported payload encoding: %s", encoding)
+	}
+	return payload, nil
+}
 
 func sendNonBlockingErr(sub Subscription, err error) {
 	log.Error(err)
@@ -0,0 +1,136 @@
+// VulcanizeDB
+// Copyright © 2022 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package serve
+
+import (
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	log "github.com/sirupsen/logrus"
+)
+
+// ReorgTrackingDepth is the number of recently streamed blocks kept to detect reorgs against
+const ReorgTrackingDepth = 128
+
+// Reorg describes previously streamed blocks which are no longer canonical
+// It is sent to subscribers with the ReorgFlag, its Height is the first height that was replaced
+type Reorg struct {
+	Dropped  []common.Hash `json:"dropped"`  // hashes of the previously streamed blocks which are no longer canonical, in ascending height order
+	NewChain []common.Hash `json:"newChain"` // hashes of the new canonical chain segment from the replaced height up to the new head
+}
+
+// headerLookup is used to walk back the new chain segment by parent hash
+type headerLookup func(hash common.Hash) (*types.Header, error)
+
+// chainTracker keeps the hashes of recently streamed blocks so that reorgs can be detected
+type chainTracker struct {
+	depth  int64
+	head   int64
+	hashes map[int64]common.Hash
+	lookup headerLookup
+}
+
+func newChainTracker(depth int64, lookup headerLookup) *chainTracker {
+	return &chainTracker{
+		depth:  depth,
+		hashes: make(map[int64]common.Hash),
+		lookup: lookup,
+	}
+}
+
+// add records the header as the new canonical head
+// it returns the height of the first replaced block and a Reorg if the header does not extend the streamed chain
+func (ct *chainTracker) add(header *types.Header) (int64, *Reorg) {
+	number := header.Number.Int64()
+	hash := header.Hash()
+	if len(ct.hashes) == 0 {
+		ct.record(number, hash)
+		return 0, nil
+	}
+	if streamed, ok := ct.hashes[number]; ok && streamed == hash {
+		// we have already streamed this block
+		return 0, nil
+	}
+	parent, parentStreamed := ct.hashes[number-1]
+	_, replacesStreamed := ct.hashes[number]
+	if !replacesStreamed && (!parentStreamed || parent == header.ParentHash) {
+		// the header extends (or skips ahead of) the chain we have streamed so far
+		ct.record(number, hash)
+		return 0, nil
+	}
+
+	// walk back along the new chain until we find the block it shares with the streamed chain
+	newChain := []common.Hash{hash}
+	forkHeight := number
+	ancestor := header.ParentHash
+	for height := number - 1; height > ct.head-ct.depth; height-- {
+		if streamed, ok := ct.hashes[height]; !ok || streamed == ancestor {
+			break
+		}
+		newChain = append(newChain, ancestor)
+		forkHeight = height
+		ancestorHeader, err := ct.lookup(ancestor)
+		if err != nil {
+			log.Errorf("eth ipld server unable to find header %s while resolving reorg: %v", ancestor.Hex(), err)
+			break
+		}
+		ancestor = ancestorHeader.ParentHash
+	}
+	for i, j := 0, len(newChain)-1; i < j; i, j = i+1, j-1 {
+		newChain[i], newChain[j] = newChain[j], newChain[i]
+	}
+
+	dropped := make([]int64, 0)
+	for height := range ct.hashes {
+		if height >= forkHeight {
+			dropped = append(dropped, height)
+		}
+	}
+	sort.Slice(dropped, func(i, j int) bool { return dropped[i] < dropped[j] })
+	reorg := &Reorg{
+		Dropped:  make([]common.Hash, len(dropped)),
+		NewChain: newChain,
+	}
+	for i, height := range dropped {
+		reorg.Dropped[i] = ct.hashes[height]
+		delete(ct.hashes, height)
+	}
+	for i, newHash := range newChain {
+		ct.hashes[forkHeight+int64(i)] = newHash
+	}
+	ct.head = number
+	ct.prune()
+	return forkHeight, reorg
+}
+
+func (ct *chainTracker) record(number int64, hash common.Hash) {
+	ct.hashes[number] = hash
+	if number > ct.head {
+		ct.head = number
+	}
+	ct.prune()
+}
+
+// prune drops blocks which have fallen out of the tracking window
+func (ct *chainTracker) prune() {
+	for height := range ct.hashes {
+		if height <= ct.head-ct.depth {
+			delete(ct.hashes, height)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+// VulcanizeDB
+// Copyright © 2022 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package serve_test
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/ipld-eth-server/pkg/eth"
+	"github.com/vulcanize/ipld-eth-server/pkg/eth/test_helpers"
+	"github.com/vulcanize/ipld-eth-server/pkg/serve"
+)
+
+// makeChain builds a chain of headers on top of the parent, using extra to distinguish forks
+func makeChain(parent *types.Header, length int, extra byte) []*types.Header {
+	headers := make([]*types.Header, length)
+	for i := range headers {
+		header := &types.Header{
+			ParentHash: parent.Hash(),
+			Number:     new(big.Int).Add(parent.Number, big.NewInt(1)),
+			Difficulty: big.NewInt(1),
+			Extra:      []byte{extra},
+		}
+		headers[i] = header
+		parent = header
+	}
+	return headers
+}
+
+func hashes(headers []*types.Header) []common.Hash {
+	hs := make([]common.Hash, len(headers))
+	for i, header := range headers {
+		hs[i] = header.Hash()
+	}
+	return hs
+}
+
+var _ = Describe("Reorg tracking", func() {
+	var (
+		genesis *types.Header
+		known   map[common.Hash]*types.Header
+		tracker *serve.ChainTracker
+	)
+	BeforeEach(func() {
+		genesis = &types.Header{Number: big.NewInt(0), Difficulty: big.NewInt(1)}
+		known = make(map[common.Hash]*types.Header)
+		tracker = serve.NewChainTracker(serve.ReorgTrackingDepth, func(hash common.Hash) (*types.Header, error) {
+			header, ok := known[hash]
+			if !ok {
+				return nil, fmt.Errorf("unknown header %s", hash.Hex())
+			}
+			return header, nil
+		})
+	})
+	index := func(headers []*types.Header) {
+		for _, header := range headers {
+			known[header.Hash()] = header
+		}
+	}
+
+	It("Does not report a reorg while the chain is extended", func() {
+		chain := makeChain(genesis, 5, 0)
+		for _, header := range chain {
+			_, reorg := tracker.Add(header)
+			Expect(reorg).To(BeNil())
+		}
+		_, reorg := tracker.Add(chain[4])
+		Expect(reorg).To(BeNil())
+	})
+
+	It("Reports the dropped blocks and the new chain segment when a streamed block is replaced", func() {
+		chain := makeChain(genesis, 5, 0)
+		index(chain)
+		for _, header := range chain {
+			tracker.Add(header)
+		}
+		fork := makeChain(chain[1], 4, 1)
+		index(fork)
+		forkHeight, reorg := tracker.Add(fork[3])
+		Expect(reorg).ToNot(BeNil())
+		Expect(forkHeight).To(Equal(int64(3)))
+		Expect(reorg.Dropped).To(Equal(hashes(chain[2:])))
+		Expect(reorg.NewChain).To(Equal(hashes(fork)))
+
+		// the new chain is now the one being tracked
+		_, reorg = tracker.Add(makeChain(fork[3], 1, 1)[0])
+		Expect(reorg).To(BeNil())
+	})
+
+	It("Reports a reorg when a sibling of the head is streamed", func() {
+		chain := makeChain(genesis, 3, 0)
+		for _, header := range chain {
+			tracker.Add(header)
+		}
+		sibling := makeChain(chain[1], 1, 1)[0]
+		forkHeight, reorg := tracker.Add(sibling)
+		Expect(reorg).ToNot(BeNil())
+		Expect(forkHeight).To(Equal(int64(3)))
+		Expect(reorg.Dropped).To(Equal([]common.Hash{chain[2].Hash()}))
+		Expect(reorg.NewChain).To(Equal([]common.Hash{sibling.Hash()}))
+	})
+})
+
+var _ = Describe("Reorg notices", func() {
+	It("Are only sent to the subscriptions which were sent a dropped block", func() {
+		retriever := &blockingRetriever{release: make(chan struct{})}
+		defer close(retriever.release)
+		service := serve.NewTestService(eth.NewResponseFilterer(), retriever, mockFetcher{})
+		settings := eth.SubscriptionSettings{
+			Start:         big.NewInt(0),
+			End:           big.NewInt(0),
+			StateFilter:   eth.StateFilter{Off: true},
+			StorageFilter: eth.StorageFilter{Off: true},
+		}
+		streamed, late := newSubscriber(10), newSubscriber(10)
+		service.Subscribe(rpc.ID("streamed"), streamed.payloads, streamed.quit, settings)
+		service.FilterAndServe(test_helpers.MockConvertedPayload)
+		Expect(streamed.payloads).To(Receive())
+		service.Subscribe(rpc.ID("late"), late.payloads, late.quit, settings)
+
+		height := test_helpers.BlockNumber.Int64()
+		reorg := &serve.Reorg{Dropped: []common.Hash{test_helpers.MockBlock.Hash()}}
+		service.SendReorg(height+1, reorg)
+		Expect(streamed.payloads).ToNot(Receive())
+		service.SendReorg(height, reorg)
+		var notice serve.SubscriptionPayload
+		Expect(streamed.payloads).To(Receive(&notice))
+		Expect(notice.ReorgNotice()).To(BeTrue())
+		Expect(notice.Height).To(Equal(height))
+		Expect(late.payloads).ToNot(Receive())
+	})
+})
@@ -17,11 +17,15 @@
 package serve
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"strconv"
 	"sync"
+	"sync/atomic"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
 	ethnode "github.com/ethereum/go-ethereum/node"
@@ -32,7 +36,9 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	"github.com/vulcanize/ipld-eth-server/pkg/eth"
+	"github.com/vulcanize/ipld-eth-server/pkg/gapfill"
 	"github.com/vulcanize/ipld-eth-server/pkg/net"
+	"github.com/vulcanize/ipld-eth-server/pkg/upstream"
 )
 
 const (
@@ -46,6 +52,7 @@ type Server interface {
 	// Start() and Stop()
 	ethnode.Lifecycle
 	APIs() []rpc.API
+	AdminAPIs() []rpc.API
 	Protocols() []p2p.Protocol
 	// Pub-Sub handling event loop
 	Serve(wg *sync.WaitGroup, screenAndServePayload <-chan eth.ConvertedPayload)
@@ -55,6 +62,10 @@ type Server interface {
 	Unsubscribe(id rpc.ID)
 	// Backend exposes the server's backend
 	Backend() *eth.Backend
+	// Client exposes the server's client to the proxied nodes, nil if there is none
+	Client() *rpc.Client
+	// GapFiller exposes the server's gap filler, nil if it is disabled
+	GapFiller() *gapfill.Scheduler
 }
 
 // Service is the underlying struct for the watcher
@@ -77,16 +88,36 @@ type Service struct {
 	db *postgres.DB
 	// wg for syncing serve processes
 	serveWg *sync.WaitGroup
-	// rpc client for forwarding cache misses
+	// upstream nodes for forwarding cache misses and filling the index, nil if there are none
+	upstreams *upstream.Pools
+	// rpc client for forwarding cache misses, routed through the read pool
 	client *rpc.Client
 	// whether the proxied client supports state diffing
 	supportsStateDiffing bool
+	// queue for the statediff writes requested on proxied cache misses, shared by every eth api
+	writer *eth.StateDiffWriter
+	// compares a sample of the local answers with the proxy node's, nil when disabled
+	shadow *eth.Shadow
+	// races the local lookups of the hedged methods against the proxy node, nil when no method is hedged
+	hedger *eth.Hedger
 	// backend for the server
 	backend *eth.Backend
 	// whether to forward eth_calls directly to proxy node
 	forwardEthCalls bool
 	// whether to forward all calls to proxy node if they throw an error locally
 	proxyOnError bool
+	// recently streamed blocks, used to notify subscribers of reorgs
+	chain *chainTracker
+	// bookkeeping for every live and backfilling subscription, exposed through the admin API
+	subscriptionStats map[rpc.ID]*subscriptionStats
+	// caps on the number of concurrent subscriptions and backfills
+	limits SubscriptionLimits
+	// number of backfills currently running
+	backfills int
+	// addresses watched by the statediffing proxy node, managed through the admin API
+	watched *WatchedAddresses
+	// scans the index for gaps and fills them from the proxy node, nil when disabled
+	gapFiller *gapfill.Scheduler
 }
 
 // NewServer creates a new Server using an underlying Service struct
@@ -99,11 +130,55 @@ func NewServer(settings *Config) (Server, error) {
 	sap.QuitChan = make(chan bool)
 	sap.Subscriptions = make(map[common.Hash]map[rpc.ID]Subscription)
 	sap.SubscriptionTypes = make(map[common.Hash]eth.SubscriptionSettings)
-	sap.client = settings.Client
+	sap.subscriptionStats = make(map[rpc.ID]*subscriptionStats)
+	sap.limits = SubscriptionLimits{
+		MaxSubscriptions: settings.MaxSubscriptions,
+		MaxBackfills:     settings.MaxBackfills,
+	}
+	var stateDiffClient *rpc.Client
+	if settings.Upstreams != nil {
+		sap.upstreams = settings.Upstreams
+		sap.client = settings.Upstreams.ReadClient()
+		stateDiffClient = settings.Upstreams.StateDiffClient()
+	}
 	sap.supportsStateDiffing = settings.SupportStateDiff
 	sap.forwardEthCalls = settings.ForwardEthCalls
 	sap.proxyOnError = settings.ProxyOnError
+	sap.chain = newChainTracker(ReorgTrackingDepth, func(hash common.Hash) (*types.Header, error) {
+		return sap.backend.HeaderByHash(context.Background(), hash)
+	})
 	var err error
+	if settings.SupportStateDiff && stateDiffClient != nil {
+		sap.writer, err = eth.NewStateDiffWriter(stateDiffClient, settings.StateDiffWrites)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if settings.Shadow.SampleRate > 0 {
+		sap.shadow, err = eth.NewShadow(sap.client, settings.Shadow)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(settings.Hedge.Delays) > 0 {
+		sap.hedger, err = eth.NewHedger(sap.client, settings.Hedge)
+		if err != nil {
+			return nil, err
+		}
+	}
+	sap.watched, err = NewWatchedAddresses(stateDiffClient, sap.writer, settings.WatchedAddressesPath)
+	if err != nil {
+		return nil, err
+	}
+	if settings.GapFill.Enabled {
+		if sap.writer == nil {
+			return nil, errors.New("gap filling requires a proxy node which supports state diffing")
+		}
+		sap.gapFiller, err = gapfill.NewScheduler(gapfill.NewDBFinder(settings.DB), sap.writer, settings.GapFill)
+		if err != nil {
+			return nil, err
+		}
+	}
 	sap.backend, err = eth.NewEthBackend(sap.db, &eth.Config{
 		ChainConfig:      settings.ChainConfig,
 		VMConfig:         vm.Config{NoBaseFee: true},
@@ -136,7 +211,14 @@ func (sap *Service) APIs() []rpc.API {
 			Public:    true,
 		},
 	}
-	ethAPI, err := eth.NewPublicEthAPI(sap.backend, sap.client, sap.supportsStateDiffing, sap.forwardEthCalls, sap.proxyOnError)
+	ethAPI, err := eth.NewPublicEthAPI(sap.backend, sap.client, eth.APIOptions{
+		SupportsStateDiff: sap.supportsStateDiffing,
+		ForwardEthCalls:   sap.forwardEthCalls,
+		ProxyOnError:      sap.proxyOnError,
+		Writer:            sap.writer,
+		Shadow:            sap.shadow,
+		Hedger:            sap.hedger,
+	})
 	if err != nil {
 		log.Fatalf("unable to create public eth api: %v", err)
 	}
@@ -148,6 +230,19 @@ func (sap *Service) APIs() []rpc.API {
 	})
 }
 
+// AdminAPIs returns the RPC descriptors for the subscription administration API
+// These are kept separate from APIs() so that they are never exposed on the public endpoints
+func (sap *Service) AdminAPIs() []rpc.API {
+	return []rpc.API{
+		{
+			Namespace: AdminAPIName,
+			Version:   AdminAPIVersion,
+			Service:   NewAdminServerAPI(sap),
+			Public:    false,
+		},
+	}
+}
+
 // Serve listens for incoming converter data off the screenAndServePayload from the Sync process
 // It filters and sends this data to any subscribers to the service
 // This process can also be stood up alone, without an screenAndServePayload attached to a Sync process
@@ -167,6 +262,15 @@ func (sap *Service) Serve(wg *sync.WaitGroup, screenAndServePayload <-chan eth.C
 			}
 		}
 	}()
+	if sap.upstreams != nil {
+		sap.upstreams.Start()
+	}
+	if sap.gapFiller != nil {
+		sap.gapFiller.Start(wg)
+	}
+	if sap.watched != nil {
+		sap.watched.ResumeBackfills()
+	}
 	log.Info("eth ipld server process successfully spun up")
 }
 
@@ -177,6 +281,10 @@ func (sap *Service) filterAndServe(payload eth.ConvertedPayload) {
 	sap.serveWg.Add(1)
 	defer sap.Unlock()
 	defer sap.serveWg.Done()
+	if forkHeight, reorg := sap.chain.add(payload.Block.Header()); reorg != nil {
+		log.Infof("eth ipld server detected reorg at height %d; dropping %d streamed blocks", forkHeight, len(reorg.Dropped))
+		sap.sendReorg(forkHeight, reorg)
+	}
 	for ty, subs := range sap.Subscriptions {
 		// Retrieve the subscription parameters for this subscription type
 		subConfig, ok := sap.SubscriptionTypes[ty]
@@ -197,22 +305,43 @@ func (sap *Service) filterAndServe(payload eth.ConvertedPayload) {
 			sap.closeType(ty)
 			continue
 		}
-		responseRLP, err := rlp.EncodeToBytes(response)
+		subPayload, err := newSubscriptionPayload(response, subConfig.Encoding)
 		if err != nil {
-			log.Errorf("eth ipld server rlp encoding error: %v", err)
+			log.Errorf("eth ipld server payload encoding error: %v", err)
 			continue
 		}
 		for id, sub := range subs {
 			select {
-			case sub.PayloadChan <- SubscriptionPayload{Data: responseRLP, Err: "", Flag: EmptyFlag, Height: response.BlockNumber.Int64()}:
+			case sub.PayloadChan <- subPayload:
+				sap.subscriptionStats[id].record(true, subPayload.Height)
 				log.Debugf("sending eth ipld server payload to subscription %s", id)
 			default:
+				sap.subscriptionStats[id].record(false, subPayload.Height)
 				log.Infof("unable to send eth ipld payload to subscription %s; channel has no receiver", id)
 			}
 		}
 	}
 }
 
+// sendReorg notifies the live subscriptions which were sent any of the previously streamed blocks which are no longer
+// canonical, the ones whose highest delivered height is below the fork height have nothing to drop
+// sendReorg needs to be called with subscription access locked
+func (sap *Service) sendReorg(forkHeight int64, reorg *Reorg) {
+	for _, subs := range sap.Subscriptions {
+		for id, sub := range subs {
+			if !sap.subscriptionStats[id].deliveredFrom(forkHeight) {
+				continue
+			}
+			select {
+			case sub.PayloadChan <- SubscriptionPayload{Err: "", Flag: ReorgFlag, Height: forkHeight, Reorg: reorg}:
+				log.Debugf("sending eth ipld server reorg notice to subscription %s", id)
+			default:
+				log.Infof("unable to send eth ipld reorg notice to subscription %s; channel has no receiver", id)
+			}
+		}
+	}
+}
+
 // Subscribe is used by the API to remotely subscribe to the service loop
 // The params must be rlp serializable and satisfy the SubscriptionSettings() interface
 func (sap *Service) Subscribe(id rpc.ID, sub chan<- SubscriptionPayload, quitChan chan<- bool, params eth.SubscriptionSettings) {
@@ -224,6 +353,18 @@ func (sap *Service) Subscribe(id rpc.ID, sub chan<- SubscriptionPayload, quitCha
 		PayloadChan: sub,
 		QuitChan:    quitChan,
 	}
+	if !params.Encoding.Valid() {
+		sendNonBlockingErr(subscription, fmt.Errorf("unsupported payload encoding: %s", params.Encoding))
+		sendNonBlockingQuit(subscription)
+		return
+	}
+	if params.Expression != "" {
+		if _, err := eth.ParseFilterExpression(params.Expression); err != nil {
+			sendNonBlockingErr(subscription, err)
+			sendNonBlockingQuit(subscription)
+			return
+		}
+	}
 	// Subscription type is defined as the hash of the rlp-serialized subscription settings
 	by, err := rlp.EncodeToBytes(params)
 	if err != nil {
@@ -232,20 +373,34 @@ func (sap *Service) Subscribe(id rpc.ID, sub chan<- SubscriptionPayload, quitCha
 		return
 	}
 	subscriptionType := crypto.Keccak256Hash(by)
+	backFill := params.BackFill || params.BackFillOnly
+	sap.Lock()
+	if err := sap.checkLimits(backFill); err != nil {
+		sap.Unlock()
+		sendNonBlockingErr(subscription, err)
+		sendNonBlockingQuit(subscription)
+		return
+	}
+	stats := newSubscriptionStats(subscription, subscriptionType)
+	sap.subscriptionStats[id] = stats
+	if backFill {
+		sap.backfills++
+		stats.backfilling = 1
+	}
 	if !params.BackFillOnly {
 		// Add subscriber
-		sap.Lock()
 		if sap.Subscriptions[subscriptionType] == nil {
 			sap.Subscriptions[subscriptionType] = make(map[rpc.ID]Subscription)
 		}
 		sap.Subscriptions[subscriptionType][id] = subscription
 		sap.SubscriptionTypes[subscriptionType] = params
-		sap.Unlock()
 	}
+	sap.Unlock()
 	// If the subscription requests a backfill, use the Postgres index to lookup and retrieve historical data
 	// Otherwise we only filter new data as it is streamed in from the state diffing geth node
-	if params.BackFill || params.BackFillOnly {
-		if err := sap.sendHistoricalData(subscription, id, params); err != nil {
+	if backFill {
+		if err := sap.sendHistoricalData(subscription, id, params, stats); err != nil {
+			sap.finishBackfill(id, stats, params.BackFillOnly)
 			sendNonBlockingErr(subscription, fmt.Errorf("eth ipld server subscription backfill error: %v", err))
 			sendNonBlockingQuit(subscription)
 			return
@@ -253,8 +408,21 @@ func (sap *Service) Subscribe(id rpc.ID, sub chan<- SubscriptionPayload, quitCha
 	}
 }
 
+// finishBackfill releases the backfill slot held by the subscription
+// a backfill-only subscription is done once its backfill is, so its bookkeeping is dropped as well
+func (sap *Service) finishBackfill(id rpc.ID, stats *subscriptionStats, backFillOnly bool) {
+	sap.Lock()
+	defer sap.Unlock()
+	sap.backfills--
+	atomic.StoreInt32(&stats.backfilling, 0)
+	if backFillOnly && sap.subscriptionStats[id] == stats {
+		delete(sap.subscriptionStats, id)
+		stats.close()
+	}
+}
+
 // sendHistoricalData sends historical data to the requesting subscription
-func (sap *Service) sendHistoricalData(sub Subscription, id rpc.ID, params eth.SubscriptionSettings) error {
+func (sap *Service) sendHistoricalData(sub Subscription, id rpc.ID, params eth.SubscriptionSettings, stats *subscriptionStats) error {
 	log.Infof("sending eth ipld historical data to subscription %s", id)
 	// Retrieve cached CIDs relevant to this subscriber
 	var endingBlock int64
@@ -279,11 +447,15 @@ func (sap *Service) sendHistoricalData(sub Subscription, id rpc.ID, params eth.S
 	go func() {
 		sap.serveWg.Add(1)
 		defer sap.serveWg.Done()
+		defer sap.finishBackfill(id, stats, params.BackFillOnly)
 		for i := startingBlock; i <= endingBlock; i++ {
 			select {
 			case <-sap.QuitChan:
 				log.Infof("ethereum historical data feed to subscription %s closed", id)
 				return
+			case <-stats.closed:
+				log.Infof("ethereum historical data feed to subscription %s closed", id)
+				return
 			default:
 			}
 			cidWrappers, empty, err := sap.Retriever.Retrieve(params, i)
@@ -300,15 +472,18 @@ func (sap *Service) sendHistoricalData(sub Subscription, id rpc.ID, params eth.S
 					sendNonBlockingErr(sub, fmt.Errorf("eth ipld server ipld fetching error at block %d\r%s", i, err.Error()))
 					continue
 				}
-				responseRLP, err := rlp.EncodeToBytes(response)
+				subPayload, err := newSubscriptionPayload(response, params.Encoding)
 				if err != nil {
 					log.Error(err)
 					continue
 				}
+				subPayload.backFilled = true
 				select {
-				case sub.PayloadChan <- SubscriptionPayload{Data: responseRLP, Err: "", Flag: EmptyFlag, Height: response.BlockNumber.Int64()}:
+				case sub.PayloadChan <- subPayload:
+					stats.record(true, subPayload.Height)
 					log.Debugf("eth ipld server sending historical data payload to subscription %s", id)
 				default:
+					stats.record(false, subPayload.Height)
 					log.Infof("eth ipld server unable to send backFill payload to subscription %s; channel has no receiver", id)
 				}
 			}
@@ -328,6 +503,10 @@ func (sap *Service) sendHistoricalData(sub Subscription, id rpc.ID, params eth.S
 func (sap *Service) Unsubscribe(id rpc.ID) {
 	log.Infof("unsubscribing %s from the eth ipld server", id)
 	sap.Lock()
+	if stats, ok := sap.subscriptionStats[id]; ok {
+		stats.close()
+		delete(sap.subscriptionStats, id)
+	}
 	for ty := range sap.Subscriptions {
 		delete(sap.Subscriptions[ty], id)
 		if len(sap.Subscriptions[ty]) == 0 {
@@ -353,6 +532,15 @@ func (sap *Service) Start() error {
 // This is mostly just to satisfy the node.Service interface
 func (sap *Service) Stop() error {
 	log.Infof("stopping eth ipld server")
+	if sap.gapFiller != nil {
+		sap.gapFiller.Stop()
+	}
+	if sap.writer != nil {
+		sap.writer.Stop()
+	}
+	if sap.upstreams != nil {
+		sap.upstreams.Stop()
+	}
 	sap.Lock()
 	close(sap.QuitChan)
 	sap.close()
@@ -365,6 +553,16 @@ func (sap *Service) Backend() *eth.Backend {
 	return sap.backend
 }
 
+// Client exposes the server's client to the proxied nodes
+func (sap *Service) Client() *rpc.Client {
+	return sap.client
+}
+
+// GapFiller exposes the server's gap filler
+func (sap *Service) GapFiller() *gapfill.Scheduler {
+	return sap.gapFiller
+}
+
 // close is used to close all listening subscriptions
 // close needs to be called with subscription access locked
 func (sap *Service) close() {
@@ -376,6 +574,10 @@ func (sap *Service) close() {
 		delete(sap.Subscriptions, subType)
 		delete(sap.SubscriptionTypes, subType)
 	}
+	for id, stats := range sap.subscriptionStats {
+		stats.close()
+		delete(sap.subscriptionStats, id)
+	}
 }
 
 // closeType is used to close all subscriptions of given type
@@ -383,8 +585,12 @@ func (sap *Service) close() {
 func (sap *Service) closeType(subType common.Hash) {
 	log.Infof("closing all eth ipld server subscriptions of type %s", subType.String())
 	subs := sap.Subscriptions[subType]
-	for _, sub := range subs {
+	for id, sub := range subs {
 		sendNonBlockingQuit(sub)
+		if stats, ok := sap.subscriptionStats[id]; ok {
+			stats.close()
+			delete(sap.subscriptionStats, id)
+		}
 	}
 	delete(sap.Subscriptions, subType)
 	delete(sap.SubscriptionTypes, subType)
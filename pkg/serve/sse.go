@@ -0,0 +1,252 @@
+// VulcanizeDB
+// Copyright © 2022 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package serve
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vulcanize/ipld-eth-server/pkg/eth"
+	"github.com/vulcanize/ipld-eth-server/pkg/prom"
+)
+
+// SSEKeepAliveInterval is how often a comment is written to idle event streams to keep intermediaries from closing them
+const SSEKeepAliveInterval = 15 * time.Second
+
+// SSE event types
+const (
+	SSEPayloadEvent          = "payload"
+	SSEBackFillCompleteEvent = "backFillComplete"
+	SSEReorgEvent            = "reorg"
+	SSEErrorEvent            = "error"
+)
+
+// SSEHandler relays vdb stream subscriptions to HTTP clients as server-sent events
+//
+// The SubscriptionSettings are given as JSON, either in the body of a POST request or in the settings query parameter
+// Each payload event carries the height of its block as the event id; a client reconnecting with a Last-Event-ID header
+// (or lastEventId query parameter) is backfilled from the following height before it resumes streaming
+// While a subscription is backfilling, live payloads are interleaved with the backfilled ones, so only the backfilled
+// payloads carry an id until the backfill is complete; a client resuming from its last id is backfilled again over the
+// live payloads it already received, rather than skipping the blocks which hadn't been backfilled yet
+type SSEHandler struct {
+	s              Server
+	allowedOrigins []string
+}
+
+// NewSSEHandler creates a new SSEHandler around the provided Server
+func NewSSEHandler(s Server, allowedOrigins []string) *SSEHandler {
+	return &SSEHandler{
+		s:              s,
+		allowedOrigins: allowedOrigins,
+	}
+}
+
+// StartSSEEndpoint starts an HTTP server serving the SSEHandler on the endpoint
+// It uses no write timeout since responses are streamed for the lifetime of the subscription
+func StartSSEEndpoint(endpoint string, s Server, allowedOrigins []string) (net.Listener, error) {
+	listener, err := net.Listen("tcp", endpoint)
+	if err != nil {
+		return nil, err
+	}
+	srv := &http.Server{
+		Handler:           prom.HTTPMiddleware(NewSSEHandler(s, allowedOrigins)),
+		ReadHeaderTimeout: rpc.DefaultHTTPTimeouts.ReadTimeout,
+		IdleTimeout:       rpc.DefaultHTTPTimeouts.IdleTimeout,
+	}
+	go srv.Serve(listener)
+	log.Infof("SSE endpoint opened http://%v/", listener.Addr())
+	return listener, nil
+}
+
+// ServeHTTP subscribes to the server with the requested settings and writes the payloads to the response as events
+func (h *SSEHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.setCORSHeaders(w, r)
+	switch r.Method {
+	case http.MethodOptions:
+		w.WriteHeader(http.StatusNoContent)
+		return
+	case http.MethodGet, http.MethodPost:
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	params, err := sseSettings(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	id := rpc.NewID()
+	payloadChannel := make(chan SubscriptionPayload, PayloadChanBufferSize)
+	quitChan := make(chan bool, 1)
+	go h.s.Subscribe(id, payloadChannel, quitChan, params)
+	log.Infof("new eth ipld SSE subscription %s", id)
+
+	// height of the last event id sent, the client has received every block up to it
+	lastID := params.Start.Int64() - 1
+	backFilling := params.BackFill
+	// set when a reorg rewinds the id while backfilling, the backfilled payloads may then predate the reorg
+	rewound := false
+	keepAlive := time.NewTicker(SSEKeepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case packet := <-payloadChannel:
+			eventID, ok := sseEventID(packet)
+			if ok && backFilling && (rewound || !packet.backFilled) && eventID > lastID {
+				ok = false
+			}
+			if ok && backFilling && packet.ReorgNotice() {
+				rewound = true
+			}
+			if packet.BackFillComplete() {
+				backFilling = false
+			}
+			if ok {
+				lastID = eventID
+			}
+			if err := writeSSEEvent(w, packet, eventID, ok); err != nil {
+				log.Errorf("failed to send eth ipld SSE event to subscription %s: %v", id, err)
+				h.s.Unsubscribe(id)
+				return
+			}
+			flusher.Flush()
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				h.s.Unsubscribe(id)
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			h.s.Unsubscribe(id)
+			return
+		case <-quitChan:
+			// don't need to unsubscribe from the watcher, the service does so before sending the quit signal this way
+			return
+		}
+	}
+}
+
+func (h *SSEHandler) setCORSHeaders(w http.ResponseWriter, r *http.Request) {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return
+	}
+	for _, allowed := range h.allowedOrigins {
+		if allowed == "*" || allowed == origin {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Last-Event-ID")
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+			return
+		}
+	}
+}
+
+// sseSettings reads the subscription settings from the request and applies any requested resumption
+func sseSettings(r *http.Request) (eth.SubscriptionSettings, error) {
+	params := eth.SubscriptionSettings{}
+	if r.Method == http.MethodPost {
+		if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+			return params, fmt.Errorf("invalid subscription settings: %v", err)
+		}
+	} else if settings := r.URL.Query().Get("settings"); settings != "" {
+		if err := json.Unmarshal([]byte(settings), &params); err != nil {
+			return params, fmt.Errorf("invalid subscription settings: %v", err)
+		}
+	}
+	if params.Start == nil {
+		params.Start = big.NewInt(0)
+	}
+	if params.End == nil {
+		params.End = big.NewInt(0)
+	}
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("lastEventId")
+	}
+	if lastEventID != "" {
+		lastHeight, err := strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil {
+			return params, fmt.Errorf("invalid Last-Event-ID: %s", lastEventID)
+		}
+		// backfill everything after the last event the client received before resuming the live stream
+		if resumeFrom := lastHeight + 1; resumeFrom > params.Start.Int64() {
+			params.Start = big.NewInt(resumeFrom)
+		}
+		params.BackFill = true
+	}
+	return params, nil
+}
+
+// sseEventID returns the event id of the payload, if it has one
+// payloads carry their height as the event id; a reorg carries the height before the first replaced block,
+// so that a client resuming after it is backfilled with the new chain
+func sseEventID(packet SubscriptionPayload) (int64, bool) {
+	switch {
+	case packet.Err != "", packet.BackFillComplete():
+		return 0, false
+	case packet.ReorgNotice():
+		return packet.Height - 1, true
+	default:
+		return packet.Height, true
+	}
+}
+
+// writeSSEEvent writes the payload to the response as an event, with the event id if withID is set
+func writeSSEEvent(w http.ResponseWriter, packet SubscriptionPayload, eventID int64, withID bool) error {
+	data, err := json.Marshal(packet)
+	if err != nil {
+		return err
+	}
+	event := SSEPayloadEvent
+	switch {
+	case packet.Err != "":
+		event = SSEErrorEvent
+	case packet.BackFillComplete():
+		event = SSEBackFillCompleteEvent
+	case packet.ReorgNotice():
+		event = SSEReorgEvent
+	}
+	if withID {
+		if _, err := fmt.Fprintf(w, "id: %d\n", eventID); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+	return err
+}
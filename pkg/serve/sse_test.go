@@ -0,0 +1,194 @@
+// VulcanizeDB
+// Copyright © 2022 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package serve_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/ipld-eth-server/pkg/eth"
+	"github.com/vulcanize/ipld-eth-server/pkg/eth/test_helpers"
+	"github.com/vulcanize/ipld-eth-server/pkg/serve"
+)
+
+type sseEvent struct {
+	id      string
+	event   string
+	payload serve.SubscriptionPayload
+}
+
+// readSSEEvent reads the next event off the stream, skipping comments
+func readSSEEvent(reader *bufio.Reader) sseEvent {
+	var event sseEvent
+	for {
+		line, err := reader.ReadString('\n')
+		Expect(err).ToNot(HaveOccurred())
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "" && event.event != "":
+			return event
+		case strings.HasPrefix(line, "id: "):
+			event.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			event.event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			Expect(json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event.payload)).To(Succeed())
+		}
+	}
+}
+
+var _ = Describe("SSE", func() {
+	var (
+		service   *serve.Service
+		retriever *blockingRetriever
+		server    *httptest.Server
+		settings  string
+	)
+	BeforeEach(func() {
+		retriever = &blockingRetriever{release: make(chan struct{})}
+		service = serve.NewTestService(eth.NewResponseFilterer(), retriever, mockFetcher{})
+		server = httptest.NewServer(serve.NewSSEHandler(service, []string{"*"}))
+		settings = url.QueryEscape(`{"StateFilter":{"Off":true},"StorageFilter":{"Off":true}}`)
+	})
+	AfterEach(func() {
+		close(retriever.release)
+		server.Close()
+	})
+
+	It("Streams payloads as events with their height as the event id", func() {
+		res, err := http.Get(server.URL + "?settings=" + settings)
+		Expect(err).ToNot(HaveOccurred())
+		defer res.Body.Close()
+		Expect(res.StatusCode).To(Equal(http.StatusOK))
+		Expect(res.Header.Get("Content-Type")).To(Equal("text/event-stream"))
+		Eventually(service.SubscriptionInfos).Should(HaveLen(1))
+
+		service.FilterAndServe(test_helpers.MockConvertedPayload)
+		event := readSSEEvent(bufio.NewReader(res.Body))
+		Expect(event.event).To(Equal(serve.SSEPayloadEvent))
+		Expect(event.id).To(Equal(test_helpers.BlockNumber.String()))
+		Expect(event.payload.Height).To(Equal(test_helpers.BlockNumber.Int64()))
+		Expect(event.payload.Data).ToNot(BeEmpty())
+	})
+
+	It("Backfills from the block after the Last-Event-ID when resuming", func() {
+		req, err := http.NewRequest(http.MethodPost, server.URL, strings.NewReader(`{"HeaderFilter":{"Off":true}}`))
+		Expect(err).ToNot(HaveOccurred())
+		req.Header.Set("Last-Event-ID", "0")
+		res, err := http.DefaultClient.Do(req)
+		Expect(err).ToNot(HaveOccurred())
+		defer res.Body.Close()
+		Eventually(service.SubscriptionInfos).Should(HaveLen(1))
+		Expect(service.SubscriptionInfos()[0].Backfilling).To(BeTrue())
+
+		retriever.release <- struct{}{}
+		reader := bufio.NewReader(res.Body)
+		event := readSSEEvent(reader)
+		Expect(event.event).To(Equal(serve.SSEPayloadEvent))
+		Expect(event.id).To(Equal("1"))
+		event = readSSEEvent(reader)
+		Expect(event.event).To(Equal(serve.SSEBackFillCompleteEvent))
+		Expect(event.id).To(BeEmpty())
+	})
+
+	It("Only gives backfilled events an id while backfilling, so that resuming mid-backfill doesn't skip blocks", func() {
+		retriever.last = 3
+		resume := func(lastEventID string) (*http.Response, *bufio.Reader) {
+			req, err := http.NewRequest(http.MethodPost, server.URL, strings.NewReader(`{"HeaderFilter":{"Off":true}}`))
+			Expect(err).ToNot(HaveOccurred())
+			req.Header.Set("Last-Event-ID", lastEventID)
+			res, err := http.DefaultClient.Do(req)
+			Expect(err).ToNot(HaveOccurred())
+			return res, bufio.NewReader(res.Body)
+		}
+		res, reader := resume("0")
+		defer res.Body.Close()
+		Eventually(service.SubscriptionInfos).Should(HaveLen(1))
+
+		retriever.release <- struct{}{}
+		event := readSSEEvent(reader)
+		Expect(event.payload.Height).To(Equal(int64(1)))
+		Expect(event.id).To(Equal("1"))
+
+		// a live block arrives while blocks 2 and 3 are still being backfilled
+		header := types.CopyHeader(test_helpers.MockConvertedPayload.Block.Header())
+		header.Number = big.NewInt(10)
+		live := test_helpers.MockConvertedPayload
+		live.Block = live.Block.WithSeal(header)
+		service.FilterAndServe(live)
+		event = readSSEEvent(reader)
+		Expect(event.payload.Height).To(Equal(int64(10)))
+		Expect(event.id).To(BeEmpty())
+
+		// the client disconnects and resumes from the last id it received
+		res.Body.Close()
+		Eventually(service.SubscriptionInfos).Should(BeEmpty())
+		stop, stopped := make(chan struct{}), make(chan struct{})
+		go func() {
+			defer close(stopped)
+			for {
+				select {
+				case retriever.release <- struct{}{}:
+				case <-stop:
+					return
+				}
+			}
+		}()
+		defer func() {
+			close(stop)
+			<-stopped
+		}()
+		res, reader = resume("1")
+		defer res.Body.Close()
+		for _, id := range []string{"2", "3"} {
+			event = readSSEEvent(reader)
+			Expect(event.event).To(Equal(serve.SSEPayloadEvent))
+			Expect(event.id).To(Equal(id))
+		}
+		event = readSSEEvent(reader)
+		Expect(event.event).To(Equal(serve.SSEBackFillCompleteEvent))
+
+		// once the backfill is complete live events carry their id again
+		service.FilterAndServe(live)
+		event = readSSEEvent(reader)
+		Expect(event.id).To(Equal("10"))
+	})
+
+	It("Rejects invalid settings", func() {
+		res, err := http.Get(server.URL + "?settings=" + url.QueryEscape(`{"Start":"one"}`))
+		Expect(err).ToNot(HaveOccurred())
+		defer res.Body.Close()
+		Expect(res.StatusCode).To(Equal(http.StatusBadRequest))
+
+		req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+		Expect(err).ToNot(HaveOccurred())
+		req.Header.Set("Last-Event-ID", "latest")
+		res, err = http.DefaultClient.Do(req)
+		Expect(err).ToNot(HaveOccurred())
+		defer res.Body.Close()
+		Expect(res.StatusCode).To(Equal(http.StatusBadRequest))
+	})
+})
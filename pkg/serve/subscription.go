@@ -20,6 +20,8 @@ import (
 	"errors"
 
 	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/vulcanize/ipld-eth-server/pkg/eth"
 )
 
 type Flag int32
@@ -27,6 +29,7 @@ type Flag int32
 const (
 	EmptyFlag Flag = iota
 	BackFillCompleteFlag
+	ReorgFlag
 )
 
 // Subscription holds the information for an individual client subscription to the watcher
@@ -39,10 +42,14 @@ type Subscription struct {
 // SubscriptionPayload is the struct for a watcher data subscription payload
 // It carries data of a type specific to the chain being supported/queried and an error message
 type SubscriptionPayload struct {
-	Data   []byte `json:"data"` // e.g. for Ethereum rlp serialized eth.StreamPayload
-	Height int64  `json:"height"`
-	Err    string `json:"err"`  // field for error
-	Flag   Flag   `json:"flag"` // field for message
+	Data    []byte            `json:"data"`              // e.g. for Ethereum rlp serialized eth.StreamPayload
+	Decoded *eth.DecodedIPLDs `json:"decoded,omitempty"` // set instead of Data when the subscription requested eth.JSONEncoding
+	Reorg   *Reorg            `json:"reorg,omitempty"`   // set when the payload carries the ReorgFlag
+	Height  int64             `json:"height"`
+	Err     string            `json:"err"`  // field for error
+	Flag    Flag              `json:"flag"` // field for message
+
+	backFilled bool // set on the payloads sent by the backfill, rather than the live stream
 }
 
 func (sp SubscriptionPayload) Error() error {
@@ -58,3 +65,7 @@ func (sp SubscriptionPayload) BackFillComplete() bool {
 	}
 	return false
 }
+
+func (sp SubscriptionPayload) ReorgNotice() bool {
+	return sp.Flag == ReorgFlag
+}
@@ -0,0 +1,362 @@
+// VulcanizeDB
+// Copyright © 2022 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package serve
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vulcanize/ipld-eth-server/pkg/eth"
+)
+
+// Watched address operations understood by statediff_watchAddress
+const (
+	WatchAddressAdd    = "add"
+	WatchAddressRemove = "remove"
+)
+
+// MaxWatchedAddressBackfillRange is the largest number of blocks a single watched address backfill may cover
+const MaxWatchedAddressBackfillRange = 100000
+
+// Watched address backfill parameters
+const (
+	// time to wait for each write made while backfilling
+	watchedAddressBackfillTimeout = 240 * time.Second
+	// number of times a failed write is retried before the backfill is left to be resumed on restart
+	watchedAddressBackfillMaxRetries = 5
+	// delay before the first retry of a failed write, doubled for every following retry
+	watchedAddressBackfillRetryInterval = 5 * time.Second
+	// cap on the backoff between retries of a failed write
+	watchedAddressBackfillMaxRetryInterval = 5 * time.Minute
+	// time between the writes of the backfill progress to the file
+	watchedAddressBackfillPersistInterval = 10 * time.Second
+)
+
+var errStateDiffUnsupported = errors.New("the proxy node does not support state diffing")
+
+// WatchAddressArg is an address to watch, and the height of the block its contract was created at
+type WatchAddressArg struct {
+	Address   common.Address `json:"address"`
+	CreatedAt uint64         `json:"createdAt"`
+}
+
+// WatchedAddress is an address watched by the statediffing proxy node
+type WatchedAddress struct {
+	Address   common.Address `json:"address"`
+	CreatedAt uint64         `json:"createdAt"`
+	WatchedAt time.Time      `json:"watchedAt"`
+	// LastFilledAt is the height of the last block backfilled for the address, 0 if it hasn't been backfilled
+	LastFilledAt uint64 `json:"lastFilledAt"`
+	// Backfill is the range requested to be backfilled for the address, nil once it is complete
+	Backfill *BackfillRange `json:"backfill,omitempty"`
+}
+
+// BackfillRange is an inclusive range of block heights
+type BackfillRange struct {
+	Start uint64 `json:"start"`
+	End   uint64 `json:"end"`
+}
+
+// WatchedAddresses manages the addresses watched by the statediffing proxy node
+// Changes are forwarded to statediff_watchAddress and the list is persisted to a JSON file, when a path is provided;
+// failing to persist a change the proxy node accepted is logged rather than failing it
+type WatchedAddresses struct {
+	sync.Mutex
+	client    *rpc.Client
+	writer    *eth.StateDiffWriter
+	path      string
+	addresses map[common.Address]*WatchedAddress
+
+	maxRetries      int
+	retryInterval   time.Duration
+	persistInterval time.Duration
+}
+
+// NewWatchedAddresses creates a new WatchedAddresses, loading the list persisted at the path
+// Backfills are written through the writer, which is nil when the proxy node doesn't support state diffing
+func NewWatchedAddresses(client *rpc.Client, writer *eth.StateDiffWriter, path string) (*WatchedAddresses, error) {
+	wa := &WatchedAddresses{
+		client:    client,
+		writer:    writer,
+		path:      path,
+		addresses: make(map[common.Address]*WatchedAddress),
+
+		maxRetries:      watchedAddressBackfillMaxRetries,
+		retryInterval:   watchedAddressBackfillRetryInterval,
+		persistInterval: watchedAddressBackfillPersistInterval,
+	}
+	if path == "" {
+		return wa, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return wa, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var list []WatchedAddress
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("invalid watched addresses file %s: %v", path, err)
+	}
+	for i := range list {
+		wa.addresses[list[i].Address] = &list[i]
+	}
+	return wa, nil
+}
+
+// List returns the watched addresses, ordered by address
+func (wa *WatchedAddresses) List() []WatchedAddress {
+	wa.Lock()
+	defer wa.Unlock()
+	return wa.list()
+}
+
+// list needs to be called with the lock held
+func (wa *WatchedAddresses) list() []WatchedAddress {
+	list := make([]WatchedAddress, 0, len(wa.addresses))
+	for _, addr := range wa.addresses {
+		list = append(list, *addr)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Address.Hex() < list[j].Address.Hex()
+	})
+	return list
+}
+
+// Add watches the addresses on the proxy node and persists them
+// If a range is provided, state for the newly added addresses is backfilled over it in the background
+func (wa *WatchedAddresses) Add(ctx context.Context, args []WatchAddressArg, fill *BackfillRange) ([]WatchedAddress, error) {
+	if wa.writer == nil {
+		return nil, errStateDiffUnsupported
+	}
+	if fill != nil {
+		if fill.End < fill.Start {
+			return nil, fmt.Errorf("invalid backfill range: end %d is before start %d", fill.End, fill.Start)
+		}
+		if fill.End-fill.Start >= MaxWatchedAddressBackfillRange {
+			return nil, fmt.Errorf("backfill range of %d blocks exceeds the maximum of %d", fill.End-fill.Start+1, MaxWatchedAddressBackfillRange)
+		}
+	}
+	wa.Lock()
+	defer wa.Unlock()
+	added := make([]WatchAddressArg, 0, len(args))
+	for _, arg := range args {
+		if _, ok := wa.addresses[arg.Address]; !ok {
+			added = append(added, arg)
+		}
+	}
+	if len(added) == 0 {
+		return wa.list(), nil
+	}
+	if err := wa.client.CallContext(ctx, nil, "statediff_watchAddress", WatchAddressAdd, added); err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	addresses := make([]common.Address, len(added))
+	for i, arg := range added {
+		watched := &WatchedAddress{
+			Address:   arg.Address,
+			CreatedAt: arg.CreatedAt,
+			WatchedAt: now,
+		}
+		if fill != nil {
+			watched.Backfill = &BackfillRange{Start: fill.Start, End: fill.End}
+		}
+		wa.addresses[arg.Address] = watched
+		addresses[i] = arg.Address
+	}
+	// the proxy node already applied the change, so the list keeps matching it and is persisted again on the next change
+	if err := wa.persist(); err != nil {
+		log.Errorf("failed to persist watched addresses: %v", err)
+	}
+	if fill != nil {
+		go wa.backfill(addresses, *fill)
+	}
+	return wa.list(), nil
+}
+
+// Remove stops watching the addresses on the proxy node and persists the change
+func (wa *WatchedAddresses) Remove(ctx context.Context, addresses []common.Address) ([]WatchedAddress, error) {
+	if wa.writer == nil {
+		return nil, errStateDiffUnsupported
+	}
+	wa.Lock()
+	defer wa.Unlock()
+	removed := make([]WatchAddressArg, 0, len(addresses))
+	for _, addr := range addresses {
+		if watched, ok := wa.addresses[addr]; ok {
+			removed = append(removed, WatchAddressArg{Address: addr, CreatedAt: watched.CreatedAt})
+		}
+	}
+	if len(removed) == 0 {
+		return wa.list(), nil
+	}
+	if err := wa.client.CallContext(ctx, nil, "statediff_watchAddress", WatchAddressRemove, removed); err != nil {
+		return nil, err
+	}
+	for _, arg := range removed {
+		delete(wa.addresses, arg.Address)
+	}
+	// the proxy node already applied the change, so the list keeps matching it and is persisted again on the next change
+	if err := wa.persist(); err != nil {
+		log.Errorf("failed to persist watched addresses: %v", err)
+	}
+	return wa.list(), nil
+}
+
+// ResumeBackfills restarts the backfills which were persisted incomplete, from the height after the last one filled
+func (wa *WatchedAddresses) ResumeBackfills() {
+	if wa.writer == nil {
+		return
+	}
+	wa.Lock()
+	resumed := make(map[BackfillRange][]common.Address)
+	for _, watched := range wa.list() {
+		if watched.Backfill == nil {
+			continue
+		}
+		fill := *watched.Backfill
+		if watched.LastFilledAt > 0 && watched.LastFilledAt >= fill.Start {
+			fill.Start = watched.LastFilledAt + 1
+		}
+		resumed[fill] = append(resumed[fill], watched.Address)
+	}
+	wa.Unlock()
+	for fill, addresses := range resumed {
+		go wa.backfill(addresses, fill)
+	}
+}
+
+// backfill writes the state diffs for the addresses at each height in the range, recording the progress of each address
+// The progress is persisted periodically, and when the backfill completes or stops on a write failing every retry
+func (wa *WatchedAddresses) backfill(addresses []common.Address, fill BackfillRange) {
+	log.Infof("backfilling %d watched addresses from block %d to %d", len(addresses), fill.Start, fill.End)
+	persisted := time.Now()
+	for height := fill.Start; height <= fill.End; height++ {
+		addresses = wa.stillWatched(addresses)
+		if len(addresses) == 0 {
+			log.Infof("watched address backfill stopped at block %d, the addresses are no longer watched", height)
+			return
+		}
+		err := wa.fill(height, addresses)
+		wa.Lock()
+		if err == nil {
+			for _, addr := range addresses {
+				watched, ok := wa.addresses[addr]
+				if !ok {
+					continue
+				}
+				if height > watched.LastFilledAt {
+					watched.LastFilledAt = height
+				}
+				if height == fill.End {
+					watched.Backfill = nil
+				}
+			}
+		}
+		if err != nil || height == fill.End || time.Since(persisted) >= wa.persistInterval {
+			if err := wa.persist(); err != nil {
+				log.Errorf("failed to persist watched addresses: %v", err)
+			}
+			persisted = time.Now()
+		}
+		wa.Unlock()
+		if err != nil {
+			log.Errorf("watched address backfill stopped at block %d, it is resumed on restart: %v", height, err)
+			return
+		}
+	}
+	log.Infof("finished backfilling %d watched addresses from block %d to %d", len(addresses), fill.Start, fill.End)
+}
+
+// fill writes the state of the addresses at the height, retrying with backoff
+func (wa *WatchedAddresses) fill(height uint64, addresses []common.Address) error {
+	backoff := wa.retryInterval
+	for attempt := 0; ; attempt++ {
+		err := wa.writeStateDiffAt(height, addresses)
+		if err == nil {
+			return nil
+		}
+		if attempt >= wa.maxRetries {
+			return fmt.Errorf("failed to write block %d after %d attempts: %v", height, attempt+1, err)
+		}
+		log.Warnf("watched address backfill failed to write block %d, retrying in %s: %v", height, backoff, err)
+		time.Sleep(backoff)
+		if backoff *= 2; backoff > watchedAddressBackfillMaxRetryInterval {
+			backoff = watchedAddressBackfillMaxRetryInterval
+		}
+	}
+}
+
+// stillWatched filters out the addresses which have since been removed
+func (wa *WatchedAddresses) stillWatched(addresses []common.Address) []common.Address {
+	wa.Lock()
+	defer wa.Unlock()
+	watched := addresses[:0]
+	for _, addr := range addresses {
+		if _, ok := wa.addresses[addr]; ok {
+			watched = append(watched, addr)
+		}
+	}
+	return watched
+}
+
+// writeStateDiffAt writes the state of the addresses at the height
+func (wa *WatchedAddresses) writeStateDiffAt(height uint64, addresses []common.Address) error {
+	ctx, cancel := context.WithTimeout(context.Background(), watchedAddressBackfillTimeout)
+	defer cancel()
+	return wa.writer.Write(ctx, height, addresses)
+}
+
+// persist writes the list to the file, replacing it atomically
+// persist needs to be called with the lock held
+func (wa *WatchedAddresses) persist() error {
+	if wa.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(wa.list(), "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(wa.path), filepath.Base(wa.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), wa.path)
+}
@@ -0,0 +1,228 @@
+// VulcanizeDB
+// Copyright © 2022 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package serve_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/statediff"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/ipld-eth-server/pkg/eth"
+	"github.com/vulcanize/ipld-eth-server/pkg/serve"
+)
+
+type watchAddressCall struct {
+	operation string
+	args      []serve.WatchAddressArg
+}
+
+type writeStateDiffAtCall struct {
+	height    uint64
+	addresses []common.Address
+}
+
+// mockStateDiffAPI records the statediff calls forwarded to the proxy node
+type mockStateDiffAPI struct {
+	sync.Mutex
+	watchCalls []watchAddressCall
+	writeCalls []writeStateDiffAtCall
+	// number of the next writes of each height which fail
+	failures map[uint64]int
+}
+
+func (api *mockStateDiffAPI) WatchAddress(operation string, args []serve.WatchAddressArg) error {
+	api.Lock()
+	defer api.Unlock()
+	api.watchCalls = append(api.watchCalls, watchAddressCall{operation: operation, args: args})
+	return nil
+}
+
+func (api *mockStateDiffAPI) WriteStateDiffAt(height uint64, params statediff.Params) error {
+	api.Lock()
+	defer api.Unlock()
+	api.writeCalls = append(api.writeCalls, writeStateDiffAtCall{height: height, addresses: params.WatchedAddresses})
+	if api.failures[height] > 0 {
+		api.failures[height]--
+		return errors.New("write failed")
+	}
+	return nil
+}
+
+func (api *mockStateDiffAPI) writes() []writeStateDiffAtCall {
+	api.Lock()
+	defer api.Unlock()
+	return append([]writeStateDiffAtCall{}, api.writeCalls...)
+}
+
+var _ = Describe("WatchedAddresses", func() {
+	var (
+		api     *mockStateDiffAPI
+		client  *rpc.Client
+		writer  *eth.StateDiffWriter
+		dir     string
+		path    string
+		watched *serve.WatchedAddresses
+		first   = common.HexToAddress("0x1")
+		second  = common.HexToAddress("0x2")
+	)
+	BeforeEach(func() {
+		api = new(mockStateDiffAPI)
+		srv := rpc.NewServer()
+		Expect(srv.RegisterName("statediff", api)).To(Succeed())
+		client = rpc.DialInProc(srv)
+		var err error
+		writer, err = eth.NewStateDiffWriter(client, eth.StateDiffWriterConfig{})
+		Expect(err).ToNot(HaveOccurred())
+		dir, err = ioutil.TempDir("", "watched-addresses")
+		Expect(err).ToNot(HaveOccurred())
+		path = filepath.Join(dir, "watched.json")
+		watched, err = serve.NewWatchedAddresses(client, writer, path)
+		Expect(err).ToNot(HaveOccurred())
+	})
+	AfterEach(func() {
+		writer.Stop()
+		client.Close()
+		os.RemoveAll(dir)
+	})
+
+	It("Forwards added and removed addresses to the proxy node and persists the list", func() {
+		list, err := watched.Add(context.Background(), []serve.WatchAddressArg{{Address: first, CreatedAt: 10}, {Address: second, CreatedAt: 20}}, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(list).To(HaveLen(2))
+		Expect(list[0].Address).To(Equal(first))
+		Expect(list[0].CreatedAt).To(Equal(uint64(10)))
+
+		// addresses which are already watched aren't forwarded again
+		_, err = watched.Add(context.Background(), []serve.WatchAddressArg{{Address: first, CreatedAt: 10}}, nil)
+		Expect(err).ToNot(HaveOccurred())
+		list, err = watched.Remove(context.Background(), []common.Address{first})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(list).To(HaveLen(1))
+		Expect(list[0].Address).To(Equal(second))
+
+		Expect(api.watchCalls).To(HaveLen(2))
+		Expect(api.watchCalls[0].operation).To(Equal(serve.WatchAddressAdd))
+		Expect(api.watchCalls[0].args).To(HaveLen(2))
+		Expect(api.watchCalls[1].operation).To(Equal(serve.WatchAddressRemove))
+		Expect(api.watchCalls[1].args).To(Equal([]serve.WatchAddressArg{{Address: first, CreatedAt: 10}}))
+
+		reloaded, err := serve.NewWatchedAddresses(client, writer, path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(reloaded.List()).To(Equal(watched.List()))
+	})
+
+	It("Keeps the changes accepted by the proxy node when the list can't be persisted", func() {
+		unpersisted, err := serve.NewWatchedAddresses(client, writer, filepath.Join(dir, "missing", "watched.json"))
+		Expect(err).ToNot(HaveOccurred())
+		list, err := unpersisted.Add(context.Background(), []serve.WatchAddressArg{{Address: first}, {Address: second}}, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(list).To(HaveLen(2))
+		list, err = unpersisted.Remove(context.Background(), []common.Address{first})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(list).To(HaveLen(1))
+		Expect(list[0].Address).To(Equal(second))
+		Expect(api.watchCalls).To(HaveLen(2))
+	})
+
+	It("Backfills the newly added addresses over the requested range", func() {
+		_, err := watched.Add(context.Background(), []serve.WatchAddressArg{{Address: first}}, nil)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = watched.Add(context.Background(), []serve.WatchAddressArg{{Address: first}, {Address: second}}, &serve.BackfillRange{Start: 5, End: 7})
+		Expect(err).ToNot(HaveOccurred())
+
+		Eventually(api.writes).Should(HaveLen(3))
+		for i, call := range api.writes() {
+			Expect(call.height).To(Equal(uint64(5 + i)))
+			Expect(call.addresses).To(Equal([]common.Address{second}))
+		}
+		Eventually(func() uint64 { return watched.List()[1].LastFilledAt }).Should(Equal(uint64(7)))
+		Expect(watched.List()[0].LastFilledAt).To(Equal(uint64(0)))
+	})
+
+	It("Retries the failed writes of a backfill", func() {
+		api.failures = map[uint64]int{5: 2}
+		watched.SetBackfillRetries(2, time.Millisecond)
+		_, err := watched.Add(context.Background(), []serve.WatchAddressArg{{Address: first}}, &serve.BackfillRange{Start: 5, End: 6})
+		Expect(err).ToNot(HaveOccurred())
+
+		Eventually(func() *serve.BackfillRange { return watched.List()[0].Backfill }).Should(BeNil())
+		heights := make([]uint64, 0, 4)
+		for _, call := range api.writes() {
+			heights = append(heights, call.height)
+		}
+		Expect(heights).To(Equal([]uint64{5, 5, 5, 6}))
+		Expect(watched.List()[0].LastFilledAt).To(Equal(uint64(6)))
+
+		reloaded, err := serve.NewWatchedAddresses(client, writer, path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(reloaded.List()).To(Equal(watched.List()))
+	})
+
+	It("Persists the backfills stopped on a failing write and resumes them", func() {
+		api.failures = map[uint64]int{6: 2}
+		watched.SetBackfillRetries(1, time.Millisecond)
+		_, err := watched.Add(context.Background(), []serve.WatchAddressArg{{Address: first}, {Address: second}}, &serve.BackfillRange{Start: 5, End: 8})
+		Expect(err).ToNot(HaveOccurred())
+
+		// block 5 is written, and block 6 fails the first attempt and its retry
+		Eventually(api.writes).Should(HaveLen(3))
+		Consistently(api.writes, 50*time.Millisecond).Should(HaveLen(3))
+		reloaded, err := serve.NewWatchedAddresses(client, writer, path)
+		Expect(err).ToNot(HaveOccurred())
+		list := reloaded.List()
+		Expect(list).To(HaveLen(2))
+		for _, address := range list {
+			Expect(address.LastFilledAt).To(Equal(uint64(5)))
+			Expect(address.Backfill).To(Equal(&serve.BackfillRange{Start: 5, End: 8}))
+		}
+
+		reloaded.ResumeBackfills()
+		Eventually(func() *serve.BackfillRange { return reloaded.List()[0].Backfill }).Should(BeNil())
+		writes := api.writes()
+		Expect(writes).To(HaveLen(6))
+		for i, call := range writes[3:] {
+			Expect(call.height).To(Equal(uint64(6 + i)))
+			Expect(call.addresses).To(ConsistOf(first, second))
+		}
+		Expect(reloaded.List()[1].LastFilledAt).To(Equal(uint64(8)))
+	})
+
+	It("Rejects invalid backfill ranges and proxies without state diffing", func() {
+		_, err := watched.Add(context.Background(), []serve.WatchAddressArg{{Address: first}}, &serve.BackfillRange{Start: 7, End: 5})
+		Expect(err).To(HaveOccurred())
+		_, err = watched.Add(context.Background(), []serve.WatchAddressArg{{Address: first}}, &serve.BackfillRange{Start: 0, End: serve.MaxWatchedAddressBackfillRange})
+		Expect(err).To(HaveOccurred())
+		Expect(watched.List()).To(BeEmpty())
+
+		unsupported, err := serve.NewWatchedAddresses(client, nil, "")
+		Expect(err).ToNot(HaveOccurred())
+		_, err = unsupported.Add(context.Background(), []serve.WatchAddressArg{{Address: first}}, nil)
+		Expect(err).To(HaveOccurred())
+		Expect(api.watchCalls).To(BeEmpty())
+	})
+})
@@ -0,0 +1,134 @@
+// VulcanizeDB
+// Copyright © 2022 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package sink
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/viper"
+
+	"github.com/vulcanize/ipld-eth-server/pkg/eth"
+)
+
+// Config describes a sink, sinks are declared as [[eth.server.sinks]] tables in the TOML config
+type Config struct {
+	Name       string
+	Type       string // WebhookType or FileType
+	BufferSize int    // payloads buffered while the sink is writing, defaults to DefaultBufferSize
+	// Settings are read from the sink's settings table, which uses the same keys as watcher.ethSubscription
+	Settings eth.SubscriptionSettings
+
+	// webhook sink
+	URL           string
+	Secret        string        // key used to sign each request body with HMAC-SHA256, no signature is sent when empty
+	MaxRetries    int           // retries after a failed delivery before the payload is dropped, a negative value retries indefinitely
+	RetryInterval time.Duration // delay before the first retry, doubled for each following retry
+	Timeout       time.Duration // timeout of each request
+	CursorPath    string        // file the height of the last delivered block is persisted to
+
+	// file sink
+	Path    string        // directory the files are written to
+	Format  string        // JSONLFormat or RLPFormat
+	MaxSize int64         // size in bytes after which a new file is started, 0 for no limit
+	MaxAge  time.Duration // age after which a new file is started, 0 for no limit
+}
+
+// NewConfigs reads the sink configs from the eth.server.sinks tables
+func NewConfigs() ([]Config, error) {
+	if !viper.IsSet("eth.server.sinks") {
+		return nil, nil
+	}
+	tables, ok := viper.Get("eth.server.sinks").([]interface{})
+	if !ok {
+		return nil, errors.New("eth.server.sinks must be an array of tables")
+	}
+	configs := make([]Config, 0, len(tables))
+	names := make(map[string]bool, len(tables))
+	for i, entry := range tables {
+		table, ok := entry.(map[string]interface{})
+		if !ok {
+			return nil, errors.New("eth.server.sinks must be an array of tables")
+		}
+		v := viper.New()
+		if err := v.MergeConfigMap(table); err != nil {
+			return nil, err
+		}
+		conf, err := newConfig(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sink %d: %v", i, err)
+		}
+		if names[conf.Name] {
+			return nil, fmt.Errorf("duplicate sink name %s", conf.Name)
+		}
+		names[conf.Name] = true
+		configs = append(configs, conf)
+	}
+	return configs, nil
+}
+
+func newConfig(v *viper.Viper) (Config, error) {
+	v.SetDefault("maxRetries", 5)
+	v.SetDefault("retryInterval", time.Second)
+	v.SetDefault("timeout", 10*time.Second)
+	v.SetDefault("format", JSONLFormat)
+	conf := Config{
+		Name:          v.GetString("name"),
+		Type:          v.GetString("type"),
+		BufferSize:    v.GetInt("bufferSize"),
+		URL:           v.GetString("url"),
+		Secret:        v.GetString("secret"),
+		MaxRetries:    v.GetInt("maxRetries"),
+		RetryInterval: v.GetDuration("retryInterval"),
+		Timeout:       v.GetDuration("timeout"),
+		CursorPath:    v.GetString("cursorPath"),
+		Path:          v.GetString("path"),
+		Format:        v.GetString("format"),
+		MaxSize:       v.GetInt64("maxSize"),
+		MaxAge:        v.GetDuration("maxAge"),
+	}
+	if conf.Name == "" {
+		return conf, errors.New("sink name is required")
+	}
+	settingsConfig := v.Sub("settings")
+	if settingsConfig == nil {
+		settingsConfig = viper.New()
+	}
+	settings, err := eth.NewSubscriptionSettingsFromConfig(settingsConfig)
+	if err != nil {
+		return conf, err
+	}
+	conf.Settings = *settings
+
+	switch conf.Type {
+	case WebhookType:
+		if conf.URL == "" {
+			return conf, fmt.Errorf("webhook sink %s requires a url", conf.Name)
+		}
+	case FileType:
+		if conf.Path == "" {
+			return conf, fmt.Errorf("file sink %s requires a path", conf.Name)
+		}
+		if conf.Format != JSONLFormat && conf.Format != RLPFormat {
+			return conf, fmt.Errorf("file sink %s has unknown format %q", conf.Name, conf.Format)
+		}
+	default:
+		return conf, fmt.Errorf("sink %s has unknown type %q", conf.Name, conf.Type)
+	}
+	return conf, nil
+}
@@ -0,0 +1,149 @@
+// VulcanizeDB
+// Copyright © 2022 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package sink
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vulcanize/ipld-eth-server/pkg/eth"
+	"github.com/vulcanize/ipld-eth-server/pkg/serve"
+)
+
+// File sink formats
+const (
+	// JSONLFormat writes each payload as a line of JSON
+	JSONLFormat = "jsonl"
+	// RLPFormat writes the rlp encoded IPLDs of each block back to back, they can be read with an rlp.Stream
+	// Payloads without block data, such as reorg notices, are not written in this format
+	RLPFormat = "rlp"
+)
+
+// FileSink writes payloads to files in a directory, starting a new file once the current one exceeds its maximum size or age
+// Files are named <name>-<UTC start time>.<format>, so they sort in the order they were written
+type FileSink struct {
+	conf    Config
+	file    *os.File
+	writer  *bufio.Writer
+	size    int64
+	opened  time.Time
+	newTime func() time.Time
+}
+
+// NewFileSink creates a new FileSink, creating its directory if needed
+func NewFileSink(conf Config) (*FileSink, error) {
+	if err := os.MkdirAll(conf.Path, 0755); err != nil {
+		return nil, err
+	}
+	if conf.Format == RLPFormat {
+		conf.Settings.Encoding = eth.RLPEncoding
+	}
+	return &FileSink{
+		conf:    conf,
+		newTime: time.Now,
+	}, nil
+}
+
+// Name satisfies Sink
+func (fs *FileSink) Name() string {
+	return fs.conf.Name
+}
+
+// Settings satisfies Sink
+func (fs *FileSink) Settings() eth.SubscriptionSettings {
+	return fs.conf.Settings
+}
+
+// Write satisfies Sink
+func (fs *FileSink) Write(_ context.Context, payload serve.SubscriptionPayload) error {
+	var record []byte
+	switch fs.conf.Format {
+	case RLPFormat:
+		if len(payload.Data) == 0 {
+			log.Debugf("%s sink skipping payload without block data at height %d", fs.conf.Name, payload.Height)
+			return nil
+		}
+		record = payload.Data
+	default:
+		line, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		record = append(line, '\n')
+	}
+	if err := fs.rotate(); err != nil {
+		return err
+	}
+	n, err := fs.writer.Write(record)
+	fs.size += int64(n)
+	if err != nil {
+		return err
+	}
+	// flush every record so that the files can be tailed
+	return fs.writer.Flush()
+}
+
+// rotate opens a new file if there is none or the current one is full or old enough
+func (fs *FileSink) rotate() error {
+	now := fs.newTime()
+	if fs.file != nil {
+		full := fs.conf.MaxSize > 0 && fs.size >= fs.conf.MaxSize
+		old := fs.conf.MaxAge > 0 && now.Sub(fs.opened) >= fs.conf.MaxAge
+		if !full && !old {
+			return nil
+		}
+		if err := fs.closeFile(); err != nil {
+			return err
+		}
+	}
+	name := fmt.Sprintf("%s-%s.%s", fs.conf.Name, now.UTC().Format("20060102T150405.000000000Z"), fs.conf.Format)
+	file, err := os.OpenFile(filepath.Join(fs.conf.Path, name), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	fs.file = file
+	fs.writer = bufio.NewWriter(file)
+	fs.size = 0
+	fs.opened = now
+	log.Debugf("%s sink writing to %s", fs.conf.Name, file.Name())
+	return nil
+}
+
+func (fs *FileSink) closeFile() error {
+	if err := fs.writer.Flush(); err != nil {
+		fs.file.Close()
+		return err
+	}
+	err := fs.file.Close()
+	fs.file, fs.writer = nil, nil
+	return err
+}
+
+// Close satisfies Sink
+func (fs *FileSink) Close() error {
+	if fs.file == nil {
+		return nil
+	}
+	return fs.closeFile()
+}
@@ -0,0 +1,126 @@
+// VulcanizeDB
+// Copyright © 2022 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package sink_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rlp"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/ipld-eth-server/pkg/eth"
+	"github.com/vulcanize/ipld-eth-server/pkg/eth/test_helpers"
+	"github.com/vulcanize/ipld-eth-server/pkg/serve"
+	"github.com/vulcanize/ipld-eth-server/pkg/sink"
+)
+
+// sinkFiles returns the paths of the files in the directory in name order
+func sinkFiles(dir string) []string {
+	infos, err := ioutil.ReadDir(dir)
+	Expect(err).ToNot(HaveOccurred())
+	paths := make([]string, len(infos))
+	for i, info := range infos {
+		paths[i] = filepath.Join(dir, info.Name())
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+var _ = Describe("FileSink", func() {
+	var (
+		dir   string
+		now   time.Time
+		clock func() time.Time
+	)
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "file-sink")
+		Expect(err).ToNot(HaveOccurred())
+		now = time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+		clock = func() time.Time {
+			now = now.Add(time.Second)
+			return now
+		}
+	})
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("Writes payloads as JSON lines, rotating files once they reach their maximum size", func() {
+		file, err := sink.NewFileSink(sink.Config{Name: "archive", Path: filepath.Join(dir, "out"), Format: sink.JSONLFormat, MaxSize: 1})
+		Expect(err).ToNot(HaveOccurred())
+		file.SetClock(clock)
+		Expect(file.Write(context.Background(), serve.SubscriptionPayload{Height: 1, Data: []byte{1}})).To(Succeed())
+		Expect(file.Write(context.Background(), serve.SubscriptionPayload{Height: 2, Flag: serve.ReorgFlag})).To(Succeed())
+		Expect(file.Close()).To(Succeed())
+
+		paths := sinkFiles(filepath.Join(dir, "out"))
+		Expect(paths).To(HaveLen(2))
+		Expect(filepath.Base(paths[0])).To(Equal("archive-20220101T000001.000000000Z.jsonl"))
+		heights := make([]int64, 0, 2)
+		for _, path := range paths {
+			f, err := os.Open(path)
+			Expect(err).ToNot(HaveOccurred())
+			scanner := bufio.NewScanner(f)
+			for scanner.Scan() {
+				var payload serve.SubscriptionPayload
+				Expect(json.Unmarshal(scanner.Bytes(), &payload)).To(Succeed())
+				heights = append(heights, payload.Height)
+			}
+			f.Close()
+		}
+		Expect(heights).To(Equal([]int64{1, 2}))
+	})
+
+	It("Writes the rlp encoded IPLDs back to back, rotating files once they reach their maximum age", func() {
+		file, err := sink.NewFileSink(sink.Config{Name: "archive", Path: dir, Format: sink.RLPFormat, MaxAge: time.Minute})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(file.Settings().Encoding).To(Equal(eth.RLPEncoding))
+		file.SetClock(clock)
+		data, err := rlp.EncodeToBytes(&test_helpers.MockIPLDs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(file.Write(context.Background(), serve.SubscriptionPayload{Height: 1, Data: data})).To(Succeed())
+		Expect(file.Write(context.Background(), serve.SubscriptionPayload{Height: 1, Data: data})).To(Succeed())
+		Expect(file.Write(context.Background(), serve.SubscriptionPayload{Flag: serve.BackFillCompleteFlag})).To(Succeed())
+		now = now.Add(time.Minute)
+		Expect(file.Write(context.Background(), serve.SubscriptionPayload{Height: 1, Data: data})).To(Succeed())
+		Expect(file.Close()).To(Succeed())
+
+		paths := sinkFiles(dir)
+		Expect(paths).To(HaveLen(2))
+		contents, err := ioutil.ReadFile(paths[0])
+		Expect(err).ToNot(HaveOccurred())
+		stream := rlp.NewStream(bytes.NewReader(contents), 0)
+		for i := 0; i < 2; i++ {
+			var iplds eth.IPLDs
+			Expect(stream.Decode(&iplds)).To(Succeed())
+			Expect(iplds.BlockNumber.Int64()).To(Equal(test_helpers.MockIPLDs.BlockNumber.Int64()))
+			Expect(iplds.Header).To(Equal(test_helpers.MockIPLDs.Header))
+		}
+		var iplds eth.IPLDs
+		Expect(stream.Decode(&iplds)).ToNot(Succeed())
+	})
+})
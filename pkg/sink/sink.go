@@ -0,0 +1,119 @@
+// VulcanizeDB
+// Copyright © 2022 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package sink
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vulcanize/ipld-eth-server/pkg/eth"
+	"github.com/vulcanize/ipld-eth-server/pkg/serve"
+)
+
+// Sink types
+const (
+	WebhookType = "webhook"
+	FileType    = "file"
+)
+
+// DefaultBufferSize is the default number of payloads buffered for a sink while it is writing
+const DefaultBufferSize = 1000
+
+// Sink pushes the payloads of a vdb stream subscription to an output without a connected client
+type Sink interface {
+	// Name identifies the sink in logs
+	Name() string
+	// Settings returns the settings to subscribe with, they may start after the last delivered block of a previous run
+	Settings() eth.SubscriptionSettings
+	// Write delivers the payload, returning once it has been delivered or can't be
+	// The context is cancelled once the subscription ends, after which the sink shouldn't wait to retry a write
+	Write(ctx context.Context, payload serve.SubscriptionPayload) error
+	// Close releases the sink's resources
+	Close() error
+}
+
+// New creates the sink described by the config
+func New(conf Config) (Sink, error) {
+	switch conf.Type {
+	case WebhookType:
+		return NewWebhookSink(conf)
+	case FileType:
+		return NewFileSink(conf)
+	default:
+		return nil, fmt.Errorf("sink %s has unknown type %q", conf.Name, conf.Type)
+	}
+}
+
+// Start subscribes the sink to the server and writes its payloads to it until the subscription ends
+// The subscription goes through the same filtering path as any other subscriber, so a sink which falls more than
+// bufferSize payloads behind has payloads dropped just like a slow client would
+func Start(wg *sync.WaitGroup, s serve.Server, sink Sink, bufferSize int) {
+	if bufferSize <= 0 {
+		bufferSize = DefaultBufferSize
+	}
+	id := rpc.NewID()
+	payloadChan := make(chan serve.SubscriptionPayload, bufferSize)
+	quitChan := make(chan bool, 1)
+	go s.Subscribe(id, payloadChan, quitChan, sink.Settings())
+	log.Infof("started %s sink with subscription %s", sink.Name(), id)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-quitChan
+		cancel()
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer func() {
+			if err := sink.Close(); err != nil {
+				log.Errorf("failed to close %s sink: %v", sink.Name(), err)
+			}
+		}()
+		write := func(payload serve.SubscriptionPayload) {
+			if payload.Err != "" {
+				log.Errorf("%s sink subscription error: %s", sink.Name(), payload.Err)
+				return
+			}
+			if err := sink.Write(ctx, payload); err != nil {
+				log.Errorf("%s sink failed to write payload at height %d: %v", sink.Name(), payload.Height, err)
+			}
+		}
+		for {
+			select {
+			case payload := <-payloadChan:
+				write(payload)
+			case <-ctx.Done():
+				// write anything sent before the subscription was closed
+				for {
+					select {
+					case payload := <-payloadChan:
+						write(payload)
+					default:
+						log.Infof("%s sink subscription %s closed", sink.Name(), id)
+						return
+					}
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,181 @@
+// VulcanizeDB
+// Copyright © 2022 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package sink_test
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/viper"
+
+	"github.com/vulcanize/ipld-eth-server/pkg/eth"
+	"github.com/vulcanize/ipld-eth-server/pkg/serve"
+	"github.com/vulcanize/ipld-eth-server/pkg/sink"
+)
+
+// mockServer sends the queued payloads to the subscriber and then closes the subscription
+type mockServer struct {
+	serve.Server
+	payloads []serve.SubscriptionPayload
+	params   chan eth.SubscriptionSettings
+}
+
+func (s *mockServer) Subscribe(id rpc.ID, sub chan<- serve.SubscriptionPayload, quitChan chan<- bool, params eth.SubscriptionSettings) {
+	s.params <- params
+	for _, payload := range s.payloads {
+		sub <- payload
+	}
+	quitChan <- true
+}
+
+// recordingSink records the payloads written to it
+type recordingSink struct {
+	settings eth.SubscriptionSettings
+	written  []serve.SubscriptionPayload
+	closed   bool
+}
+
+func (r *recordingSink) Name() string                       { return "recording" }
+func (r *recordingSink) Settings() eth.SubscriptionSettings { return r.settings }
+func (r *recordingSink) Close() error                       { r.closed = true; return nil }
+func (r *recordingSink) Write(_ context.Context, payload serve.SubscriptionPayload) error {
+	r.written = append(r.written, payload)
+	return nil
+}
+
+var _ = Describe("Sinks", func() {
+	Describe("Start", func() {
+		It("Subscribes with the sink's settings and writes its payloads until the subscription ends", func() {
+			server := &mockServer{
+				payloads: []serve.SubscriptionPayload{
+					{Height: 1, Data: []byte{1}},
+					{Err: "subscription error"},
+					{Height: 2, Data: []byte{2}},
+				},
+				params: make(chan eth.SubscriptionSettings, 1),
+			}
+			snk := &recordingSink{settings: eth.SubscriptionSettings{Expression: "tx.type == 2"}}
+			wg := new(sync.WaitGroup)
+			sink.Start(wg, server, snk, 0)
+			Expect((<-server.params).Expression).To(Equal("tx.type == 2"))
+
+			done := make(chan struct{})
+			go func() {
+				wg.Wait()
+				close(done)
+			}()
+			Eventually(done, time.Second).Should(BeClosed())
+			Expect(snk.closed).To(BeTrue())
+			Expect(snk.written).To(HaveLen(2))
+			Expect(snk.written[0].Height).To(Equal(int64(1)))
+			Expect(snk.written[1].Height).To(Equal(int64(2)))
+		})
+	})
+
+	Describe("NewConfigs", func() {
+		AfterEach(func() {
+			viper.Reset()
+		})
+
+		It("Reads each sink with its own subscription settings", func() {
+			viper.SetConfigType("toml")
+			Expect(viper.ReadConfig(strings.NewReader(`
+[eth.server]
+    [[eth.server.sinks]]
+        name = "hook"
+        type = "webhook"
+        url = "http://127.0.0.1:9000/hook"
+        secret = "secret"
+        retryInterval = "2s"
+        cursorPath = "./hook.cursor"
+        [eth.server.sinks.settings]
+            historicalData = true
+            startingBlock = 10
+            [eth.server.sinks.settings.txFilter]
+                types = [2]
+                minValue = "1000"
+            [eth.server.sinks.settings.stateFilter]
+                off = true
+    [[eth.server.sinks]]
+        name = "archive"
+        type = "file"
+        path = "./archive"
+        format = "rlp"
+        maxSize = 1048576
+        maxAge = "1h"
+`))).To(Succeed())
+			configs, err := sink.NewConfigs()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(configs).To(HaveLen(2))
+
+			hook := configs[0]
+			Expect(hook.Name).To(Equal("hook"))
+			Expect(hook.Type).To(Equal(sink.WebhookType))
+			Expect(hook.URL).To(Equal("http://127.0.0.1:9000/hook"))
+			Expect(hook.Secret).To(Equal("secret"))
+			Expect(hook.MaxRetries).To(Equal(5))
+			Expect(hook.RetryInterval).To(Equal(2 * time.Second))
+			Expect(hook.Timeout).To(Equal(10 * time.Second))
+			Expect(hook.CursorPath).To(Equal("./hook.cursor"))
+			Expect(hook.Settings.BackFill).To(BeTrue())
+			Expect(hook.Settings.Start.Int64()).To(Equal(int64(10)))
+			Expect(hook.Settings.TxFilter.Types).To(Equal([]uint64{2}))
+			Expect(hook.Settings.TxFilter.MinValue.Int64()).To(Equal(int64(1000)))
+			Expect(hook.Settings.StateFilter.Off).To(BeTrue())
+			Expect(hook.Settings.StorageFilter.Off).To(BeFalse())
+
+			archive := configs[1]
+			Expect(archive.Type).To(Equal(sink.FileType))
+			Expect(archive.Format).To(Equal(sink.RLPFormat))
+			Expect(archive.MaxSize).To(Equal(int64(1048576)))
+			Expect(archive.MaxAge).To(Equal(time.Hour))
+			Expect(archive.Settings.BackFill).To(BeFalse())
+		})
+
+		It("Rejects invalid sinks", func() {
+			viper.SetConfigType("toml")
+			Expect(viper.ReadConfig(strings.NewReader(`
+[[eth.server.sinks]]
+    name = "hook"
+    type = "webhook"
+`))).To(Succeed())
+			_, err := sink.NewConfigs()
+			Expect(err).To(HaveOccurred())
+
+			viper.Reset()
+			viper.SetConfigType("toml")
+			Expect(viper.ReadConfig(strings.NewReader(`
+[[eth.server.sinks]]
+    name = "queue"
+    type = "kafka"
+`))).To(Succeed())
+			_, err = sink.NewConfigs()
+			Expect(err).To(MatchError(ContainSubstring(`unknown type "kafka"`)))
+		})
+
+		It("Returns no configs when no sinks are declared", func() {
+			configs, err := sink.NewConfigs()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(configs).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,249 @@
+// VulcanizeDB
+// Copyright © 2022 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package sink
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vulcanize/ipld-eth-server/pkg/eth"
+	"github.com/vulcanize/ipld-eth-server/pkg/serve"
+)
+
+// Webhook request headers
+const (
+	SinkHeader      = "X-Vdb-Sink"
+	HeightHeader    = "X-Vdb-Height"
+	SignatureHeader = "X-Vdb-Signature" // "sha256=" followed by the hex encoded HMAC-SHA256 of the body
+)
+
+// WebhookSink POSTs each payload as JSON to a URL
+// Failed deliveries are retried with exponential backoff, and the height up to which every payload has been delivered
+// is persisted to the cursor file so that after a restart the subscription is backfilled from the following block
+// Backfilled and live payloads are interleaved, so while the subscription is backfilling the cursor is held and only
+// moved to the highest delivered height once the backfill is complete; once a payload can't be delivered the cursor
+// stays below it, so that it is delivered again after a restart
+type WebhookSink struct {
+	conf   Config
+	client *http.Client
+	cursor int64 // height up to which every payload has been delivered, -1 when nothing has been delivered
+
+	backfilling bool  // the subscription is backfilling, the cursor is held until it is complete
+	staged      int64 // highest height delivered while the cursor is held
+	stalled     bool  // a payload couldn't be delivered, the cursor is no longer moved forward
+}
+
+// NewWebhookSink creates a new WebhookSink, reading its cursor if it has one
+func NewWebhookSink(conf Config) (*WebhookSink, error) {
+	sink := &WebhookSink{
+		conf:   conf,
+		client: &http.Client{Timeout: conf.Timeout},
+		cursor: -1,
+	}
+	if err := sink.readCursor(); err != nil {
+		return nil, err
+	}
+	sink.staged = sink.cursor
+	sink.backfilling = sink.Settings().BackFill
+	return sink, nil
+}
+
+// readCursor reads the cursor persisted by a previous run, if any
+func (ws *WebhookSink) readCursor() error {
+	if ws.conf.CursorPath == "" {
+		return nil
+	}
+	data, err := ioutil.ReadFile(ws.conf.CursorPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if ws.cursor, err = strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64); err != nil {
+		return fmt.Errorf("invalid cursor in %s: %v", ws.conf.CursorPath, err)
+	}
+	return nil
+}
+
+// Name satisfies Sink
+func (ws *WebhookSink) Name() string {
+	return ws.conf.Name
+}
+
+// Settings satisfies Sink, resuming from the block after the cursor
+func (ws *WebhookSink) Settings() eth.SubscriptionSettings {
+	settings := ws.conf.Settings
+	if ws.cursor < 0 {
+		return settings
+	}
+	if resumeFrom := ws.cursor + 1; settings.Start == nil || resumeFrom > settings.Start.Int64() {
+		settings.Start = big.NewInt(resumeFrom)
+	}
+	settings.BackFill = true
+	return settings
+}
+
+// Cursor returns the height up to which every payload has been delivered, or -1 if none has been delivered
+func (ws *WebhookSink) Cursor() int64 {
+	return ws.cursor
+}
+
+// Write satisfies Sink
+func (ws *WebhookSink) Write(ctx context.Context, payload serve.SubscriptionPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	err = ws.deliver(ctx, payload.Height, body)
+	if payload.ReorgNotice() {
+		// the blocks from the reorg height onwards are replaced, they will be delivered again
+		if payload.Height-1 < ws.staged {
+			ws.staged = payload.Height - 1
+		}
+		if payload.Height-1 < ws.cursor {
+			if err := ws.setCursor(payload.Height - 1); err != nil {
+				return err
+			}
+		}
+	}
+	if err != nil {
+		if !ws.stalled {
+			log.Errorf("%s sink holds its cursor at block %d, the following blocks will be delivered again after a restart", ws.conf.Name, ws.cursor)
+		}
+		ws.stalled = true
+		return err
+	}
+	if ws.stalled {
+		return nil
+	}
+	switch {
+	case payload.BackFillComplete():
+		ws.backfilling = false
+		if ws.staged > ws.cursor {
+			return ws.setCursor(ws.staged)
+		}
+	case payload.Flag == serve.EmptyFlag && payload.Height > ws.staged:
+		ws.staged = payload.Height
+		if !ws.backfilling {
+			return ws.setCursor(payload.Height)
+		}
+	}
+	return nil
+}
+
+// deliver POSTs the body, retrying until it is accepted, the retries are exhausted or the context is cancelled
+func (ws *WebhookSink) deliver(ctx context.Context, height int64, body []byte) error {
+	backoff := ws.conf.RetryInterval
+	for attempt := 0; ; attempt++ {
+		retry, err := ws.post(height, body)
+		if err == nil {
+			return nil
+		}
+		if !retry || (ws.conf.MaxRetries >= 0 && attempt >= ws.conf.MaxRetries) {
+			return fmt.Errorf("webhook delivery failed after %d attempts: %v", attempt+1, err)
+		}
+		log.Warnf("%s sink delivery failed, retrying in %s: %v", ws.conf.Name, backoff, err)
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("webhook delivery abandoned after %d attempts: %v", attempt+1, err)
+		}
+		backoff *= 2
+	}
+}
+
+// post sends a single request, returning whether a failure may succeed on retry
+func (ws *WebhookSink) post(height int64, body []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, ws.conf.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SinkHeader, ws.conf.Name)
+	req.Header.Set(HeightHeader, strconv.FormatInt(height, 10))
+	if ws.conf.Secret != "" {
+		req.Header.Set(SignatureHeader, Signature(ws.conf.Secret, body))
+	}
+	res, err := ws.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	io.Copy(ioutil.Discard, res.Body)
+	res.Body.Close()
+	switch {
+	case res.StatusCode >= 200 && res.StatusCode < 300:
+		return false, nil
+	case res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= 500:
+		return true, fmt.Errorf("webhook responded %s", res.Status)
+	default:
+		return false, fmt.Errorf("webhook responded %s", res.Status)
+	}
+}
+
+// setCursor persists the cursor, replacing the cursor file atomically
+func (ws *WebhookSink) setCursor(height int64) error {
+	ws.cursor = height
+	if ws.conf.CursorPath == "" {
+		return nil
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(ws.conf.CursorPath), filepath.Base(ws.conf.CursorPath)+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.WriteString(strconv.FormatInt(height, 10)); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), ws.conf.CursorPath)
+}
+
+// Close satisfies Sink
+func (ws *WebhookSink) Close() error {
+	ws.client.CloseIdleConnections()
+	return nil
+}
+
+// Signature returns the value of the SignatureHeader for the body signed with the secret
+func Signature(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
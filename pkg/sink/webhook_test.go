@@ -0,0 +1,198 @@
+// VulcanizeDB
+// Copyright © 2022 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package sink_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/ipld-eth-server/pkg/eth"
+	"github.com/vulcanize/ipld-eth-server/pkg/serve"
+	"github.com/vulcanize/ipld-eth-server/pkg/sink"
+)
+
+type webhookRequest struct {
+	header  http.Header
+	body    []byte
+	payload serve.SubscriptionPayload
+}
+
+var _ = Describe("WebhookSink", func() {
+	var (
+		dir       string
+		server    *httptest.Server
+		mux       sync.Mutex
+		requests  []webhookRequest
+		responses []int
+		conf      sink.Config
+	)
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "webhook-sink")
+		Expect(err).ToNot(HaveOccurred())
+		requests, responses = nil, nil
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mux.Lock()
+			defer mux.Unlock()
+			body, _ := ioutil.ReadAll(r.Body)
+			req := webhookRequest{header: r.Header, body: body}
+			json.Unmarshal(body, &req.payload)
+			requests = append(requests, req)
+			status := http.StatusOK
+			if len(responses) > 0 {
+				status, responses = responses[0], responses[1:]
+			}
+			w.WriteHeader(status)
+		}))
+		conf = sink.Config{
+			Name:       "hook",
+			Type:       sink.WebhookType,
+			URL:        server.URL,
+			Secret:     "secret",
+			MaxRetries: 2,
+			CursorPath: filepath.Join(dir, "hook.cursor"),
+			Settings:   eth.SubscriptionSettings{Start: big.NewInt(5), End: big.NewInt(0)},
+		}
+	})
+	AfterEach(func() {
+		server.Close()
+		os.RemoveAll(dir)
+	})
+
+	It("POSTs signed payloads and persists the cursor", func() {
+		hook, err := sink.NewWebhookSink(conf)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(hook.Settings().BackFill).To(BeFalse())
+		Expect(hook.Write(context.Background(), serve.SubscriptionPayload{Height: 10, Data: []byte{1, 2, 3}})).To(Succeed())
+
+		Expect(requests).To(HaveLen(1))
+		req := requests[0]
+		Expect(req.header.Get("Content-Type")).To(Equal("application/json"))
+		Expect(req.header.Get(sink.SinkHeader)).To(Equal("hook"))
+		Expect(req.header.Get(sink.HeightHeader)).To(Equal("10"))
+		Expect(req.header.Get(sink.SignatureHeader)).To(Equal(sink.Signature("secret", req.body)))
+		Expect(req.payload.Data).To(Equal([]byte{1, 2, 3}))
+		Expect(hook.Cursor()).To(Equal(int64(10)))
+
+		// a restarted sink backfills from the block after its cursor
+		restarted, err := sink.NewWebhookSink(conf)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(restarted.Cursor()).To(Equal(int64(10)))
+		settings := restarted.Settings()
+		Expect(settings.BackFill).To(BeTrue())
+		Expect(settings.Start.Int64()).To(Equal(int64(11)))
+		Expect(conf.Settings.Start.Int64()).To(Equal(int64(5)))
+	})
+
+	It("Retries failed deliveries", func() {
+		responses = []int{http.StatusServiceUnavailable, http.StatusTooManyRequests}
+		hook, err := sink.NewWebhookSink(conf)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(hook.Write(context.Background(), serve.SubscriptionPayload{Height: 10})).To(Succeed())
+		Expect(requests).To(HaveLen(3))
+		Expect(hook.Cursor()).To(Equal(int64(10)))
+
+		responses = []int{http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError}
+		Expect(hook.Write(context.Background(), serve.SubscriptionPayload{Height: 11})).ToNot(Succeed())
+		Expect(requests).To(HaveLen(6))
+		Expect(hook.Cursor()).To(Equal(int64(10)))
+	})
+
+	It("Does not retry rejected deliveries", func() {
+		responses = []int{http.StatusBadRequest}
+		hook, err := sink.NewWebhookSink(conf)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(hook.Write(context.Background(), serve.SubscriptionPayload{Height: 10})).ToNot(Succeed())
+		Expect(requests).To(HaveLen(1))
+		Expect(hook.Cursor()).To(Equal(int64(-1)))
+	})
+
+	It("Rewinds the cursor to before a reorg", func() {
+		hook, err := sink.NewWebhookSink(conf)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(hook.Write(context.Background(), serve.SubscriptionPayload{Height: 10})).To(Succeed())
+		Expect(hook.Write(context.Background(), serve.SubscriptionPayload{Flag: serve.BackFillCompleteFlag})).To(Succeed())
+		Expect(hook.Cursor()).To(Equal(int64(10)))
+		Expect(hook.Write(context.Background(), serve.SubscriptionPayload{Height: 9, Flag: serve.ReorgFlag})).To(Succeed())
+		Expect(hook.Cursor()).To(Equal(int64(8)))
+		data, err := ioutil.ReadFile(conf.CursorPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal("8"))
+	})
+
+	It("Holds the cursor until the backfill is complete", func() {
+		conf.Settings.BackFill = true
+		hook, err := sink.NewWebhookSink(conf)
+		Expect(err).ToNot(HaveOccurred())
+		// a live payload arrives before the backfilled ones
+		Expect(hook.Write(context.Background(), serve.SubscriptionPayload{Height: 20})).To(Succeed())
+		Expect(hook.Write(context.Background(), serve.SubscriptionPayload{Height: 5})).To(Succeed())
+		Expect(hook.Cursor()).To(Equal(int64(-1)))
+
+		// restarting mid-backfill backfills from the start again
+		restarted, err := sink.NewWebhookSink(conf)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(restarted.Settings().Start.Int64()).To(Equal(int64(5)))
+
+		Expect(hook.Write(context.Background(), serve.SubscriptionPayload{Height: 6})).To(Succeed())
+		Expect(hook.Write(context.Background(), serve.SubscriptionPayload{Flag: serve.BackFillCompleteFlag})).To(Succeed())
+		Expect(hook.Cursor()).To(Equal(int64(20)))
+		Expect(hook.Write(context.Background(), serve.SubscriptionPayload{Height: 21})).To(Succeed())
+		Expect(hook.Cursor()).To(Equal(int64(21)))
+	})
+
+	It("Holds the cursor below a payload which couldn't be delivered", func() {
+		hook, err := sink.NewWebhookSink(conf)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(hook.Write(context.Background(), serve.SubscriptionPayload{Height: 10})).To(Succeed())
+		responses = []int{http.StatusBadRequest}
+		Expect(hook.Write(context.Background(), serve.SubscriptionPayload{Height: 11})).ToNot(Succeed())
+		Expect(hook.Write(context.Background(), serve.SubscriptionPayload{Height: 12})).To(Succeed())
+		Expect(requests).To(HaveLen(3))
+		Expect(hook.Cursor()).To(Equal(int64(10)))
+
+		restarted, err := sink.NewWebhookSink(conf)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(restarted.Settings().Start.Int64()).To(Equal(int64(11)))
+	})
+
+	It("Stops retrying once the subscription ends", func() {
+		conf.MaxRetries = -1
+		conf.RetryInterval = time.Hour
+		responses = []int{http.StatusServiceUnavailable}
+		hook, err := sink.NewWebhookSink(conf)
+		Expect(err).ToNot(HaveOccurred())
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() { done <- hook.Write(ctx, serve.SubscriptionPayload{Height: 10}) }()
+		Consistently(done, 50*time.Millisecond).ShouldNot(Receive())
+		cancel()
+		Eventually(done).Should(Receive(HaveOccurred()))
+		Expect(hook.Cursor()).To(Equal(int64(-1)))
+	})
+})
@@ -0,0 +1,126 @@
+// VulcanizeDB
+// Copyright © 2022 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package upstream
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vulcanize/ipld-eth-server/pkg/prom"
+)
+
+// Circuit breaker states
+const (
+	// BreakerClosed lets every request through
+	BreakerClosed = "closed"
+	// BreakerOpen fails every request without sending it
+	BreakerOpen = "open"
+	// BreakerHalfOpen lets a single probe request through, which closes the breaker if it succeeds
+	BreakerHalfOpen = "half-open"
+)
+
+// ErrCircuitOpen is returned for the requests made while a pool's circuit breaker is open
+var ErrCircuitOpen = errors.New("upstream circuit breaker is open")
+
+// breaker stops sending requests to a pool after a run of consecutive failed requests
+// Once the cooldown has elapsed, a single probe request is let through: its success closes the breaker, its failure opens it again
+type breaker struct {
+	pool      string
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	state    string
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func newBreaker(pool string, threshold int, cooldown time.Duration) *breaker {
+	prom.SetUpstreamBreakerState(pool, BreakerClosed)
+	return &breaker{pool: pool, threshold: threshold, cooldown: cooldown, state: BreakerClosed}
+}
+
+// allow returns whether a request can be sent, and whether it is the probe of a half-open breaker
+func (b *breaker) allow() (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case BreakerOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return false, ErrCircuitOpen
+		}
+		b.setState(BreakerHalfOpen)
+		fallthrough
+	case BreakerHalfOpen:
+		if b.probing {
+			return false, ErrCircuitOpen
+		}
+		b.probing = true
+		return true, nil
+	}
+	return false, nil
+}
+
+// done records the outcome of a request let through by allow
+func (b *breaker) done(probe, success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if probe {
+		b.probing = false
+	}
+	if success {
+		b.failures = 0
+		if b.state != BreakerClosed {
+			log.Infof("upstream pool %s circuit breaker closed", b.pool)
+			b.setState(BreakerClosed)
+		}
+		return
+	}
+	b.failures++
+	if probe || (b.state == BreakerClosed && b.failures >= b.threshold) {
+		log.Warnf("upstream pool %s circuit breaker opened after %d consecutive failed requests", b.pool, b.failures)
+		b.openedAt = time.Now()
+		b.setState(BreakerOpen)
+	}
+}
+
+// abandon releases the probe of a request given up by its caller, which says nothing about the pool
+func (b *breaker) abandon(probe bool) {
+	if !probe {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// currentState returns the state of the breaker
+func (b *breaker) currentState() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// setState needs to be called with the breaker lock held
+func (b *breaker) setState(state string) {
+	b.state = state
+	prom.SetUpstreamBreakerState(b.pool, state)
+}
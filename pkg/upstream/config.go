@@ -0,0 +1,193 @@
+// VulcanizeDB
+// Copyright © 2022 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package upstream
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Roles a node can serve
+const (
+	// ReadRole nodes serve the calls proxied on cache misses and the net namespace
+	ReadRole = "read"
+	// StateDiffRole nodes serve the statediff_* calls used to fill the index
+	StateDiffRole = "statediff"
+)
+
+// Config defaults
+const (
+	DefaultHealthCheckInterval = 15 * time.Second
+	DefaultHealthCheckTimeout  = 5 * time.Second
+	DefaultMaxFailures         = 3
+	DefaultBreakerThreshold    = 5
+	DefaultBreakerCooldown     = 30 * time.Second
+)
+
+// NodeConfig describes an upstream node
+type NodeConfig struct {
+	Name string
+	URL  string
+	// nodes with a lower priority are preferred, nodes with the same priority share the load according to their weight
+	Priority int
+	Weight   int
+	Roles    []string
+}
+
+// HasRole returns whether the node serves the role
+func (c NodeConfig) HasRole(role string) bool {
+	for _, r := range c.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// PoolConfig holds the health checking and failover parameters of a pool
+type PoolConfig struct {
+	// time between health checks
+	HealthCheckInterval time.Duration
+	// timeout of each health check
+	HealthCheckTimeout time.Duration
+	// number of consecutive failed requests or health checks after which a node is considered down
+	MaxFailures int
+	// number of blocks a node can fall behind the most advanced node of its pool before it is considered unhealthy, 0 to disable
+	MaxLag uint64
+	// timeout of each attempt before failing over to the next node, 0 to rely on the caller's context
+	// it only applies to the read pool, since statediff calls can legitimately take minutes
+	RequestTimeout time.Duration
+	// number of consecutive requests failed by every node after which the pool's circuit breaker opens
+	BreakerThreshold int
+	// time the circuit breaker stays open before letting a probe request through
+	BreakerCooldown time.Duration
+}
+
+func (c PoolConfig) withDefaults() PoolConfig {
+	if c.HealthCheckInterval <= 0 {
+		c.HealthCheckInterval = DefaultHealthCheckInterval
+	}
+	if c.HealthCheckTimeout <= 0 {
+		c.HealthCheckTimeout = DefaultHealthCheckTimeout
+	}
+	if c.MaxFailures <= 0 {
+		c.MaxFailures = DefaultMaxFailures
+	}
+	if c.BreakerThreshold <= 0 {
+		c.BreakerThreshold = DefaultBreakerThreshold
+	}
+	if c.BreakerCooldown <= 0 {
+		c.BreakerCooldown = DefaultBreakerCooldown
+	}
+	return c
+}
+
+// NewConfigs reads the nodes declared in the ethereum.upstreams tables of the config, along with the
+// ethereum.upstreamHealth parameters; when no upstreams are declared, the node at the ethereum.httpPath serves every role
+func NewConfigs() ([]NodeConfig, PoolConfig, error) {
+	pool := PoolConfig{
+		HealthCheckInterval: viper.GetDuration("ethereum.upstreamHealth.interval"),
+		HealthCheckTimeout:  viper.GetDuration("ethereum.upstreamHealth.timeout"),
+		MaxFailures:         viper.GetInt("ethereum.upstreamHealth.maxFailures"),
+		MaxLag:              viper.GetUint64("ethereum.upstreamHealth.maxLag"),
+		RequestTimeout:      viper.GetDuration("ethereum.upstreamHealth.requestTimeout"),
+		BreakerThreshold:    viper.GetInt("ethereum.upstreamHealth.breakerThreshold"),
+		BreakerCooldown:     viper.GetDuration("ethereum.upstreamHealth.breakerCooldown"),
+	}
+	raw := viper.Get("ethereum.upstreams")
+	if raw == nil {
+		node, err := newNodeConfig(map[string]interface{}{
+			"name":     "default",
+			"httpPath": viper.GetString("ethereum.httpPath"),
+		})
+		if err != nil {
+			return nil, pool, err
+		}
+		return []NodeConfig{node}, pool, nil
+	}
+	var tables []map[string]interface{}
+	switch t := raw.(type) {
+	case []map[string]interface{}:
+		tables = t
+	case []interface{}:
+		for _, entry := range t {
+			table, ok := entry.(map[string]interface{})
+			if !ok {
+				return nil, pool, fmt.Errorf("invalid ethereum.upstreams entry: %v", entry)
+			}
+			tables = append(tables, table)
+		}
+	default:
+		return nil, pool, fmt.Errorf("ethereum.upstreams must be an array of tables")
+	}
+	nodes := make([]NodeConfig, 0, len(tables))
+	names := make(map[string]bool, len(tables))
+	for _, table := range tables {
+		node, err := newNodeConfig(table)
+		if err != nil {
+			return nil, pool, err
+		}
+		if names[node.Name] {
+			return nil, pool, fmt.Errorf("duplicate upstream name %s", node.Name)
+		}
+		names[node.Name] = true
+		nodes = append(nodes, node)
+	}
+	return nodes, pool, nil
+}
+
+func newNodeConfig(table map[string]interface{}) (NodeConfig, error) {
+	v := viper.New()
+	if err := v.MergeConfigMap(table); err != nil {
+		return NodeConfig{}, err
+	}
+	v.SetDefault("weight", 1)
+	v.SetDefault("roles", []string{ReadRole, StateDiffRole})
+	path := v.GetString("httpPath")
+	if path == "" {
+		return NodeConfig{}, fmt.Errorf("upstream %s has no httpPath", v.GetString("name"))
+	}
+	node := NodeConfig{
+		Name:     v.GetString("name"),
+		URL:      path,
+		Priority: v.GetInt("priority"),
+		Weight:   v.GetInt("weight"),
+		Roles:    v.GetStringSlice("roles"),
+	}
+	if !strings.Contains(node.URL, "://") {
+		node.URL = "http://" + node.URL
+	}
+	if _, err := url.Parse(node.URL); err != nil {
+		return NodeConfig{}, fmt.Errorf("upstream %s has an invalid httpPath: %v", node.Name, err)
+	}
+	if node.Name == "" {
+		node.Name = path
+	}
+	if node.Weight <= 0 {
+		return NodeConfig{}, fmt.Errorf("upstream %s must have a positive weight", node.Name)
+	}
+	for _, role := range node.Roles {
+		if role != ReadRole && role != StateDiffRole {
+			return NodeConfig{}, fmt.Errorf("upstream %s has unknown role %s", node.Name, role)
+		}
+	}
+	return node, nil
+}
@@ -0,0 +1,103 @@
+// VulcanizeDB
+// Copyright © 2022 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package upstream_test
+
+import (
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/viper"
+
+	"github.com/vulcanize/ipld-eth-server/pkg/upstream"
+)
+
+var _ = Describe("NewConfigs", func() {
+	AfterEach(func() {
+		viper.Reset()
+	})
+
+	It("Reads each upstream and the health parameters", func() {
+		viper.SetConfigType("toml")
+		Expect(viper.ReadConfig(strings.NewReader(`
+[ethereum]
+    httpPath = "127.0.0.1:8545"
+    [[ethereum.upstreams]]
+        name = "geth-1"
+        httpPath = "10.0.0.1:8545"
+        weight = 3
+    [[ethereum.upstreams]]
+        httpPath = "https://10.0.0.2:8545"
+        priority = 1
+        roles = ["read"]
+    [ethereum.upstreamHealth]
+        interval = "30s"
+        maxFailures = 5
+        maxLag = 10
+        requestTimeout = "2s"
+`))).To(Succeed())
+		nodes, pool, err := upstream.NewConfigs()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(nodes).To(Equal([]upstream.NodeConfig{
+			{Name: "geth-1", URL: "http://10.0.0.1:8545", Priority: 0, Weight: 3, Roles: []string{upstream.ReadRole, upstream.StateDiffRole}},
+			{Name: "https://10.0.0.2:8545", URL: "https://10.0.0.2:8545", Priority: 1, Weight: 1, Roles: []string{upstream.ReadRole}},
+		}))
+		Expect(pool).To(Equal(upstream.PoolConfig{
+			HealthCheckInterval: 30 * time.Second,
+			MaxFailures:         5,
+			MaxLag:              10,
+			RequestTimeout:      2 * time.Second,
+		}))
+	})
+
+	It("Falls back to the ethereum.httpPath node for every role", func() {
+		viper.Set("ethereum.httpPath", "127.0.0.1:8545")
+		nodes, _, err := upstream.NewConfigs()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(nodes).To(Equal([]upstream.NodeConfig{
+			{Name: "default", URL: "http://127.0.0.1:8545", Weight: 1, Roles: []string{upstream.ReadRole, upstream.StateDiffRole}},
+		}))
+	})
+
+	It("Rejects invalid upstreams", func() {
+		viper.SetConfigType("toml")
+		Expect(viper.ReadConfig(strings.NewReader(`
+[ethereum]
+    [[ethereum.upstreams]]
+        name = "geth"
+        httpPath = "10.0.0.1:8545"
+        roles = ["write"]
+`))).To(Succeed())
+		_, _, err := upstream.NewConfigs()
+		Expect(err).To(MatchError("upstream geth has unknown role write"))
+
+		viper.Reset()
+		viper.SetConfigType("toml")
+		Expect(viper.ReadConfig(strings.NewReader(`
+[ethereum]
+    [[ethereum.upstreams]]
+        name = "geth"
+        httpPath = "10.0.0.1:8545"
+    [[ethereum.upstreams]]
+        name = "geth"
+        httpPath = "10.0.0.2:8545"
+`))).To(Succeed())
+		_, _, err = upstream.NewConfigs()
+		Expect(err).To(MatchError("duplicate upstream name geth"))
+	})
+})
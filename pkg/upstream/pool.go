@@ -0,0 +1,434 @@
+// VulcanizeDB
+// Copyright © 2022 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package upstream
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vulcanize/ipld-eth-server/pkg/prom"
+)
+
+// NodeStatus describes the state of a node
+type NodeStatus struct {
+	Name      string    `json:"name"`
+	URL       string    `json:"url"`
+	Priority  int       `json:"priority"`
+	Weight    int       `json:"weight"`
+	Healthy   bool      `json:"healthy"`
+	Failures  int       `json:"failures"` // consecutive failed requests and health checks
+	Lagging   bool      `json:"lagging"`
+	Head      uint64    `json:"head"` // block number reported by the last successful health check
+	LastCheck time.Time `json:"lastCheck"`
+	LastError string    `json:"lastError,omitempty"`
+	Requests  uint64    `json:"requests"`
+	Errors    uint64    `json:"errors"`
+}
+
+// PoolStatus describes the state of a pool
+type PoolStatus struct {
+	Name    string       `json:"name"`
+	Breaker string       `json:"breaker"` // state of the circuit breaker
+	Nodes   []NodeStatus `json:"nodes"`
+}
+
+type node struct {
+	conf      NodeConfig
+	url       *url.URL
+	failures  int
+	lagging   bool
+	head      uint64
+	lastCheck time.Time
+	lastError string
+	requests  uint64
+	errors    uint64
+}
+
+// healthy needs to be called with the pool lock held
+func (n *node) healthy(maxFailures int) bool {
+	return n.failures < maxFailures && !n.lagging
+}
+
+// Pool routes JSON-RPC requests over HTTP to a set of upstream nodes
+// Requests go to the healthy nodes with the lowest priority, spread according to their weight, and fail over to the
+// next node on transport errors, timeouts and 429 or 5xx responses; when every node is unhealthy they are all tried anyway
+// A circuit breaker fails requests immediately with ErrCircuitOpen after BreakerThreshold consecutive requests failed by every node
+type Pool struct {
+	name      string
+	conf      PoolConfig
+	nodes     []*node
+	transport http.RoundTripper
+	client    *rpc.Client
+	breaker   *breaker
+
+	mu   sync.Mutex
+	rand *rand.Rand
+
+	quit     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewPool creates a new Pool of the nodes, and a client which routes its calls through it
+func NewPool(name string, nodes []NodeConfig, conf PoolConfig) (*Pool, error) {
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("upstream pool %s has no nodes", name)
+	}
+	p := &Pool{
+		name:      name,
+		conf:      conf.withDefaults(),
+		nodes:     make([]*node, len(nodes)),
+		transport: http.DefaultTransport,
+		rand:      rand.New(rand.NewSource(time.Now().UnixNano())),
+		quit:      make(chan struct{}),
+	}
+	p.breaker = newBreaker(name, p.conf.BreakerThreshold, p.conf.BreakerCooldown)
+	for i, nc := range nodes {
+		u, err := url.Parse(nc.URL)
+		if err != nil {
+			return nil, err
+		}
+		if nc.Weight <= 0 {
+			nc.Weight = 1
+		}
+		p.nodes[i] = &node{conf: nc, url: u}
+		prom.SetUpstreamHealthy(name, nc.Name, true)
+	}
+	var err error
+	p.client, err = rpc.DialHTTPWithClient("http://"+name+".upstream", &http.Client{Transport: p})
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Client returns the client routing its calls through the pool
+func (p *Pool) Client() *rpc.Client {
+	return p.client
+}
+
+// Name returns the name of the pool
+func (p *Pool) Name() string {
+	return p.name
+}
+
+// Start spins up the health checks
+func (p *Pool) Start() {
+	go func() {
+		ticker := time.NewTicker(p.conf.HealthCheckInterval)
+		defer ticker.Stop()
+		for {
+			p.CheckHealth()
+			select {
+			case <-ticker.C:
+			case <-p.quit:
+				return
+			}
+		}
+	}()
+}
+
+// Stop shuts down the health checks
+func (p *Pool) Stop() {
+	p.stopOnce.Do(func() {
+		close(p.quit)
+	})
+}
+
+// Status returns the state of the nodes
+func (p *Pool) Status() PoolStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	status := PoolStatus{Name: p.name, Breaker: p.breaker.currentState(), Nodes: make([]NodeStatus, len(p.nodes))}
+	for i, n := range p.nodes {
+		status.Nodes[i] = NodeStatus{
+			Name:      n.conf.Name,
+			URL:       n.conf.URL,
+			Priority:  n.conf.Priority,
+			Weight:    n.conf.Weight,
+			Healthy:   n.healthy(p.conf.MaxFailures),
+			Failures:  n.failures,
+			Lagging:   n.lagging,
+			Head:      n.head,
+			LastCheck: n.lastCheck,
+			LastError: n.lastError,
+			Requests:  n.requests,
+			Errors:    n.errors,
+		}
+	}
+	return status
+}
+
+// RoundTrip sends the request to the nodes in turn until one of them answers it, unless the circuit breaker is open
+func (p *Pool) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		defer req.Body.Close()
+	}
+	probe, err := p.breaker.allow()
+	if err != nil {
+		return nil, fmt.Errorf("%s pool: %w", p.name, err)
+	}
+	res, err := p.roundTrip(req)
+	if err != nil && req.Context().Err() != nil {
+		p.breaker.abandon(probe)
+	} else {
+		p.breaker.done(probe, err == nil)
+	}
+	return res, err
+}
+
+func (p *Pool) roundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var lastErr error
+	for i, n := range p.order() {
+		if err := req.Context().Err(); err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			log.Debugf("upstream pool %s failing over to %s", p.name, n.conf.Name)
+			prom.IncUpstreamFailovers(p.name)
+		}
+		res, err := p.send(req, n, body)
+		if err == nil {
+			p.recordSuccess(n)
+			return res, nil
+		}
+		// don't hold the node responsible for the caller giving up
+		if req.Context().Err() != nil {
+			return nil, req.Context().Err()
+		}
+		p.recordFailure(n, err)
+		lastErr = err
+	}
+	return nil, fmt.Errorf("every node of the %s upstream pool failed, last error: %v", p.name, lastErr)
+}
+
+// send sends the request to the node, treating 429 and 5xx responses as errors
+func (p *Pool) send(req *http.Request, n *node, body []byte) (*http.Response, error) {
+	ctx, cancel := req.Context(), context.CancelFunc(func() {})
+	if p.conf.RequestTimeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, p.conf.RequestTimeout)
+	}
+	attempt := req.Clone(ctx)
+	u := *n.url
+	attempt.URL = &u
+	attempt.Host = n.url.Host
+	attempt.Body = ioutil.NopCloser(bytes.NewReader(body))
+	attempt.ContentLength = int64(len(body))
+	res, err := p.transport.RoundTrip(attempt)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	if res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= http.StatusInternalServerError {
+		res.Body.Close()
+		cancel()
+		return nil, fmt.Errorf("%s responded %s", n.conf.Name, res.Status)
+	}
+	// the attempt's context lives until the response has been read
+	res.Body = &cancelOnClose{ReadCloser: res.Body, cancel: cancel}
+	return res, nil
+}
+
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	defer c.cancel()
+	return c.ReadCloser.Close()
+}
+
+// order returns the nodes in the order they should be tried
+// healthy nodes come first, by priority and then by weighted random order; unhealthy nodes follow by priority
+func (p *Pool) order() []*node {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var healthy, unhealthy []*node
+	for _, n := range p.nodes {
+		if n.healthy(p.conf.MaxFailures) {
+			healthy = append(healthy, n)
+		} else {
+			unhealthy = append(unhealthy, n)
+		}
+	}
+	sort.SliceStable(healthy, func(i, j int) bool { return healthy[i].conf.Priority < healthy[j].conf.Priority })
+	sort.SliceStable(unhealthy, func(i, j int) bool { return unhealthy[i].conf.Priority < unhealthy[j].conf.Priority })
+	ordered := make([]*node, 0, len(p.nodes))
+	for start := 0; start < len(healthy); {
+		end := start
+		for end < len(healthy) && healthy[end].conf.Priority == healthy[start].conf.Priority {
+			end++
+		}
+		ordered = append(ordered, p.shuffle(healthy[start:end])...)
+		start = end
+	}
+	return append(ordered, unhealthy...)
+}
+
+// shuffle returns the nodes in weighted random order
+// shuffle needs to be called with the pool lock held
+func (p *Pool) shuffle(nodes []*node) []*node {
+	remaining := append([]*node{}, nodes...)
+	shuffled := make([]*node, 0, len(nodes))
+	for len(remaining) > 0 {
+		total := 0
+		for _, n := range remaining {
+			total += n.conf.Weight
+		}
+		pick := p.rand.Intn(total)
+		for i, n := range remaining {
+			if pick < n.conf.Weight {
+				shuffled = append(shuffled, n)
+				remaining = append(remaining[:i], remaining[i+1:]...)
+				break
+			}
+			pick -= n.conf.Weight
+		}
+	}
+	return shuffled
+}
+
+func (p *Pool) recordSuccess(n *node) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	n.requests++
+	n.failures = 0
+	prom.RecordUpstreamRequest(p.name, n.conf.Name, true)
+	prom.SetUpstreamHealthy(p.name, n.conf.Name, n.healthy(p.conf.MaxFailures))
+}
+
+func (p *Pool) recordFailure(n *node, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	n.requests++
+	n.errors++
+	p.fail(n, err)
+	prom.RecordUpstreamRequest(p.name, n.conf.Name, false)
+}
+
+// fail needs to be called with the pool lock held
+func (p *Pool) fail(n *node, err error) {
+	n.failures++
+	n.lastError = err.Error()
+	if n.failures == p.conf.MaxFailures {
+		log.Warnf("upstream %s of the %s pool is down after %d consecutive failures: %v", n.conf.Name, p.name, n.failures, err)
+	}
+	prom.SetUpstreamHealthy(p.name, n.conf.Name, n.healthy(p.conf.MaxFailures))
+}
+
+// CheckHealth asks every node for its latest block number, marking the nodes which fail to answer or fall behind
+func (p *Pool) CheckHealth() {
+	heads := make([]uint64, len(p.nodes))
+	errs := make([]error, len(p.nodes))
+	var wg sync.WaitGroup
+	for i, n := range p.nodes {
+		wg.Add(1)
+		go func(i int, n *node) {
+			defer wg.Done()
+			heads[i], errs[i] = p.blockNumber(n)
+		}(i, n)
+	}
+	wg.Wait()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var best uint64
+	for i := range p.nodes {
+		if errs[i] == nil && heads[i] > best {
+			best = heads[i]
+		}
+	}
+	now := time.Now()
+	for i, n := range p.nodes {
+		n.lastCheck = now
+		if errs[i] != nil {
+			p.fail(n, errs[i])
+			continue
+		}
+		if n.failures >= p.conf.MaxFailures {
+			log.Infof("upstream %s of the %s pool is back up", n.conf.Name, p.name)
+		}
+		n.failures = 0
+		n.head = heads[i]
+		lagging := p.conf.MaxLag > 0 && best-n.head > p.conf.MaxLag
+		if lagging && !n.lagging {
+			log.Warnf("upstream %s of the %s pool is %d blocks behind", n.conf.Name, p.name, best-n.head)
+		}
+		n.lagging = lagging
+		prom.SetUpstreamHealthy(p.name, n.conf.Name, n.healthy(p.conf.MaxFailures))
+	}
+}
+
+// blockNumber calls eth_blockNumber on the node
+func (p *Pool) blockNumber(n *node) (uint64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), p.conf.HealthCheckTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url.String(),
+		strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber","params":[]}`))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	res, err := p.transport.RoundTrip(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("health check responded %s", res.Status)
+	}
+	var msg struct {
+		Result *hexutil.Uint64 `json:"result"`
+		Error  *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&msg); err != nil {
+		return 0, err
+	}
+	if msg.Error != nil {
+		return 0, errors.New(msg.Error.Message)
+	}
+	if msg.Result == nil {
+		return 0, errors.New("health check returned no block number")
+	}
+	return uint64(*msg.Result), nil
+}
@@ -0,0 +1,290 @@
+// VulcanizeDB
+// Copyright © 2022 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package upstream_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/ipld-eth-server/pkg/upstream"
+)
+
+// fakeNode is a JSON-RPC server answering eth_blockNumber with its head, and every other method with its name
+type fakeNode struct {
+	*httptest.Server
+	sync.Mutex
+	head     uint64
+	status   int
+	delay    time.Duration
+	requests int32
+}
+
+func newFakeNode(head uint64) *fakeNode {
+	n := &fakeNode{head: head, status: http.StatusOK}
+	n.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		n.Lock()
+		status, delay, head := n.status, n.delay, n.head
+		n.Unlock()
+		if req.Method != "eth_blockNumber" {
+			atomic.AddInt32(&n.requests, 1)
+		}
+		time.Sleep(delay)
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+		var result interface{} = hexutil.Uint64(head)
+		if req.Method != "eth_blockNumber" {
+			result = n.URL
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": result})
+	}))
+	return n
+}
+
+func (n *fakeNode) set(status int, delay time.Duration) {
+	n.Lock()
+	defer n.Unlock()
+	n.status, n.delay = status, delay
+}
+
+func (n *fakeNode) count() int {
+	return int(atomic.LoadInt32(&n.requests))
+}
+
+func call(pool *upstream.Pool) (string, error) {
+	var res string
+	err := pool.Client().CallContext(context.Background(), &res, "web3_clientVersion")
+	return res, err
+}
+
+var _ = Describe("Pool", func() {
+	var (
+		primary, secondary, backup *fakeNode
+		conf                       upstream.PoolConfig
+	)
+	BeforeEach(func() {
+		primary, secondary, backup = newFakeNode(100), newFakeNode(100), newFakeNode(100)
+		conf = upstream.PoolConfig{MaxFailures: 2, HealthCheckTimeout: time.Second}
+	})
+	AfterEach(func() {
+		primary.Close()
+		secondary.Close()
+		backup.Close()
+	})
+
+	nodes := func() []upstream.NodeConfig {
+		return []upstream.NodeConfig{
+			{Name: "primary", URL: primary.URL, Priority: 0, Weight: 3},
+			{Name: "secondary", URL: secondary.URL, Priority: 0, Weight: 1},
+			{Name: "backup", URL: backup.URL, Priority: 1, Weight: 1},
+		}
+	}
+
+	It("Spreads requests over the nodes with the lowest priority according to their weight", func() {
+		pool, err := upstream.NewPool("read", nodes(), conf)
+		Expect(err).ToNot(HaveOccurred())
+		for i := 0; i < 200; i++ {
+			_, err := call(pool)
+			Expect(err).ToNot(HaveOccurred())
+		}
+		Expect(backup.count()).To(Equal(0))
+		Expect(primary.count() + secondary.count()).To(Equal(200))
+		Expect(primary.count()).To(BeNumerically(">", secondary.count()))
+	})
+
+	It("Fails over on errors and takes failing nodes out of rotation", func() {
+		primary.set(http.StatusBadGateway, 0)
+		secondary.set(http.StatusServiceUnavailable, 0)
+		pool, err := upstream.NewPool("read", nodes(), conf)
+		Expect(err).ToNot(HaveOccurred())
+
+		for i := 0; i < 3; i++ {
+			res, err := call(pool)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res).To(Equal(backup.URL))
+		}
+		// both nodes were down after their second failure, so the third call went straight to the backup
+		Expect(primary.count()).To(Equal(2))
+		Expect(secondary.count()).To(Equal(2))
+		status := pool.Status()
+		Expect(status.Nodes[0].Healthy).To(BeFalse())
+		Expect(status.Nodes[1].Healthy).To(BeFalse())
+		Expect(status.Nodes[2].Healthy).To(BeTrue())
+
+		// the health check brings recovered nodes back
+		primary.set(http.StatusOK, 0)
+		pool.CheckHealth()
+		res, err := call(pool)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(res).To(Equal(primary.URL))
+	})
+
+	It("Fails over on timeouts", func() {
+		primary.set(http.StatusOK, time.Second)
+		conf.RequestTimeout = 100 * time.Millisecond
+		pool, err := upstream.NewPool("read", []upstream.NodeConfig{
+			{Name: "primary", URL: primary.URL, Priority: 0, Weight: 1},
+			{Name: "backup", URL: backup.URL, Priority: 1, Weight: 1},
+		}, conf)
+		Expect(err).ToNot(HaveOccurred())
+		res, err := call(pool)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(res).To(Equal(backup.URL))
+		Expect(pool.Status().Nodes[0].Failures).To(Equal(1))
+	})
+
+	It("Marks lagging nodes unhealthy", func() {
+		secondary.head = 50
+		conf.MaxLag = 10
+		pool, err := upstream.NewPool("read", nodes(), conf)
+		Expect(err).ToNot(HaveOccurred())
+		pool.CheckHealth()
+		status := pool.Status()
+		Expect(status.Nodes[0].Head).To(Equal(uint64(100)))
+		Expect(status.Nodes[1].Lagging).To(BeTrue())
+		Expect(status.Nodes[1].Healthy).To(BeFalse())
+		for i := 0; i < 20; i++ {
+			_, err := call(pool)
+			Expect(err).ToNot(HaveOccurred())
+		}
+		Expect(secondary.count()).To(Equal(0))
+	})
+
+	It("Returns an error when every node fails", func() {
+		for _, n := range []*fakeNode{primary, secondary, backup} {
+			n.set(http.StatusInternalServerError, 0)
+		}
+		pool, err := upstream.NewPool("read", nodes(), conf)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = call(pool)
+		Expect(err).To(MatchError(ContainSubstring("every node of the read upstream pool failed")))
+	})
+
+	It("Opens the circuit breaker after consecutive failed requests and closes it once a probe succeeds", func() {
+		for _, n := range []*fakeNode{primary, secondary, backup} {
+			n.set(http.StatusInternalServerError, 0)
+		}
+		conf.BreakerThreshold = 2
+		conf.BreakerCooldown = 100 * time.Millisecond
+		pool, err := upstream.NewPool("read", nodes(), conf)
+		Expect(err).ToNot(HaveOccurred())
+		sent := func() int { return primary.count() + secondary.count() + backup.count() }
+
+		for i := 0; i < 2; i++ {
+			_, err = call(pool)
+			Expect(err).To(MatchError(ContainSubstring("every node of the read upstream pool failed")))
+		}
+		Expect(pool.Status().Breaker).To(Equal(upstream.BreakerOpen))
+		before := sent()
+		_, err = call(pool)
+		Expect(err).To(MatchError(ContainSubstring(upstream.ErrCircuitOpen.Error())))
+		Expect(sent()).To(Equal(before))
+
+		// the probe fails and the breaker opens again
+		time.Sleep(conf.BreakerCooldown)
+		_, err = call(pool)
+		Expect(err).To(MatchError(ContainSubstring("every node of the read upstream pool failed")))
+		Expect(sent()).To(BeNumerically(">", before))
+		Expect(pool.Status().Breaker).To(Equal(upstream.BreakerOpen))
+		_, err = call(pool)
+		Expect(err).To(MatchError(ContainSubstring(upstream.ErrCircuitOpen.Error())))
+
+		// the probe succeeds and the breaker closes
+		for _, n := range []*fakeNode{primary, secondary, backup} {
+			n.set(http.StatusOK, 0)
+		}
+		time.Sleep(conf.BreakerCooldown)
+		_, err = call(pool)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pool.Status().Breaker).To(Equal(upstream.BreakerClosed))
+		_, err = call(pool)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("Lets a single probe through while half-open", func() {
+		for _, n := range []*fakeNode{primary, secondary, backup} {
+			n.set(http.StatusInternalServerError, 0)
+		}
+		conf.BreakerThreshold = 1
+		conf.BreakerCooldown = 50 * time.Millisecond
+		pool, err := upstream.NewPool("read", nodes(), conf)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = call(pool)
+		Expect(err).To(HaveOccurred())
+		Expect(pool.Status().Breaker).To(Equal(upstream.BreakerOpen))
+
+		for _, n := range []*fakeNode{primary, secondary, backup} {
+			n.set(http.StatusOK, 200*time.Millisecond)
+		}
+		time.Sleep(conf.BreakerCooldown)
+		probed := make(chan error, 1)
+		go func() {
+			_, err := call(pool)
+			probed <- err
+		}()
+		Eventually(func() string { return pool.Status().Breaker }).Should(Equal(upstream.BreakerHalfOpen))
+		_, err = call(pool)
+		Expect(err).To(MatchError(ContainSubstring(upstream.ErrCircuitOpen.Error())))
+		Eventually(probed).Should(Receive(BeNil()))
+		Expect(pool.Status().Breaker).To(Equal(upstream.BreakerClosed))
+	})
+})
+
+var _ = Describe("NewPools", func() {
+	It("Splits the nodes into read and statediff pools by role", func() {
+		pools, err := upstream.NewPools([]upstream.NodeConfig{
+			{Name: "reader", URL: "http://127.0.0.1:1", Weight: 1, Roles: []string{upstream.ReadRole}},
+			{Name: "writer", URL: "http://127.0.0.1:2", Weight: 1, Roles: []string{upstream.StateDiffRole}},
+		}, upstream.PoolConfig{})
+		Expect(err).ToNot(HaveOccurred())
+		status := pools.Status()
+		Expect(status).To(HaveLen(2))
+		Expect(status[0].Name).To(Equal(upstream.ReadPoolName))
+		Expect(status[0].Nodes[0].Name).To(Equal("reader"))
+		Expect(status[1].Name).To(Equal(upstream.StateDiffPoolName))
+		Expect(status[1].Nodes[0].Name).To(Equal("writer"))
+
+		pools, err = upstream.NewPools([]upstream.NodeConfig{
+			{Name: "reader", URL: "http://127.0.0.1:1", Weight: 1, Roles: []string{upstream.ReadRole}},
+		}, upstream.PoolConfig{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pools.StateDiffClient()).To(BeNil())
+
+		_, err = upstream.NewPools([]upstream.NodeConfig{
+			{Name: "writer", URL: "http://127.0.0.1:2", Weight: 1, Roles: []string{upstream.StateDiffRole}},
+		}, upstream.PoolConfig{})
+		Expect(err).To(MatchError(fmt.Sprintf("no upstream serves the %s role", upstream.ReadRole)))
+	})
+})
@@ -0,0 +1,103 @@
+// VulcanizeDB
+// Copyright © 2022 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package upstream
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Pool names
+const (
+	ReadPoolName      = "read"
+	StateDiffPoolName = "statediff"
+)
+
+// Pools holds the read and statediff pools
+type Pools struct {
+	Read *Pool
+	// StateDiff is nil when no node serves the statediff role
+	StateDiff *Pool
+}
+
+// NewPools creates the read and statediff pools out of the nodes, according to their roles
+func NewPools(nodes []NodeConfig, conf PoolConfig) (*Pools, error) {
+	var read, stateDiff []NodeConfig
+	for _, n := range nodes {
+		if n.HasRole(ReadRole) {
+			read = append(read, n)
+		}
+		if n.HasRole(StateDiffRole) {
+			stateDiff = append(stateDiff, n)
+		}
+	}
+	if len(read) == 0 {
+		return nil, fmt.Errorf("no upstream serves the %s role", ReadRole)
+	}
+	pools := new(Pools)
+	var err error
+	if pools.Read, err = NewPool(ReadPoolName, read, conf); err != nil {
+		return nil, err
+	}
+	if len(stateDiff) > 0 {
+		// statediff calls can run for minutes, so their timeout is left to the callers
+		conf.RequestTimeout = 0
+		if pools.StateDiff, err = NewPool(StateDiffPoolName, stateDiff, conf); err != nil {
+			return nil, err
+		}
+	}
+	return pools, nil
+}
+
+// ReadClient returns the client routing its calls through the read pool
+func (p *Pools) ReadClient() *rpc.Client {
+	return p.Read.Client()
+}
+
+// StateDiffClient returns the client routing its calls through the statediff pool, nil if there is none
+func (p *Pools) StateDiffClient() *rpc.Client {
+	if p.StateDiff == nil {
+		return nil
+	}
+	return p.StateDiff.Client()
+}
+
+// Start spins up the health checks of the pools
+func (p *Pools) Start() {
+	p.Read.Start()
+	if p.StateDiff != nil {
+		p.StateDiff.Start()
+	}
+}
+
+// Stop shuts down the health checks of the pools
+func (p *Pools) Stop() {
+	p.Read.Stop()
+	if p.StateDiff != nil {
+		p.StateDiff.Stop()
+	}
+}
+
+// Status returns the state of the pools
+func (p *Pools) Status() []PoolStatus {
+	status := []PoolStatus{p.Read.Status()}
+	if p.StateDiff != nil {
+		status = append(status, p.StateDiff.Status())
+	}
+	return status
+}
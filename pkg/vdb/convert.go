@@ -0,0 +1,218 @@
+// VulcanizeDB
+// Copyright © 2022 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package vdb
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/statediff/indexer/ipfs"
+	sdtypes "github.com/ethereum/go-ethereum/statediff/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/vulcanize/ipld-eth-server/pkg/eth"
+	"github.com/vulcanize/ipld-eth-server/pkg/serve"
+	"github.com/vulcanize/ipld-eth-server/pkg/vdb/pb"
+)
+
+// SubscriptionSettings converts the protobuf settings into eth.SubscriptionSettings
+func SubscriptionSettings(settings *pb.SubscriptionSettings) (eth.SubscriptionSettings, error) {
+	params := eth.SubscriptionSettings{
+		BackFill:     settings.GetBackFill(),
+		BackFillOnly: settings.GetBackFillOnly(),
+		Start:        big.NewInt(settings.GetStart()),
+		End:          big.NewInt(settings.GetEnd()),
+		HeaderFilter: eth.HeaderFilter{
+			Off:    settings.GetHeaderFilter().GetOff(),
+			Uncles: settings.GetHeaderFilter().GetUncles(),
+		},
+		TxFilter: eth.TxFilter{
+			Off:              settings.GetTxFilter().GetOff(),
+			Src:              settings.GetTxFilter().GetSrc(),
+			Dst:              settings.GetTxFilter().GetDst(),
+			MethodSelectors:  settings.GetTxFilter().GetMethodSelectors(),
+			ContractCreation: settings.GetTxFilter().GetContractCreation(),
+			Types:            settings.GetTxFilter().GetTypes(),
+			FailedOnly:       settings.GetTxFilter().GetFailedOnly(),
+		},
+		ReceiptFilter: eth.ReceiptFilter{
+			Off:          settings.GetReceiptFilter().GetOff(),
+			MatchTxs:     settings.GetReceiptFilter().GetMatchTxs(),
+			LogAddresses: settings.GetReceiptFilter().GetLogAddresses(),
+		},
+		StateFilter: eth.StateFilter{
+			Off:               settings.GetStateFilter().GetOff(),
+			Addresses:         settings.GetStateFilter().GetAddresses(),
+			IntermediateNodes: settings.GetStateFilter().GetIntermediateNodes(),
+		},
+		StorageFilter: eth.StorageFilter{
+			Off:               settings.GetStorageFilter().GetOff(),
+			Addresses:         settings.GetStorageFilter().GetAddresses(),
+			StorageKeys:       settings.GetStorageFilter().GetStorageKeys(),
+			IntermediateNodes: settings.GetStorageFilter().GetIntermediateNodes(),
+		},
+		// payloads are decoded from rlp before they are sent as protobuf IPLDs
+		Encoding:   eth.RLPEncoding,
+		Expression: settings.GetExpression(),
+	}
+	for _, topics := range settings.GetReceiptFilter().GetTopics() {
+		params.ReceiptFilter.Topics = append(params.ReceiptFilter.Topics, topics.GetTopics())
+	}
+	var err error
+	if params.TxFilter.MinValue, err = parseBig(settings.GetTxFilter().GetMinValue()); err != nil {
+		return params, fmt.Errorf("invalid txFilter minValue: %v", err)
+	}
+	if params.TxFilter.MaxValue, err = parseBig(settings.GetTxFilter().GetMaxValue()); err != nil {
+		return params, fmt.Errorf("invalid txFilter maxValue: %v", err)
+	}
+	if params.Expression != "" {
+		if _, err := eth.ParseFilterExpression(params.Expression); err != nil {
+			return params, err
+		}
+	}
+	return params, nil
+}
+
+// parseBig parses a base 10 integer, an empty string gives nil
+func parseBig(value string) (*big.Int, error) {
+	if value == "" {
+		return nil, nil
+	}
+	i, ok := new(big.Int).SetString(value, 10)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a base 10 integer", value)
+	}
+	return i, nil
+}
+
+// IPLDs converts eth.IPLDs into their protobuf form
+func IPLDs(iplds *eth.IPLDs) *pb.IPLDs {
+	msg := &pb.IPLDs{
+		Header:       ipld(iplds.Header),
+		Uncles:       ipldList(iplds.Uncles),
+		Transactions: ipldList(iplds.Transactions),
+		Receipts:     ipldList(iplds.Receipts),
+		StateNodes:   make([]*pb.StateNode, len(iplds.StateNodes)),
+		StorageNodes: make([]*pb.StorageNode, len(iplds.StorageNodes)),
+	}
+	if iplds.BlockNumber != nil {
+		msg.BlockNumber = iplds.BlockNumber.String()
+	}
+	if iplds.TotalDifficulty != nil {
+		msg.TotalDifficulty = iplds.TotalDifficulty.String()
+	}
+	for i, node := range iplds.StateNodes {
+		msg.StateNodes[i] = &pb.StateNode{
+			Type:         nodeType(node.Type),
+			StateLeafKey: node.StateLeafKey.Bytes(),
+			Path:         node.Path,
+			Ipld:         ipld(node.IPLD),
+		}
+	}
+	for i, node := range iplds.StorageNodes {
+		msg.StorageNodes[i] = &pb.StorageNode{
+			Type:           nodeType(node.Type),
+			StateLeafKey:   node.StateLeafKey.Bytes(),
+			StorageLeafKey: node.StorageLeafKey.Bytes(),
+			Path:           node.Path,
+			Ipld:           ipld(node.IPLD),
+		}
+	}
+	return msg
+}
+
+func ipld(model ipfs.BlockModel) *pb.IPLD {
+	return &pb.IPLD{Cid: model.CID, Data: model.Data}
+}
+
+func ipldList(models []ipfs.BlockModel) []*pb.IPLD {
+	list := make([]*pb.IPLD, len(models))
+	for i, model := range models {
+		list[i] = ipld(model)
+	}
+	return list
+}
+
+func nodeType(t sdtypes.NodeType) pb.NodeType {
+	switch t {
+	case sdtypes.Branch:
+		return pb.NodeType_BRANCH
+	case sdtypes.Extension:
+		return pb.NodeType_EXTENSION
+	case sdtypes.Leaf:
+		return pb.NodeType_LEAF
+	case sdtypes.Removed:
+		return pb.NodeType_REMOVED
+	default:
+		return pb.NodeType_UNKNOWN
+	}
+}
+
+// SubscriptionPayload converts a vdb stream payload into its protobuf form, decoding its rlp encoded IPLDs
+func SubscriptionPayload(payload serve.SubscriptionPayload) (*pb.SubscriptionPayload, error) {
+	msg := &pb.SubscriptionPayload{
+		Height: payload.Height,
+		Err:    payload.Err,
+	}
+	switch payload.Flag {
+	case serve.BackFillCompleteFlag:
+		msg.Flag = pb.SubscriptionPayload_BACK_FILL_COMPLETE
+	case serve.ReorgFlag:
+		msg.Flag = pb.SubscriptionPayload_REORG
+	}
+	if payload.Reorg != nil {
+		msg.Reorg = &pb.Reorg{
+			Dropped:  hashList(payload.Reorg.Dropped),
+			NewChain: hashList(payload.Reorg.NewChain),
+		}
+	}
+	if len(payload.Data) > 0 {
+		var iplds eth.IPLDs
+		if err := rlp.DecodeBytes(payload.Data, &iplds); err != nil {
+			return nil, err
+		}
+		msg.Iplds = IPLDs(&iplds)
+	}
+	return msg, nil
+}
+
+func hashList(hashes []common.Hash) [][]byte {
+	list := make([][]byte, len(hashes))
+	for i, hash := range hashes {
+		list[i] = hash.Bytes()
+	}
+	return list
+}
+
+// blockNumberOrHash converts the protobuf block selector, an unset selector is the latest block
+func blockNumberOrHash(block *pb.BlockNumberOrHash) (rpc.BlockNumberOrHash, error) {
+	switch b := block.GetBlock().(type) {
+	case *pb.BlockNumberOrHash_Hash:
+		if len(b.Hash) != common.HashLength {
+			return rpc.BlockNumberOrHash{}, status.Errorf(codes.InvalidArgument, "block hash must be %d bytes", common.HashLength)
+		}
+		return rpc.BlockNumberOrHashWithHash(common.BytesToHash(b.Hash), block.GetRequireCanonical()), nil
+	case *pb.BlockNumberOrHash_Number:
+		return rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(b.Number)), nil
+	default:
+		return rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), nil
+	}
+}
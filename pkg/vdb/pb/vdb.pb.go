@@ -0,0 +1,2446 @@
+// VulcanizeDB
+// Copyright © 2022 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.27.1
+// 	protoc        v3.19.1
+// source: vdb.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type NodeType int32
+
+const (
+	NodeType_UNKNOWN   NodeType = 0
+	NodeType_BRANCH    NodeType = 1
+	NodeType_EXTENSION NodeType = 2
+	NodeType_LEAF      NodeType = 3
+	NodeType_REMOVED   NodeType = 4
+)
+
+// Enum value maps for NodeType.
+var (
+	NodeType_name = map[int32]string{
+		0: "UNKNOWN",
+		1: "BRANCH",
+		2: "EXTENSION",
+		3: "LEAF",
+		4: "REMOVED",
+	}
+	NodeType_value = map[string]int32{
+		"UNKNOWN":   0,
+		"BRANCH":    1,
+		"EXTENSION": 2,
+		"LEAF":      3,
+		"REMOVED":   4,
+	}
+)
+
+func (x NodeType) Enum() *NodeType {
+	p := new(NodeType)
+	*p = x
+	return p
+}
+
+func (x NodeType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (NodeType) Descriptor() protoreflect.EnumDescriptor {
+	return file_vdb_proto_enumTypes[0].Descriptor()
+}
+
+func (NodeType) Type() protoreflect.EnumType {
+	return &file_vdb_proto_enumTypes[0]
+}
+
+func (x NodeType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use NodeType.Descriptor instead.
+func (NodeType) EnumDescriptor() ([]byte, []int) {
+	return file_vdb_proto_rawDescGZIP(), []int{0}
+}
+
+type SubscriptionPayload_Flag int32
+
+const (
+	SubscriptionPayload_EMPTY              SubscriptionPayload_Flag = 0
+	SubscriptionPayload_BACK_FILL_COMPLETE SubscriptionPayload_Flag = 1
+	SubscriptionPayload_REORG              SubscriptionPayload_Flag = 2
+)
+
+// Enum value maps for SubscriptionPayload_Flag.
+var (
+	SubscriptionPayload_Flag_name = map[int32]string{
+		0: "EMPTY",
+		1: "BACK_FILL_COMPLETE",
+		2: "REORG",
+	}
+	SubscriptionPayload_Flag_value = map[string]int32{
+		"EMPTY":              0,
+		"BACK_FILL_COMPLETE": 1,
+		"REORG":              2,
+	}
+)
+
+func (x SubscriptionPayload_Flag) Enum() *SubscriptionPayload_Flag {
+	p := new(SubscriptionPayload_Flag)
+	*p = x
+	return p
+}
+
+func (x SubscriptionPayload_Flag) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SubscriptionPayload_Flag) Descriptor() protoreflect.EnumDescriptor {
+	return file_vdb_proto_enumTypes[1].Descriptor()
+}
+
+func (SubscriptionPayload_Flag) Type() protoreflect.EnumType {
+	return &file_vdb_proto_enumTypes[1]
+}
+
+func (x SubscriptionPayload_Flag) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SubscriptionPayload_Flag.Descriptor instead.
+func (SubscriptionPayload_Flag) EnumDescriptor() ([]byte, []int) {
+	return file_vdb_proto_rawDescGZIP(), []int{7, 0}
+}
+
+// SubscriptionSettings mirrors eth.SubscriptionSettings
+// big integers are given as base 10 strings, addresses, hashes and selectors as hex strings
+type SubscriptionSettings struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	BackFill      bool           `protobuf:"varint,1,opt,name=back_fill,json=backFill,proto3" json:"back_fill,omitempty"`
+	BackFillOnly  bool           `protobuf:"varint,2,opt,name=back_fill_only,json=backFillOnly,proto3" json:"back_fill_only,omitempty"`
+	Start         int64          `protobuf:"varint,3,opt,name=start,proto3" json:"start,omitempty"`
+	End           int64          `protobuf:"varint,4,opt,name=end,proto3" json:"end,omitempty"` // set to 0 or a negative value to have no ending block
+	HeaderFilter  *HeaderFilter  `protobuf:"bytes,5,opt,name=header_filter,json=headerFilter,proto3" json:"header_filter,omitempty"`
+	TxFilter      *TxFilter      `protobuf:"bytes,6,opt,name=tx_filter,json=txFilter,proto3" json:"tx_filter,omitempty"`
+	ReceiptFilter *ReceiptFilter `protobuf:"bytes,7,opt,name=receipt_filter,json=receiptFilter,proto3" json:"receipt_filter,omitempty"`
+	StateFilter   *StateFilter   `protobuf:"bytes,8,opt,name=state_filter,json=stateFilter,proto3" json:"state_filter,omitempty"`
+	StorageFilter *StorageFilter `protobuf:"bytes,9,opt,name=storage_filter,json=storageFilter,proto3" json:"storage_filter,omitempty"`
+	Expression    string         `protobuf:"bytes,10,opt,name=expression,proto3" json:"expression,omitempty"`
+}
+
+func (x *SubscriptionSettings) Reset() {
+	*x = SubscriptionSettings{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_vdb_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SubscriptionSettings) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscriptionSettings) ProtoMessage() {}
+
+func (x *SubscriptionSettings) ProtoReflect() protoreflect.Message {
+	mi := &file_vdb_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscriptionSettings.ProtoReflect.Descriptor instead.
+func (*SubscriptionSettings) Descriptor() ([]byte, []int) {
+	return file_vdb_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *SubscriptionSettings) GetBackFill() bool {
+	if x != nil {
+		return x.BackFill
+	}
+	return false
+}
+
+func (x *SubscriptionSettings) GetBackFillOnly() bool {
+	if x != nil {
+		return x.BackFillOnly
+	}
+	return false
+}
+
+func (x *SubscriptionSettings) GetStart() int64 {
+	if x != nil {
+		return x.Start
+	}
+	return 0
+}
+
+func (x *SubscriptionSettings) GetEnd() int64 {
+	if x != nil {
+		return x.End
+	}
+	return 0
+}
+
+func (x *SubscriptionSettings) GetHeaderFilter() *HeaderFilter {
+	if x != nil {
+		return x.HeaderFilter
+	}
+	return nil
+}
+
+func (x *SubscriptionSettings) GetTxFilter() *TxFilter {
+	if x != nil {
+		return x.TxFilter
+	}
+	return nil
+}
+
+func (x *SubscriptionSettings) GetReceiptFilter() *ReceiptFilter {
+	if x != nil {
+		return x.ReceiptFilter
+	}
+	return nil
+}
+
+func (x *SubscriptionSettings) GetStateFilter() *StateFilter {
+	if x != nil {
+		return x.StateFilter
+	}
+	return nil
+}
+
+func (x *SubscriptionSettings) GetStorageFilter() *StorageFilter {
+	if x != nil {
+		return x.StorageFilter
+	}
+	return nil
+}
+
+func (x *SubscriptionSettings) GetExpression() string {
+	if x != nil {
+		return x.Expression
+	}
+	return ""
+}
+
+type HeaderFilter struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Off    bool `protobuf:"varint,1,opt,name=off,proto3" json:"off,omitempty"`
+	Uncles bool `protobuf:"varint,2,opt,name=uncles,proto3" json:"uncles,omitempty"`
+}
+
+func (x *HeaderFilter) Reset() {
+	*x = HeaderFilter{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_vdb_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *HeaderFilter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HeaderFilter) ProtoMessage() {}
+
+func (x *HeaderFilter) ProtoReflect() protoreflect.Message {
+	mi := &file_vdb_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HeaderFilter.ProtoReflect.Descriptor instead.
+func (*HeaderFilter) Descriptor() ([]byte, []int) {
+	return file_vdb_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *HeaderFilter) GetOff() bool {
+	if x != nil {
+		return x.Off
+	}
+	return false
+}
+
+func (x *HeaderFilter) GetUncles() bool {
+	if x != nil {
+		return x.Uncles
+	}
+	return false
+}
+
+type TxFilter struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Off              bool     `protobuf:"varint,1,opt,name=off,proto3" json:"off,omitempty"`
+	Src              []string `protobuf:"bytes,2,rep,name=src,proto3" json:"src,omitempty"`
+	Dst              []string `protobuf:"bytes,3,rep,name=dst,proto3" json:"dst,omitempty"`
+	MethodSelectors  []string `protobuf:"bytes,4,rep,name=method_selectors,json=methodSelectors,proto3" json:"method_selectors,omitempty"`
+	MinValue         string   `protobuf:"bytes,5,opt,name=min_value,json=minValue,proto3" json:"min_value,omitempty"` // empty for no lower bound
+	MaxValue         string   `protobuf:"bytes,6,opt,name=max_value,json=maxValue,proto3" json:"max_value,omitempty"` // empty for no upper bound
+	ContractCreation bool     `protobuf:"varint,7,opt,name=contract_creation,json=contractCreation,proto3" json:"contract_creation,omitempty"`
+	Types            []uint64 `protobuf:"varint,8,rep,packed,name=types,proto3" json:"types,omitempty"`
+	FailedOnly       bool     `protobuf:"varint,9,opt,name=failed_only,json=failedOnly,proto3" json:"failed_only,omitempty"`
+}
+
+func (x *TxFilter) Reset() {
+	*x = TxFilter{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_vdb_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TxFilter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TxFilter) ProtoMessage() {}
+
+func (x *TxFilter) ProtoReflect() protoreflect.Message {
+	mi := &file_vdb_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TxFilter.ProtoReflect.Descriptor instead.
+func (*TxFilter) Descriptor() ([]byte, []int) {
+	return file_vdb_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *TxFilter) GetOff() bool {
+	if x != nil {
+		return x.Off
+	}
+	return false
+}
+
+func (x *TxFilter) GetSrc() []string {
+	if x != nil {
+		return x.Src
+	}
+	return nil
+}
+
+func (x *TxFilter) GetDst() []string {
+	if x != nil {
+		return x.Dst
+	}
+	return nil
+}
+
+func (x *TxFilter) GetMethodSelectors() []string {
+	if x != nil {
+		return x.MethodSelectors
+	}
+	return nil
+}
+
+func (x *TxFilter) GetMinValue() string {
+	if x != nil {
+		return x.MinValue
+	}
+	return ""
+}
+
+func (x *TxFilter) GetMaxValue() string {
+	if x != nil {
+		return x.MaxValue
+	}
+	return ""
+}
+
+func (x *TxFilter) GetContractCreation() bool {
+	if x != nil {
+		return x.ContractCreation
+	}
+	return false
+}
+
+func (x *TxFilter) GetTypes() []uint64 {
+	if x != nil {
+		return x.Types
+	}
+	return nil
+}
+
+func (x *TxFilter) GetFailedOnly() bool {
+	if x != nil {
+		return x.FailedOnly
+	}
+	return false
+}
+
+type ReceiptFilter struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Off          bool      `protobuf:"varint,1,opt,name=off,proto3" json:"off,omitempty"`
+	MatchTxs     bool      `protobuf:"varint,2,opt,name=match_txs,json=matchTxs,proto3" json:"match_txs,omitempty"`
+	LogAddresses []string  `protobuf:"bytes,3,rep,name=log_addresses,json=logAddresses,proto3" json:"log_addresses,omitempty"`
+	Topics       []*Topics `protobuf:"bytes,4,rep,name=topics,proto3" json:"topics,omitempty"`
+}
+
+func (x *ReceiptFilter) Reset() {
+	*x = ReceiptFilter{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_vdb_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ReceiptFilter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReceiptFilter) ProtoMessage() {}
+
+func (x *ReceiptFilter) ProtoReflect() protoreflect.Message {
+	mi := &file_vdb_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReceiptFilter.ProtoReflect.Descriptor instead.
+func (*ReceiptFilter) Descriptor() ([]byte, []int) {
+	return file_vdb_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ReceiptFilter) GetOff() bool {
+	if x != nil {
+		return x.Off
+	}
+	return false
+}
+
+func (x *ReceiptFilter) GetMatchTxs() bool {
+	if x != nil {
+		return x.MatchTxs
+	}
+	return false
+}
+
+func (x *ReceiptFilter) GetLogAddresses() []string {
+	if x != nil {
+		return x.LogAddresses
+	}
+	return nil
+}
+
+func (x *ReceiptFilter) GetTopics() []*Topics {
+	if x != nil {
+		return x.Topics
+	}
+	return nil
+}
+
+// Topics are the accepted values for one topic position
+type Topics struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Topics []string `protobuf:"bytes,1,rep,name=topics,proto3" json:"topics,omitempty"`
+}
+
+func (x *Topics) Reset() {
+	*x = Topics{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_vdb_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Topics) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Topics) ProtoMessage() {}
+
+func (x *Topics) ProtoReflect() protoreflect.Message {
+	mi := &file_vdb_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Topics.ProtoReflect.Descriptor instead.
+func (*Topics) Descriptor() ([]byte, []int) {
+	return file_vdb_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Topics) GetTopics() []string {
+	if x != nil {
+		return x.Topics
+	}
+	return nil
+}
+
+type StateFilter struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Off               bool     `protobuf:"varint,1,opt,name=off,proto3" json:"off,omitempty"`
+	Addresses         []string `protobuf:"bytes,2,rep,name=addresses,proto3" json:"addresses,omitempty"`
+	IntermediateNodes bool     `protobuf:"varint,3,opt,name=intermediate_nodes,json=intermediateNodes,proto3" json:"intermediate_nodes,omitempty"`
+}
+
+func (x *StateFilter) Reset() {
+	*x = StateFilter{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_vdb_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StateFilter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StateFilter) ProtoMessage() {}
+
+func (x *StateFilter) ProtoReflect() protoreflect.Message {
+	mi := &file_vdb_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StateFilter.ProtoReflect.Descriptor instead.
+func (*StateFilter) Descriptor() ([]byte, []int) {
+	return file_vdb_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *StateFilter) GetOff() bool {
+	if x != nil {
+		return x.Off
+	}
+	return false
+}
+
+func (x *StateFilter) GetAddresses() []string {
+	if x != nil {
+		return x.Addresses
+	}
+	return nil
+}
+
+func (x *StateFilter) GetIntermediateNodes() bool {
+	if x != nil {
+		return x.IntermediateNodes
+	}
+	return false
+}
+
+type StorageFilter struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Off               bool     `protobuf:"varint,1,opt,name=off,proto3" json:"off,omitempty"`
+	Addresses         []string `protobuf:"bytes,2,rep,name=addresses,proto3" json:"addresses,omitempty"`
+	StorageKeys       []string `protobuf:"bytes,3,rep,name=storage_keys,json=storageKeys,proto3" json:"storage_keys,omitempty"`
+	IntermediateNodes bool     `protobuf:"varint,4,opt,name=intermediate_nodes,json=intermediateNodes,proto3" json:"intermediate_nodes,omitempty"`
+}
+
+func (x *StorageFilter) Reset() {
+	*x = StorageFilter{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_vdb_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StorageFilter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StorageFilter) ProtoMessage() {}
+
+func (x *StorageFilter) ProtoReflect() protoreflect.Message {
+	mi := &file_vdb_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StorageFilter.ProtoReflect.Descriptor instead.
+func (*StorageFilter) Descriptor() ([]byte, []int) {
+	return file_vdb_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *StorageFilter) GetOff() bool {
+	if x != nil {
+		return x.Off
+	}
+	return false
+}
+
+func (x *StorageFilter) GetAddresses() []string {
+	if x != nil {
+		return x.Addresses
+	}
+	return nil
+}
+
+func (x *StorageFilter) GetStorageKeys() []string {
+	if x != nil {
+		return x.StorageKeys
+	}
+	return nil
+}
+
+func (x *StorageFilter) GetIntermediateNodes() bool {
+	if x != nil {
+		return x.IntermediateNodes
+	}
+	return false
+}
+
+// SubscriptionPayload is a message sent to Stream subscribers
+type SubscriptionPayload struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Height int64                    `protobuf:"varint,1,opt,name=height,proto3" json:"height,omitempty"`
+	Iplds  *IPLDs                   `protobuf:"bytes,2,opt,name=iplds,proto3" json:"iplds,omitempty"` // set for payloads carrying block data
+	Reorg  *Reorg                   `protobuf:"bytes,3,opt,name=reorg,proto3" json:"reorg,omitempty"` // set for REORG payloads
+	Err    string                   `protobuf:"bytes,4,opt,name=err,proto3" json:"err,omitempty"`
+	Flag   SubscriptionPayload_Flag `protobuf:"varint,5,opt,name=flag,proto3,enum=vdb.v1.SubscriptionPayload_Flag" json:"flag,omitempty"`
+}
+
+func (x *SubscriptionPayload) Reset() {
+	*x = SubscriptionPayload{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_vdb_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SubscriptionPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscriptionPayload) ProtoMessage() {}
+
+func (x *SubscriptionPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_vdb_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscriptionPayload.ProtoReflect.Descriptor instead.
+func (*SubscriptionPayload) Descriptor() ([]byte, []int) {
+	return file_vdb_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *SubscriptionPayload) GetHeight() int64 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *SubscriptionPayload) GetIplds() *IPLDs {
+	if x != nil {
+		return x.Iplds
+	}
+	return nil
+}
+
+func (x *SubscriptionPayload) GetReorg() *Reorg {
+	if x != nil {
+		return x.Reorg
+	}
+	return nil
+}
+
+func (x *SubscriptionPayload) GetErr() string {
+	if x != nil {
+		return x.Err
+	}
+	return ""
+}
+
+func (x *SubscriptionPayload) GetFlag() SubscriptionPayload_Flag {
+	if x != nil {
+		return x.Flag
+	}
+	return SubscriptionPayload_EMPTY
+}
+
+// Reorg mirrors serve.Reorg
+type Reorg struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Dropped  [][]byte `protobuf:"bytes,1,rep,name=dropped,proto3" json:"dropped,omitempty"`
+	NewChain [][]byte `protobuf:"bytes,2,rep,name=new_chain,json=newChain,proto3" json:"new_chain,omitempty"`
+}
+
+func (x *Reorg) Reset() {
+	*x = Reorg{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_vdb_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Reorg) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Reorg) ProtoMessage() {}
+
+func (x *Reorg) ProtoReflect() protoreflect.Message {
+	mi := &file_vdb_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Reorg.ProtoReflect.Descriptor instead.
+func (*Reorg) Descriptor() ([]byte, []int) {
+	return file_vdb_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Reorg) GetDropped() [][]byte {
+	if x != nil {
+		return x.Dropped
+	}
+	return nil
+}
+
+func (x *Reorg) GetNewChain() [][]byte {
+	if x != nil {
+		return x.NewChain
+	}
+	return nil
+}
+
+// IPLDs mirrors eth.IPLDs
+type IPLDs struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	BlockNumber     string         `protobuf:"bytes,1,opt,name=block_number,json=blockNumber,proto3" json:"block_number,omitempty"`
+	TotalDifficulty string         `protobuf:"bytes,2,opt,name=total_difficulty,json=totalDifficulty,proto3" json:"total_difficulty,omitempty"`
+	Header          *IPLD          `protobuf:"bytes,3,opt,name=header,proto3" json:"header,omitempty"`
+	Uncles          []*IPLD        `protobuf:"bytes,4,rep,name=uncles,proto3" json:"uncles,omitempty"`
+	Transactions    []*IPLD        `protobuf:"bytes,5,rep,name=transactions,proto3" json:"transactions,omitempty"`
+	Receipts        []*IPLD        `protobuf:"bytes,6,rep,name=receipts,proto3" json:"receipts,omitempty"`
+	StateNodes      []*StateNode   `protobuf:"bytes,7,rep,name=state_nodes,json=stateNodes,proto3" json:"state_nodes,omitempty"`
+	StorageNodes    []*StorageNode `protobuf:"bytes,8,rep,name=storage_nodes,json=storageNodes,proto3" json:"storage_nodes,omitempty"`
+}
+
+func (x *IPLDs) Reset() {
+	*x = IPLDs{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_vdb_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *IPLDs) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IPLDs) ProtoMessage() {}
+
+func (x *IPLDs) ProtoReflect() protoreflect.Message {
+	mi := &file_vdb_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IPLDs.ProtoReflect.Descriptor instead.
+func (*IPLDs) Descriptor() ([]byte, []int) {
+	return file_vdb_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *IPLDs) GetBlockNumber() string {
+	if x != nil {
+		return x.BlockNumber
+	}
+	return ""
+}
+
+func (x *IPLDs) GetTotalDifficulty() string {
+	if x != nil {
+		return x.TotalDifficulty
+	}
+	return ""
+}
+
+func (x *IPLDs) GetHeader() *IPLD {
+	if x != nil {
+		return x.Header
+	}
+	return nil
+}
+
+func (x *IPLDs) GetUncles() []*IPLD {
+	if x != nil {
+		return x.Uncles
+	}
+	return nil
+}
+
+func (x *IPLDs) GetTransactions() []*IPLD {
+	if x != nil {
+		return x.Transactions
+	}
+	return nil
+}
+
+func (x *IPLDs) GetReceipts() []*IPLD {
+	if x != nil {
+		return x.Receipts
+	}
+	return nil
+}
+
+func (x *IPLDs) GetStateNodes() []*StateNode {
+	if x != nil {
+		return x.StateNodes
+	}
+	return nil
+}
+
+func (x *IPLDs) GetStorageNodes() []*StorageNode {
+	if x != nil {
+		return x.StorageNodes
+	}
+	return nil
+}
+
+// IPLD is a raw IPLD block and its CID
+type IPLD struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Cid  string `protobuf:"bytes,1,opt,name=cid,proto3" json:"cid,omitempty"`
+	Data []byte `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+}
+
+func (x *IPLD) Reset() {
+	*x = IPLD{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_vdb_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *IPLD) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IPLD) ProtoMessage() {}
+
+func (x *IPLD) ProtoReflect() protoreflect.Message {
+	mi := &file_vdb_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IPLD.ProtoReflect.Descriptor instead.
+func (*IPLD) Descriptor() ([]byte, []int) {
+	return file_vdb_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *IPLD) GetCid() string {
+	if x != nil {
+		return x.Cid
+	}
+	return ""
+}
+
+func (x *IPLD) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type StateNode struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Type         NodeType `protobuf:"varint,1,opt,name=type,proto3,enum=vdb.v1.NodeType" json:"type,omitempty"`
+	StateLeafKey []byte   `protobuf:"bytes,2,opt,name=state_leaf_key,json=stateLeafKey,proto3" json:"state_leaf_key,omitempty"`
+	Path         []byte   `protobuf:"bytes,3,opt,name=path,proto3" json:"path,omitempty"`
+	Ipld         *IPLD    `protobuf:"bytes,4,opt,name=ipld,proto3" json:"ipld,omitempty"`
+}
+
+func (x *StateNode) Reset() {
+	*x = StateNode{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_vdb_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StateNode) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StateNode) ProtoMessage() {}
+
+func (x *StateNode) ProtoReflect() protoreflect.Message {
+	mi := &file_vdb_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StateNode.ProtoReflect.Descriptor instead.
+func (*StateNode) Descriptor() ([]byte, []int) {
+	return file_vdb_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *StateNode) GetType() NodeType {
+	if x != nil {
+		return x.Type
+	}
+	return NodeType_UNKNOWN
+}
+
+func (x *StateNode) GetStateLeafKey() []byte {
+	if x != nil {
+		return x.StateLeafKey
+	}
+	return nil
+}
+
+func (x *StateNode) GetPath() []byte {
+	if x != nil {
+		return x.Path
+	}
+	return nil
+}
+
+func (x *StateNode) GetIpld() *IPLD {
+	if x != nil {
+		return x.Ipld
+	}
+	return nil
+}
+
+type StorageNode struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Type           NodeType `protobuf:"varint,1,opt,name=type,proto3,enum=vdb.v1.NodeType" json:"type,omitempty"`
+	StateLeafKey   []byte   `protobuf:"bytes,2,opt,name=state_leaf_key,json=stateLeafKey,proto3" json:"state_leaf_key,omitempty"`
+	StorageLeafKey []byte   `protobuf:"bytes,3,opt,name=storage_leaf_key,json=storageLeafKey,proto3" json:"storage_leaf_key,omitempty"`
+	Path           []byte   `protobuf:"bytes,4,opt,name=path,proto3" json:"path,omitempty"`
+	Ipld           *IPLD    `protobuf:"bytes,5,opt,name=ipld,proto3" json:"ipld,omitempty"`
+}
+
+func (x *StorageNode) Reset() {
+	*x = StorageNode{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_vdb_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StorageNode) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StorageNode) ProtoMessage() {}
+
+func (x *StorageNode) ProtoReflect() protoreflect.Message {
+	mi := &file_vdb_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StorageNode.ProtoReflect.Descriptor instead.
+func (*StorageNode) Descriptor() ([]byte, []int) {
+	return file_vdb_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *StorageNode) GetType() NodeType {
+	if x != nil {
+		return x.Type
+	}
+	return NodeType_UNKNOWN
+}
+
+func (x *StorageNode) GetStateLeafKey() []byte {
+	if x != nil {
+		return x.StateLeafKey
+	}
+	return nil
+}
+
+func (x *StorageNode) GetStorageLeafKey() []byte {
+	if x != nil {
+		return x.StorageLeafKey
+	}
+	return nil
+}
+
+func (x *StorageNode) GetPath() []byte {
+	if x != nil {
+		return x.Path
+	}
+	return nil
+}
+
+func (x *StorageNode) GetIpld() *IPLD {
+	if x != nil {
+		return x.Ipld
+	}
+	return nil
+}
+
+// BlockNumberOrHash selects a block by hash or by number
+// negative numbers follow the rpc.BlockNumber conventions (-1 for latest, -2 for pending)
+type BlockNumberOrHash struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Block:
+	//	*BlockNumberOrHash_Number
+	//	*BlockNumberOrHash_Hash
+	Block            isBlockNumberOrHash_Block `protobuf_oneof:"block"`
+	RequireCanonical bool                      `protobuf:"varint,3,opt,name=require_canonical,json=requireCanonical,proto3" json:"require_canonical,omitempty"`
+}
+
+func (x *BlockNumberOrHash) Reset() {
+	*x = BlockNumberOrHash{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_vdb_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BlockNumberOrHash) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BlockNumberOrHash) ProtoMessage() {}
+
+func (x *BlockNumberOrHash) ProtoReflect() protoreflect.Message {
+	mi := &file_vdb_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BlockNumberOrHash.ProtoReflect.Descriptor instead.
+func (*BlockNumberOrHash) Descriptor() ([]byte, []int) {
+	return file_vdb_proto_rawDescGZIP(), []int{13}
+}
+
+func (m *BlockNumberOrHash) GetBlock() isBlockNumberOrHash_Block {
+	if m != nil {
+		return m.Block
+	}
+	return nil
+}
+
+func (x *BlockNumberOrHash) GetNumber() int64 {
+	if x, ok := x.GetBlock().(*BlockNumberOrHash_Number); ok {
+		return x.Number
+	}
+	return 0
+}
+
+func (x *BlockNumberOrHash) GetHash() []byte {
+	if x, ok := x.GetBlock().(*BlockNumberOrHash_Hash); ok {
+		return x.Hash
+	}
+	return nil
+}
+
+func (x *BlockNumberOrHash) GetRequireCanonical() bool {
+	if x != nil {
+		return x.RequireCanonical
+	}
+	return false
+}
+
+type isBlockNumberOrHash_Block interface {
+	isBlockNumberOrHash_Block()
+}
+
+type BlockNumberOrHash_Number struct {
+	Number int64 `protobuf:"varint,1,opt,name=number,proto3,oneof"`
+}
+
+type BlockNumberOrHash_Hash struct {
+	Hash []byte `protobuf:"bytes,2,opt,name=hash,proto3,oneof"`
+}
+
+func (*BlockNumberOrHash_Number) isBlockNumberOrHash_Block() {}
+
+func (*BlockNumberOrHash_Hash) isBlockNumberOrHash_Block() {}
+
+type BlockRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Block *BlockNumberOrHash `protobuf:"bytes,1,opt,name=block,proto3" json:"block,omitempty"`
+}
+
+func (x *BlockRequest) Reset() {
+	*x = BlockRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_vdb_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BlockRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BlockRequest) ProtoMessage() {}
+
+func (x *BlockRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_vdb_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BlockRequest.ProtoReflect.Descriptor instead.
+func (*BlockRequest) Descriptor() ([]byte, []int) {
+	return file_vdb_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *BlockRequest) GetBlock() *BlockNumberOrHash {
+	if x != nil {
+		return x.Block
+	}
+	return nil
+}
+
+type Block struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Hash   []byte `protobuf:"bytes,1,opt,name=hash,proto3" json:"hash,omitempty"`
+	Number uint64 `protobuf:"varint,2,opt,name=number,proto3" json:"number,omitempty"`
+	Rlp    []byte `protobuf:"bytes,3,opt,name=rlp,proto3" json:"rlp,omitempty"`
+}
+
+func (x *Block) Reset() {
+	*x = Block{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_vdb_proto_msgTypes[15]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Block) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Block) ProtoMessage() {}
+
+func (x *Block) ProtoReflect() protoreflect.Message {
+	mi := &file_vdb_proto_msgTypes[15]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Block.ProtoReflect.Descriptor instead.
+func (*Block) Descriptor() ([]byte, []int) {
+	return file_vdb_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *Block) GetHash() []byte {
+	if x != nil {
+		return x.Hash
+	}
+	return nil
+}
+
+func (x *Block) GetNumber() uint64 {
+	if x != nil {
+		return x.Number
+	}
+	return 0
+}
+
+func (x *Block) GetRlp() []byte {
+	if x != nil {
+		return x.Rlp
+	}
+	return nil
+}
+
+type TransactionRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Hash []byte `protobuf:"bytes,1,opt,name=hash,proto3" json:"hash,omitempty"`
+}
+
+func (x *TransactionRequest) Reset() {
+	*x = TransactionRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_vdb_proto_msgTypes[16]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TransactionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TransactionRequest) ProtoMessage() {}
+
+func (x *TransactionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_vdb_proto_msgTypes[16]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TransactionRequest.ProtoReflect.Descriptor instead.
+func (*TransactionRequest) Descriptor() ([]byte, []int) {
+	return file_vdb_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *TransactionRequest) GetHash() []byte {
+	if x != nil {
+		return x.Hash
+	}
+	return nil
+}
+
+type Transaction struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Data        []byte `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"` // the binary (EIP-2718) encoded transaction
+	BlockHash   []byte `protobuf:"bytes,2,opt,name=block_hash,json=blockHash,proto3" json:"block_hash,omitempty"`
+	BlockNumber uint64 `protobuf:"varint,3,opt,name=block_number,json=blockNumber,proto3" json:"block_number,omitempty"`
+	Index       uint64 `protobuf:"varint,4,opt,name=index,proto3" json:"index,omitempty"`
+}
+
+func (x *Transaction) Reset() {
+	*x = Transaction{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_vdb_proto_msgTypes[17]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Transaction) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Transaction) ProtoMessage() {}
+
+func (x *Transaction) ProtoReflect() protoreflect.Message {
+	mi := &file_vdb_proto_msgTypes[17]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Transaction.ProtoReflect.Descriptor instead.
+func (*Transaction) Descriptor() ([]byte, []int) {
+	return file_vdb_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *Transaction) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *Transaction) GetBlockHash() []byte {
+	if x != nil {
+		return x.BlockHash
+	}
+	return nil
+}
+
+func (x *Transaction) GetBlockNumber() uint64 {
+	if x != nil {
+		return x.BlockNumber
+	}
+	return 0
+}
+
+func (x *Transaction) GetIndex() uint64 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+type Receipt struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Data        []byte `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"` // the binary (EIP-2718) encoded receipt
+	BlockHash   []byte `protobuf:"bytes,2,opt,name=block_hash,json=blockHash,proto3" json:"block_hash,omitempty"`
+	BlockNumber uint64 `protobuf:"varint,3,opt,name=block_number,json=blockNumber,proto3" json:"block_number,omitempty"`
+	Index       uint64 `protobuf:"varint,4,opt,name=index,proto3" json:"index,omitempty"`
+}
+
+func (x *Receipt) Reset() {
+	*x = Receipt{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_vdb_proto_msgTypes[18]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Receipt) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Receipt) ProtoMessage() {}
+
+func (x *Receipt) ProtoReflect() protoreflect.Message {
+	mi := &file_vdb_proto_msgTypes[18]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Receipt.ProtoReflect.Descriptor instead.
+func (*Receipt) Descriptor() ([]byte, []int) {
+	return file_vdb_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *Receipt) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *Receipt) GetBlockHash() []byte {
+	if x != nil {
+		return x.BlockHash
+	}
+	return nil
+}
+
+func (x *Receipt) GetBlockNumber() uint64 {
+	if x != nil {
+		return x.BlockNumber
+	}
+	return 0
+}
+
+func (x *Receipt) GetIndex() uint64 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+type AccountRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Address []byte             `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	Block   *BlockNumberOrHash `protobuf:"bytes,2,opt,name=block,proto3" json:"block,omitempty"`
+}
+
+func (x *AccountRequest) Reset() {
+	*x = AccountRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_vdb_proto_msgTypes[19]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AccountRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AccountRequest) ProtoMessage() {}
+
+func (x *AccountRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_vdb_proto_msgTypes[19]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AccountRequest.ProtoReflect.Descriptor instead.
+func (*AccountRequest) Descriptor() ([]byte, []int) {
+	return file_vdb_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *AccountRequest) GetAddress() []byte {
+	if x != nil {
+		return x.Address
+	}
+	return nil
+}
+
+func (x *AccountRequest) GetBlock() *BlockNumberOrHash {
+	if x != nil {
+		return x.Block
+	}
+	return nil
+}
+
+type Account struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Nonce       uint64 `protobuf:"varint,1,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	Balance     []byte `protobuf:"bytes,2,opt,name=balance,proto3" json:"balance,omitempty"` // big endian
+	StorageRoot []byte `protobuf:"bytes,3,opt,name=storage_root,json=storageRoot,proto3" json:"storage_root,omitempty"`
+	CodeHash    []byte `protobuf:"bytes,4,opt,name=code_hash,json=codeHash,proto3" json:"code_hash,omitempty"`
+}
+
+func (x *Account) Reset() {
+	*x = Account{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_vdb_proto_msgTypes[20]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Account) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Account) ProtoMessage() {}
+
+func (x *Account) ProtoReflect() protoreflect.Message {
+	mi := &file_vdb_proto_msgTypes[20]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Account.ProtoReflect.Descriptor instead.
+func (*Account) Descriptor() ([]byte, []int) {
+	return file_vdb_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *Account) GetNonce() uint64 {
+	if x != nil {
+		return x.Nonce
+	}
+	return 0
+}
+
+func (x *Account) GetBalance() []byte {
+	if x != nil {
+		return x.Balance
+	}
+	return nil
+}
+
+func (x *Account) GetStorageRoot() []byte {
+	if x != nil {
+		return x.StorageRoot
+	}
+	return nil
+}
+
+func (x *Account) GetCodeHash() []byte {
+	if x != nil {
+		return x.CodeHash
+	}
+	return nil
+}
+
+type Code struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Code []byte `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"`
+}
+
+func (x *Code) Reset() {
+	*x = Code{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_vdb_proto_msgTypes[21]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Code) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Code) ProtoMessage() {}
+
+func (x *Code) ProtoReflect() protoreflect.Message {
+	mi := &file_vdb_proto_msgTypes[21]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Code.ProtoReflect.Descriptor instead.
+func (*Code) Descriptor() ([]byte, []int) {
+	return file_vdb_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *Code) GetCode() []byte {
+	if x != nil {
+		return x.Code
+	}
+	return nil
+}
+
+type StorageRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Address []byte             `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	Key     []byte             `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	Block   *BlockNumberOrHash `protobuf:"bytes,3,opt,name=block,proto3" json:"block,omitempty"`
+}
+
+func (x *StorageRequest) Reset() {
+	*x = StorageRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_vdb_proto_msgTypes[22]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StorageRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StorageRequest) ProtoMessage() {}
+
+func (x *StorageRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_vdb_proto_msgTypes[22]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StorageRequest.ProtoReflect.Descriptor instead.
+func (*StorageRequest) Descriptor() ([]byte, []int) {
+	return file_vdb_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *StorageRequest) GetAddress() []byte {
+	if x != nil {
+		return x.Address
+	}
+	return nil
+}
+
+func (x *StorageRequest) GetKey() []byte {
+	if x != nil {
+		return x.Key
+	}
+	return nil
+}
+
+func (x *StorageRequest) GetBlock() *BlockNumberOrHash {
+	if x != nil {
+		return x.Block
+	}
+	return nil
+}
+
+type Storage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Value []byte `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
+}
+
+func (x *Storage) Reset() {
+	*x = Storage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_vdb_proto_msgTypes[23]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Storage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Storage) ProtoMessage() {}
+
+func (x *Storage) ProtoReflect() protoreflect.Message {
+	mi := &file_vdb_proto_msgTypes[23]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Storage.ProtoReflect.Descriptor instead.
+func (*Storage) Descriptor() ([]byte, []int) {
+	return file_vdb_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *Storage) GetValue() []byte {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+var File_vdb_proto protoreflect.FileDescriptor
+
+var file_vdb_proto_rawDesc = []byte{
+	0x0a, 0x09, 0x76, 0x64, 0x62, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x06, 0x76, 0x64, 0x62,
+	0x2e, 0x76, 0x31, 0x22, 0xbf, 0x03, 0x0a, 0x14, 0x53, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x70,
+	0x74, 0x69, 0x6f, 0x6e, 0x53, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x12, 0x1b, 0x0a, 0x09,
+	0x62, 0x61, 0x63, 0x6b, 0x5f, 0x66, 0x69, 0x6c, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52,
+	0x08, 0x62, 0x61, 0x63, 0x6b, 0x46, 0x69, 0x6c, 0x6c, 0x12, 0x24, 0x0a, 0x0e, 0x62, 0x61, 0x63,
+	0x6b, 0x5f, 0x66, 0x69, 0x6c, 0x6c, 0x5f, 0x6f, 0x6e, 0x6c, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x08, 0x52, 0x0c, 0x62, 0x61, 0x63, 0x6b, 0x46, 0x69, 0x6c, 0x6c, 0x4f, 0x6e, 0x6c, 0x79, 0x12,
+	0x14, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x72, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05,
+	0x73, 0x74, 0x61, 0x72, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x65, 0x6e, 0x64, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x03, 0x65, 0x6e, 0x64, 0x12, 0x39, 0x0a, 0x0d, 0x68, 0x65, 0x61, 0x64, 0x65,
+	0x72, 0x5f, 0x66, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14,
+	0x2e, 0x76, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x46, 0x69,
+	0x6c, 0x74, 0x65, 0x72, 0x52, 0x0c, 0x68, 0x65, 0x61, 0x64, 0x65, 0x72, 0x46, 0x69, 0x6c, 0x74,
+	0x65, 0x72, 0x12, 0x2d, 0x0a, 0x09, 0x74, 0x78, 0x5f, 0x66, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x18,
+	0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x76, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x54,
+	0x78, 0x46, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x52, 0x08, 0x74, 0x78, 0x46, 0x69, 0x6c, 0x74, 0x65,
+	0x72, 0x12, 0x3c, 0x0a, 0x0e, 0x72, 0x65, 0x63, 0x65, 0x69, 0x70, 0x74, 0x5f, 0x66, 0x69, 0x6c,
+	0x74, 0x65, 0x72, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x76, 0x64, 0x62, 0x2e,
+	0x76, 0x31, 0x2e, 0x52, 0x65, 0x63, 0x65, 0x69, 0x70, 0x74, 0x46, 0x69, 0x6c, 0x74, 0x65, 0x72,
+	0x52, 0x0d, 0x72, 0x65, 0x63, 0x65, 0x69, 0x70, 0x74, 0x46, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x12,
+	0x36, 0x0a, 0x0c, 0x73, 0x74, 0x61, 0x74, 0x65, 0x5f, 0x66, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x18,
+	0x08, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x76, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x53,
+	0x74, 0x61, 0x74, 0x65, 0x46, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x52, 0x0b, 0x73, 0x74, 0x61, 0x74,
+	0x65, 0x46, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x12, 0x3c, 0x0a, 0x0e, 0x73, 0x74, 0x6f, 0x72, 0x61,
+	0x67, 0x65, 0x5f, 0x66, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x15, 0x2e, 0x76, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65,
+	0x46, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x52, 0x0d, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x46,
+	0x69, 0x6c, 0x74, 0x65, 0x72, 0x12, 0x1e, 0x0a, 0x0a, 0x65, 0x78, 0x70, 0x72, 0x65, 0x73, 0x73,
+	0x69, 0x6f, 0x6e, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x65, 0x78, 0x70, 0x72, 0x65,
+	0x73, 0x73, 0x69, 0x6f, 0x6e, 0x22, 0x38, 0x0a, 0x0c, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x46,
+	0x69, 0x6c, 0x74, 0x65, 0x72, 0x12, 0x10, 0x0a, 0x03, 0x6f, 0x66, 0x66, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x03, 0x6f, 0x66, 0x66, 0x12, 0x16, 0x0a, 0x06, 0x75, 0x6e, 0x63, 0x6c, 0x65,
+	0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x75, 0x6e, 0x63, 0x6c, 0x65, 0x73, 0x22,
+	0x89, 0x02, 0x0a, 0x08, 0x54, 0x78, 0x46, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x12, 0x10, 0x0a, 0x03,
+	0x6f, 0x66, 0x66, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x03, 0x6f, 0x66, 0x66, 0x12, 0x10,
+	0x0a, 0x03, 0x73, 0x72, 0x63, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x03, 0x73, 0x72, 0x63,
+	0x12, 0x10, 0x0a, 0x03, 0x64, 0x73, 0x74, 0x18, 0x03, 0x20, 0x03, 0x28, 0x09, 0x52, 0x03, 0x64,
+	0x73, 0x74, 0x12, 0x29, 0x0a, 0x10, 0x6d, 0x65, 0x74, 0x68, 0x6f, 0x64, 0x5f, 0x73, 0x65, 0x6c,
+	0x65, 0x63, 0x74, 0x6f, 0x72, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0f, 0x6d, 0x65,
+	0x74, 0x68, 0x6f, 0x64, 0x53, 0x65, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x73, 0x12, 0x1b, 0x0a,
+	0x09, 0x6d, 0x69, 0x6e, 0x5f, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x08, 0x6d, 0x69, 0x6e, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x1b, 0x0a, 0x09, 0x6d, 0x61,
+	0x78, 0x5f, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x6d,
+	0x61, 0x78, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x2b, 0x0a, 0x11, 0x63, 0x6f, 0x6e, 0x74, 0x72,
+	0x61, 0x63, 0x74, 0x5f, 0x63, 0x72, 0x65, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x07, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x10, 0x63, 0x6f, 0x6e, 0x74, 0x72, 0x61, 0x63, 0x74, 0x43, 0x72, 0x65, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x12, 0x14, 0x0a, 0x05, 0x74, 0x79, 0x70, 0x65, 0x73, 0x18, 0x08, 0x20,
+	0x03, 0x28, 0x04, 0x52, 0x05, 0x74, 0x79, 0x70, 0x65, 0x73, 0x12, 0x1f, 0x0a, 0x0b, 0x66, 0x61,
+	0x69, 0x6c, 0x65, 0x64, 0x5f, 0x6f, 0x6e, 0x6c, 0x79, 0x18, 0x09, 0x20, 0x01, 0x28, 0x08, 0x52,
+	0x0a, 0x66, 0x61, 0x69, 0x6c, 0x65, 0x64, 0x4f, 0x6e, 0x6c, 0x79, 0x22, 0x8b, 0x01, 0x0a, 0x0d,
+	0x52, 0x65, 0x63, 0x65, 0x69, 0x70, 0x74, 0x46, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x12, 0x10, 0x0a,
+	0x03, 0x6f, 0x66, 0x66, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x03, 0x6f, 0x66, 0x66, 0x12,
+	0x1b, 0x0a, 0x09, 0x6d, 0x61, 0x74, 0x63, 0x68, 0x5f, 0x74, 0x78, 0x73, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x08, 0x6d, 0x61, 0x74, 0x63, 0x68, 0x54, 0x78, 0x73, 0x12, 0x23, 0x0a, 0x0d,
+	0x6c, 0x6f, 0x67, 0x5f, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x65, 0x73, 0x18, 0x03, 0x20,
+	0x03, 0x28, 0x09, 0x52, 0x0c, 0x6c, 0x6f, 0x67, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x65,
+	0x73, 0x12, 0x26, 0x0a, 0x06, 0x74, 0x6f, 0x70, 0x69, 0x63, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x0e, 0x2e, 0x76, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x6f, 0x70, 0x69, 0x63,
+	0x73, 0x52, 0x06, 0x74, 0x6f, 0x70, 0x69, 0x63, 0x73, 0x22, 0x20, 0x0a, 0x06, 0x54, 0x6f, 0x70,
+	0x69, 0x63, 0x73, 0x12, 0x16, 0x0a, 0x06, 0x74, 0x6f, 0x70, 0x69, 0x63, 0x73, 0x18, 0x01, 0x20,
+	0x03, 0x28, 0x09, 0x52, 0x06, 0x74, 0x6f, 0x70, 0x69, 0x63, 0x73, 0x22, 0x6c, 0x0a, 0x0b, 0x53,
+	0x74, 0x61, 0x74, 0x65, 0x46, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x12, 0x10, 0x0a, 0x03, 0x6f, 0x66,
+	0x66, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x03, 0x6f, 0x66, 0x66, 0x12, 0x1c, 0x0a, 0x09,
+	0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52,
+	0x09, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x65, 0x73, 0x12, 0x2d, 0x0a, 0x12, 0x69, 0x6e,
+	0x74, 0x65, 0x72, 0x6d, 0x65, 0x64, 0x69, 0x61, 0x74, 0x65, 0x5f, 0x6e, 0x6f, 0x64, 0x65, 0x73,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x11, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6d, 0x65, 0x64,
+	0x69, 0x61, 0x74, 0x65, 0x4e, 0x6f, 0x64, 0x65, 0x73, 0x22, 0x91, 0x01, 0x0a, 0x0d, 0x53, 0x74,
+	0x6f, 0x72, 0x61, 0x67, 0x65, 0x46, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x12, 0x10, 0x0a, 0x03, 0x6f,
+	0x66, 0x66, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x03, 0x6f, 0x66, 0x66, 0x12, 0x1c, 0x0a,
+	0x09, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09,
+	0x52, 0x09, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x65, 0x73, 0x12, 0x21, 0x0a, 0x0c, 0x73,
+	0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x5f, 0x6b, 0x65, 0x79, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28,
+	0x09, 0x52, 0x0b, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x4b, 0x65, 0x79, 0x73, 0x12, 0x2d,
+	0x0a, 0x12, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6d, 0x65, 0x64, 0x69, 0x61, 0x74, 0x65, 0x5f, 0x6e,
+	0x6f, 0x64, 0x65, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x11, 0x69, 0x6e, 0x74, 0x65,
+	0x72, 0x6d, 0x65, 0x64, 0x69, 0x61, 0x74, 0x65, 0x4e, 0x6f, 0x64, 0x65, 0x73, 0x22, 0xf5, 0x01,
+	0x0a, 0x13, 0x53, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x50, 0x61,
+	0x79, 0x6c, 0x6f, 0x61, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x12, 0x23, 0x0a,
+	0x05, 0x69, 0x70, 0x6c, 0x64, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0d, 0x2e, 0x76,
+	0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x49, 0x50, 0x4c, 0x44, 0x73, 0x52, 0x05, 0x69, 0x70, 0x6c,
+	0x64, 0x73, 0x12, 0x23, 0x0a, 0x05, 0x72, 0x65, 0x6f, 0x72, 0x67, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x0d, 0x2e, 0x76, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x52, 0x65, 0x6f, 0x72, 0x67,
+	0x52, 0x05, 0x72, 0x65, 0x6f, 0x72, 0x67, 0x12, 0x10, 0x0a, 0x03, 0x65, 0x72, 0x72, 0x18, 0x04,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x65, 0x72, 0x72, 0x12, 0x34, 0x0a, 0x04, 0x66, 0x6c, 0x61,
+	0x67, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x20, 0x2e, 0x76, 0x64, 0x62, 0x2e, 0x76, 0x31,
+	0x2e, 0x53, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x50, 0x61, 0x79,
+	0x6c, 0x6f, 0x61, 0x64, 0x2e, 0x46, 0x6c, 0x61, 0x67, 0x52, 0x04, 0x66, 0x6c, 0x61, 0x67, 0x22,
+	0x34, 0x0a, 0x04, 0x46, 0x6c, 0x61, 0x67, 0x12, 0x09, 0x0a, 0x05, 0x45, 0x4d, 0x50, 0x54, 0x59,
+	0x10, 0x00, 0x12, 0x16, 0x0a, 0x12, 0x42, 0x41, 0x43, 0x4b, 0x5f, 0x46, 0x49, 0x4c, 0x4c, 0x5f,
+	0x43, 0x4f, 0x4d, 0x50, 0x4c, 0x45, 0x54, 0x45, 0x10, 0x01, 0x12, 0x09, 0x0a, 0x05, 0x52, 0x45,
+	0x4f, 0x52, 0x47, 0x10, 0x02, 0x22, 0x3e, 0x0a, 0x05, 0x52, 0x65, 0x6f, 0x72, 0x67, 0x12, 0x18,
+	0x0a, 0x07, 0x64, 0x72, 0x6f, 0x70, 0x70, 0x65, 0x64, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0c, 0x52,
+	0x07, 0x64, 0x72, 0x6f, 0x70, 0x70, 0x65, 0x64, 0x12, 0x1b, 0x0a, 0x09, 0x6e, 0x65, 0x77, 0x5f,
+	0x63, 0x68, 0x61, 0x69, 0x6e, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x08, 0x6e, 0x65, 0x77,
+	0x43, 0x68, 0x61, 0x69, 0x6e, 0x22, 0xeb, 0x02, 0x0a, 0x05, 0x49, 0x50, 0x4c, 0x44, 0x73, 0x12,
+	0x21, 0x0a, 0x0c, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x5f, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x4e, 0x75, 0x6d, 0x62,
+	0x65, 0x72, 0x12, 0x29, 0x0a, 0x10, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x5f, 0x64, 0x69, 0x66, 0x66,
+	0x69, 0x63, 0x75, 0x6c, 0x74, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0f, 0x74, 0x6f,
+	0x74, 0x61, 0x6c, 0x44, 0x69, 0x66, 0x66, 0x69, 0x63, 0x75, 0x6c, 0x74, 0x79, 0x12, 0x24, 0x0a,
+	0x06, 0x68, 0x65, 0x61, 0x64, 0x65, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0c, 0x2e,
+	0x76, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x49, 0x50, 0x4c, 0x44, 0x52, 0x06, 0x68, 0x65, 0x61,
+	0x64, 0x65, 0x72, 0x12, 0x24, 0x0a, 0x06, 0x75, 0x6e, 0x63, 0x6c, 0x65, 0x73, 0x18, 0x04, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x0c, 0x2e, 0x76, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x49, 0x50, 0x4c,
+	0x44, 0x52, 0x06, 0x75, 0x6e, 0x63, 0x6c, 0x65, 0x73, 0x12, 0x30, 0x0a, 0x0c, 0x74, 0x72, 0x61,
+	0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x0c, 0x2e, 0x76, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x49, 0x50, 0x4c, 0x44, 0x52, 0x0c, 0x74,
+	0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x28, 0x0a, 0x08, 0x72,
+	0x65, 0x63, 0x65, 0x69, 0x70, 0x74, 0x73, 0x18, 0x06, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0c, 0x2e,
+	0x76, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x49, 0x50, 0x4c, 0x44, 0x52, 0x08, 0x72, 0x65, 0x63,
+	0x65, 0x69, 0x70, 0x74, 0x73, 0x12, 0x32, 0x0a, 0x0b, 0x73, 0x74, 0x61, 0x74, 0x65, 0x5f, 0x6e,
+	0x6f, 0x64, 0x65, 0x73, 0x18, 0x07, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x76, 0x64, 0x62,
+	0x2e, 0x76, 0x31, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x65, 0x4e, 0x6f, 0x64, 0x65, 0x52, 0x0a, 0x73,
+	0x74, 0x61, 0x74, 0x65, 0x4e, 0x6f, 0x64, 0x65, 0x73, 0x12, 0x38, 0x0a, 0x0d, 0x73, 0x74, 0x6f,
+	0x72, 0x61, 0x67, 0x65, 0x5f, 0x6e, 0x6f, 0x64, 0x65, 0x73, 0x18, 0x08, 0x20, 0x03, 0x28, 0x0b,
+	0x32, 0x13, 0x2e, 0x76, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x74, 0x6f, 0x72, 0x61, 0x67,
+	0x65, 0x4e, 0x6f, 0x64, 0x65, 0x52, 0x0c, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x4e, 0x6f,
+	0x64, 0x65, 0x73, 0x22, 0x2c, 0x0a, 0x04, 0x49, 0x50, 0x4c, 0x44, 0x12, 0x10, 0x0a, 0x03, 0x63,
+	0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x63, 0x69, 0x64, 0x12, 0x12, 0x0a,
+	0x04, 0x64, 0x61, 0x74, 0x61, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x64, 0x61, 0x74,
+	0x61, 0x22, 0x8d, 0x01, 0x0a, 0x09, 0x53, 0x74, 0x61, 0x74, 0x65, 0x4e, 0x6f, 0x64, 0x65, 0x12,
+	0x24, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x10, 0x2e,
+	0x76, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x4e, 0x6f, 0x64, 0x65, 0x54, 0x79, 0x70, 0x65, 0x52,
+	0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x24, 0x0a, 0x0e, 0x73, 0x74, 0x61, 0x74, 0x65, 0x5f, 0x6c,
+	0x65, 0x61, 0x66, 0x5f, 0x6b, 0x65, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0c, 0x73,
+	0x74, 0x61, 0x74, 0x65, 0x4c, 0x65, 0x61, 0x66, 0x4b, 0x65, 0x79, 0x12, 0x12, 0x0a, 0x04, 0x70,
+	0x61, 0x74, 0x68, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x70, 0x61, 0x74, 0x68, 0x12,
+	0x20, 0x0a, 0x04, 0x69, 0x70, 0x6c, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0c, 0x2e,
+	0x76, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x49, 0x50, 0x4c, 0x44, 0x52, 0x04, 0x69, 0x70, 0x6c,
+	0x64, 0x22, 0xb9, 0x01, 0x0a, 0x0b, 0x53, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x4e, 0x6f, 0x64,
+	0x65, 0x12, 0x24, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32,
+	0x10, 0x2e, 0x76, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x4e, 0x6f, 0x64, 0x65, 0x54, 0x79, 0x70,
+	0x65, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x24, 0x0a, 0x0e, 0x73, 0x74, 0x61, 0x74, 0x65,
+	0x5f, 0x6c, 0x65, 0x61, 0x66, 0x5f, 0x6b, 0x65, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52,
+	0x0c, 0x73, 0x74, 0x61, 0x74, 0x65, 0x4c, 0x65, 0x61, 0x66, 0x4b, 0x65, 0x79, 0x12, 0x28, 0x0a,
+	0x10, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x5f, 0x6c, 0x65, 0x61, 0x66, 0x5f, 0x6b, 0x65,
+	0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0e, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65,
+	0x4c, 0x65, 0x61, 0x66, 0x4b, 0x65, 0x79, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x61, 0x74, 0x68, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x70, 0x61, 0x74, 0x68, 0x12, 0x20, 0x0a, 0x04, 0x69,
+	0x70, 0x6c, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0c, 0x2e, 0x76, 0x64, 0x62, 0x2e,
+	0x76, 0x31, 0x2e, 0x49, 0x50, 0x4c, 0x44, 0x52, 0x04, 0x69, 0x70, 0x6c, 0x64, 0x22, 0x79, 0x0a,
+	0x11, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x4f, 0x72, 0x48, 0x61,
+	0x73, 0x68, 0x12, 0x18, 0x0a, 0x06, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x03, 0x48, 0x00, 0x52, 0x06, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x12, 0x14, 0x0a, 0x04,
+	0x68, 0x61, 0x73, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x48, 0x00, 0x52, 0x04, 0x68, 0x61,
+	0x73, 0x68, 0x12, 0x2b, 0x0a, 0x11, 0x72, 0x65, 0x71, 0x75, 0x69, 0x72, 0x65, 0x5f, 0x63, 0x61,
+	0x6e, 0x6f, 0x6e, 0x69, 0x63, 0x61, 0x6c, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x10, 0x72,
+	0x65, 0x71, 0x75, 0x69, 0x72, 0x65, 0x43, 0x61, 0x6e, 0x6f, 0x6e, 0x69, 0x63, 0x61, 0x6c, 0x42,
+	0x07, 0x0a, 0x05, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x22, 0x3f, 0x0a, 0x0c, 0x42, 0x6c, 0x6f, 0x63,
+	0x6b, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x2f, 0x0a, 0x05, 0x62, 0x6c, 0x6f, 0x63,
+	0x6b, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x76, 0x64, 0x62, 0x2e, 0x76, 0x31,
+	0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x4f, 0x72, 0x48, 0x61,
+	0x73, 0x68, 0x52, 0x05, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x22, 0x45, 0x0a, 0x05, 0x42, 0x6c, 0x6f,
+	0x63, 0x6b, 0x12, 0x12, 0x0a, 0x04, 0x68, 0x61, 0x73, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c,
+	0x52, 0x04, 0x68, 0x61, 0x73, 0x68, 0x12, 0x16, 0x0a, 0x06, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x06, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x12, 0x10,
+	0x0a, 0x03, 0x72, 0x6c, 0x70, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x03, 0x72, 0x6c, 0x70,
+	0x22, 0x28, 0x0a, 0x12, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x68, 0x61, 0x73, 0x68, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x68, 0x61, 0x73, 0x68, 0x22, 0x79, 0x0a, 0x0b, 0x54, 0x72,
+	0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x61, 0x74,
+	0x61, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x12, 0x1d, 0x0a,
+	0x0a, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x5f, 0x68, 0x61, 0x73, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x0c, 0x52, 0x09, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x48, 0x61, 0x73, 0x68, 0x12, 0x21, 0x0a, 0x0c,
+	0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x5f, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x04, 0x52, 0x0b, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x12,
+	0x14, 0x0a, 0x05, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x18, 0x04, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05,
+	0x69, 0x6e, 0x64, 0x65, 0x78, 0x22, 0x75, 0x0a, 0x07, 0x52, 0x65, 0x63, 0x65, 0x69, 0x70, 0x74,
+	0x12, 0x12, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04,
+	0x64, 0x61, 0x74, 0x61, 0x12, 0x1d, 0x0a, 0x0a, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x5f, 0x68, 0x61,
+	0x73, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x09, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x48,
+	0x61, 0x73, 0x68, 0x12, 0x21, 0x0a, 0x0c, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x5f, 0x6e, 0x75, 0x6d,
+	0x62, 0x65, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0b, 0x62, 0x6c, 0x6f, 0x63, 0x6b,
+	0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x12, 0x14, 0x0a, 0x05, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x22, 0x5b, 0x0a, 0x0e,
+	0x41, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x18,
+	0x0a, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52,
+	0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x12, 0x2f, 0x0a, 0x05, 0x62, 0x6c, 0x6f, 0x63,
+	0x6b, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x76, 0x64, 0x62, 0x2e, 0x76, 0x31,
+	0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x4f, 0x72, 0x48, 0x61,
+	0x73, 0x68, 0x52, 0x05, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x22, 0x79, 0x0a, 0x07, 0x41, 0x63, 0x63,
+	0x6f, 0x75, 0x6e, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x6e, 0x6f, 0x6e, 0x63, 0x65, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x04, 0x52, 0x05, 0x6e, 0x6f, 0x6e, 0x63, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x62, 0x61,
+	0x6c, 0x61, 0x6e, 0x63, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x62, 0x61, 0x6c,
+	0x61, 0x6e, 0x63, 0x65, 0x12, 0x21, 0x0a, 0x0c, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x5f,
+	0x72, 0x6f, 0x6f, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0b, 0x73, 0x74, 0x6f, 0x72,
+	0x61, 0x67, 0x65, 0x52, 0x6f, 0x6f, 0x74, 0x12, 0x1b, 0x0a, 0x09, 0x63, 0x6f, 0x64, 0x65, 0x5f,
+	0x68, 0x61, 0x73, 0x68, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x08, 0x63, 0x6f, 0x64, 0x65,
+	0x48, 0x61, 0x73, 0x68, 0x22, 0x1a, 0x0a, 0x04, 0x43, 0x6f, 0x64, 0x65, 0x12, 0x12, 0x0a, 0x04,
+	0x63, 0x6f, 0x64, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x63, 0x6f, 0x64, 0x65,
+	0x22, 0x6d, 0x0a, 0x0e, 0x53, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x0c, 0x52, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x12, 0x10, 0x0a, 0x03,
+	0x6b, 0x65, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x2f,
+	0x0a, 0x05, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e,
+	0x76, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x4e, 0x75, 0x6d, 0x62,
+	0x65, 0x72, 0x4f, 0x72, 0x48, 0x61, 0x73, 0x68, 0x52, 0x05, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x22,
+	0x1f, 0x0a, 0x07, 0x53, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61,
+	0x6c, 0x75, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65,
+	0x2a, 0x49, 0x0a, 0x08, 0x4e, 0x6f, 0x64, 0x65, 0x54, 0x79, 0x70, 0x65, 0x12, 0x0b, 0x0a, 0x07,
+	0x55, 0x4e, 0x4b, 0x4e, 0x4f, 0x57, 0x4e, 0x10, 0x00, 0x12, 0x0a, 0x0a, 0x06, 0x42, 0x52, 0x41,
+	0x4e, 0x43, 0x48, 0x10, 0x01, 0x12, 0x0d, 0x0a, 0x09, 0x45, 0x58, 0x54, 0x45, 0x4e, 0x53, 0x49,
+	0x4f, 0x4e, 0x10, 0x02, 0x12, 0x08, 0x0a, 0x04, 0x4c, 0x45, 0x41, 0x46, 0x10, 0x03, 0x12, 0x0b,
+	0x0a, 0x07, 0x52, 0x45, 0x4d, 0x4f, 0x56, 0x45, 0x44, 0x10, 0x04, 0x32, 0x9a, 0x03, 0x0a, 0x03,
+	0x56, 0x64, 0x62, 0x12, 0x45, 0x0a, 0x06, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x12, 0x1c, 0x2e,
+	0x76, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74,
+	0x69, 0x6f, 0x6e, 0x53, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x1a, 0x1b, 0x2e, 0x76, 0x64,
+	0x62, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f,
+	0x6e, 0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x30, 0x01, 0x12, 0x2f, 0x0a, 0x08, 0x47, 0x65,
+	0x74, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x12, 0x14, 0x2e, 0x76, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e,
+	0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0d, 0x2e, 0x76,
+	0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x12, 0x41, 0x0a, 0x0e, 0x47,
+	0x65, 0x74, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x1a, 0x2e,
+	0x76, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69,
+	0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x13, 0x2e, 0x76, 0x64, 0x62, 0x2e,
+	0x76, 0x31, 0x2e, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x39,
+	0x0a, 0x0a, 0x47, 0x65, 0x74, 0x52, 0x65, 0x63, 0x65, 0x69, 0x70, 0x74, 0x12, 0x1a, 0x2e, 0x76,
+	0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f,
+	0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0f, 0x2e, 0x76, 0x64, 0x62, 0x2e, 0x76,
+	0x31, 0x2e, 0x52, 0x65, 0x63, 0x65, 0x69, 0x70, 0x74, 0x12, 0x35, 0x0a, 0x0a, 0x47, 0x65, 0x74,
+	0x41, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x16, 0x2e, 0x76, 0x64, 0x62, 0x2e, 0x76, 0x31,
+	0x2e, 0x41, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x0f, 0x2e, 0x76, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x41, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74,
+	0x12, 0x2f, 0x0a, 0x07, 0x47, 0x65, 0x74, 0x43, 0x6f, 0x64, 0x65, 0x12, 0x16, 0x2e, 0x76, 0x64,
+	0x62, 0x2e, 0x76, 0x31, 0x2e, 0x41, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x0c, 0x2e, 0x76, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x6f, 0x64,
+	0x65, 0x12, 0x35, 0x0a, 0x0a, 0x47, 0x65, 0x74, 0x53, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x12,
+	0x16, 0x2e, 0x76, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0f, 0x2e, 0x76, 0x64, 0x62, 0x2e, 0x76, 0x31,
+	0x2e, 0x53, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x42, 0x31, 0x5a, 0x2f, 0x67, 0x69, 0x74, 0x68,
+	0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x76, 0x75, 0x6c, 0x63, 0x61, 0x6e, 0x69, 0x7a, 0x65,
+	0x2f, 0x69, 0x70, 0x6c, 0x64, 0x2d, 0x65, 0x74, 0x68, 0x2d, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72,
+	0x2f, 0x70, 0x6b, 0x67, 0x2f, 0x76, 0x64, 0x62, 0x2f, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x33,
+}
+
+var (
+	file_vdb_proto_rawDescOnce sync.Once
+	file_vdb_proto_rawDescData = file_vdb_proto_rawDesc
+)
+
+func file_vdb_proto_rawDescGZIP() []byte {
+	file_vdb_proto_rawDescOnce.Do(func() {
+		file_vdb_proto_rawDescData = protoimpl.X.CompressGZIP(file_vdb_proto_rawDescData)
+	})
+	return file_vdb_proto_rawDescData
+}
+
+var file_vdb_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_vdb_proto_msgTypes = make([]protoimpl.MessageInfo, 24)
+var file_vdb_proto_goTypes = []interface{}{
+	(NodeType)(0),                 // 0: vdb.v1.NodeType
+	(SubscriptionPayload_Flag)(0), // 1: vdb.v1.SubscriptionPayload.Flag
+	(*SubscriptionSettings)(nil),  // 2: vdb.v1.SubscriptionSettings
+	(*HeaderFilter)(nil),          // 3: vdb.v1.HeaderFilter
+	(*TxFilter)(nil),              // 4: vdb.v1.TxFilter
+	(*ReceiptFilter)(nil),         // 5: vdb.v1.ReceiptFilter
+	(*Topics)(nil),                // 6: vdb.v1.Topics
+	(*StateFilter)(nil),           // 7: vdb.v1.StateFilter
+	(*StorageFilter)(nil),         // 8: vdb.v1.StorageFilter
+	(*SubscriptionPayload)(nil),   // 9: vdb.v1.SubscriptionPayload
+	(*Reorg)(nil),                 // 10: vdb.v1.Reorg
+	(*IPLDs)(nil),                 // 11: vdb.v1.IPLDs
+	(*IPLD)(nil),                  // 12: vdb.v1.IPLD
+	(*StateNode)(nil),             // 13: vdb.v1.StateNode
+	(*StorageNode)(nil),           // 14: vdb.v1.StorageNode
+	(*BlockNumberOrHash)(nil),     // 15: vdb.v1.BlockNumberOrHash
+	(*BlockRequest)(nil),          // 16: vdb.v1.BlockRequest
+	(*Block)(nil),                 // 17: vdb.v1.Block
+	(*TransactionRequest)(nil),    // 18: vdb.v1.TransactionRequest
+	(*Transaction)(nil),           // 19: vdb.v1.Transaction
+	(*Receipt)(nil),               // 20: vdb.v1.Receipt
+	(*AccountRequest)(nil),        // 21: vdb.v1.AccountRequest
+	(*Account)(nil),               // 22: vdb.v1.Account
+	(*Code)(nil),                  // 23: vdb.v1.Code
+	(*StorageRequest)(nil),        // 24: vdb.v1.StorageRequest
+	(*Storage)(nil),               // 25: vdb.v1.Storage
+}
+var file_vdb_proto_depIdxs = []int32{
+	3,  // 0: vdb.v1.SubscriptionSettings.header_filter:type_name -> vdb.v1.HeaderFilter
+	4,  // 1: vdb.v1.SubscriptionSettings.tx_filter:type_name -> vdb.v1.TxFilter
+	5,  // 2: vdb.v1.SubscriptionSettings.receipt_filter:type_name -> vdb.v1.ReceiptFilter
+	7,  // 3: vdb.v1.SubscriptionSettings.state_filter:type_name -> vdb.v1.StateFilter
+	8,  // 4: vdb.v1.SubscriptionSettings.storage_filter:type_name -> vdb.v1.StorageFilter
+	6,  // 5: vdb.v1.ReceiptFilter.topics:type_name -> vdb.v1.Topics
+	11, // 6: vdb.v1.SubscriptionPayload.iplds:type_name -> vdb.v1.IPLDs
+	10, // 7: vdb.v1.SubscriptionPayload.reorg:type_name -> vdb.v1.Reorg
+	1,  // 8: vdb.v1.SubscriptionPayload.flag:type_name -> vdb.v1.SubscriptionPayload.Flag
+	12, // 9: vdb.v1.IPLDs.header:type_name -> vdb.v1.IPLD
+	12, // 10: vdb.v1.IPLDs.uncles:type_name -> vdb.v1.IPLD
+	12, // 11: vdb.v1.IPLDs.transactions:type_name -> vdb.v1.IPLD
+	12, // 12: vdb.v1.IPLDs.receipts:type_name -> vdb.v1.IPLD
+	13, // 13: vdb.v1.IPLDs.state_nodes:type_name -> vdb.v1.StateNode
+	14, // 14: vdb.v1.IPLDs.storage_nodes:type_name -> vdb.v1.StorageNode
+	0,  // 15: vdb.v1.StateNode.type:type_name -> vdb.v1.NodeType
+	12, // 16: vdb.v1.StateNode.ipld:type_name -> vdb.v1.IPLD
+	0,  // 17: vdb.v1.StorageNode.type:type_name -> vdb.v1.NodeType
+	12, // 18: vdb.v1.StorageNode.ipld:type_name -> vdb.v1.IPLD
+	15, // 19: vdb.v1.BlockRequest.block:type_name -> vdb.v1.BlockNumberOrHash
+	15, // 20: vdb.v1.AccountRequest.block:type_name -> vdb.v1.BlockNumberOrHash
+	15, // 21: vdb.v1.StorageRequest.block:type_name -> vdb.v1.BlockNumberOrHash
+	2,  // 22: vdb.v1.Vdb.Stream:input_type -> vdb.v1.SubscriptionSettings
+	16, // 23: vdb.v1.Vdb.GetBlock:input_type -> vdb.v1.BlockRequest
+	18, // 24: vdb.v1.Vdb.GetTransaction:input_type -> vdb.v1.TransactionRequest
+	18, // 25: vdb.v1.Vdb.GetReceipt:input_type -> vdb.v1.TransactionRequest
+	21, // 26: vdb.v1.Vdb.GetAccount:input_type -> vdb.v1.AccountRequest
+	21, // 27: vdb.v1.Vdb.GetCode:input_type -> vdb.v1.AccountRequest
+	24, // 28: vdb.v1.Vdb.GetStorage:input_type -> vdb.v1.StorageRequest
+	9,  // 29: vdb.v1.Vdb.Stream:output_type -> vdb.v1.SubscriptionPayload
+	17, // 30: vdb.v1.Vdb.GetBlock:output_type -> vdb.v1.Block
+	19, // 31: vdb.v1.Vdb.GetTransaction:output_type -> vdb.v1.Transaction
+	20, // 32: vdb.v1.Vdb.GetReceipt:output_type -> vdb.v1.Receipt
+	22, // 33: vdb.v1.Vdb.GetAccount:output_type -> vdb.v1.Account
+	23, // 34: vdb.v1.Vdb.GetCode:output_type -> vdb.v1.Code
+	25, // 35: vdb.v1.Vdb.GetStorage:output_type -> vdb.v1.Storage
+	29, // [29:36] is the sub-list for method output_type
+	22, // [22:29] is the sub-list for method input_type
+	22, // [22:22] is the sub-list for extension type_name
+	22, // [22:22] is the sub-list for extension extendee
+	0,  // [0:22] is the sub-list for field type_name
+}
+
+func init() { file_vdb_proto_init() }
+func file_vdb_proto_init() {
+	if File_vdb_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_vdb_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SubscriptionSettings); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_vdb_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*HeaderFilter); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_vdb_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TxFilter); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_vdb_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ReceiptFilter); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_vdb_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Topics); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_vdb_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StateFilter); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_vdb_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StorageFilter); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_vdb_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SubscriptionPayload); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_vdb_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Reorg); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_vdb_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*IPLDs); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_vdb_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*IPLD); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_vdb_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StateNode); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_vdb_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StorageNode); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_vdb_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BlockNumberOrHash); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_vdb_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BlockRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_vdb_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Block); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_vdb_proto_msgTypes[16].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TransactionRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_vdb_proto_msgTypes[17].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Transaction); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_vdb_proto_msgTypes[18].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Receipt); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_vdb_proto_msgTypes[19].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AccountRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_vdb_proto_msgTypes[20].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Account); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_vdb_proto_msgTypes[21].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Code); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_vdb_proto_msgTypes[22].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StorageRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_vdb_proto_msgTypes[23].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Storage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_vdb_proto_msgTypes[13].OneofWrappers = []interface{}{
+		(*BlockNumberOrHash_Number)(nil),
+		(*BlockNumberOrHash_Hash)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_vdb_proto_rawDesc,
+			NumEnums:      2,
+			NumMessages:   24,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_vdb_proto_goTypes,
+		DependencyIndexes: file_vdb_proto_depIdxs,
+		EnumInfos:         file_vdb_proto_enumTypes,
+		MessageInfos:      file_vdb_proto_msgTypes,
+	}.Build()
+	File_vdb_proto = out.File
+	file_vdb_proto_rawDesc = nil
+	file_vdb_proto_goTypes = nil
+	file_vdb_proto_depIdxs = nil
+}
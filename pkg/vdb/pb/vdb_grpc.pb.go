@@ -0,0 +1,389 @@
+// VulcanizeDB
+// Copyright © 2022 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             v3.19.1
+// source: vdb.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	Vdb_Stream_FullMethodName         = "/vdb.v1.Vdb/Stream"
+	Vdb_GetBlock_FullMethodName       = "/vdb.v1.Vdb/GetBlock"
+	Vdb_GetTransaction_FullMethodName = "/vdb.v1.Vdb/GetTransaction"
+	Vdb_GetReceipt_FullMethodName     = "/vdb.v1.Vdb/GetReceipt"
+	Vdb_GetAccount_FullMethodName     = "/vdb.v1.Vdb/GetAccount"
+	Vdb_GetCode_FullMethodName        = "/vdb.v1.Vdb/GetCode"
+	Vdb_GetStorage_FullMethodName     = "/vdb.v1.Vdb/GetStorage"
+)
+
+// VdbClient is the client API for Vdb service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type VdbClient interface {
+	// Stream subscribes to the eth ipld stream with the provided settings
+	Stream(ctx context.Context, in *SubscriptionSettings, opts ...grpc.CallOption) (Vdb_StreamClient, error)
+	// GetBlock returns the rlp encoded block at the given number or hash
+	GetBlock(ctx context.Context, in *BlockRequest, opts ...grpc.CallOption) (*Block, error)
+	// GetTransaction returns the binary encoded transaction with the given hash
+	GetTransaction(ctx context.Context, in *TransactionRequest, opts ...grpc.CallOption) (*Transaction, error)
+	// GetReceipt returns the binary encoded receipt of the transaction with the given hash
+	GetReceipt(ctx context.Context, in *TransactionRequest, opts ...grpc.CallOption) (*Receipt, error)
+	// GetAccount returns the state account of the address at the given block
+	GetAccount(ctx context.Context, in *AccountRequest, opts ...grpc.CallOption) (*Account, error)
+	// GetCode returns the code of the address at the given block
+	GetCode(ctx context.Context, in *AccountRequest, opts ...grpc.CallOption) (*Code, error)
+	// GetStorage returns the value of the storage slot of the address at the given block
+	GetStorage(ctx context.Context, in *StorageRequest, opts ...grpc.CallOption) (*Storage, error)
+}
+
+type vdbClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewVdbClient(cc grpc.ClientConnInterface) VdbClient {
+	return &vdbClient{cc}
+}
+
+func (c *vdbClient) Stream(ctx context.Context, in *SubscriptionSettings, opts ...grpc.CallOption) (Vdb_StreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Vdb_ServiceDesc.Streams[0], Vdb_Stream_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &vdbStreamClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Vdb_StreamClient interface {
+	Recv() (*SubscriptionPayload, error)
+	grpc.ClientStream
+}
+
+type vdbStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *vdbStreamClient) Recv() (*SubscriptionPayload, error) {
+	m := new(SubscriptionPayload)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *vdbClient) GetBlock(ctx context.Context, in *BlockRequest, opts ...grpc.CallOption) (*Block, error) {
+	out := new(Block)
+	err := c.cc.Invoke(ctx, Vdb_GetBlock_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *vdbClient) GetTransaction(ctx context.Context, in *TransactionRequest, opts ...grpc.CallOption) (*Transaction, error) {
+	out := new(Transaction)
+	err := c.cc.Invoke(ctx, Vdb_GetTransaction_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *vdbClient) GetReceipt(ctx context.Context, in *TransactionRequest, opts ...grpc.CallOption) (*Receipt, error) {
+	out := new(Receipt)
+	err := c.cc.Invoke(ctx, Vdb_GetReceipt_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *vdbClient) GetAccount(ctx context.Context, in *AccountRequest, opts ...grpc.CallOption) (*Account, error) {
+	out := new(Account)
+	err := c.cc.Invoke(ctx, Vdb_GetAccount_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *vdbClient) GetCode(ctx context.Context, in *AccountRequest, opts ...grpc.CallOption) (*Code, error) {
+	out := new(Code)
+	err := c.cc.Invoke(ctx, Vdb_GetCode_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *vdbClient) GetStorage(ctx context.Context, in *StorageRequest, opts ...grpc.CallOption) (*Storage, error) {
+	out := new(Storage)
+	err := c.cc.Invoke(ctx, Vdb_GetStorage_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// VdbServer is the server API for Vdb service.
+// All implementations must embed UnimplementedVdbServer
+// for forward compatibility
+type VdbServer interface {
+	// Stream subscribes to the eth ipld stream with the provided settings
+	Stream(*SubscriptionSettings, Vdb_StreamServer) error
+	// GetBlock returns the rlp encoded block at the given number or hash
+	GetBlock(context.Context, *BlockRequest) (*Block, error)
+	// GetTransaction returns the binary encoded transaction with the given hash
+	GetTransaction(context.Context, *TransactionRequest) (*Transaction, error)
+	// GetReceipt returns the binary encoded receipt of the transaction with the given hash
+	GetReceipt(context.Context, *TransactionRequest) (*Receipt, error)
+	// GetAccount returns the state account of the address at the given block
+	GetAccount(context.Context, *AccountRequest) (*Account, error)
+	// GetCode returns the code of the address at the given block
+	GetCode(context.Context, *AccountRequest) (*Code, error)
+	// GetStorage returns the value of the storage slot of the address at the given block
+	GetStorage(context.Context, *StorageRequest) (*Storage, error)
+	mustEmbedUnimplementedVdbServer()
+}
+
+// UnimplementedVdbServer must be embedded to have forward compatible implementations.
+type UnimplementedVdbServer struct {
+}
+
+func (UnimplementedVdbServer) Stream(*SubscriptionSettings, Vdb_StreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method Stream not implemented")
+}
+func (UnimplementedVdbServer) GetBlock(context.Context, *BlockRequest) (*Block, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBlock not implemented")
+}
+func (UnimplementedVdbServer) GetTransaction(context.Context, *TransactionRequest) (*Transaction, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTransaction not implemented")
+}
+func (UnimplementedVdbServer) GetReceipt(context.Context, *TransactionRequest) (*Receipt, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetReceipt not implemented")
+}
+func (UnimplementedVdbServer) GetAccount(context.Context, *AccountRequest) (*Account, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAccount not implemented")
+}
+func (UnimplementedVdbServer) GetCode(context.Context, *AccountRequest) (*Code, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCode not implemented")
+}
+func (UnimplementedVdbServer) GetStorage(context.Context, *StorageRequest) (*Storage, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStorage not implemented")
+}
+func (UnimplementedVdbServer) mustEmbedUnimplementedVdbServer() {}
+
+// UnsafeVdbServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to VdbServer will
+// result in compilation errors.
+type UnsafeVdbServer interface {
+	mustEmbedUnimplementedVdbServer()
+}
+
+func RegisterVdbServer(s grpc.ServiceRegistrar, srv VdbServer) {
+	s.RegisterService(&Vdb_ServiceDesc, srv)
+}
+
+func _Vdb_Stream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscriptionSettings)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(VdbServer).Stream(m, &vdbStreamServer{stream})
+}
+
+type Vdb_StreamServer interface {
+	Send(*SubscriptionPayload) error
+	grpc.ServerStream
+}
+
+type vdbStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *vdbStreamServer) Send(m *SubscriptionPayload) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Vdb_GetBlock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BlockRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VdbServer).GetBlock(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Vdb_GetBlock_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VdbServer).GetBlock(ctx, req.(*BlockRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Vdb_GetTransaction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TransactionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VdbServer).GetTransaction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Vdb_GetTransaction_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VdbServer).GetTransaction(ctx, req.(*TransactionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Vdb_GetReceipt_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TransactionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VdbServer).GetReceipt(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Vdb_GetReceipt_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VdbServer).GetReceipt(ctx, req.(*TransactionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Vdb_GetAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VdbServer).GetAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Vdb_GetAccount_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VdbServer).GetAccount(ctx, req.(*AccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Vdb_GetCode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VdbServer).GetCode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Vdb_GetCode_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VdbServer).GetCode(ctx, req.(*AccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Vdb_GetStorage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StorageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VdbServer).GetStorage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Vdb_GetStorage_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VdbServer).GetStorage(ctx, req.(*StorageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Vdb_ServiceDesc is the grpc.ServiceDesc for Vdb service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Vdb_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "vdb.v1.Vdb",
+	HandlerType: (*VdbServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetBlock",
+			Handler:    _Vdb_GetBlock_Handler,
+		},
+		{
+			MethodName: "GetTransaction",
+			Handler:    _Vdb_GetTransaction_Handler,
+		},
+		{
+			MethodName: "GetReceipt",
+			Handler:    _Vdb_GetReceipt_Handler,
+		},
+		{
+			MethodName: "GetAccount",
+			Handler:    _Vdb_GetAccount_Handler,
+		},
+		{
+			MethodName: "GetCode",
+			Handler:    _Vdb_GetCode_Handler,
+		},
+		{
+			MethodName: "GetStorage",
+			Handler:    _Vdb_GetStorage_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Stream",
+			Handler:       _Vdb_Stream_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "vdb.proto",
+}
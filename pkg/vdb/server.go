@@ -0,0 +1,285 @@
+// VulcanizeDB
+// Copyright © 2022 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package vdb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/vulcanize/ipld-eth-server/pkg/serve"
+	"github.com/vulcanize/ipld-eth-server/pkg/vdb/pb"
+)
+
+// Backend is the subset of eth.Backend used to serve the unary lookups
+type Backend interface {
+	BlockByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*types.Block, error)
+	GetTransaction(ctx context.Context, txHash common.Hash) (*types.Transaction, common.Hash, uint64, uint64, error)
+	GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error)
+	GetAccountByNumberOrHash(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (*types.StateAccount, error)
+	GetCodeByNumberOrHash(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) ([]byte, error)
+	GetStorageByNumberOrHash(ctx context.Context, address common.Address, key common.Hash, blockNrOrHash rpc.BlockNumberOrHash) (hexutil.Bytes, error)
+}
+
+// Server implements the Vdb gRPC service defined in pb/vdb.proto
+type Server struct {
+	pb.UnimplementedVdbServer
+	stream  serve.Server
+	backend Backend
+}
+
+// NewServer creates a new Server, streaming from the serve.Server and serving lookups from the Backend
+func NewServer(stream serve.Server, backend Backend) *Server {
+	return &Server{
+		stream:  stream,
+		backend: backend,
+	}
+}
+
+// StartGRPCEndpoint registers the Server with a new gRPC server and serves it on the endpoint
+// The server applies no connection timeout since Stream calls last for the lifetime of the subscription
+func StartGRPCEndpoint(endpoint string, srv *Server) (net.Listener, *grpc.Server, error) {
+	listener, err := net.Listen("tcp", endpoint)
+	if err != nil {
+		return nil, nil, err
+	}
+	grpcSrv := grpc.NewServer()
+	pb.RegisterVdbServer(grpcSrv, srv)
+	go grpcSrv.Serve(listener)
+	log.Infof("gRPC endpoint opened %v", listener.Addr())
+	return listener, grpcSrv, nil
+}
+
+// Stream subscribes to the vdb stream with the provided settings and sends each payload to the client
+// The call completes when the subscription ends, or is cancelled by the client
+func (s *Server) Stream(settings *pb.SubscriptionSettings, srv pb.Vdb_StreamServer) error {
+	params, err := SubscriptionSettings(settings)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	id := rpc.NewID()
+	payloadChannel := make(chan serve.SubscriptionPayload, serve.PayloadChanBufferSize)
+	quitChan := make(chan bool, 1)
+	go s.stream.Subscribe(id, payloadChannel, quitChan, params)
+	log.Infof("new eth ipld gRPC subscription %s", id)
+
+	send := func(packet serve.SubscriptionPayload) error {
+		msg, err := SubscriptionPayload(packet)
+		if err != nil {
+			log.Errorf("failed to convert eth ipld payload for gRPC subscription %s: %v", id, err)
+			msg = &pb.SubscriptionPayload{Height: packet.Height, Err: err.Error()}
+		}
+		return srv.Send(msg)
+	}
+	ctx := srv.Context()
+	for {
+		select {
+		case packet := <-payloadChannel:
+			if err := send(packet); err != nil {
+				log.Errorf("failed to send eth ipld payload to gRPC subscription %s: %v", id, err)
+				s.stream.Unsubscribe(id)
+				return err
+			}
+		case <-ctx.Done():
+			s.stream.Unsubscribe(id)
+			return status.FromContextError(ctx.Err()).Err()
+		case <-quitChan:
+			// don't need to unsubscribe from the watcher, the service does so before sending the quit signal this way
+			// relay anything sent before the quit signal, e.g. the error which ended the subscription
+			for {
+				select {
+				case packet := <-payloadChannel:
+					if err := send(packet); err != nil {
+						return err
+					}
+				default:
+					return nil
+				}
+			}
+		}
+	}
+}
+
+// GetBlock returns the rlp encoded block at the requested number or hash
+func (s *Server) GetBlock(ctx context.Context, req *pb.BlockRequest) (*pb.Block, error) {
+	blockNrOrHash, err := blockNumberOrHash(req.GetBlock())
+	if err != nil {
+		return nil, err
+	}
+	block, err := s.backend.BlockByNumberOrHash(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, lookupError(err)
+	}
+	if block == nil {
+		return nil, status.Error(codes.NotFound, "block not found")
+	}
+	data, err := rlp.EncodeToBytes(block)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &pb.Block{
+		Hash:   block.Hash().Bytes(),
+		Number: block.NumberU64(),
+		Rlp:    data,
+	}, nil
+}
+
+// GetTransaction returns the binary encoded transaction with the requested hash
+func (s *Server) GetTransaction(ctx context.Context, req *pb.TransactionRequest) (*pb.Transaction, error) {
+	hash, err := hashArgument(req.GetHash())
+	if err != nil {
+		return nil, err
+	}
+	tx, blockHash, blockNumber, index, err := s.backend.GetTransaction(ctx, hash)
+	if err != nil {
+		return nil, lookupError(err)
+	}
+	if tx == nil {
+		return nil, status.Error(codes.NotFound, "transaction not found")
+	}
+	data, err := tx.MarshalBinary()
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &pb.Transaction{
+		Data:        data,
+		BlockHash:   blockHash.Bytes(),
+		BlockNumber: blockNumber,
+		Index:       index,
+	}, nil
+}
+
+// GetReceipt returns the binary encoded receipt of the transaction with the requested hash
+func (s *Server) GetReceipt(ctx context.Context, req *pb.TransactionRequest) (*pb.Receipt, error) {
+	hash, err := hashArgument(req.GetHash())
+	if err != nil {
+		return nil, err
+	}
+	tx, blockHash, blockNumber, index, err := s.backend.GetTransaction(ctx, hash)
+	if err != nil {
+		return nil, lookupError(err)
+	}
+	if tx == nil {
+		return nil, status.Error(codes.NotFound, "transaction not found")
+	}
+	receipts, err := s.backend.GetReceipts(ctx, blockHash)
+	if err != nil {
+		return nil, lookupError(err)
+	}
+	if uint64(len(receipts)) <= index {
+		return nil, status.Error(codes.NotFound, "receipt not found")
+	}
+	data, err := receipts[index].MarshalBinary()
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &pb.Receipt{
+		Data:        data,
+		BlockHash:   blockHash.Bytes(),
+		BlockNumber: blockNumber,
+		Index:       index,
+	}, nil
+}
+
+// GetAccount returns the state account of the requested address at the requested block
+func (s *Server) GetAccount(ctx context.Context, req *pb.AccountRequest) (*pb.Account, error) {
+	address, blockNrOrHash, err := accountArguments(req.GetAddress(), req.GetBlock())
+	if err != nil {
+		return nil, err
+	}
+	account, err := s.backend.GetAccountByNumberOrHash(ctx, address, blockNrOrHash)
+	if err != nil {
+		return nil, lookupError(err)
+	}
+	if account == nil {
+		return nil, status.Error(codes.NotFound, "account not found")
+	}
+	msg := &pb.Account{
+		Nonce:       account.Nonce,
+		StorageRoot: account.Root.Bytes(),
+		CodeHash:    account.CodeHash,
+	}
+	if account.Balance != nil {
+		msg.Balance = account.Balance.Bytes()
+	}
+	return msg, nil
+}
+
+// GetCode returns the code of the requested address at the requested block
+func (s *Server) GetCode(ctx context.Context, req *pb.AccountRequest) (*pb.Code, error) {
+	address, blockNrOrHash, err := accountArguments(req.GetAddress(), req.GetBlock())
+	if err != nil {
+		return nil, err
+	}
+	code, err := s.backend.GetCodeByNumberOrHash(ctx, address, blockNrOrHash)
+	if err != nil {
+		return nil, lookupError(err)
+	}
+	return &pb.Code{Code: code}, nil
+}
+
+// GetStorage returns the value of the requested storage slot of the address at the requested block
+func (s *Server) GetStorage(ctx context.Context, req *pb.StorageRequest) (*pb.Storage, error) {
+	address, blockNrOrHash, err := accountArguments(req.GetAddress(), req.GetBlock())
+	if err != nil {
+		return nil, err
+	}
+	key, err := hashArgument(req.GetKey())
+	if err != nil {
+		return nil, err
+	}
+	value, err := s.backend.GetStorageByNumberOrHash(ctx, address, key, blockNrOrHash)
+	if err != nil {
+		return nil, lookupError(err)
+	}
+	return &pb.Storage{Value: value}, nil
+}
+
+func hashArgument(hash []byte) (common.Hash, error) {
+	if len(hash) != common.HashLength {
+		return common.Hash{}, status.Errorf(codes.InvalidArgument, "hash must be %d bytes", common.HashLength)
+	}
+	return common.BytesToHash(hash), nil
+}
+
+func accountArguments(address []byte, block *pb.BlockNumberOrHash) (common.Address, rpc.BlockNumberOrHash, error) {
+	if len(address) != common.AddressLength {
+		return common.Address{}, rpc.BlockNumberOrHash{}, status.Errorf(codes.InvalidArgument, "address must be %d bytes", common.AddressLength)
+	}
+	blockNrOrHash, err := blockNumberOrHash(block)
+	return common.BytesToAddress(address), blockNrOrHash, err
+}
+
+// lookupError converts a backend error into a gRPC status error, missing rows are reported as NotFound
+func lookupError(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return status.Error(codes.NotFound, "not found")
+	}
+	return status.Error(codes.Internal, err.Error())
+}
@@ -0,0 +1,259 @@
+// VulcanizeDB
+// Copyright © 2022 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package vdb_test
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"math/big"
+	"net"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	"github.com/vulcanize/ipld-eth-server/pkg/eth"
+	"github.com/vulcanize/ipld-eth-server/pkg/eth/test_helpers"
+	"github.com/vulcanize/ipld-eth-server/pkg/serve"
+	"github.com/vulcanize/ipld-eth-server/pkg/vdb"
+	"github.com/vulcanize/ipld-eth-server/pkg/vdb/pb"
+)
+
+// mockStreamServer sends the queued payloads to each subscriber, quitting afterwards if quit is set
+type mockStreamServer struct {
+	serve.Server
+	payloads     []serve.SubscriptionPayload
+	quit         bool
+	params       chan eth.SubscriptionSettings
+	unsubscribed chan rpc.ID
+}
+
+func (s *mockStreamServer) Subscribe(id rpc.ID, sub chan<- serve.SubscriptionPayload, quitChan chan<- bool, params eth.SubscriptionSettings) {
+	s.params <- params
+	for _, payload := range s.payloads {
+		sub <- payload
+	}
+	if s.quit {
+		quitChan <- true
+	}
+}
+
+func (s *mockStreamServer) Unsubscribe(id rpc.ID) {
+	s.unsubscribed <- id
+}
+
+// mockBackend serves the mock block, its first transaction and receipt, and a single account
+type mockBackend struct{}
+
+func (b mockBackend) BlockByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*types.Block, error) {
+	if number, ok := blockNrOrHash.Number(); ok && number.Int64() == test_helpers.BlockNumber.Int64() {
+		return test_helpers.MockBlock, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (b mockBackend) GetTransaction(ctx context.Context, txHash common.Hash) (*types.Transaction, common.Hash, uint64, uint64, error) {
+	if txHash != test_helpers.MockTransactions[0].Hash() {
+		return nil, common.Hash{}, 0, 0, sql.ErrNoRows
+	}
+	return test_helpers.MockTransactions[0], test_helpers.MockBlock.Hash(), test_helpers.BlockNumber.Uint64(), 0, nil
+}
+
+func (b mockBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
+	return test_helpers.MockReceipts, nil
+}
+
+func (b mockBackend) GetAccountByNumberOrHash(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (*types.StateAccount, error) {
+	return &types.StateAccount{Nonce: 1, Balance: big.NewInt(1000), CodeHash: test_helpers.ContractCodeHash.Bytes()}, nil
+}
+
+func (b mockBackend) GetCodeByNumberOrHash(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) ([]byte, error) {
+	return test_helpers.MockContractByteCode, nil
+}
+
+func (b mockBackend) GetStorageByNumberOrHash(ctx context.Context, address common.Address, key common.Hash, blockNrOrHash rpc.BlockNumberOrHash) (hexutil.Bytes, error) {
+	return hexutil.Bytes{1}, nil
+}
+
+var _ = Describe("gRPC server", func() {
+	var (
+		stream  *mockStreamServer
+		grpcSrv *grpc.Server
+		conn    *grpc.ClientConn
+		client  pb.VdbClient
+		ctx     context.Context
+	)
+	BeforeEach(func() {
+		data, err := rlp.EncodeToBytes(&test_helpers.MockIPLDs)
+		Expect(err).ToNot(HaveOccurred())
+		stream = &mockStreamServer{
+			payloads: []serve.SubscriptionPayload{
+				{Data: data, Height: test_helpers.BlockNumber.Int64()},
+				{Flag: serve.BackFillCompleteFlag},
+			},
+			params:       make(chan eth.SubscriptionSettings, 1),
+			unsubscribed: make(chan rpc.ID, 1),
+		}
+		var listener net.Listener
+		listener, grpcSrv, err = vdb.StartGRPCEndpoint("127.0.0.1:0", vdb.NewServer(stream, mockBackend{}))
+		Expect(err).ToNot(HaveOccurred())
+		conn, err = grpc.Dial(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+		Expect(err).ToNot(HaveOccurred())
+		client = pb.NewVdbClient(conn)
+		ctx = context.Background()
+	})
+	AfterEach(func() {
+		conn.Close()
+		grpcSrv.Stop()
+	})
+
+	Describe("Stream", func() {
+		It("Subscribes with the converted settings and streams the payloads as protobuf IPLDs", func() {
+			stream.quit = true
+			sub, err := client.Stream(ctx, &pb.SubscriptionSettings{
+				Start:         1,
+				TxFilter:      &pb.TxFilter{MinValue: "1000", Types: []uint64{2}},
+				ReceiptFilter: &pb.ReceiptFilter{Topics: []*pb.Topics{{Topics: []string{"0x01"}}}},
+				StateFilter:   &pb.StateFilter{Off: true},
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			payload, err := sub.Recv()
+			Expect(err).ToNot(HaveOccurred())
+			params := <-stream.params
+			Expect(params.Start.Int64()).To(Equal(int64(1)))
+			Expect(params.End.Int64()).To(Equal(int64(0)))
+			Expect(params.TxFilter.MinValue.Int64()).To(Equal(int64(1000)))
+			Expect(params.TxFilter.MaxValue).To(BeNil())
+			Expect(params.TxFilter.Types).To(Equal([]uint64{2}))
+			Expect(params.ReceiptFilter.Topics).To(Equal([][]string{{"0x01"}}))
+			Expect(params.StateFilter.Off).To(BeTrue())
+			Expect(params.Encoding).To(Equal(eth.RLPEncoding))
+
+			Expect(payload.Height).To(Equal(test_helpers.BlockNumber.Int64()))
+			Expect(payload.Flag).To(Equal(pb.SubscriptionPayload_EMPTY))
+			iplds := payload.GetIplds()
+			Expect(iplds.BlockNumber).To(Equal(test_helpers.MockIPLDs.BlockNumber.String()))
+			Expect(iplds.Header.Cid).To(Equal(test_helpers.MockIPLDs.Header.CID))
+			Expect(iplds.Header.Data).To(Equal(test_helpers.MockIPLDs.Header.Data))
+			Expect(len(iplds.Transactions)).To(Equal(len(test_helpers.MockIPLDs.Transactions)))
+			Expect(len(iplds.Receipts)).To(Equal(len(test_helpers.MockIPLDs.Receipts)))
+			Expect(len(iplds.StateNodes)).To(Equal(len(test_helpers.MockIPLDs.StateNodes)))
+			Expect(iplds.StateNodes[0].Type).To(Equal(pb.NodeType_LEAF))
+			Expect(iplds.StateNodes[0].StateLeafKey).To(Equal(test_helpers.MockIPLDs.StateNodes[0].StateLeafKey.Bytes()))
+			Expect(len(iplds.StorageNodes)).To(Equal(len(test_helpers.MockIPLDs.StorageNodes)))
+
+			payload, err = sub.Recv()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(payload.Flag).To(Equal(pb.SubscriptionPayload_BACK_FILL_COMPLETE))
+
+			_, err = sub.Recv()
+			Expect(err).To(Equal(io.EOF))
+		})
+
+		It("Unsubscribes when the client cancels the call", func() {
+			callCtx, cancel := context.WithCancel(ctx)
+			sub, err := client.Stream(callCtx, &pb.SubscriptionSettings{})
+			Expect(err).ToNot(HaveOccurred())
+			_, err = sub.Recv()
+			Expect(err).ToNot(HaveOccurred())
+			cancel()
+			Eventually(stream.unsubscribed).Should(Receive())
+		})
+
+		It("Rejects invalid settings", func() {
+			sub, err := client.Stream(ctx, &pb.SubscriptionSettings{TxFilter: &pb.TxFilter{MaxValue: "one"}})
+			Expect(err).ToNot(HaveOccurred())
+			_, err = sub.Recv()
+			Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+
+			sub, err = client.Stream(ctx, &pb.SubscriptionSettings{Expression: "tx.value >"})
+			Expect(err).ToNot(HaveOccurred())
+			_, err = sub.Recv()
+			Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+		})
+	})
+
+	Describe("Lookups", func() {
+		It("Returns the rlp encoded block at the requested number", func() {
+			req := &pb.BlockRequest{Block: &pb.BlockNumberOrHash{Block: &pb.BlockNumberOrHash_Number{Number: test_helpers.BlockNumber.Int64()}}}
+			block, err := client.GetBlock(ctx, req)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(block.Hash).To(Equal(test_helpers.MockBlock.Hash().Bytes()))
+			Expect(block.Number).To(Equal(test_helpers.BlockNumber.Uint64()))
+			decoded := new(types.Block)
+			Expect(rlp.DecodeBytes(block.Rlp, decoded)).To(Succeed())
+			Expect(decoded.Hash()).To(Equal(test_helpers.MockBlock.Hash()))
+		})
+
+		It("Returns the requested transaction and its receipt", func() {
+			req := &pb.TransactionRequest{Hash: test_helpers.MockTransactions[0].Hash().Bytes()}
+			tx, err := client.GetTransaction(ctx, req)
+			Expect(err).ToNot(HaveOccurred())
+			expectedTx, err := test_helpers.MockTransactions[0].MarshalBinary()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(tx.Data).To(Equal(expectedTx))
+			Expect(tx.BlockHash).To(Equal(test_helpers.MockBlock.Hash().Bytes()))
+			Expect(tx.BlockNumber).To(Equal(test_helpers.BlockNumber.Uint64()))
+
+			rct, err := client.GetReceipt(ctx, req)
+			Expect(err).ToNot(HaveOccurred())
+			expectedRct, err := test_helpers.MockReceipts[0].MarshalBinary()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rct.Data).To(Equal(expectedRct))
+		})
+
+		It("Returns the requested account state", func() {
+			req := &pb.AccountRequest{Address: test_helpers.ContractAddress.Bytes()}
+			account, err := client.GetAccount(ctx, req)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(account.Nonce).To(Equal(uint64(1)))
+			Expect(new(big.Int).SetBytes(account.Balance).Int64()).To(Equal(int64(1000)))
+			Expect(account.CodeHash).To(Equal(test_helpers.ContractCodeHash.Bytes()))
+
+			code, err := client.GetCode(ctx, req)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(code.Code).To(Equal(test_helpers.MockContractByteCode))
+
+			storageReq := &pb.StorageRequest{Address: test_helpers.ContractAddress.Bytes(), Key: common.Hash{}.Bytes()}
+			storage, err := client.GetStorage(ctx, storageReq)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(storage.Value).To(Equal([]byte{1}))
+		})
+
+		It("Returns gRPC status errors", func() {
+			_, err := client.GetTransaction(ctx, &pb.TransactionRequest{Hash: common.Hash{}.Bytes()})
+			Expect(status.Code(err)).To(Equal(codes.NotFound))
+
+			_, err = client.GetTransaction(ctx, &pb.TransactionRequest{Hash: []byte{1}})
+			Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+			Expect(status.Convert(err).Message()).To(Equal("hash must be 32 bytes"))
+
+			err = conn.Invoke(ctx, "/vdb.v1.Vdb/GetLogs", &pb.TransactionRequest{}, new(pb.Transaction))
+			Expect(status.Code(err)).To(Equal(codes.Unimplemented))
+		})
+	})
+})